@@ -0,0 +1,181 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+)
+
+// The types of infrastructure shared by the names of a Cluster.
+const (
+	ClusterCNAME     = "cname"
+	ClusterAddresses = "addresses"
+)
+
+// MaxRepresentatives is the number of names kept as examples for each Cluster.
+var MaxRepresentatives = 5
+
+// The number of names that have their answers read from the graph at once.
+const clusterBatchSize = 250
+
+// The maximum length of the CNAME chains followed to the terminal target.
+const maxCNAMEChain = 10
+
+// Cluster is a group of names that share the same infrastructure.
+type Cluster struct {
+	Type            string   `json:"type"`
+	Target          string   `json:"target,omitempty"`
+	Addresses       []string `json:"addresses,omitempty"`
+	Netblocks       []string `json:"netblocks,omitempty"`
+	Size            int      `json:"size"`
+	Representatives []string `json:"representatives"`
+}
+
+// ClusterByInfrastructure groups the names discovered by the event identified by the uuid parameter.
+// Names behind CNAME records are grouped by the terminal target of the CNAME chain and the remaining
+// names by their sorted set of addresses. The optional ASNCache provides the netblocks of the addresses.
+// Names are read in batches and clusters are keyed by a hash of the answer set, so only the clusters
+// and their representative names are held in memory. Clusters are returned from the largest to the smallest.
+func ClusterByInfrastructure(g *netmap.Graph, uuid string, cache *requests.ASNCache) ([]*Cluster, error) {
+	if g == nil || uuid == "" {
+		return nil, errors.New("ClusterByInfrastructure: The graph and event identifier must be provided")
+	}
+
+	clusters := make(map[uint64]*Cluster)
+	eventNameBatches(g, uuid, func(names []string) {
+		clusterBatch(g, uuid, names, clusters, cache)
+	})
+
+	results := make([]*Cluster, 0, len(clusters))
+	for _, c := range clusters {
+		sort.Strings(c.Representatives)
+		results = append(results, c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Size != results[j].Size {
+			return results[i].Size > results[j].Size
+		}
+		return results[i].key() < results[j].key()
+	})
+	return results, nil
+}
+
+// eventNameBatches passes the names discovered by the event to the callback, clusterBatchSize names at a
+// time. The graph only provides complete lists of nodes, so the names are read for one root domain name
+// of the event at a time, instead of reading every name of the event at once.
+func eventNameBatches(g *netmap.Graph, uuid string, fn func(names []string)) {
+	for _, domain := range g.EventDomains(uuid) {
+		// The root domain name can have its own answers
+		batch := make([]string, 0, clusterBatchSize)
+		batch = append(batch, domain)
+
+		edges, _ := g.ReadInEdges(netmap.Node(domain), "root")
+		for _, edge := range edges {
+			// The root domain names are shared by the events that discovered names within them
+			if g.NodeToID(edge.From) == domain || !g.InEventScope(edge.From, uuid) {
+				continue
+			}
+
+			batch = append(batch, g.NodeToID(edge.From))
+			if len(batch) == clusterBatchSize {
+				fn(batch)
+				batch = make([]string, 0, clusterBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			fn(batch)
+		}
+	}
+}
+
+func clusterBatch(g *netmap.Graph, uuid string, names []string, clusters map[uint64]*Cluster, cache *requests.ASNCache) {
+	addrs := make(map[string][]string, len(names))
+	if pairs, err := g.NamesToAddrs(uuid, names...); err == nil {
+		for _, p := range pairs {
+			addrs[p.Name] = append(addrs[p.Name], p.Addr)
+		}
+	}
+
+	for _, name := range names {
+		var c *Cluster
+
+		if target := cnameTerminus(g, name); target != "" {
+			c = &Cluster{Type: ClusterCNAME, Target: target}
+		} else if set := addrs[name]; len(set) > 0 {
+			sort.Strings(set)
+			c = &Cluster{Type: ClusterAddresses, Addresses: set}
+		} else {
+			continue
+		}
+
+		h := c.hash()
+		if existing, found := clusters[h]; found {
+			c = existing
+		} else {
+			if c.Type == ClusterAddresses && cache != nil {
+				c.Netblocks = netblocks(c.Addresses, cache)
+			}
+			clusters[h] = c
+		}
+
+		c.Size++
+		if len(c.Representatives) < MaxRepresentatives {
+			c.Representatives = append(c.Representatives, name)
+		}
+	}
+}
+
+// cnameTerminus returns the final target of the CNAME chain starting at the name,
+// or an empty string when the name is not an alias.
+func cnameTerminus(g *netmap.Graph, name string) string {
+	var target string
+
+	cur := name
+	for i := 0; i < maxCNAMEChain; i++ {
+		edges, err := g.ReadOutEdges(netmap.Node(cur), "cname_record")
+		if err != nil || len(edges) == 0 {
+			break
+		}
+
+		cur = g.NodeToID(edges[0].To)
+		target = cur
+	}
+	return target
+}
+
+func netblocks(addrs []string, cache *requests.ASNCache) []string {
+	set := stringset.New()
+
+	for _, addr := range addrs {
+		if r := cache.AddrSearch(addr); r != nil && r.Prefix != "" {
+			set.Insert(r.Prefix)
+		}
+	}
+
+	blocks := set.Slice()
+	sort.Strings(blocks)
+	return blocks
+}
+
+func (c *Cluster) key() string {
+	if c.Type == ClusterCNAME {
+		return c.Type + ":" + c.Target
+	}
+	return c.Type + ":" + strings.Join(c.Addresses, ",")
+}
+
+func (c *Cluster) hash() uint64 {
+	h := fnv.New64a()
+
+	_, _ = h.Write([]byte(c.key()))
+	return h.Sum64()
+}
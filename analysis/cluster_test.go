@@ -0,0 +1,98 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/caffix/netmap"
+)
+
+func TestClusterByInfrastructure(t *testing.T) {
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer g.Close()
+
+	uuid := "ef9f9475-34ff-4ab8-8c3b-8e5fc2d4da32"
+	for _, name := range []string{"www.owasp.org", "api.owasp.org", "dev.owasp.org"} {
+		_ = g.UpsertA(name, "192.168.1.1", "DNS", uuid)
+		_ = g.UpsertA(name, "192.168.1.2", "DNS", uuid)
+	}
+	_ = g.UpsertA("mail.owasp.org", "192.168.1.1", "DNS", uuid)
+	_ = g.UpsertCNAME("docs.owasp.org", "owasp.github.io", "DNS", uuid)
+	_ = g.UpsertCNAME("blog.owasp.org", "owasp.github.io", "DNS", uuid)
+	_ = g.UpsertA("owasp.github.io", "185.199.108.153", "DNS", uuid)
+
+	clusters, err := ClusterByInfrastructure(g, uuid, nil)
+	if err != nil {
+		t.Fatalf("Failed to cluster the names: %v", err)
+	}
+
+	sizes := make(map[string]int)
+	for _, c := range clusters {
+		sizes[c.key()] = c.Size
+	}
+
+	expected := map[string]int{
+		"addresses:192.168.1.1,192.168.1.2": 3,
+		"cname:owasp.github.io":             2,
+		"addresses:192.168.1.1":             1,
+	}
+	for key, size := range expected {
+		if sizes[key] != size {
+			t.Errorf("Expected cluster %s to have size %d, got %d", key, size, sizes[key])
+		}
+	}
+
+	if len(clusters) == 0 || clusters[0].Size != 3 || len(clusters[0].Representatives) != 3 {
+		t.Errorf("The largest cluster was not returned first: %+v", clusters)
+	}
+}
+
+func TestClusterBatches(t *testing.T) {
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer g.Close()
+
+	uuid := "ef9f9475-34ff-4ab8-8c3b-8e5fc2d4da32"
+	num := 2*clusterBatchSize + 10
+	for i := 0; i < num; i++ {
+		_ = g.UpsertA(fmt.Sprintf("host%d.owasp.org", i), fmt.Sprintf("192.168.1.%d", i%3), "DNS", uuid)
+	}
+	_ = g.UpsertA("www.owasp-amass.com", "192.168.1.0", "DNS", uuid)
+	_ = g.UpsertA("owasp-amass.com", "192.168.1.1", "DNS", uuid)
+	// The names discovered by another event within the same root domain name are not included
+	_ = g.UpsertA("other.owasp.org", "10.0.0.1", "DNS", "8a4f4c1e-9d7d-4d4a-9b8e-3f1d2e6c7b5a")
+
+	var batches, names int
+	eventNameBatches(g, uuid, func(batch []string) {
+		batches++
+		names += len(batch)
+		if len(batch) > clusterBatchSize {
+			t.Errorf("The batch contained %d names", len(batch))
+		}
+	})
+	// The root domain names are also read
+	if names != num+3 || batches < 4 {
+		t.Errorf("Expected %d names in at least 4 batches, got %d names in %d batches", num+3, names, batches)
+	}
+
+	clusters, err := ClusterByInfrastructure(g, uuid, nil)
+	if err != nil {
+		t.Fatalf("Failed to cluster the names: %v", err)
+	}
+
+	var total int
+	for _, c := range clusters {
+		total += c.Size
+		if len(c.Representatives) > MaxRepresentatives {
+			t.Errorf("The cluster %s kept %d representatives", c.key(), len(c.Representatives))
+		}
+		if c.key() == "addresses:10.0.0.1" {
+			t.Errorf("The name discovered by another event was clustered")
+		}
+	}
+	if total != num+2 || len(clusters) != 3 {
+		t.Errorf("Expected %d names in 3 clusters, got %d names in %d clusters", num+2, total, len(clusters))
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/caffix/stringset"
+	"golang.org/x/net/idna"
+)
+
+// The CSV header fields identifying the column that holds the names of an inventory.
+var inventoryNameFields = []string{"name", "fqdn", "hostname", "domain", "asset"}
+
+// Inventory is an external list of names, such as an asset inventory, that enumeration output is compared against.
+// Entries starting with "*." are wildcard entries that can cover the names within their subtree.
+type Inventory struct {
+	names     stringset.Set
+	wildcards stringset.Set
+}
+
+// NewInventory returns an Inventory holding the normalized names.
+func NewInventory(names ...string) *Inventory {
+	inv := &Inventory{
+		names:     stringset.New(),
+		wildcards: stringset.New(),
+	}
+
+	for _, name := range names {
+		inv.Add(name)
+	}
+	return inv
+}
+
+// Add inserts the normalized name into the Inventory.
+func (inv *Inventory) Add(name string) {
+	name = strings.TrimSpace(name)
+	wildcard := strings.HasPrefix(name, "*.")
+
+	n := NormalizeName(strings.TrimPrefix(name, "*."))
+	if n == "" {
+		return
+	}
+
+	if wildcard {
+		inv.wildcards.Insert(n)
+	} else {
+		inv.names.Insert(n)
+	}
+}
+
+// Len returns the number of entries in the Inventory.
+func (inv *Inventory) Len() int {
+	return inv.names.Len() + inv.wildcards.Len()
+}
+
+// ReadInventory reads the names of an Inventory from a plain list, one name per line, or from CSV content.
+// The content is treated as CSV when the first entry contains a comma. The names are taken from the column
+// with a header such as "name" or "fqdn", and from the first column when no header is found.
+// Empty lines and lines starting with '#' are ignored.
+func ReadInventory(r io.Reader) (*Inventory, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ReadInventory: %v", err)
+	}
+
+	inv := NewInventory()
+	if len(lines) == 0 || !strings.Contains(lines[0], ",") {
+		for _, line := range lines {
+			inv.Add(line)
+		}
+		return inv, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ReadInventory: %v", err)
+	}
+
+	col := -1
+	for i, field := range records[0] {
+		for _, f := range inventoryNameFields {
+			if strings.EqualFold(strings.TrimSpace(field), f) && col == -1 {
+				col = i
+			}
+		}
+	}
+	if col == -1 {
+		col = 0
+	} else {
+		records = records[1:]
+	}
+
+	for _, rec := range records {
+		if col < len(rec) {
+			inv.Add(rec[col])
+		}
+	}
+	return inv, nil
+}
+
+// NormalizeName returns the name in the form used for comparisons: lowercase, without the trailing
+// dot, and with internationalized labels converted to their ASCII form.
+func NormalizeName(name string) string {
+	n := strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	if n == "" {
+		return ""
+	}
+
+	if ascii, err := idna.Lookup.ToASCII(n); err == nil {
+		n = ascii
+	}
+	return n
+}
+
+// Comparison holds the results of comparing the discovered names with an Inventory.
+type Comparison struct {
+	Counts struct {
+		Discovered int `json:"discovered"`
+		Missing    int `json:"missing"`
+		Matched    int `json:"matched"`
+	} `json:"counts"`
+	// Names discovered by the enumeration that are not in the inventory
+	Discovered []string `json:"discovered_not_inventoried"`
+	// Inventory entries that the enumeration did not discover
+	Missing []string `json:"inventoried_not_discovered"`
+	// Names discovered by the enumeration that are in the inventory
+	Matched []string `json:"matched"`
+}
+
+// CompareInventory compares the names discovered by an enumeration with the Inventory. When the
+// wildcards parameter is true, the wildcard entries cover the names within their subtree, excluding
+// the name the entry is anchored at, and are only missing when none of the discovered names is covered.
+// Otherwise, the wildcard entries are compared as plain entries, and are reported as missing.
+func CompareInventory(inv *Inventory, names []string, wildcards bool) *Comparison {
+	discovered := stringset.New()
+	for _, name := range names {
+		if n := NormalizeName(name); n != "" {
+			discovered.Insert(n)
+		}
+	}
+
+	c := new(Comparison)
+	covered := stringset.New()
+	for name := range discovered {
+		var w string
+		if wildcards {
+			w = inv.coveringWildcard(name)
+		}
+
+		switch {
+		case inv.names.Has(name):
+			c.Matched = append(c.Matched, name)
+		case w != "":
+			c.Matched = append(c.Matched, name)
+			covered.Insert(w)
+		default:
+			c.Discovered = append(c.Discovered, name)
+		}
+	}
+
+	for name := range inv.names {
+		if !discovered.Has(name) {
+			c.Missing = append(c.Missing, name)
+		}
+	}
+	for w := range inv.wildcards {
+		if !wildcards || !covered.Has(w) {
+			c.Missing = append(c.Missing, "*."+w)
+		}
+	}
+
+	sort.Strings(c.Discovered)
+	sort.Strings(c.Missing)
+	sort.Strings(c.Matched)
+	c.Counts.Discovered = len(c.Discovered)
+	c.Counts.Missing = len(c.Missing)
+	c.Counts.Matched = len(c.Matched)
+	return c
+}
+
+// coveringWildcard returns the closest wildcard entry with a subtree that contains the name.
+func (inv *Inventory) coveringWildcard(name string) string {
+	labels := strings.Split(name, ".")
+
+	for i := 1; i < len(labels); i++ {
+		if parent := strings.Join(labels[i:], "."); inv.wildcards.Has(parent) {
+			return parent
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadInventory(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"# Official assets\nWWW.OWASP.ORG.\n\n*.corp.owasp.org\nbücher.owasp.org\n", []string{"www.owasp.org", "*.corp.owasp.org", "xn--bcher-kva.owasp.org"}},
+		{"owner,FQDN,notes\nweb,www.owasp.org,\"public, external\"\nmail,mail.owasp.org,\n", []string{"www.owasp.org", "mail.owasp.org"}},
+		{"www.owasp.org,web\nmail.owasp.org,mail\n", []string{"www.owasp.org", "mail.owasp.org"}},
+	}
+
+	for _, test := range tests {
+		inv, err := ReadInventory(strings.NewReader(test.content))
+		if err != nil {
+			t.Fatalf("Failed to read the inventory: %v", err)
+		}
+		if inv.Len() != len(test.expected) {
+			t.Errorf("Expected %d entries, got %d", len(test.expected), inv.Len())
+		}
+
+		for _, name := range test.expected {
+			if w := strings.TrimPrefix(name, "*."); w != name {
+				if !inv.wildcards.Has(w) {
+					t.Errorf("The wildcard entry %s was not read", name)
+				}
+			} else if !inv.names.Has(name) {
+				t.Errorf("The entry %s was not read", name)
+			}
+		}
+	}
+}
+
+func TestCompareInventory(t *testing.T) {
+	inv := NewInventory("www.owasp.org", "Mail.OWASP.org.", "vpn.owasp.org", "*.corp.owasp.org", "*.lab.owasp.org")
+	names := []string{"www.owasp.org.", "MAIL.owasp.org", "api.owasp.org", "hr.corp.owasp.org", "a.b.corp.owasp.org", "corp.owasp.org"}
+
+	c := CompareInventory(inv, names, true)
+	if exp := []string{"a.b.corp.owasp.org", "hr.corp.owasp.org", "mail.owasp.org", "www.owasp.org"}; !reflect.DeepEqual(c.Matched, exp) {
+		t.Errorf("Expected the matched names %v, got %v", exp, c.Matched)
+	}
+	if exp := []string{"api.owasp.org", "corp.owasp.org"}; !reflect.DeepEqual(c.Discovered, exp) {
+		t.Errorf("Expected the names missing from the inventory %v, got %v", exp, c.Discovered)
+	}
+	if exp := []string{"*.lab.owasp.org", "vpn.owasp.org"}; !reflect.DeepEqual(c.Missing, exp) {
+		t.Errorf("Expected the undiscovered entries %v, got %v", exp, c.Missing)
+	}
+	if c.Counts.Matched != 4 || c.Counts.Discovered != 2 || c.Counts.Missing != 2 {
+		t.Errorf("Unexpected counts: %+v", c.Counts)
+	}
+
+	// Without wildcard matching, the wildcard entries do not cover any names
+	c = CompareInventory(inv, names, false)
+	if exp := []string{"mail.owasp.org", "www.owasp.org"}; !reflect.DeepEqual(c.Matched, exp) {
+		t.Errorf("Expected the matched names %v, got %v", exp, c.Matched)
+	}
+	if exp := []string{"*.corp.owasp.org", "*.lab.owasp.org", "vpn.owasp.org"}; !reflect.DeepEqual(c.Missing, exp) {
+		t.Errorf("Expected the undiscovered entries %v, got %v", exp, c.Missing)
+	}
+	if c.Counts.Discovered != 4 {
+		t.Errorf("Expected 4 names missing from the inventory, got %d", c.Counts.Discovered)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The longest run of digits treated as the number of a sequence.
+const maxSequenceDigits = 9
+
+// NumericSequences groups the resolved names by the labels surrounding their last number, such as
+// web01 and web05 within the web## stem, and generates the names filling the gaps of each sequence
+// and extending its range by the margin. The padding convention of the stem is kept, and no more
+// than the maximum number of names are generated for each stem.
+type NumericSequences struct {
+	sync.Mutex
+	margin int
+	max    int
+	stems  map[string]*sequence
+}
+
+// sequence is the state kept for the names sharing a stem.
+type sequence struct {
+	pre, post string
+	// The digits used by the padded numbers, or zero when the numbers are not padded
+	width     int
+	observed  map[int]struct{}
+	generated map[int]struct{}
+}
+
+// NewNumericSequences returns a NumericSequences that extends each range by the margin
+// and generates no more than max names for each stem.
+func NewNumericSequences(margin, max int) *NumericSequences {
+	return &NumericSequences{
+		margin: margin,
+		max:    max,
+		stems:  make(map[string]*sequence),
+	}
+}
+
+// Observe records the resolved name and returns the names not generated before for its
+// sequence. Names are only generated once the stem has at least two numbers observed.
+func (ns *NumericSequences) Observe(name string) []string {
+	parts := strings.SplitN(strings.ToLower(name), ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	label, base := parts[0], parts[1]
+	pre, digits, post := splitLastNumber(label)
+	if digits == "" {
+		return nil
+	}
+
+	num, err := strconv.Atoi(digits)
+	if err != nil {
+		return nil
+	}
+
+	ns.Lock()
+	defer ns.Unlock()
+
+	key := pre + "#" + post + "." + base
+	seq, found := ns.stems[key]
+	if !found {
+		seq = &sequence{
+			pre:       pre,
+			post:      post,
+			observed:  make(map[int]struct{}),
+			generated: make(map[int]struct{}),
+		}
+		ns.stems[key] = seq
+	}
+
+	seq.observed[num] = struct{}{}
+	// Numbers with leading zeros reveal the padding convention of the stem
+	if len(digits) > 1 && digits[0] == '0' && len(digits) > seq.width {
+		seq.width = len(digits)
+	}
+	if len(seq.observed) < 2 {
+		return nil
+	}
+
+	var names []string
+	for _, n := range ns.candidates(seq) {
+		seq.generated[n] = struct{}{}
+		names = append(names, seq.pre+seq.format(n)+seq.post+"."+base)
+	}
+	return names
+}
+
+// candidates returns the numbers within the extended range of the sequence that have not
+// been observed or generated, without exceeding the maximum for the stem.
+func (ns *NumericSequences) candidates(seq *sequence) []int {
+	var nums []int
+	for n := range seq.observed {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	low := nums[0] - ns.margin
+	if low < 0 {
+		low = 0
+	}
+	high := nums[len(nums)-1] + ns.margin
+
+	var results []int
+	for n := low; n <= high && len(seq.generated)+len(results) < ns.max; n++ {
+		if _, found := seq.observed[n]; found {
+			continue
+		}
+		if _, found := seq.generated[n]; found {
+			continue
+		}
+		if len(seq.pre)+len(seq.format(n))+len(seq.post) > 63 {
+			break
+		}
+		results = append(results, n)
+	}
+	return results
+}
+
+func (seq *sequence) format(n int) string {
+	s := strconv.Itoa(n)
+
+	if pad := seq.width - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	return s
+}
+
+// splitLastNumber returns the label separated around its last run of digits.
+func splitLastNumber(label string) (string, string, string) {
+	end := strings.LastIndexAny(label, "0123456789")
+	if end == -1 {
+		return label, "", ""
+	}
+
+	start := end
+	for start > 0 && label[start-1] >= '0' && label[start-1] <= '9' {
+		start--
+	}
+	if end-start+1 > maxSequenceDigits {
+		return label, "", ""
+	}
+	return label[:start], label[start : end+1], label[end+1:]
+}
@@ -0,0 +1,65 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNumericSequencesFillGaps(t *testing.T) {
+	ns := NewNumericSequences(2, 100)
+
+	if names := ns.Observe("web01.owasp.org"); len(names) != 0 {
+		t.Errorf("Names were generated from a single number: %v", names)
+	}
+
+	names := ns.Observe("web02.owasp.org")
+	names = append(names, ns.Observe("web05.owasp.org")...)
+	sort.Strings(names)
+
+	expected := []string{"web00.owasp.org", "web03.owasp.org", "web04.owasp.org", "web06.owasp.org", "web07.owasp.org"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	// A candidate that resolves extends the sequence without repeating the names generated
+	if names := ns.Observe("web07.owasp.org"); !reflect.DeepEqual(names, []string{"web08.owasp.org", "web09.owasp.org"}) {
+		t.Errorf("Unexpected names after extending the sequence: %v", names)
+	}
+}
+
+func TestNumericSequencesStems(t *testing.T) {
+	ns := NewNumericSequences(0, 100)
+
+	ns.Observe("db3-east.owasp.org")
+	ns.Observe("web1.owasp.org")
+	// The numbers are not padded and the stems include the labels following the number
+	if names := ns.Observe("db5-east.owasp.org"); !reflect.DeepEqual(names, []string{"db4-east.owasp.org"}) {
+		t.Errorf("Unexpected names for the db#-east stem: %v", names)
+	}
+	// The same stem under another subdomain is a separate sequence
+	if names := ns.Observe("web3.dev.owasp.org"); len(names) != 0 {
+		t.Errorf("Names were generated across subdomains: %v", names)
+	}
+	if names := ns.Observe("web3.owasp.org"); !reflect.DeepEqual(names, []string{"web2.owasp.org"}) {
+		t.Errorf("Unexpected names for the web# stem: %v", names)
+	}
+	if names := ns.Observe("www.owasp.org"); len(names) != 0 {
+		t.Errorf("Names were generated for a label without a number: %v", names)
+	}
+}
+
+func TestNumericSequencesCap(t *testing.T) {
+	ns := NewNumericSequences(5, 10)
+
+	ns.Observe("node1.owasp.org")
+	if names := ns.Observe("node100000.owasp.org"); len(names) != 10 {
+		t.Errorf("Expected the generation to be capped at 10 names, got %d", len(names))
+	}
+	if names := ns.Observe("node50000.owasp.org"); len(names) != 0 {
+		t.Errorf("Names were generated beyond the cap: %v", names)
+	}
+}
@@ -0,0 +1,470 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.17.3
+// source: amass.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// WatchRequest subscribes to the output of the enumeration.
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_amass_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_amass_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_amass_proto_rawDescGZIP(), []int{0}
+}
+
+// Output contains all the output data for an enumerated DNS name.
+type Output struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Domain    string         `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Addresses []*AddressInfo `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Tag       string         `protobuf:"bytes,4,opt,name=tag,proto3" json:"tag,omitempty"`
+	Sources   []string       `protobuf:"bytes,5,rep,name=sources,proto3" json:"sources,omitempty"`
+	// The type of DNS wildcard detected within the subdomain named by the output
+	Wildcard string `protobuf:"bytes,6,opt,name=wildcard,proto3" json:"wildcard,omitempty"`
+	// The outcome of verifying the answers across distinct resolvers, and the answer set of each
+	// resolver when the answers disagreed
+	Consistency string   `protobuf:"bytes,7,opt,name=consistency,proto3" json:"consistency,omitempty"`
+	AnswerSets  []string `protobuf:"bytes,8,rep,name=answer_sets,json=answerSets,proto3" json:"answer_sets,omitempty"`
+	// The most recent time the name was observed by the passive DNS sources
+	LastSeen *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	// The last_seen time formatted using RFC3339, or "unknown" when no source provided one. It is set
+	// for the output of passive enumerations and the names read from the graph database
+	LastObserved string `protobuf:"bytes,14,opt,name=last_observed,json=lastObserved,proto3" json:"last_observed,omitempty"`
+	// Set when the addresses were merged from several queries to a rotating DNS load balancer
+	Rotating bool `protobuf:"varint,13,opt,name=rotating,proto3" json:"rotating,omitempty"`
+	// The names the CNAME records of the name point at, and the flag of the chain when the name is a
+	// subdomain takeover candidate, with the provider of the domain the chain points into
+	CnameChain    []string `protobuf:"bytes,15,rep,name=cname_chain,json=cnameChain,proto3" json:"cname_chain,omitempty"`
+	ChainFlag     string   `protobuf:"bytes,16,opt,name=chain_flag,json=chainFlag,proto3" json:"chain_flag,omitempty"`
+	ChainProvider string   `protobuf:"bytes,17,opt,name=chain_provider,json=chainProvider,proto3" json:"chain_provider,omitempty"`
+	// The following are only provided for the apex domain names
+	Ns  []string `protobuf:"bytes,10,rep,name=ns,proto3" json:"ns,omitempty"`
+	Mx  []string `protobuf:"bytes,11,rep,name=mx,proto3" json:"mx,omitempty"`
+	Txt []string `protobuf:"bytes,12,rep,name=txt,proto3" json:"txt,omitempty"`
+}
+
+func (x *Output) Reset() {
+	*x = Output{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_amass_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Output) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Output) ProtoMessage() {}
+
+func (x *Output) ProtoReflect() protoreflect.Message {
+	mi := &file_amass_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Output.ProtoReflect.Descriptor instead.
+func (*Output) Descriptor() ([]byte, []int) {
+	return file_amass_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Output) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Output) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Output) GetAddresses() []*AddressInfo {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *Output) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *Output) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *Output) GetWildcard() string {
+	if x != nil {
+		return x.Wildcard
+	}
+	return ""
+}
+
+func (x *Output) GetConsistency() string {
+	if x != nil {
+		return x.Consistency
+	}
+	return ""
+}
+
+func (x *Output) GetAnswerSets() []string {
+	if x != nil {
+		return x.AnswerSets
+	}
+	return nil
+}
+
+func (x *Output) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *Output) GetLastObserved() string {
+	if x != nil {
+		return x.LastObserved
+	}
+	return ""
+}
+
+func (x *Output) GetRotating() bool {
+	if x != nil {
+		return x.Rotating
+	}
+	return false
+}
+
+func (x *Output) GetCnameChain() []string {
+	if x != nil {
+		return x.CnameChain
+	}
+	return nil
+}
+
+func (x *Output) GetChainFlag() string {
+	if x != nil {
+		return x.ChainFlag
+	}
+	return ""
+}
+
+func (x *Output) GetChainProvider() string {
+	if x != nil {
+		return x.ChainProvider
+	}
+	return ""
+}
+
+func (x *Output) GetNs() []string {
+	if x != nil {
+		return x.Ns
+	}
+	return nil
+}
+
+func (x *Output) GetMx() []string {
+	if x != nil {
+		return x.Mx
+	}
+	return nil
+}
+
+func (x *Output) GetTxt() []string {
+	if x != nil {
+		return x.Txt
+	}
+	return nil
+}
+
+// AddressInfo stores all network addressing info for the Output message.
+type AddressInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Cidr    string `protobuf:"bytes,2,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	Asn     int32  `protobuf:"varint,3,opt,name=asn,proto3" json:"asn,omitempty"`
+	Desc    string `protobuf:"bytes,4,opt,name=desc,proto3" json:"desc,omitempty"`
+}
+
+func (x *AddressInfo) Reset() {
+	*x = AddressInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_amass_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddressInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressInfo) ProtoMessage() {}
+
+func (x *AddressInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_amass_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressInfo.ProtoReflect.Descriptor instead.
+func (*AddressInfo) Descriptor() ([]byte, []int) {
+	return file_amass_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AddressInfo) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AddressInfo) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *AddressInfo) GetAsn() int32 {
+	if x != nil {
+		return x.Asn
+	}
+	return 0
+}
+
+func (x *AddressInfo) GetDesc() string {
+	if x != nil {
+		return x.Desc
+	}
+	return ""
+}
+
+var File_amass_proto protoreflect.FileDescriptor
+
+var file_amass_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x61, 0x6d, 0x61, 0x73, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x61,
+	0x6d, 0x61, 0x73, 0x73, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x0e, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x84, 0x04, 0x0a, 0x06, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x30, 0x0a, 0x09,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x61, 0x6d, 0x61, 0x73, 0x73, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x10,
+	0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67,
+	0x12, 0x18, 0x0a, 0x07, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x69,
+	0x6c, 0x64, 0x63, 0x61, 0x72, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x77, 0x69,
+	0x6c, 0x64, 0x63, 0x61, 0x72, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x73, 0x69, 0x73,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6e,
+	0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6e, 0x73, 0x77,
+	0x65, 0x72, 0x5f, 0x73, 0x65, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x61,
+	0x6e, 0x73, 0x77, 0x65, 0x72, 0x53, 0x65, 0x74, 0x73, 0x12, 0x37, 0x0a, 0x09, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65,
+	0x65, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x6f, 0x62, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x4f,
+	0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x6f, 0x74, 0x61, 0x74,
+	0x69, 0x6e, 0x67, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72, 0x6f, 0x74, 0x61, 0x74,
+	0x69, 0x6e, 0x67, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x63, 0x68, 0x61,
+	0x69, 0x6e, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6e, 0x61, 0x6d, 0x65, 0x43,
+	0x68, 0x61, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x66, 0x6c,
+	0x61, 0x67, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x46,
+	0x6c, 0x61, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x70, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x68, 0x61,
+	0x69, 0x6e, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x6e, 0x73,
+	0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x02, 0x6e, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x6d, 0x78,
+	0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x02, 0x6d, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x78,
+	0x74, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x74, 0x78, 0x74, 0x22, 0x61, 0x0a, 0x0b,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x64, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x69, 0x64, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x73, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x61, 0x73, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x65, 0x73, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x32,
+	0x36, 0x0a, 0x05, 0x41, 0x6d, 0x61, 0x73, 0x73, 0x12, 0x2d, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x13, 0x2e, 0x61, 0x6d, 0x61, 0x73, 0x73, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x61, 0x6d, 0x61, 0x73, 0x73, 0x2e, 0x4f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x30, 0x01, 0x42, 0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x4f, 0x57, 0x41, 0x53, 0x50, 0x2f, 0x41, 0x6d, 0x61, 0x73,
+	0x73, 0x2f, 0x76, 0x33, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_amass_proto_rawDescOnce sync.Once
+	file_amass_proto_rawDescData = file_amass_proto_rawDesc
+)
+
+func file_amass_proto_rawDescGZIP() []byte {
+	file_amass_proto_rawDescOnce.Do(func() {
+		file_amass_proto_rawDescData = protoimpl.X.CompressGZIP(file_amass_proto_rawDescData)
+	})
+	return file_amass_proto_rawDescData
+}
+
+var file_amass_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_amass_proto_goTypes = []interface{}{
+	(*WatchRequest)(nil),          // 0: amass.WatchRequest
+	(*Output)(nil),                // 1: amass.Output
+	(*AddressInfo)(nil),           // 2: amass.AddressInfo
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_amass_proto_depIdxs = []int32{
+	2, // 0: amass.Output.addresses:type_name -> amass.AddressInfo
+	3, // 1: amass.Output.last_seen:type_name -> google.protobuf.Timestamp
+	0, // 2: amass.Amass.Watch:input_type -> amass.WatchRequest
+	1, // 3: amass.Amass.Watch:output_type -> amass.Output
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_amass_proto_init() }
+func file_amass_proto_init() {
+	if File_amass_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_amass_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_amass_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Output); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_amass_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddressInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_amass_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_amass_proto_goTypes,
+		DependencyIndexes: file_amass_proto_depIdxs,
+		MessageInfos:      file_amass_proto_msgTypes,
+	}.Build()
+	File_amass_proto = out.File
+	file_amass_proto_rawDesc = nil
+	file_amass_proto_goTypes = nil
+	file_amass_proto_depIdxs = nil
+}
@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AmassClient is the client API for Amass service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AmassClient interface {
+	// Watch streams the output of the enumeration, and is closed when the enumeration is done.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Amass_WatchClient, error)
+}
+
+type amassClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAmassClient(cc grpc.ClientConnInterface) AmassClient {
+	return &amassClient{cc}
+}
+
+func (c *amassClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Amass_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Amass_ServiceDesc.Streams[0], "/amass.Amass/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &amassWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Amass_WatchClient interface {
+	Recv() (*Output, error)
+	grpc.ClientStream
+}
+
+type amassWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *amassWatchClient) Recv() (*Output, error) {
+	m := new(Output)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AmassServer is the server API for Amass service.
+// All implementations must embed UnimplementedAmassServer
+// for forward compatibility
+type AmassServer interface {
+	// Watch streams the output of the enumeration, and is closed when the enumeration is done.
+	Watch(*WatchRequest, Amass_WatchServer) error
+	mustEmbedUnimplementedAmassServer()
+}
+
+// UnimplementedAmassServer must be embedded to have forward compatible implementations.
+type UnimplementedAmassServer struct {
+}
+
+func (UnimplementedAmassServer) Watch(*WatchRequest, Amass_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedAmassServer) mustEmbedUnimplementedAmassServer() {}
+
+// UnsafeAmassServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AmassServer will
+// result in compilation errors.
+type UnsafeAmassServer interface {
+	mustEmbedUnimplementedAmassServer()
+}
+
+func RegisterAmassServer(s grpc.ServiceRegistrar, srv AmassServer) {
+	s.RegisterService(&Amass_ServiceDesc, srv)
+}
+
+func _Amass_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AmassServer).Watch(m, &amassWatchServer{stream})
+}
+
+type Amass_WatchServer interface {
+	Send(*Output) error
+	grpc.ServerStream
+}
+
+type amassWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *amassWatchServer) Send(m *Output) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Amass_ServiceDesc is the grpc.ServiceDesc for Amass service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Amass_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "amass.Amass",
+	HandlerType: (*AmassServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Amass_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "amass.proto",
+}
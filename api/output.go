@@ -0,0 +1,43 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package api
+
+import (
+	"github.com/OWASP/Amass/v3/requests"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewOutput returns the Output message for the output of the enumeration.
+func NewOutput(o *requests.Output) *Output {
+	out := &Output{
+		Name:          o.Name,
+		Domain:        o.Domain,
+		Tag:           o.Tag,
+		Sources:       append([]string(nil), o.Sources...),
+		Wildcard:      o.Wildcard,
+		Consistency:   o.Consistency,
+		AnswerSets:    append([]string(nil), o.AnswerSets...),
+		LastObserved:  o.LastObserved,
+		Rotating:      o.Rotating,
+		CnameChain:    append([]string(nil), o.CNAMEChain...),
+		ChainFlag:     o.ChainFlag,
+		ChainProvider: o.ChainProvider,
+		Ns:            append([]string(nil), o.NS...),
+		Mx:            append([]string(nil), o.MX...),
+		Txt:           append([]string(nil), o.TXT...),
+	}
+
+	for _, a := range o.Addresses {
+		out.Addresses = append(out.Addresses, &AddressInfo{
+			Address: a.Address.String(),
+			Cidr:    a.CIDRStr,
+			Asn:     int32(a.ASN),
+			Desc:    a.Description,
+		})
+	}
+	if o.LastSeen != nil {
+		out.LastSeen = timestamppb.New(*o.LastSeen)
+	}
+	return out
+}
@@ -13,10 +13,13 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/OWASP/Amass/v3/analysis"
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/datasrcs"
 	"github.com/OWASP/Amass/v3/format"
+	"github.com/OWASP/Amass/v3/graphio"
 	"github.com/OWASP/Amass/v3/requests"
 	"github.com/OWASP/Amass/v3/systems"
 	"github.com/caffix/netmap"
@@ -29,27 +32,40 @@ const (
 )
 
 type dbArgs struct {
-	Domains stringset.Set
-	Enum    int
-	Options struct {
+	Domains        stringset.Set
+	Enum           int
+	ObservedWithin int
+	Options        struct {
 		DemoMode         bool
 		IPs              bool
 		IPv4             bool
 		IPv6             bool
 		ListEnumerations bool
 		ASNTableSummary  bool
+		Clusters         bool
+		CompareWildcards bool
 		DiscoveredNames  bool
 		NoColor          bool
 		ShowAll          bool
 		Silent           bool
+		SortObserved     bool
 		Sources          bool
 	}
 	Filepaths struct {
-		ConfigFile string
-		Directory  string
-		Domains    string
-		JSONOutput string
-		TermOut    string
+		Compare     string
+		ConfigFile  string
+		Directory   string
+		Dnsmasq     string
+		Domains     string
+		Export      string
+		GraphML     string
+		Hosts       string
+		Import      string
+		JSONOutput  string
+		STIX        string
+		SummaryCSV  string
+		SummaryJSON string
+		TermOut     string
 	}
 }
 
@@ -66,6 +82,7 @@ func runDBCommand(clArgs []string) {
 	dbCommand.BoolVar(&help2, "help", false, "Show the program usage message")
 	dbCommand.Var(&args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
 	dbCommand.IntVar(&args.Enum, "enum", 0, "Identify an enumeration via an index from the listing")
+	dbCommand.IntVar(&args.ObservedWithin, "observed-within", 0, "Days within which the data sources must have observed the names shown (names never observed are kept)")
 	dbCommand.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
 	dbCommand.BoolVar(&args.Options.IPs, "ip", false, "Show the IP addresses for discovered names")
 	dbCommand.BoolVar(&args.Options.IPv4, "ipv4", false, "Show the IPv4 addresses for discovered names")
@@ -74,13 +91,25 @@ func runDBCommand(clArgs []string) {
 	dbCommand.BoolVar(&args.Options.Sources, "src", false, "Print data sources for the discovered names")
 	dbCommand.BoolVar(&args.Options.ASNTableSummary, "summary", false, "Print Just ASN Table Summary")
 	dbCommand.BoolVar(&args.Options.DiscoveredNames, "names", false, "Print Just Discovered Names")
+	dbCommand.BoolVar(&args.Options.Clusters, "clusters", false, "Group the discovered names by shared infrastructure")
+	dbCommand.BoolVar(&args.Options.CompareWildcards, "compare-wildcards", false, "Treat wildcard inventory entries (*.example.com) as covering their subtree")
 	dbCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
 	dbCommand.BoolVar(&args.Options.ShowAll, "show", false, "Print the results for the enumeration index + domains provided")
 	dbCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
-	dbCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI configuration file. Additional details below")
+	dbCommand.BoolVar(&args.Options.SortObserved, "sort-observed", false, "Sort the discovered names from the most recently observed by the data sources")
+	dbCommand.StringVar(&args.Filepaths.Compare, "compare", "", "Path to an inventory of names (plain or CSV) to compare the discovered names against")
+	dbCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI or YAML configuration file. Additional details below")
 	dbCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	dbCommand.StringVar(&args.Filepaths.Dnsmasq, "dnsmasq", "", "Path to the dnsmasq configuration of the enumeration addresses (default: the most recent)")
 	dbCommand.StringVar(&args.Filepaths.Domains, "df", "", "Path to a file providing root domain names")
+	dbCommand.StringVar(&args.Filepaths.Export, "export", "", "Path to the file receiving a portable dump of the enumerations")
+	dbCommand.StringVar(&args.Filepaths.GraphML, "graphml", "", "Path to the GraphML file of the enumeration for Gephi or yEd (default: the most recent)")
+	dbCommand.StringVar(&args.Filepaths.Hosts, "hosts", "", "Path to the hosts file of the enumeration addresses (default: the most recent)")
+	dbCommand.StringVar(&args.Filepaths.Import, "import", "", "Path to a portable dump of enumerations to be added to the database")
 	dbCommand.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file")
+	dbCommand.StringVar(&args.Filepaths.STIX, "stix", "", "Path to the STIX 2.1 bundle of the enumeration (default: the most recent)")
+	dbCommand.StringVar(&args.Filepaths.SummaryCSV, "summary-csv", "", "Path to the CSV of the per-ASN and per-netblock summary of the enumeration (default: the most recent)")
+	dbCommand.StringVar(&args.Filepaths.SummaryJSON, "summary-json", "", "Path to the JSON of the per-ASN and per-netblock summary of the enumeration (default: the most recent)")
 	dbCommand.StringVar(&args.Filepaths.TermOut, "o", "", "Path to the text file containing terminal stdout/stderr")
 
 	if len(clArgs) < 1 {
@@ -131,13 +160,18 @@ func runDBCommand(clArgs []string) {
 		_ = src.Stop()
 	}
 
-	db := openGraphDatabase(args.Filepaths.Directory, cfg)
+	db := openGraphDatabase(args.Filepaths.Directory, cfg, args.Domains.Slice())
 	if db == nil {
 		r.Fprintln(color.Error, "Failed to connect with the database")
 		os.Exit(1)
 	}
 	defer db.Close()
 
+	if args.Filepaths.Import != "" {
+		importGraphJSON(args.Filepaths.Import, db)
+		return
+	}
+
 	// Create the in-memory graph database for events that have information in scope
 	memDB, err := memGraphForScope(args.Domains.Slice(), db)
 	if err != nil {
@@ -158,7 +192,10 @@ func runDBCommand(clArgs []string) {
 		args.Options.DiscoveredNames = true
 		args.Options.ASNTableSummary = true
 	}
-	if !args.Options.DiscoveredNames && !args.Options.ASNTableSummary {
+	if !args.Options.DiscoveredNames && !args.Options.ASNTableSummary && !args.Options.Clusters &&
+		args.Filepaths.Compare == "" && args.Filepaths.Export == "" && args.Filepaths.STIX == "" &&
+		args.Filepaths.GraphML == "" && args.Filepaths.Hosts == "" && args.Filepaths.Dnsmasq == "" &&
+		args.Filepaths.SummaryCSV == "" && args.Filepaths.SummaryJSON == "" {
 		commandUsage(dbUsageMsg, dbCommand, dbBuf)
 		return
 	}
@@ -174,9 +211,40 @@ func runDBCommand(clArgs []string) {
 
 		uuids = []string{uuids[idx]}
 	}
+	if args.Filepaths.Export != "" {
+		exportGraphJSON(args.Filepaths.Export, uuids, memDB)
+		return
+	}
+	if args.Filepaths.STIX != "" {
+		exportGraphSTIX(args.Filepaths.STIX, uuids[len(uuids)-1], memDB)
+		return
+	}
+	if args.Filepaths.GraphML != "" {
+		exportGraphML(args.Filepaths.GraphML, uuids[len(uuids)-1], memDB)
+		return
+	}
+	if args.Filepaths.Hosts != "" || args.Filepaths.Dnsmasq != "" {
+		if args.Filepaths.Hosts != "" {
+			exportHostAddrs(args.Filepaths.Hosts, "hosts file", uuids[len(uuids)-1], memDB, graphio.ExportHosts)
+		}
+		if args.Filepaths.Dnsmasq != "" {
+			exportHostAddrs(args.Filepaths.Dnsmasq, "dnsmasq configuration", uuids[len(uuids)-1], memDB, graphio.ExportDnsmasq)
+		}
+		return
+	}
+
+	if args.Filepaths.SummaryCSV != "" || args.Filepaths.SummaryJSON != "" {
+		if args.Filepaths.SummaryCSV != "" {
+			exportASNSummary(args.Filepaths.SummaryCSV, "CSV", uuids[len(uuids)-1], memDB, graphio.ExportASNSummaryCSV)
+		}
+		if args.Filepaths.SummaryJSON != "" {
+			exportASNSummary(args.Filepaths.SummaryJSON, "JSON", uuids[len(uuids)-1], memDB, graphio.ExportASNSummaryJSON)
+		}
+		return
+	}
 
 	var asninfo bool
-	if args.Options.ASNTableSummary {
+	if args.Options.ASNTableSummary || args.Options.Clusters {
 		asninfo = true
 	}
 
@@ -225,6 +293,15 @@ func showEventData(args *dbArgs, uuids []string, asninfo bool, db *netmap.Graph)
 		_, _ = outfile.Seek(0, 0)
 	}
 
+	var inventory *analysis.Inventory
+	if args.Filepaths.Compare != "" {
+		inventory, err = readInventory(args.Filepaths.Compare)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to read the inventory: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	var cache *requests.ASNCache
 	if asninfo {
 		cache = requests.NewASNCache()
@@ -234,12 +311,22 @@ func showEventData(args *dbArgs, uuids []string, asninfo bool, db *netmap.Graph)
 		}
 	}
 
+	var names []string
 	tags := make(map[string]int)
 	asns := make(map[int]*format.ASNSummaryData)
-	for _, out := range getEventOutput(uuids, asninfo, db, cache) {
+	outputs := getEventOutput(uuids, asninfo, db, cache)
+	if args.ObservedWithin > 0 {
+		outputs = observedWithin(outputs, time.Duration(args.ObservedWithin)*24*time.Hour, time.Now())
+	}
+	if args.Options.SortObserved {
+		sortByLastObserved(outputs)
+	}
+
+	for _, out := range outputs {
 		if len(domains) > 0 && !domainNameInScope(out.Name, domains) {
 			continue
 		}
+		out.SetLastObserved()
 
 		out.Addresses = format.DesiredAddrTypes(out.Addresses, args.Options.IPv4, args.Options.IPv6)
 		if l := len(out.Addresses); (args.Options.IPs || args.Options.IPv4 || args.Options.IPv6) && l == 0 {
@@ -248,6 +335,7 @@ func showEventData(args *dbArgs, uuids []string, asninfo bool, db *netmap.Graph)
 			total++
 			format.UpdateSummaryData(out, tags, asns)
 		}
+		names = append(names, out.Name)
 
 		source, name, ips := format.OutputLineParts(out, args.Options.Sources,
 			args.Options.IPs || args.Options.IPv4 || args.Options.IPv6, args.Options.DemoMode)
@@ -275,9 +363,24 @@ func showEventData(args *dbArgs, uuids []string, asninfo bool, db *netmap.Graph)
 		r.Println("No names were discovered")
 		return
 	}
+
+	var clusters []*analysis.Cluster
+	if args.Options.Clusters {
+		// The clusters are computed for the most recent of the selected enumerations
+		clusters, err = analysis.ClusterByInfrastructure(db, uuids[len(uuids)-1], cache)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to group the names by infrastructure: %v\n", err)
+		}
+	}
+
+	var comparison *analysis.Comparison
+	if inventory != nil {
+		comparison = analysis.CompareInventory(inventory, names, args.Options.CompareWildcards)
+	}
+
 	if args.Filepaths.JSONOutput != "" {
-		writeJSON(args, uuids, discovered, db)
-	} else if args.Options.ASNTableSummary {
+		writeJSON(args, uuids, discovered, clusters, comparison, db)
+	} else if args.Options.ASNTableSummary || args.Options.Clusters || comparison != nil {
 		var out io.Writer
 		status := color.NoColor
 
@@ -290,7 +393,11 @@ func showEventData(args *dbArgs, uuids []string, asninfo bool, db *netmap.Graph)
 			out = color.Output
 		}
 
-		format.FprintEnumerationSummary(out, total, tags, asns, args.Options.DemoMode)
+		if args.Options.ASNTableSummary {
+			format.FprintEnumerationSummary(out, total, tags, asns, args.Options.DemoMode)
+		}
+		format.FprintClusters(out, clusters, args.Options.DemoMode)
+		format.FprintComparison(out, comparison, args.Options.DemoMode)
 		color.NoColor = status
 	}
 }
@@ -308,12 +415,18 @@ type jsonDomain struct {
 }
 
 type jsonOutput struct {
-	Events  []*jsonEvent  `json:"events"`
-	Domains []*jsonDomain `json:"domains"`
+	Events     []*jsonEvent         `json:"events"`
+	Domains    []*jsonDomain        `json:"domains"`
+	Clusters   []*analysis.Cluster  `json:"clusters,omitempty"`
+	Comparison *analysis.Comparison `json:"comparison,omitempty"`
 }
 
-func writeJSON(args *dbArgs, uuids []string, assets []*requests.Output, db *netmap.Graph) {
-	var output jsonOutput
+func writeJSON(args *dbArgs, uuids []string, assets []*requests.Output,
+	clusters []*analysis.Cluster, comparison *analysis.Comparison, db *netmap.Graph) {
+	output := jsonOutput{
+		Clusters:   clusters,
+		Comparison: comparison,
+	}
 
 	// Add the event data to the JSON
 	events, earliest, latest := orderedEvents(uuids, db)
@@ -359,6 +472,118 @@ func writeJSON(args *dbArgs, uuids []string, assets []*requests.Output, db *netm
 	_ = jsonptr.Close()
 }
 
+func readInventory(path string) (*analysis.Inventory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return analysis.ReadInventory(f)
+}
+
+func exportGraphJSON(path string, uuids []string, db *netmap.Graph) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the export file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	for _, uuid := range uuids {
+		if err := graphio.ExportJSON(db, f, uuid); err != nil {
+			r.Fprintf(color.Error, "Failed to export the enumeration %s: %v\n", uuid, err)
+			os.Exit(1)
+		}
+	}
+	g.Fprintf(color.Error, "Exported %d enumerations to %s\n", len(uuids), path)
+}
+
+func exportGraphSTIX(path, uuid string, db *netmap.Graph) {
+	data, err := graphio.ExportSTIX(db, uuid)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to export the enumeration %s: %v\n", uuid, err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		r.Fprintf(color.Error, "Failed to write the STIX bundle: %v\n", err)
+		os.Exit(1)
+	}
+	g.Fprintf(color.Error, "Exported the enumeration %s to %s\n", uuid, path)
+}
+
+func exportGraphML(path, uuid string, db *netmap.Graph) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the GraphML file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if err := graphio.ExportGraphML(db, uuid, f, sourceTags); err != nil {
+		r.Fprintf(color.Error, "Failed to export the enumeration %s: %v\n", uuid, err)
+		os.Exit(1)
+	}
+	g.Fprintf(color.Error, "Exported the enumeration %s to %s\n", uuid, path)
+}
+
+func exportHostAddrs(path, desc, uuid string, db *netmap.Graph, export func(*netmap.Graph, string, io.Writer) (int, error)) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the %s: %v\n", desc, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	skipped, err := export(db, uuid, f)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to export the enumeration %s: %v\n", uuid, err)
+		os.Exit(1)
+	}
+	g.Fprintf(color.Error, "Exported the enumeration %s to %s\n", uuid, path)
+	if skipped > 0 {
+		fmt.Fprintf(color.Error, "%s\n", yellow(fmt.Sprintf("Skipped %d names without addresses", skipped)))
+	}
+}
+
+func exportASNSummary(path, desc, uuid string, db *netmap.Graph, export func(*netmap.Graph, string, io.Writer) error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the %s summary file: %v\n", desc, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if err := export(db, uuid, f); err != nil {
+		r.Fprintf(color.Error, "Failed to export the enumeration %s: %v\n", uuid, err)
+		os.Exit(1)
+	}
+	g.Fprintf(color.Error, "Exported the ASN summary of the enumeration %s to %s\n", uuid, path)
+}
+
+func importGraphJSON(path string, db *netmap.Graph) {
+	f, err := os.Open(path)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the import file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	stats, err := graphio.ImportJSON(db, f)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if stats.Operations > 0 {
+		g.Fprintf(color.Error, "Replayed %d graph writes from the journal of the enumeration %s\n",
+			stats.Operations, stats.Events[0])
+		return
+	}
+	g.Fprintf(color.Error, "Imported %d enumerations: %d nodes, %d edges and %d properties\n",
+		len(stats.Events), stats.Nodes, stats.Edges, stats.Properties)
+}
+
 func fillCache(cache *requests.ASNCache, db *netmap.Graph) error {
 	aslist, err := db.AllNodesOfType(netmap.TypeAS)
 	if err != nil {
@@ -81,7 +81,7 @@ func defineDNSOptionFlags(dnsFlags *flag.FlagSet, args *dnsArgs) {
 func defineDNSFilepathFlags(dnsFlags *flag.FlagSet, args *dnsArgs) {
 	dnsFlags.StringVar(&args.Filepaths.AllFilePrefix, "oA", "", "Path prefix used for naming all output files")
 	dnsFlags.StringVar(&args.Filepaths.Blacklist, "blf", "", "Path to a file providing blacklisted subdomains")
-	dnsFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI configuration file. Additional details below")
+	dnsFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI or YAML configuration file. Additional details below")
 	dnsFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
 	dnsFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
 	dnsFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file")
@@ -0,0 +1,155 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/enum"
+	"github.com/OWASP/Amass/v3/graphio"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+)
+
+func sortedEventOutput(t *testing.T, g *netmap.Graph, uuid string) []*requests.Output {
+	cache := requests.NewASNCache()
+	if err := fillCache(cache, g); err != nil {
+		t.Fatalf("Failed to fill the ASN cache: %v", err)
+	}
+
+	output := enum.EventOutput(g, uuid, nil, nil, true, cache)
+	for _, o := range output {
+		sort.Strings(o.Sources)
+		sort.Slice(o.Addresses, func(i, j int) bool {
+			return o.Addresses[i].Address.String() < o.Addresses[j].Address.String()
+		})
+	}
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].Name < output[j].Name
+	})
+	return output
+}
+
+func TestGraphJSONRoundTrip(t *testing.T) {
+	from := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer from.Close()
+
+	uuid := "8e3c2a6b-1d2f-4d6e-9c1a-6c6f1c9a2b77"
+	records := []struct {
+		name, addr, source string
+	}{
+		{"www.owasp.org", "104.22.26.77", "DNS"},
+		{"www.owasp.org", "104.22.27.77", "Crtsh"},
+		{"vpn.owasp.org", "54.230.10.7", "VirusTotal"},
+		{"mail.owasp.org", "2606:4700:10::6816:1b4d", "Brute Forcing"},
+	}
+	for _, r := range records {
+		if _, err := from.UpsertFQDN(r.name, r.source, uuid); err != nil {
+			t.Fatalf("Failed to insert %s: %v", r.name, err)
+		}
+		insert := from.UpsertA
+		if strings.Contains(r.addr, ":") {
+			insert = from.UpsertAAAA
+		}
+		if err := insert(r.name, r.addr, r.source, uuid); err != nil {
+			t.Fatalf("Failed to insert the address of %s: %v", r.name, err)
+		}
+	}
+	if err := from.UpsertCNAME("docs.owasp.org", "www.owasp.org", "DNS", uuid); err != nil {
+		t.Fatalf("Failed to insert the CNAME record: %v", err)
+	}
+
+	infra := []struct {
+		asn        int
+		desc, addr string
+		cidr       string
+	}{
+		{13335, "CLOUDFLARENET", "104.22.26.77", "104.22.16.0/20"},
+		{13335, "CLOUDFLARENET", "104.22.27.77", "104.22.16.0/20"},
+		{16509, "AMAZON-02", "54.230.10.7", "54.230.0.0/16"},
+		{13335, "CLOUDFLARENET", "2606:4700:10::6816:1b4d", "2606:4700::/32"},
+	}
+	for _, i := range infra {
+		if err := from.UpsertInfrastructure(i.asn, i.desc, i.addr, i.cidr, "RIR", uuid); err != nil {
+			t.Fatalf("Failed to insert the infrastructure for %s: %v", i.addr, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := graphio.ExportJSON(from, &buf, uuid); err != nil {
+		t.Fatalf("Failed to export the event: %v", err)
+	}
+
+	to := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer to.Close()
+
+	if _, err := graphio.ImportJSON(to, &buf); err != nil {
+		t.Fatalf("Failed to import the event: %v", err)
+	}
+
+	before := sortedEventOutput(t, from, uuid)
+	after := sortedEventOutput(t, to, uuid)
+	// The alias is not reported, since the infrastructure of its target is not associated with it
+	if len(before) != 4 {
+		t.Fatalf("Expected output for four names before the export, got %d", len(before))
+	}
+	for _, o := range before {
+		if len(o.Addresses) == 0 || o.Addresses[0].ASN == 0 || o.Addresses[0].Netblock == nil {
+			t.Fatalf("Expected %s to have addresses with infrastructure information", o.Name)
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		for i := range before {
+			if i < len(after) && !reflect.DeepEqual(before[i], after[i]) {
+				t.Errorf("Expected %+v, got %+v", before[i], after[i])
+			}
+		}
+		t.Fatalf("The event output changed during the round trip")
+	}
+}
+
+func TestLastObservedOrdering(t *testing.T) {
+	now := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	observed := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	outputs := []*requests.Output{
+		{Name: "old.owasp.org", LastSeen: observed(400)},
+		{Name: "unknown.owasp.org"},
+		{Name: "www.owasp.org", LastSeen: observed(2)},
+		{Name: "mail.owasp.org", LastSeen: observed(30)},
+		{Name: "zero.owasp.org", LastSeen: new(time.Time)},
+	}
+
+	names := func(outputs []*requests.Output) string {
+		var list []string
+		for _, o := range outputs {
+			list = append(list, o.Name)
+		}
+		return strings.Join(list, ",")
+	}
+
+	recent := observedWithin(outputs, 90*24*time.Hour, now)
+	if got := names(recent); got != "unknown.owasp.org,www.owasp.org,mail.owasp.org,zero.owasp.org" {
+		t.Errorf("Unexpected names observed within 90 days: %s", got)
+	}
+
+	sortByLastObserved(outputs)
+	if got := names(outputs); got != "www.owasp.org,mail.owasp.org,old.owasp.org,unknown.owasp.org,zero.owasp.org" {
+		t.Errorf("Unexpected order of the names: %s", got)
+	}
+
+	outputs[0].SetLastObserved()
+	outputs[3].SetLastObserved()
+	if outputs[0].LastObserved != "2021-03-30T00:00:00Z" || outputs[3].LastObserved != requests.ObservedUnknown {
+		t.Errorf("Unexpected last observed annotations: %q and %q", outputs[0].LastObserved, outputs[3].LastObserved)
+	}
+}
@@ -35,8 +35,10 @@ import (
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/datasrcs"
+	"github.com/OWASP/Amass/v3/enum"
 	"github.com/OWASP/Amass/v3/filter"
 	"github.com/OWASP/Amass/v3/format"
+	"github.com/OWASP/Amass/v3/graphio"
 	amassnet "github.com/OWASP/Amass/v3/net"
 	"github.com/OWASP/Amass/v3/requests"
 	"github.com/OWASP/Amass/v3/systems"
@@ -221,11 +223,15 @@ func expandCategoryNames(names []string, categories map[string][]string) []strin
 	return newnames
 }
 
-func openGraphDatabase(dir string, cfg *config.Config) *netmap.Graph {
+func openGraphDatabase(dir string, cfg *config.Config, domains []string) *netmap.Graph {
 	for _, db := range cfg.GraphDBs {
 		if !db.Primary {
 			continue
 		}
+		// The events exported to Neo4j for the domains are read into an in-memory graph
+		if db.System == config.Neo4jSystem {
+			return openNeo4jEvents(db, domains)
+		}
 
 		cayley := netmap.NewCayleyGraph(db.System, db.URL, db.Options)
 		if cayley == nil {
@@ -256,6 +262,22 @@ func openGraphDatabase(dir string, cfg *config.Config) *netmap.Graph {
 	return nil
 }
 
+func openNeo4jEvents(db *config.Database, domains []string) *netmap.Graph {
+	uuids, err := graphio.Neo4jEvents(db, domains)
+	if err != nil {
+		fmt.Fprintf(color.Error, "%v\n", err)
+		return nil
+	}
+
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	if _, err := graphio.ImportNeo4j(g, db, uuids...); err != nil {
+		fmt.Fprintf(color.Error, "%v\n", err)
+		g.Close()
+		return nil
+	}
+	return g
+}
+
 func orderedEvents(events []string, db *netmap.Graph) ([]string, []time.Time, []time.Time) {
 	sort.Slice(events, func(i, j int) bool {
 		var less bool
@@ -332,7 +354,7 @@ func getEventOutput(uuids []string, asninfo bool, db *netmap.Graph, cache *reque
 	filter := filter.NewStringFilter()
 
 	for i := len(uuids) - 1; i >= 0; i-- {
-		output = append(output, EventOutput(db, uuids[i], filter, asninfo, cache)...)
+		output = append(output, enum.EventOutput(db, uuids[i], sourceTags, filter, asninfo, cache)...)
 	}
 
 	return output
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/enum"
 	"github.com/OWASP/Amass/v3/requests"
 	"github.com/caffix/netmap"
 	"github.com/caffix/stringset"
@@ -58,7 +59,7 @@ func runTrackCommand(clArgs []string) {
 	trackCommand.BoolVar(&args.Options.History, "history", false, "Show the difference between all enumeration pairs")
 	trackCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
 	trackCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
-	trackCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI configuration file. Additional details below")
+	trackCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the INI or YAML configuration file. Additional details below")
 	trackCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
 	trackCommand.StringVar(&args.Filepaths.Domains, "df", "", "Path to a file providing root domain names")
 
@@ -133,7 +134,7 @@ func runTrackCommand(clArgs []string) {
 	}
 
 	// Connect with the graph database containing the enumeration data
-	db := openGraphDatabase(args.Filepaths.Directory, cfg)
+	db := openGraphDatabase(args.Filepaths.Directory, cfg, args.Domains.Slice())
 	if db == nil {
 		r.Fprintln(color.Error, "Failed to connect with the database")
 		os.Exit(1)
@@ -209,7 +210,7 @@ func printOneEvent(uuid, domains []string, earliest, latest time.Time, db *netma
 		blue("and"), yellow(earliest.Format(timeFormat)), blue(" -> "), yellow(latest.Format(timeFormat)))
 	blueLine()
 
-	for _, d := range diffEnumOutput([]*requests.Output{}, one) {
+	for _, d := range diffEnumOutput([]*requests.Output{}, one, db) {
 		fmt.Fprintln(color.Output, d)
 	}
 }
@@ -226,7 +227,7 @@ func cumulativeOutput(uuids, domains []string, ea, la []time.Time, db *netmap.Gr
 
 	var updates bool
 	out := getScopedOutput([]string{uuids[idx]}, domains, db, cache)
-	for _, d := range diffEnumOutput(cum, out) {
+	for _, d := range diffEnumOutput(cum, out, db) {
 		updates = true
 		fmt.Fprintln(color.Output, d)
 	}
@@ -270,7 +271,7 @@ func completeHistoryOutput(uuids, domains []string, ea, la []time.Time, db *netm
 		var updates bool
 		out1 := getScopedOutput([]string{prev}, domains, db, cache)
 		out2 := getScopedOutput([]string{uuid}, domains, db, cache)
-		for _, d := range diffEnumOutput(out1, out2) {
+		for _, d := range diffEnumOutput(out1, out2, db) {
 			updates = true
 			fmt.Fprintln(color.Output, d)
 		}
@@ -288,7 +289,7 @@ func blueLine() {
 	fmt.Println()
 }
 
-func diffEnumOutput(older, newer []*requests.Output) []string {
+func diffEnumOutput(older, newer []*requests.Output, db *netmap.Graph) []string {
 	oldmap := make(map[string]*requests.Output)
 	newmap := make(map[string]*requests.Output)
 
@@ -317,6 +318,12 @@ func diffEnumOutput(older, newer []*requests.Output) []string {
 
 	for name, o := range oldmap {
 		if _, found := newmap[name]; !found {
+			// Names confirmed to no longer exist are distinguished from those not discovered again
+			if since, gone := enum.ReadDisappeared(db, name); gone {
+				diff = append(diff, fmt.Sprintf("%s%s %s", blue("Disappeared: "),
+					green(name), yellow("(NXDOMAIN since "+since.Format(timeFormat)+")")))
+				continue
+			}
 			diff = append(diff, fmt.Sprintf("%s%s %s", blue("Removed: "),
 				green(name), yellow(lineOfAddresses(o.Addresses))))
 		}
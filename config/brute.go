@@ -5,11 +5,28 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/caffix/stringset"
 	"github.com/go-ini/ini"
 )
 
+// DefaultBruteChunkSize is the number of brute forced names under a subdomain that have their
+// hit rate measured together.
+const DefaultBruteChunkSize = 10000
+
+// DefaultBruteHitFloor is the fraction of the brute forced names in a chunk that must resolve
+// for the chunk to not be counted towards the truncation.
+const DefaultBruteHitFloor = 0.0001
+
+// DefaultBruteLowChunks is the number of consecutive chunks below the hit rate floor that stop
+// brute forcing the subdomain.
+const DefaultBruteLowChunks = 2
+
+// DefaultTLDList is the top-level domains substituted in the discovered names, in addition to
+// the TLDs listed in the alterations section.
+var DefaultTLDList = []string{"com", "net", "org", "io"}
+
 func (c *Config) loadBruteForceSettings(cfg *ini.File) error {
 	bruteforce, err := cfg.GetSection("bruteforce")
 	if err != nil {
@@ -23,6 +40,18 @@ func (c *Config) loadBruteForceSettings(cfg *ini.File) error {
 
 	c.Recursive = bruteforce.Key("recursive").MustBool(true)
 	c.MinForRecursive = bruteforce.Key("minimum_for_recursive").MustInt(0)
+	c.DynamicWordlist = bruteforce.Key("dynamic_wordlist").MustBool(false)
+
+	c.AdaptiveBruteForcing = bruteforce.Key("adaptive_truncation").MustBool(true)
+	if size := bruteforce.Key("chunk_size").MustInt(0); size > 0 {
+		c.BruteChunkSize = size
+	}
+	if floor := bruteforce.Key("hit_rate_floor").MustFloat64(-1); floor >= 0 && floor <= 1 {
+		c.BruteHitFloor = floor
+	}
+	if chunks := bruteforce.Key("low_chunks").MustInt(0); chunks > 0 {
+		c.BruteLowChunks = chunks
+	}
 
 	if bruteforce.HasKey("wordlist_file") {
 		for _, wordlist := range bruteforce.Key("wordlist_file").ValueWithShadows() {
@@ -34,7 +63,12 @@ func (c *Config) loadBruteForceSettings(cfg *ini.File) error {
 		}
 	}
 
+	if bruteforce.HasKey("mask") {
+		c.BruteMasks = append(c.BruteMasks, bruteforce.Key("mask").ValueWithShadows()...)
+	}
+
 	c.Wordlist = stringset.Deduplicate(c.Wordlist)
+	c.BruteMasks = stringset.Deduplicate(c.BruteMasks)
 	return nil
 }
 
@@ -55,6 +89,18 @@ func (c *Config) loadAlterationSettings(cfg *ini.File) error {
 	c.AddNumbers = alterations.Key("add_numbers").MustBool(true)
 	c.MinForWordFlip = alterations.Key("minimum_for_word_flip").MustInt(2)
 	c.EditDistance = alterations.Key("edit_distance").MustInt(1)
+	c.FillSequences = alterations.Key("fill_sequences").MustBool(true)
+	c.SequenceMargin = alterations.Key("sequence_margin").MustInt(5)
+	c.MaxSequenceNames = alterations.Key("max_sequence_names").MustInt(100)
+	c.TLDSubstitution = alterations.Key("tld_substitution").MustBool(true)
+
+	if alterations.HasKey("tld") {
+		for _, tld := range alterations.Key("tld").ValueWithShadows() {
+			if tld = strings.Trim(strings.ToLower(strings.TrimSpace(tld)), "."); tld != "" {
+				c.TLDList = append(c.TLDList, tld)
+			}
+		}
+	}
 
 	if alterations.HasKey("wordlist_file") {
 		for _, wordlist := range alterations.Key("wordlist_file").ValueWithShadows() {
@@ -67,5 +113,6 @@ func (c *Config) loadAlterationSettings(cfg *ini.File) error {
 	}
 
 	c.AltWordlist = stringset.Deduplicate(c.AltWordlist)
+	c.TLDList = stringset.Deduplicate(c.TLDList)
 	return nil
 }
@@ -19,8 +19,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/OWASP/Amass/v3/config/statik" // The content being embedded into the binary
+	amassnet "github.com/OWASP/Amass/v3/net"
 	"github.com/caffix/stringset"
 	"github.com/go-ini/ini"
 	"github.com/google/uuid"
@@ -29,6 +31,56 @@ import (
 
 const (
 	outputDirectoryName = "amass"
+
+	// DefaultSelfTestDomain is the canary domain used when checking the system components.
+	DefaultSelfTestDomain = "owasp.org"
+
+	// DefaultIPv6SweepPrefix is the shortest prefix length of the IPv6 netblocks that have
+	// all their addresses investigated by default.
+	DefaultIPv6SweepPrefix = 116
+
+	// MinIPv6SweepPrefix is the shortest prefix length accepted for sweeping all the
+	// addresses of IPv6 netblocks.
+	MinIPv6SweepPrefix = 104
+
+	// DefaultSweepBlockPrefix is the prefix length of the IPv4 blocks swept by reverse DNS
+	// around the addresses of resolved names by default.
+	DefaultSweepBlockPrefix = 24
+
+	// MinSweepBlockPrefix is the shortest prefix length accepted for the reverse DNS sweep blocks.
+	MinSweepBlockPrefix = 16
+
+	// DefaultSweepRate is the number of reverse DNS sweep queries released per second by default.
+	DefaultSweepRate = 100
+
+	// DefaultConsistencyNames is the number of names, beyond the apex domain names, that have
+	// their answers verified across distinct resolvers by default.
+	DefaultConsistencyNames = 25
+
+	// DefaultRotationSamples is the number of additional queries sent by default for the names
+	// that appear to be answered by a rotating DNS load balancer.
+	DefaultRotationSamples = 3
+
+	// DefaultRotationTTL is the TTL in seconds below which the answers are sampled by default.
+	DefaultRotationTTL = 60
+
+	// DefaultRotationInterval is the number of seconds between the samples by default.
+	DefaultRotationInterval = 2
+
+	// DefaultASNCacheTTL is the age at which the persisted ASN information is considered stale.
+	DefaultASNCacheTTL = 7 * 24 * time.Hour
+
+	// DefaultUntrustedQueryShare is the fraction of the concurrent DNS queries available to the
+	// names from scraping and guessing by default.
+	DefaultUntrustedQueryShare = 0.5
+)
+
+// The log levels that set the minimum severity of the messages written to the Log.
+const (
+	LogLevelDebug   = "debug"
+	LogLevelInfo    = "info"
+	LogLevelWarning = "warning"
+	LogLevelError   = "error"
 )
 
 var (
@@ -53,9 +105,15 @@ type Config struct {
 	// A Universally Unique Identifier (UUID) for the enumeration
 	UUID uuid.UUID
 
+	// The UUID of an interrupted enumeration in the graph databases to resume
+	ResumeEvent string
+
 	// Logger for error messages
 	Log *log.Logger
 
+	// The minimum severity of the messages written to the Log: debug, info, warning or error
+	LogLevel string `ini:"log_level"`
+
 	// The directory that stores the bolt db and other files created
 	Dir string `ini:"output_directory"`
 
@@ -71,15 +129,78 @@ type Config struct {
 	// The maximum number of concurrent DNS queries
 	MaxDNSQueries int `ini:"maximum_dns_queries"`
 
+	// The number of resolved names in a proper subdomain before its untrusted discoveries are sampled
+	MaxNamesPerSubdomain int `ini:"max_names_per_subdomain"`
+
+	// The maximum number of DNS queries per second for the names within each zone. Zones served by
+	// the same authoritative name servers share the limit. The queries are not limited when zero
+	MaxQueriesPerDomain int `ini:"max_queries_per_domain"`
+
+	// Will the names from trusted sources be processed before the names from scraping and guessing?
+	PriorityNames bool `ini:"priority_names"`
+
+	// The fraction of the concurrent DNS queries available to the names from scraping and guessing,
+	// which are processed separately from the names provided by the trusted sources
+	UntrustedQueryShare float64 `ini:"untrusted_query_share"`
+
 	// Names provided to seed the enumeration
 	ProvidedNames []string
 
+	// The well-known domain name used to check the system components
+	SelfTestDomain string `ini:"self_test_domain"`
+
+	// Names that have their lifecycle traced through the enumeration
+	TraceNames []string
+
+	// The DNS record types queried for each discovered name. The default types are queried when empty
+	QueryTypes []string
+
+	// Will the apex domain names be resolved and included in the output?
+	ApexRecords bool `ini:"apex_records"`
+
+	// Will the answers for the apex domain names, and up to ConsistencyNames of the other names
+	// reported by the most data sources, be verified across distinct resolvers once the
+	// enumeration completes? Each verified name costs two queries sent to each resolver
+	VerifyConsistency bool `ini:"verify_consistency"`
+	ConsistencyNames  int  `ini:"consistency_names"`
+
+	// Will the names answered with several addresses, or with a TTL below RotationTTL seconds, be
+	// queried RotationSamples more times, RotationInterval seconds apart, to collect the addresses
+	// rotated by DNS load balancers? The samples are not sent once the resolution phase budget expires
+	SampleRotation   bool `ini:"sample_rotation"`
+	RotationSamples  int  `ini:"rotation_samples"`
+	RotationTTL      int  `ini:"rotation_ttl"`
+	RotationInterval int  `ini:"rotation_interval"`
+
+	// Will the enumeration stop once the rate of discovery has plateaued?
+	AutoStopOnPlateau bool `ini:"auto_stop_on_plateau"`
+
+	// The fraction of the peak discovery rate that indicates a plateau
+	PlateauFraction float64 `ini:"plateau_fraction"`
+
+	// The number of consecutive buckets below the fraction required to signal a plateau
+	PlateauBuckets int `ini:"plateau_buckets"`
+
+	// The time spent on each enumeration phase, keyed by the phase name, after which no further
+	// work is dispatched for the phase. The phases without a budget are not limited
+	PhaseBudgets map[string]time.Duration
+
 	// The IP addresses specified as in scope
 	Addresses []net.IP
 
 	// CIDR that is in scope
 	CIDRs []*net.IPNet
 
+	// IPv6 netblocks with at least this prefix length have all their addresses investigated,
+	// while likely host addresses are sampled from the larger IPv6 netblocks
+	IPv6SweepPrefix int `ini:"ipv6_sweep_prefix"`
+
+	// The addresses of resolved names have the IPv4 block with this prefix length, or the IPv6
+	// block with as many addresses, swept by reverse DNS once per enumeration. No more than
+	// SweepRate of the addresses are released per second, and the rate is not limited when zero
+	SweepBlockPrefix int `ini:"sweep_block_prefix"`
+	SweepRate        int `ini:"sweep_rate"`
+
 	// ASNs specified as in scope
 	ASNs []int
 
@@ -98,6 +219,20 @@ type Config struct {
 	// Minimum number of subdomain discoveries before performing recursive brute forcing
 	MinForRecursive int
 
+	// The "hashcat-style" masks that generate labels during brute forcing, optionally
+	// followed by the subdomain name the labels are generated under
+	BruteMasks []string
+
+	// Will the labels of the resolved names be tried first when brute forcing other subdomains?
+	DynamicWordlist bool
+
+	// Brute forcing a subdomain stops once the rate of resolved names stays below the floor for
+	// consecutive chunks of candidates, unless the adaptive truncation is disabled
+	AdaptiveBruteForcing bool
+	BruteChunkSize       int
+	BruteHitFloor        float64
+	BruteLowChunks       int
+
 	// Will discovered subdomain name alterations be generated?
 	Alterations    bool
 	FlipWords      bool
@@ -108,6 +243,18 @@ type Config struct {
 	EditDistance   int
 	AltWordlist    []string
 
+	// Will the gaps of the numeric sequences in resolved names be filled, and by what
+	// margin will each sequence be extended? No more names are generated for each sequence
+	// than the maximum.
+	FillSequences    bool
+	SequenceMargin   int
+	MaxSequenceNames int
+
+	// Will the top-level domain of discovered names be replaced with the TLDs in the list?
+	// Only the names under the root domain names in scope are generated.
+	TLDSubstitution bool
+	TLDList         []string
+
 	// Only access the data sources for names and return results?
 	Passive bool
 
@@ -126,13 +273,76 @@ type Config struct {
 	// The minimum number of minutes that data source responses will be reused
 	MinimumTTL int
 
+	// The number of data source requests allocated per enumeration by historical yield (0 = unlimited)
+	SourceBudget int
+
+	// The share of the data sources phase budget, or of the time elapsed when the phase has no
+	// budget, that passes between the reallocations of the unused data source requests
+	SourceBudgetReallocation float64
+
+	// The data sources with more consecutive failed HTTP requests than the threshold have their
+	// requests dropped for the cool-down period (0 = the circuit breakers are disabled)
+	SourceFailureThreshold int
+	SourceCooldown         time.Duration
+
+	// The time between the requests sent to each data source, keyed by the lowercase data source
+	// name, in place of the rate limit set by the data source
+	DataSourceRateLimits map[string]time.Duration
+
+	// The file that persists the ASN and netblock information obtained from the data sources
+	// between runs, and the age at which the persisted entries are obtained again
+	ASNCacheFile string
+	ASNCacheTTL  time.Duration
+
+	// The log events are forwarded in batches to the collector at the URL, which is an http or https
+	// endpoint receiving JSON arrays, or a syslog target with the udp scheme. Identical events are
+	// forwarded once within the deduplication window, and the oldest events are dropped once the
+	// buffer is full while the collector is not reachable
+	LogForwardURL       string
+	LogForwardBatchSize int
+	LogForwardInterval  time.Duration
+	LogForwardBuffer    int
+	LogForwardDedup     time.Duration
+
+	// The token authenticating the workers of a coordinated enumeration with the leader, the time
+	// a worker holds an assignment without reporting before it is issued again, and the most words
+	// of the brute forcing wordlist in each assignment
+	CoordinationToken string
+	CoordinationLease time.Duration
+	WordlistShardSize int
+
+	// The data sources skip the records most recently observed longer ago than the maximum age,
+	// and passive enumerations exclude the names with such observations (0 = no records are skipped)
+	MaxRecordAge time.Duration
+
 	// Type of DNS records to query for
 	RecordTypes []string
 
+	// Signatures used to identify the DNS hosting providers of in-scope zones
+	NameServerSignatures []*NameServerSignature
+
 	// Resolver settings
 	Resolvers           []string
 	MonitorResolverRate bool
 
+	// Select the resolvers for each query by their recent round-trip times and timeouts
+	WeightedResolvers bool
+
+	// The weighted pool quarantines a resolver that returns more false answers than the
+	// threshold within a minute, and admits it again after the cool-down period
+	QuarantineThreshold int
+	QuarantineCooldown  time.Duration
+
+	// The transport used to query the trusted resolvers: udp, tcp, dot or doh
+	ResolverTransport string
+
+	// The SOCKS5 proxy that the DNS queries and the data source traffic are sent through
+	Proxy string `ini:"proxy"`
+
+	// The maximum time spent on each DNS query, including retries, indexed by the query priority.
+	// A zero value selects the default deadline for the priority
+	QueryDeadlines [4]time.Duration
+
 	// Option for verbose logging and output
 	Verbose bool
 
@@ -142,6 +352,9 @@ type Config struct {
 	// The regular expressions for the root domains added to the enumeration
 	regexps map[string]*regexp.Regexp
 
+	// The resolvers used for the names within each special-use TLD
+	specialUseResolvers map[string][]string
+
 	// The data source configurations
 	datasrcConfigs map[string]*DataSourceConfig
 }
@@ -151,10 +364,24 @@ func NewConfig() *Config {
 	c := &Config{
 		UUID:                uuid.New(),
 		Log:                 log.New(ioutil.Discard, "", 0),
+		LogLevel:            LogLevelInfo,
 		Ports:               []int{80, 443},
 		MinForRecursive:     1,
+		IPv6SweepPrefix:     DefaultIPv6SweepPrefix,
+		SweepBlockPrefix:    DefaultSweepBlockPrefix,
+		SweepRate:           DefaultSweepRate,
 		MonitorResolverRate: true,
+		ResolverTransport:   TransportUDP,
+		QuarantineThreshold: DefaultQuarantineThreshold,
+		QuarantineCooldown:  DefaultQuarantineCooldown,
 		LocalDatabase:       true,
+		ApexRecords:         true,
+		ConsistencyNames:    DefaultConsistencyNames,
+		RotationSamples:     DefaultRotationSamples,
+		RotationTTL:         DefaultRotationTTL,
+		RotationInterval:    DefaultRotationInterval,
+		UntrustedQueryShare: DefaultUntrustedQueryShare,
+		SelfTestDomain:      DefaultSelfTestDomain,
 		// The following is enum-only, but intel will just ignore them anyway
 		Alterations:    true,
 		FlipWords:      true,
@@ -164,7 +391,36 @@ func NewConfig() *Config {
 		MinForWordFlip: 2,
 		EditDistance:   1,
 		Recursive:      true,
-		MinimumTTL:     1440,
+		// Adaptive truncation of brute forcing for the subdomains with few names
+		AdaptiveBruteForcing: true,
+		BruteChunkSize:       DefaultBruteChunkSize,
+		BruteHitFloor:        DefaultBruteHitFloor,
+		BruteLowChunks:       DefaultBruteLowChunks,
+		// Numeric sequences found in the resolved names
+		FillSequences:    true,
+		SequenceMargin:   5,
+		MaxSequenceNames: 100,
+		MinimumTTL:       1440,
+		ASNCacheTTL:      DefaultASNCacheTTL,
+		// Top-level domains substituted in the discovered names
+		TLDSubstitution: true,
+		TLDList:         append([]string(nil), DefaultTLDList...),
+		// Circuit breakers for the data sources that keep failing
+		SourceFailureThreshold: DefaultSourceFailureThreshold,
+		SourceCooldown:         DefaultSourceCooldown,
+		// Reallocation of the unused data source requests
+		SourceBudgetReallocation: DefaultSourceBudgetReallocation,
+		// Forwarding of the log events to an external collector
+		LogForwardBatchSize: DefaultLogForwardBatchSize,
+		LogForwardInterval:  DefaultLogForwardInterval,
+		LogForwardBuffer:    DefaultLogForwardBuffer,
+		LogForwardDedup:     DefaultLogForwardDedupWindow,
+		// Partitioning of the work of coordinated enumerations
+		CoordinationLease: DefaultCoordinationLease,
+		WordlistShardSize: DefaultWordlistShardSize,
+		// Plateau detection for the rate of discovery
+		PlateauFraction: 0.1,
+		PlateauBuckets:  3,
 	}
 
 	c.calcDNSQueriesMax()
@@ -190,9 +446,46 @@ func (c *Config) CheckSettings() error {
 			}
 		}
 	}
+	for _, mask := range c.BruteMasks {
+		if _, _, err := ParseBruteMask(mask); err != nil {
+			return err
+		}
+	}
 	if c.Passive && c.Active {
 		return errors.New("Active enumeration cannot be performed without DNS resolution")
 	}
+	if c.IPv6SweepPrefix < MinIPv6SweepPrefix || c.IPv6SweepPrefix > 128 {
+		return fmt.Errorf("The IPv6 sweep prefix length must be between %d and 128", MinIPv6SweepPrefix)
+	}
+	if c.SweepBlockPrefix < MinSweepBlockPrefix || c.SweepBlockPrefix > 32 {
+		return fmt.Errorf("The sweep block prefix length must be between %d and 32", MinSweepBlockPrefix)
+	}
+	if c.SweepRate < 0 {
+		return errors.New("The sweep rate cannot be negative")
+	}
+	if c.UntrustedQueryShare <= 0 || c.UntrustedQueryShare >= 1 {
+		return errors.New("The untrusted query share must be greater than zero and less than one")
+	}
+	if c.RotationSamples < 0 || c.RotationTTL < 0 || c.RotationInterval < 0 {
+		return errors.New("The rotation samples, TTL and interval cannot be negative")
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "", LogLevelDebug, LogLevelInfo, LogLevelWarning, LogLevelError:
+	default:
+		return fmt.Errorf("The log level %s is not one of debug, info, warning or error", c.LogLevel)
+	}
+	if c.Proxy != "" {
+		if _, err := amassnet.ParseProxyURL(c.Proxy); err != nil {
+			return err
+		}
+		if c.ResolverTransport == TransportUDP {
+			return errors.New("The udp resolver transport cannot be used with the SOCKS5 proxy, since UDP " +
+				"is not reliably supported by proxies: select the tcp, dot or doh transport")
+		}
+	}
+	if err := c.checkSpecialUse(); err != nil {
+		return err
+	}
 	if c.Alterations {
 		if len(c.AltWordlist) == 0 {
 			c.AltWordlist, err = getWordlistByFS("/alterations.txt")
@@ -214,17 +507,43 @@ func (c *Config) CheckSettings() error {
 	return err
 }
 
+// The options used to read the INI settings, which are shared by the YAML settings.
+var iniLoadOptions = ini.LoadOptions{
+	Insensitive:  true,
+	AllowShadows: true,
+}
+
+// The options used to read the settings named by their sections, such as the DNS hosting
+// providers, so the names are kept as written.
+var iniNamedLoadOptions = ini.LoadOptions{
+	InsensitiveKeys: true,
+	AllowShadows:    true,
+}
+
 // LoadSettings parses settings from an .ini file and assigns them to the Config.
+// Files with the .yaml or .yml extension are parsed by LoadYAML instead.
 func (c *Config) LoadSettings(path string) error {
-	cfg, err := ini.LoadSources(ini.LoadOptions{
-		Insensitive:  true,
-		AllowShadows: true,
-	}, path)
+	if isYAMLFile(path) {
+		return c.LoadYAML(path)
+	}
+
+	cfg, err := ini.LoadSources(iniLoadOptions, path)
 	if err != nil {
 		return fmt.Errorf("Failed to load the configuration file: %v", err)
 	}
+
+	named, err := ini.LoadSources(iniNamedLoadOptions, path)
+	if err != nil {
+		return fmt.Errorf("Failed to load the configuration file: %v", err)
+	}
+	return c.loadSettings(cfg, named)
+}
+
+// loadSettings assigns the settings to the Config regardless of the file format they were read from.
+// The named file holds the same settings with the section names kept as written.
+func (c *Config) loadSettings(cfg, named *ini.File) error {
 	// Get the easy ones out of the way using mapping
-	if err = cfg.MapTo(c); err != nil {
+	if err := cfg.MapTo(c); err != nil {
 		return fmt.Errorf("Error mapping configuration settings to internal values: %v", err)
 	}
 	// Attempt to load a special mode of operation specified by the user
@@ -238,13 +557,26 @@ func (c *Config) LoadSettings(path string) error {
 		}
 	}
 
+	if sec := cfg.Section(ini.DefaultSection); sec.HasKey("trace_name") {
+		c.TraceNames = stringset.Deduplicate(sec.Key("trace_name").ValueWithShadows())
+	}
+	if sec := cfg.Section(ini.DefaultSection); sec.HasKey("query_type") {
+		if err := c.SetQueryTypes(sec.Key("query_type").ValueWithShadows()...); err != nil {
+			return err
+		}
+	}
+
 	loads := []func(cfg *ini.File) error{
 		c.loadResolverSettings,
+		c.loadSpecialUseSettings,
 		c.loadScopeSettings,
 		c.loadAlterationSettings,
 		c.loadBruteForceSettings,
 		c.loadDatabaseSettings,
 		c.loadDataSourceSettings,
+		c.loadPhaseBudgetSettings,
+		c.loadLogForwardSettings,
+		c.loadCoordinationSettings,
 	}
 	for _, load := range loads {
 		if err := load(cfg); err != nil {
@@ -252,7 +584,7 @@ func (c *Config) LoadSettings(path string) error {
 		}
 	}
 
-	return nil
+	return c.loadNameServerSettings(named)
 }
 
 // AcquireConfig populates the Config struct provided by the Config argument.
@@ -266,6 +598,15 @@ func AcquireConfig(dir, file string, cfg *Config) error {
 	} else if d := OutputDirectory(dir); d != "" {
 		if finfo, err := os.Stat(d); !os.IsNotExist(err) && finfo.IsDir() {
 			path = filepath.Join(d, "config.ini")
+			// The YAML configuration file is discovered when the INI file does not exist
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				for _, name := range []string{"config.yaml", "config.yml"} {
+					if _, err := os.Stat(filepath.Join(d, name)); err == nil {
+						path = filepath.Join(d, name)
+						break
+					}
+				}
+			}
 		}
 	}
 
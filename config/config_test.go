@@ -4,10 +4,13 @@
 package config
 
 import (
+	"io/ioutil"
 	"net"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestCheckSettings(t *testing.T) {
@@ -110,3 +113,195 @@ func TestLoadSettings(t *testing.T) {
 		t.Errorf("Config file failed to load.")
 	}
 }
+
+func TestSetQueryTypes(t *testing.T) {
+	c := NewConfig()
+
+	if err := c.SetQueryTypes("cname", "A", " srv", "A", ""); err != nil {
+		t.Fatalf("Failed to set the supported query types: %v", err)
+	}
+	if expected := []string{"CNAME", "A", "SRV"}; !reflect.DeepEqual(c.QueryTypes, expected) {
+		t.Errorf("Expected the query types %v, got %v", expected, c.QueryTypes)
+	}
+
+	if err := c.SetQueryTypes("A", "NAPTR"); err == nil {
+		t.Errorf("The unsupported query type was accepted")
+	}
+	if len(c.QueryTypes) != 3 {
+		t.Errorf("The query types were changed by the failed assignment")
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	c := NewConfig()
+	if c.LogLevel != LogLevelInfo {
+		t.Errorf("Expected the default log level %s, got %s", LogLevelInfo, c.LogLevel)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := ioutil.WriteFile(path, []byte("log_level = warning\n\n[data_sources]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil || c.LogLevel != LogLevelWarning {
+		t.Errorf("Failed to load the log level: %v", err)
+	}
+	if err := c.CheckSettings(); err != nil {
+		t.Errorf("The log level was rejected: %v", err)
+	}
+
+	c.LogLevel = "verbose"
+	if err := c.CheckSettings(); err == nil {
+		t.Error("The unknown log level was accepted")
+	}
+}
+
+func TestUntrustedQueryShare(t *testing.T) {
+	c := NewConfig()
+	if c.UntrustedQueryShare != DefaultUntrustedQueryShare {
+		t.Errorf("Expected the default untrusted query share %g, got %g", DefaultUntrustedQueryShare, c.UntrustedQueryShare)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := ioutil.WriteFile(path, []byte("untrusted_query_share = 0.25\n\n[data_sources]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil || c.UntrustedQueryShare != 0.25 {
+		t.Errorf("Failed to load the untrusted query share: %v", err)
+	}
+	if err := c.CheckSettings(); err != nil {
+		t.Errorf("The untrusted query share was rejected: %v", err)
+	}
+
+	for _, share := range []float64{0, 1, -0.5} {
+		c.UntrustedQueryShare = share
+		if err := c.CheckSettings(); err == nil {
+			t.Errorf("The untrusted query share %g was accepted", share)
+		}
+	}
+}
+
+func TestRotationSettings(t *testing.T) {
+	c := NewConfig()
+	if c.SampleRotation || c.RotationSamples != DefaultRotationSamples || c.RotationTTL != DefaultRotationTTL {
+		t.Errorf("Unexpected default rotation settings: %v %d %d", c.SampleRotation, c.RotationSamples, c.RotationTTL)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	data := "sample_rotation = true\nrotation_samples = 5\nrotation_ttl = 30\nrotation_interval = 0\n\n[data_sources]\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the rotation settings: %v", err)
+	}
+	if !c.SampleRotation || c.RotationSamples != 5 || c.RotationTTL != 30 || c.RotationInterval != 0 {
+		t.Errorf("Unexpected rotation settings: %v %d %d %d", c.SampleRotation, c.RotationSamples, c.RotationTTL, c.RotationInterval)
+	}
+	if err := c.CheckSettings(); err != nil {
+		t.Errorf("The rotation settings were rejected: %v", err)
+	}
+
+	c.RotationSamples = -1
+	if err := c.CheckSettings(); err == nil {
+		t.Errorf("The negative number of rotation samples was accepted")
+	}
+}
+
+func TestSweepSettings(t *testing.T) {
+	c := NewConfig()
+	if c.SweepBlockPrefix != DefaultSweepBlockPrefix || c.SweepRate != DefaultSweepRate {
+		t.Errorf("Unexpected default sweep settings: %d %d", c.SweepBlockPrefix, c.SweepRate)
+	}
+
+	for _, prefix := range []int{MinSweepBlockPrefix - 1, 33} {
+		c.SweepBlockPrefix = prefix
+		if err := c.CheckSettings(); err == nil {
+			t.Errorf("The sweep block prefix length %d was accepted", prefix)
+		}
+	}
+
+	c.SweepBlockPrefix = DefaultSweepBlockPrefix
+	c.SweepRate = -1
+	if err := c.CheckSettings(); err == nil {
+		t.Errorf("The negative sweep rate was accepted")
+	}
+}
+
+func TestTLDList(t *testing.T) {
+	c := NewConfig()
+	if !c.TLDSubstitution || len(c.TLDList) != len(DefaultTLDList) {
+		t.Errorf("Expected the default TLD list %v, got %v", DefaultTLDList, c.TLDList)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	settings := "[alterations]\ntld = DEV\ntld = .co\ntld = net\n\n[data_sources]\n"
+	if err := ioutil.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the TLD list: %v", err)
+	}
+	// The listed TLDs are added to the defaults
+	if len(c.TLDList) != len(DefaultTLDList)+2 {
+		t.Errorf("The TLD list was not extended: %v", c.TLDList)
+	}
+	for _, tld := range []string{"dev", "co", "net"} {
+		var found bool
+		for _, listed := range c.TLDList {
+			if listed == tld {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("The TLD %s is missing from the list: %v", tld, c.TLDList)
+		}
+	}
+}
+
+func TestLogForwardSettings(t *testing.T) {
+	c := NewConfig()
+	if c.LogForwardURL != "" || c.LogForwardBatchSize != DefaultLogForwardBatchSize {
+		t.Errorf("Unexpected default log forwarding settings")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	settings := "[log_forwarding]\nurl = udp://127.0.0.1\nbatch_size = 50\nflush_interval = 30\ndedup_window = 0\n\n[data_sources]\n"
+	if err := ioutil.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the log forwarding settings: %v", err)
+	}
+	if c.LogForwardURL != "udp://127.0.0.1:514" || c.LogForwardBatchSize != 50 ||
+		c.LogForwardInterval != 30*time.Second || c.LogForwardDedup != 0 {
+		t.Errorf("Unexpected log forwarding settings: %s %d %v %v",
+			c.LogForwardURL, c.LogForwardBatchSize, c.LogForwardInterval, c.LogForwardDedup)
+	}
+
+	for _, u := range []string{"ftp://collector.example.com", "https://", "collector.example.com"} {
+		if _, err := ParseLogForwardURL(u); err == nil {
+			t.Errorf("The log collector URL %s was accepted", u)
+		}
+	}
+}
+
+func TestCoordinationSettings(t *testing.T) {
+	c := NewConfig()
+	if c.CoordinationToken != "" || c.CoordinationLease != DefaultCoordinationLease ||
+		c.WordlistShardSize != DefaultWordlistShardSize {
+		t.Errorf("Unexpected default coordination settings")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.ini")
+	settings := "[coordination]\ntoken = secret\nlease = 30\nshard_size = 250\n\n[data_sources]\n"
+	if err := ioutil.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the coordination settings: %v", err)
+	}
+	if c.CoordinationToken != "secret" || c.CoordinationLease != 30*time.Second || c.WordlistShardSize != 250 {
+		t.Errorf("Unexpected coordination settings: %s %v %d",
+			c.CoordinationToken, c.CoordinationLease, c.WordlistShardSize)
+	}
+}
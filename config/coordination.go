@@ -0,0 +1,39 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+const (
+	// DefaultCoordinationLease is the time a worker holds an assignment without reporting to the
+	// leader, after which the assignment is issued to another worker.
+	DefaultCoordinationLease = time.Minute
+
+	// DefaultWordlistShardSize is the most words of the brute forcing wordlist in each assignment.
+	DefaultWordlistShardSize = 1000
+)
+
+// loadCoordinationSettings loads the settings shared by the leader and the workers of a coordinated
+// enumeration. The lease is provided in seconds.
+func (c *Config) loadCoordinationSettings(cfg *ini.File) error {
+	sec, err := cfg.GetSection("coordination")
+	if err != nil {
+		return nil
+	}
+
+	if sec.HasKey("token") {
+		c.CoordinationToken = sec.Key("token").String()
+	}
+	if secs := sec.Key("lease").MustInt(0); secs > 0 {
+		c.CoordinationLease = time.Duration(secs) * time.Second
+	}
+	if size := sec.Key("shard_size").MustInt(0); size > 0 {
+		c.WordlistShardSize = size
+	}
+	return nil
+}
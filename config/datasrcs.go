@@ -7,16 +7,32 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"time"
 
 	"github.com/caffix/stringset"
 	"github.com/go-ini/ini"
 )
 
+// DefaultSourceFailureThreshold is the number of consecutive failed HTTP requests that trip
+// the circuit breaker of a data source.
+const DefaultSourceFailureThreshold = 5
+
+// DefaultSourceCooldown is the time the requests for a data source are dropped once its circuit
+// breaker has tripped.
+const DefaultSourceCooldown = 5 * time.Minute
+
+// DefaultSourceBudgetReallocation is the share of the data sources phase budget, or of the time
+// elapsed, that passes between the reallocations of the unused data source request budget.
+const DefaultSourceBudgetReallocation = 0.5
+
 // DataSourceConfig contains the configurations specific to a data source.
 type DataSourceConfig struct {
-	Name  string
-	TTL   int `ini:"ttl"`
-	creds map[string]*Credentials
+	Name        string
+	TTL         int `ini:"ttl"`
+	MaxRequests int `ini:"max_requests"`
+	// The time between the requests sent to the data source, in place of its own rate limit
+	RateLimit time.Duration `ini:"rate_limit"`
+	creds     map[string]*Credentials
 }
 
 // Credentials contains values required for authenticating with web APIs.
@@ -49,6 +65,29 @@ func (c *Config) GetDataSourceConfig(source string) *DataSourceConfig {
 	return c.datasrcConfigs[key]
 }
 
+// DataSourceRateLimit returns the time between the requests sent to the data source configured
+// by the user, or zero when the data source keeps its own rate limit. The SOURCENAME_rate_limit
+// keys take precedence over the rate_limit in the section of the data source.
+func (c *Config) DataSourceRateLimit(source string) time.Duration {
+	c.Lock()
+	defer c.Unlock()
+
+	key := strings.ToLower(strings.TrimSpace(source))
+	if d, found := c.DataSourceRateLimits[key]; found {
+		return d
+	}
+	if dsc, found := c.datasrcConfigs[key]; found && dsc.RateLimit > 0 {
+		return dsc.RateLimit
+	}
+	return 0
+}
+
+// RecordTooOld returns true when the time a record was most recently observed is older than the
+// maximum record age. Records without an observation time are never too old.
+func (c *Config) RecordTooOld(last time.Time) bool {
+	return c.MaxRecordAge > 0 && !last.IsZero() && time.Since(last) > c.MaxRecordAge
+}
+
 // AddCredentials adds the Credentials provided to the configuration.
 func (dsc *DataSourceConfig) AddCredentials(cred *Credentials) error {
 	if cred == nil || cred.Name == "" {
@@ -87,6 +126,59 @@ func (c *Config) loadDataSourceSettings(cfg *ini.File) error {
 		}
 	}
 
+	if sec.HasKey("asn_cache_file") {
+		c.ASNCacheFile = sec.Key("asn_cache_file").String()
+	}
+	// The time-to-live of the persisted ASN information is provided in hours
+	if sec.HasKey("asn_cache_ttl") {
+		if ttl, err := sec.Key("asn_cache_ttl").Int(); err == nil && ttl >= 0 {
+			c.ASNCacheTTL = time.Duration(ttl) * time.Hour
+		}
+	}
+
+	// The maximum age of the passive DNS observations is provided in days
+	if sec.HasKey("max_record_age") {
+		if days, err := sec.Key("max_record_age").Int(); err == nil && days >= 0 {
+			c.MaxRecordAge = time.Duration(days) * 24 * time.Hour
+		}
+	}
+
+	c.SourceFailureThreshold = sec.Key("failure_threshold").MustInt(DefaultSourceFailureThreshold)
+	// The cool-down period is provided in minutes
+	if sec.HasKey("failure_cooldown") {
+		if mins, err := sec.Key("failure_cooldown").Int(); err == nil && mins >= 0 {
+			c.SourceCooldown = time.Duration(mins) * time.Minute
+		}
+	}
+
+	if sec.HasKey("request_budget") {
+		if budget, err := sec.Key("request_budget").Int(); err == nil {
+			c.SourceBudget = budget
+		}
+	}
+	if sec.HasKey("request_budget_reallocation") {
+		if share, err := sec.Key("request_budget_reallocation").Float64(); err == nil && share >= 0 && share <= 1 {
+			c.SourceBudgetReallocation = share
+		}
+	}
+
+	// The rate limits are provided as durations in the SOURCENAME_rate_limit keys
+	for _, key := range sec.Keys() {
+		name := strings.TrimSuffix(key.Name(), "_rate_limit")
+		if name == key.Name() || name == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(key.String()))
+		if err != nil || d < 0 {
+			return fmt.Errorf("The rate limit of the %s data source is invalid: %s", name, key.String())
+		}
+		if c.DataSourceRateLimits == nil {
+			c.DataSourceRateLimits = make(map[string]time.Duration)
+		}
+		c.DataSourceRateLimits[strings.ToLower(name)] = d
+	}
+
 	for _, child := range sec.ChildSections() {
 		name := strings.Split(child.Name(), ".")[1]
 
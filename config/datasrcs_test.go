@@ -5,6 +5,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-ini/ini"
 )
@@ -80,16 +81,27 @@ func TestLoadDataSourceSettings(t *testing.T) {
 		[]byte(`
 		[data_sources]
 		minimum_ttl = 1440
+		asn_cache_file = /tmp/asn_cache.json
+		asn_cache_ttl = 48
+		max_record_age = 365
+		failure_threshold = 3
+		failure_cooldown = 10
+		request_budget = 1000
+		request_budget_reallocation = 0.25
+		AlienVault_rate_limit = 2s
+		Shodan_rate_limit = 500ms
 
 		[data_sources.disabled]
 		data_source = CommonCrawl
 
 		[data_sources.AlienVault]
 		ttl = 4320
+		rate_limit = 10s
 		[data_sources.AlienVault.Credentials]
 		apikey = fake
 
 		[data_sources.BinaryEdge]
+		rate_limit = 250ms
 		[data_sources.BinaryEdge.Credentials]
 		apikey = fake2
 		`),
@@ -101,6 +113,28 @@ func TestLoadDataSourceSettings(t *testing.T) {
 	if c.MinimumTTL != 1440 {
 		t.Errorf("Failed to load global data source settings")
 	}
+	if c.ASNCacheFile != "/tmp/asn_cache.json" || c.ASNCacheTTL != 48*time.Hour {
+		t.Errorf("Failed to load the ASN cache settings")
+	}
+	if c.MaxRecordAge != 365*24*time.Hour {
+		t.Errorf("Failed to load the maximum record age")
+	}
+	if c.SourceBudget != 1000 || c.SourceBudgetReallocation != 0.25 {
+		t.Errorf("Failed to load the data source request budget settings")
+	}
+	if c.SourceFailureThreshold != 3 || c.SourceCooldown != 10*time.Minute {
+		t.Errorf("Failed to load the circuit breaker settings")
+	}
+	if c.DataSourceRateLimit("AlienVault") != 2*time.Second || c.DataSourceRateLimit("shodan") != 500*time.Millisecond {
+		t.Errorf("Failed to load the data source rate limits: %v", c.DataSourceRateLimits)
+	}
+	// The rate limit in the section of the data source is used without a SOURCENAME_rate_limit key
+	if c.DataSourceRateLimit("BinaryEdge") != 250*time.Millisecond {
+		t.Errorf("Failed to load the rate limit of the data source section")
+	}
+	if c.DataSourceRateLimit("CommonCrawl") != 0 {
+		t.Errorf("The data source without a rate limit was assigned one")
+	}
 
 	dsc := c.GetDataSourceConfig("AlienVault")
 	if dsc == nil {
@@ -110,3 +144,39 @@ func TestLoadDataSourceSettings(t *testing.T) {
 		t.Errorf("Failed to load data source credentials")
 	}
 }
+
+func TestLoadDataSourceRateLimitErrors(t *testing.T) {
+	for _, limit := range []string{"fast", "-1s", "10"} {
+		cfg, _ := ini.LoadSources(
+			ini.LoadOptions{
+				Insensitive:  true,
+				AllowShadows: true,
+			},
+			[]byte("[data_sources]\nAlienVault_rate_limit = "+limit+"\n"),
+		)
+
+		if err := NewConfig().loadDataSourceSettings(cfg); err == nil {
+			t.Errorf("Failed to report an error for the rate limit %s", limit)
+		}
+	}
+}
+
+func TestRecordTooOld(t *testing.T) {
+	c := NewConfig()
+
+	old := time.Now().AddDate(-2, 0, 0)
+	if c.RecordTooOld(old) {
+		t.Errorf("The record was too old without a maximum record age")
+	}
+
+	c.MaxRecordAge = 365 * 24 * time.Hour
+	if !c.RecordTooOld(old) {
+		t.Errorf("The record observed two years ago was not too old")
+	}
+	if c.RecordTooOld(time.Now().AddDate(0, -1, 0)) {
+		t.Errorf("The record observed a month ago was too old")
+	}
+	if c.RecordTooOld(time.Time{}) {
+		t.Errorf("The record without an observation time was too old")
+	}
+}
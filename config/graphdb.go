@@ -9,6 +9,10 @@ import (
 	"github.com/go-ini/ini"
 )
 
+// Neo4jSystem identifies the graph database that the enumeration findings are exported to over
+// the Bolt protocol, since Neo4j is not one of the Cayley backends.
+const Neo4jSystem = "neo4j"
+
 // Database contains values required for connecting with graph databases.
 type Database struct {
 	System   string
@@ -46,6 +50,18 @@ func (c *Config) loadDatabaseSettings(cfg *ini.File) error {
 	return nil
 }
 
+// Neo4jDatabases returns the Neo4j graph databases provided in the configuration.
+func (c *Config) Neo4jDatabases() []*Database {
+	var dbs []*Database
+
+	for _, db := range c.GraphDBs {
+		if db.System == Neo4jSystem {
+			dbs = append(dbs, db)
+		}
+	}
+	return dbs
+}
+
 // LocalDatabaseSettings returns the Database for the local bolt store.
 func (c *Config) LocalDatabaseSettings(dbs []*Database) *Database {
 	if !c.LocalDatabase {
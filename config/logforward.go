@@ -0,0 +1,79 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+const (
+	// DefaultLogForwardBatchSize is the most log events sent to the collector together.
+	DefaultLogForwardBatchSize = 100
+
+	// DefaultLogForwardInterval is the time between the sends of the log events buffered for the collector.
+	DefaultLogForwardInterval = 10 * time.Second
+
+	// DefaultLogForwardBuffer is the most log events buffered while the collector is not reachable.
+	DefaultLogForwardBuffer = 10000
+
+	// DefaultLogForwardDedupWindow is the time that identical log events are forwarded only once within.
+	DefaultLogForwardDedupWindow = time.Minute
+)
+
+// ParseLogForwardURL checks that the collector URL provided selects a supported transport: an
+// http or https endpoint, or a syslog target with the udp scheme.
+func ParseLogForwardURL(collector string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(collector))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the log collector URL %s: %v", collector, err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "udp":
+	default:
+		return nil, fmt.Errorf("The log collector URL %s must use the http, https or udp scheme", collector)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("The log collector URL %s does not provide a host", collector)
+	}
+	if strings.EqualFold(u.Scheme, "udp") && u.Port() == "" {
+		u.Host += ":514"
+	}
+	return u, nil
+}
+
+// loadLogForwardSettings loads the collector that the log events of the enumeration are forwarded
+// to, and how the events are batched. The intervals are provided in seconds.
+func (c *Config) loadLogForwardSettings(cfg *ini.File) error {
+	sec, err := cfg.GetSection("log_forwarding")
+	if err != nil {
+		return nil
+	}
+
+	if sec.HasKey("url") {
+		u, err := ParseLogForwardURL(sec.Key("url").String())
+		if err != nil {
+			return err
+		}
+		c.LogForwardURL = u.String()
+	}
+	if size := sec.Key("batch_size").MustInt(0); size > 0 {
+		c.LogForwardBatchSize = size
+	}
+	if secs := sec.Key("flush_interval").MustInt(0); secs > 0 {
+		c.LogForwardInterval = time.Duration(secs) * time.Second
+	}
+	if size := sec.Key("buffer_size").MustInt(0); size > 0 {
+		c.LogForwardBuffer = size
+	}
+	if sec.HasKey("dedup_window") {
+		c.LogForwardDedup = time.Duration(sec.Key("dedup_window").MustInt(0)) * time.Second
+	}
+	return nil
+}
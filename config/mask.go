@@ -0,0 +1,186 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxMaskKeyspace is the largest number of words that a Mask can match.
+var MaxMaskKeyspace int64 = 1 << 32
+
+// Mask is a "hashcat-style" mask that is expanded lazily, so the words matched by large
+// masks are generated one at a time. The mask syntax supports the ?l, ?u, ?d, ?a and ?s
+// character sets used by ExpandMask, bracketed character classes such as [a-z0-9_-], and
+// a repetition count such as {3} following a character set, class or literal character.
+type Mask struct {
+	mask      string
+	positions []string
+	keyspace  int64
+}
+
+// ParseMask returns the Mask for the mask string, or an error when the mask is not valid
+// or matches more than MaxMaskKeyspace words.
+func ParseMask(mask string) (*Mask, error) {
+	m := &Mask{mask: mask, keyspace: 1}
+
+	for i := 0; i < len(mask); {
+		var chars string
+
+		switch mask[i] {
+		case '?':
+			if i+1 >= len(mask) {
+				return nil, fmt.Errorf("Improper mask used: %s", mask)
+			}
+			switch mask[i+1] {
+			case 'a':
+				chars = maskLetters + maskDigits + maskSpecial
+			case 'd':
+				chars = maskDigits
+			case 'u', 'l':
+				chars = maskLetters
+			case 's':
+				chars = maskSpecial
+			default:
+				return nil, fmt.Errorf("Improper mask used: %s", mask)
+			}
+			i += 2
+		case '[':
+			end := strings.IndexByte(mask[i:], ']')
+			if end < 2 {
+				return nil, fmt.Errorf("Improper character class in the mask: %s", mask)
+			}
+			var err error
+			if chars, err = maskClass(mask[i+1 : i+end]); err != nil {
+				return nil, fmt.Errorf("%v: %s", err, mask)
+			}
+			i += end + 1
+		case ']', '{', '}':
+			return nil, fmt.Errorf("Improper mask used: %s", mask)
+		default:
+			if !maskLabelChar(mask[i]) {
+				return nil, fmt.Errorf("Improper character %q in the mask: %s", mask[i], mask)
+			}
+			chars = mask[i : i+1]
+			i++
+		}
+
+		count := 1
+		if i < len(mask) && mask[i] == '{' {
+			end := strings.IndexByte(mask[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("Improper repetition in the mask: %s", mask)
+			}
+			n, err := strconv.Atoi(mask[i+1 : i+end])
+			if err != nil || n < 1 || n > 63 {
+				return nil, fmt.Errorf("Improper repetition in the mask: %s", mask)
+			}
+			count = n
+			i += end + 1
+		}
+
+		for j := 0; j < count; j++ {
+			if m.keyspace > MaxMaskKeyspace/int64(len(chars)) {
+				return nil, fmt.Errorf("The mask matches more than %d words: %s", MaxMaskKeyspace, mask)
+			}
+			m.keyspace *= int64(len(chars))
+			m.positions = append(m.positions, chars)
+		}
+	}
+
+	if len(m.positions) == 0 {
+		return nil, fmt.Errorf("Improper mask used: %s", mask)
+	}
+	if len(m.positions) > 63 {
+		return nil, fmt.Errorf("The mask generates labels longer than 63 characters: %s", mask)
+	}
+	return m, nil
+}
+
+// ParseBruteMask parses an entry of the BruteMasks setting. The mask is applied to the first
+// label, and the remaining labels provide the subdomain name the mask is applied under. The
+// subdomain name returned is empty when the mask is applied under each root domain name.
+func ParseBruteMask(entry string) (*Mask, string, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ".", 2)
+
+	m, err := ParseMask(parts[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	var sub string
+	if len(parts) == 2 {
+		sub = strings.ToLower(strings.Trim(parts[1], "."))
+	}
+	return m, sub, nil
+}
+
+// maskLabelChar returns true when the character can be part of a label generated by a mask.
+func maskLabelChar(c byte) bool {
+	return strings.IndexByte(maskLetters+maskDigits+maskSpecial+"_", c) != -1
+}
+
+// maskClass returns the characters of the bracketed character class, expanding the ranges.
+func maskClass(class string) (string, error) {
+	var chars []byte
+	seen := make(map[byte]struct{})
+
+	add := func(c byte) error {
+		if !maskLabelChar(c) {
+			return fmt.Errorf("Improper character %q in the mask class", c)
+		}
+		if _, found := seen[c]; !found {
+			seen[c] = struct{}{}
+			chars = append(chars, c)
+		}
+		return nil
+	}
+
+	for i := 0; i < len(class); i++ {
+		// A hyphen at the start or end of the class is a literal character
+		if i+2 < len(class) && class[i+1] == '-' {
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				return "", fmt.Errorf("Improper range %c-%c in the mask class", lo, hi)
+			}
+			for c := lo; c <= hi; c++ {
+				if err := add(c); err != nil {
+					return "", err
+				}
+			}
+			i += 2
+			continue
+		}
+		if err := add(class[i]); err != nil {
+			return "", err
+		}
+	}
+	return string(chars), nil
+}
+
+// String returns the mask string.
+func (m *Mask) String() string {
+	return m.mask
+}
+
+// Keyspace returns the number of words matched by the mask.
+func (m *Mask) Keyspace() int64 {
+	return m.keyspace
+}
+
+// Word returns the word at the index within the keyspace of the mask.
+func (m *Mask) Word(idx int64) string {
+	word := make([]byte, len(m.positions))
+
+	for i := len(m.positions) - 1; i >= 0; i-- {
+		chars := m.positions[i]
+		size := int64(len(chars))
+
+		word[i] = chars[idx%size]
+		idx /= size
+	}
+	return string(word)
+}
@@ -0,0 +1,84 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestParseMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		mask     string
+		keyspace int64
+	}{
+		{"Test 1: Digits", "vpn-?d?d", 100},
+		{"Test 2: Classes", "[a-z]{3}[0-9]{2}", 1757600},
+		{"Test 3: Literal Hyphen", "[a-c-]x", 4},
+		{"Test 4: Duplicate Characters", "[aab-c]", 3},
+		{"Test 5: Repeated Literal", "x{3}?s", 1},
+		{"Test 6: All", "?a{2}", 1369},
+		{"Test 7: Bad Set", "?#", 0},
+		{"Test 8: Bad Class", "[z-a]", 0},
+		{"Test 9: Unclosed Class", "[a-z", 0},
+		{"Test 10: Bad Repetition", "?d{0}", 0},
+		{"Test 11: Bad Literal", "a.b", 0},
+		{"Test 12: Empty", "", 0},
+		{"Test 13: Keyspace Too Large", "?a{8}", 0},
+		{"Test 14: Label Too Long", "a{63}b", 0},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMask(tt.mask)
+		if tt.keyspace == 0 {
+			if err == nil {
+				t.Errorf("Error Event %s: was expecting an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error Event %s: %v", tt.name, err)
+		} else if m.Keyspace() != tt.keyspace {
+			t.Errorf("Error Event %s: was expecting %d, got %d", tt.name, tt.keyspace, m.Keyspace())
+		}
+	}
+}
+
+func TestMaskWord(t *testing.T) {
+	m, err := ParseMask("[a-c]?d")
+	if err != nil {
+		t.Fatalf("Failed to parse the mask: %v", err)
+	}
+
+	seen := make(map[string]struct{})
+	for i := int64(0); i < m.Keyspace(); i++ {
+		seen[m.Word(i)] = struct{}{}
+	}
+	if len(seen) != 30 {
+		t.Errorf("The mask generated %d distinct words, was expecting 30", len(seen))
+	}
+	if w := m.Word(0); w != "a0" {
+		t.Errorf("The first word was %s, was expecting a0", w)
+	}
+	if w := m.Word(m.Keyspace() - 1); w != "c9" {
+		t.Errorf("The last word was %s, was expecting c9", w)
+	}
+}
+
+func TestParseBruteMask(t *testing.T) {
+	m, sub, err := ParseBruteMask("vpn-?d?d.Corp.Example.com.")
+	if err != nil || m.String() != "vpn-?d?d" || sub != "corp.example.com" {
+		t.Errorf("Unexpected results: %v, %s, %v", m, sub, err)
+	}
+
+	if _, sub, err := ParseBruteMask("[a-z]{2}"); err != nil || sub != "" {
+		t.Errorf("Unexpected results for the mask without a subdomain: %s, %v", sub, err)
+	}
+
+	c := NewConfig()
+	c.BruteMasks = []string{"[a-z.example.com"}
+	if err := c.CheckSettings(); err == nil {
+		t.Error("CheckSettings accepted the improper mask")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// NameServerSignature identifies a DNS hosting provider by the names and
+// CHAOS class responses of the name servers it operates.
+type NameServerSignature struct {
+	Provider string
+	Names    []*regexp.Regexp
+	Versions []*regexp.Regexp
+}
+
+// DefaultNameServerSignatures is the built-in table of DNS hosting provider signatures.
+var DefaultNameServerSignatures = []*NameServerSignature{
+	newNameServerSignature("Amazon Route 53", []string{`awsdns-[0-9]+\.(com|net|org|co\.uk)$`}, nil),
+	newNameServerSignature("Cloudflare", []string{`\.ns\.cloudflare\.com$`}, nil),
+	newNameServerSignature("Azure DNS", []string{`\.azure-dns\.(com|net|org|info)$`}, nil),
+	newNameServerSignature("Google Cloud DNS", []string{`\.googledomains\.com$`}, nil),
+	newNameServerSignature("Akamai", []string{`\.akam\.net$`, `\.akamaiedge\.net$`}, nil),
+	newNameServerSignature("NS1", []string{`\.nsone\.net$`}, nil),
+	newNameServerSignature("UltraDNS", []string{`\.ultradns\.(com|net|org|biz|info|co\.uk)$`}, nil),
+	newNameServerSignature("Dyn", []string{`\.dynect\.net$`}, nil),
+	newNameServerSignature("GoDaddy", []string{`\.domaincontrol\.com$`}, nil),
+	newNameServerSignature("DigitalOcean", []string{`\.digitalocean\.com$`}, nil),
+	newNameServerSignature("DNS Made Easy", []string{`\.dnsmadeeasy\.com$`}, nil),
+	newNameServerSignature("BIND", nil, []string{`(?i)bind`, `^9\.[0-9]+\.[0-9]+`}),
+	newNameServerSignature("PowerDNS", nil, []string{`(?i)powerdns`}),
+	newNameServerSignature("Knot DNS", nil, []string{`(?i)knot`}),
+	newNameServerSignature("NSD", nil, []string{`(?i)nsd`}),
+	newNameServerSignature("Microsoft DNS", nil, []string{`(?i)microsoft`}),
+}
+
+func newNameServerSignature(provider string, names, versions []string) *NameServerSignature {
+	sig := &NameServerSignature{Provider: provider}
+
+	for _, n := range names {
+		sig.Names = append(sig.Names, regexp.MustCompile(n))
+	}
+	for _, v := range versions {
+		sig.Versions = append(sig.Versions, regexp.MustCompile(v))
+	}
+	return sig
+}
+
+// MatchName returns true if the name server hostname matches the signature.
+func (s *NameServerSignature) MatchName(name string) bool {
+	n := strings.ToLower(strings.Trim(strings.TrimSpace(name), "."))
+
+	for _, re := range s.Names {
+		if re.MatchString(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchVersion returns true if the version.bind or hostname.bind response matches the signature.
+func (s *NameServerSignature) MatchVersion(version string) bool {
+	v := strings.TrimSpace(version)
+	if v == "" {
+		return false
+	}
+
+	for _, re := range s.Versions {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// NameServerProvider returns the DNS hosting provider identified by the name server hostname
+// and the optional CHAOS class responses. Signatures from the configuration file are checked
+// before the built-in table. An empty string is returned when no signature matches.
+func (c *Config) NameServerProvider(name string, versions ...string) string {
+	sigs := append(append([]*NameServerSignature(nil), c.NameServerSignatures...), DefaultNameServerSignatures...)
+
+	for _, sig := range sigs {
+		if sig.MatchName(name) {
+			return sig.Provider
+		}
+	}
+
+	for _, sig := range sigs {
+		for _, v := range versions {
+			if sig.MatchVersion(v) {
+				return sig.Provider
+			}
+		}
+	}
+	return ""
+}
+
+// loadNameServerSettings reads the signatures of the DNS hosting providers from the file provided,
+// which must keep the section names as written, since each subsection is named by its provider.
+func (c *Config) loadNameServerSettings(cfg *ini.File) error {
+	for _, child := range cfg.Sections() {
+		parts := strings.SplitN(child.Name(), ".", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "nameservers") || parts[1] == "" {
+			continue
+		}
+
+		provider := parts[1]
+		sig := &NameServerSignature{Provider: provider}
+
+		for _, n := range child.Key("name").ValueWithShadows() {
+			if n == "" {
+				continue
+			}
+
+			re, err := regexp.Compile(n)
+			if err != nil {
+				return fmt.Errorf("Invalid name server name pattern for %s: %v", provider, err)
+			}
+			sig.Names = append(sig.Names, re)
+		}
+
+		for _, v := range child.Key("version").ValueWithShadows() {
+			if v == "" {
+				continue
+			}
+
+			re, err := regexp.Compile(v)
+			if err != nil {
+				return fmt.Errorf("Invalid name server version pattern for %s: %v", provider, err)
+			}
+			sig.Versions = append(sig.Versions, re)
+		}
+
+		if len(sig.Names) > 0 || len(sig.Versions) > 0 {
+			c.NameServerSignatures = append(c.NameServerSignatures, sig)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/go-ini/ini"
+)
+
+func TestNameServerProvider(t *testing.T) {
+	c := NewConfig()
+
+	tests := []struct {
+		name     string
+		versions []string
+		expected string
+	}{
+		{"ns-1234.awsdns-56.org", nil, "Amazon Route 53"},
+		{"kate.ns.cloudflare.com.", nil, "Cloudflare"},
+		{"ns1-01.azure-dns.com", nil, "Azure DNS"},
+		{"ns1.owasp.org", []string{"9.11.3-1ubuntu1"}, "BIND"},
+		{"ns1.owasp.org", nil, ""},
+	}
+
+	for _, test := range tests {
+		if p := c.NameServerProvider(test.name, test.versions...); p != test.expected {
+			t.Errorf("%s returned %s instead of %s", test.name, p, test.expected)
+		}
+	}
+}
+
+func TestLoadNameServerSettings(t *testing.T) {
+	c := NewConfig()
+
+	cfg, _ := ini.LoadSources(iniNamedLoadOptions, []byte(`
+[nameservers]
+[nameservers.Example]
+name = \.example-dns\.net$
+version = ^exampled
+`))
+
+	if err := c.loadNameServerSettings(cfg); err != nil {
+		t.Fatalf("loadNameServerSettings returned an error: %v", err)
+	}
+	if p := c.NameServerProvider("ns1.example-dns.net"); p != "Example" {
+		t.Errorf("The name pattern from the configuration was not matched: %s", p)
+	}
+	if p := c.NameServerProvider("ns1.owasp.org", "exampled 1.0"); p != "Example" {
+		t.Errorf("The version pattern from the configuration was not matched: %s", p)
+	}
+}
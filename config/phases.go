@@ -0,0 +1,35 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+// loadPhaseBudgetSettings loads the time budgets of the enumeration phases, which are provided
+// in minutes and keyed by the phase name, such as sources or brute_forcing.
+func (c *Config) loadPhaseBudgetSettings(cfg *ini.File) error {
+	sec, err := cfg.GetSection("phase_budgets")
+	if err != nil {
+		return nil
+	}
+
+	budgets := make(map[string]time.Duration)
+	for _, key := range sec.Keys() {
+		minutes, err := key.Int()
+		if err != nil || minutes <= 0 {
+			return fmt.Errorf("The time budget of the %s phase must be a positive number of minutes", key.Name())
+		}
+
+		budgets[key.Name()] = time.Duration(minutes) * time.Minute
+	}
+
+	if len(budgets) > 0 {
+		c.PhaseBudgets = budgets
+	}
+	return nil
+}
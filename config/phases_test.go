@@ -0,0 +1,39 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+func TestLoadPhaseBudgetSettings(t *testing.T) {
+	c := NewConfig()
+
+	cfg, _ := ini.LoadSources(ini.LoadOptions{
+		Insensitive:  true,
+		AllowShadows: true,
+	}, []byte(`
+[phase_budgets]
+sources = 30
+brute_forcing = 120
+`))
+	if err := c.loadPhaseBudgetSettings(cfg); err != nil {
+		t.Fatalf("Failed to load the phase budgets: %v", err)
+	}
+	if len(c.PhaseBudgets) != 2 || c.PhaseBudgets["sources"] != 30*time.Minute ||
+		c.PhaseBudgets["brute_forcing"] != 2*time.Hour {
+		t.Errorf("Unexpected phase budgets: %v", c.PhaseBudgets)
+	}
+
+	cfg, _ = ini.LoadSources(ini.LoadOptions{Insensitive: true}, []byte(`
+[phase_budgets]
+sources = soon
+`))
+	if err := NewConfig().loadPhaseBudgetSettings(cfg); err == nil {
+		t.Error("The invalid phase budget was accepted")
+	}
+}
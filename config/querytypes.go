@@ -0,0 +1,47 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caffix/stringset"
+)
+
+// SupportedQueryTypes are the DNS record types that can be queried for each discovered name.
+var SupportedQueryTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "PTR", "SOA", "SPF", "SRV", "TXT"}
+
+// SetQueryTypes assigns the DNS record types queried for each discovered name.
+// An error is returned when a type is not supported, and the types are left unchanged.
+func (c *Config) SetQueryTypes(types ...string) error {
+	var qtypes []string
+	seen := stringset.New()
+
+	for _, t := range types {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+
+		var supported bool
+		for _, s := range SupportedQueryTypes {
+			if t == s {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("The DNS record type %s is not supported for the queried names", t)
+		}
+		// The order of the types is kept, since the answers to a CNAME query end the queries for a name
+		if !seen.Has(t) {
+			seen.Insert(t)
+			qtypes = append(qtypes, t)
+		}
+	}
+
+	c.QueryTypes = qtypes
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/OWASP/Amass/v3/net/http"
 	"github.com/caffix/stringset"
@@ -25,6 +26,13 @@ const DefaultQueriesPerBaselineResolver = 50
 
 const minResolverReliability = 0.85
 
+// DefaultQuarantineThreshold is the number of false answers a resolver can return within a minute
+// before the weighted pool quarantines it.
+const DefaultQuarantineThreshold = 5
+
+// DefaultQuarantineCooldown is the time a quarantined resolver is excluded from the weighted pool.
+const DefaultQuarantineCooldown = 10 * time.Minute
+
 // DefaultBaselineResolvers is a list of trusted public DNS resolvers.
 var DefaultBaselineResolvers = []string{
 	"8.8.8.8",        // Google
@@ -40,6 +48,35 @@ var DefaultBaselineResolvers = []string{
 	"77.88.8.8",      // Yandex.DNS
 }
 
+// DefaultDoHResolvers is a list of trusted public DNS-over-HTTPS services.
+var DefaultDoHResolvers = []string{
+	"https://cloudflare-dns.com/dns-query", // Cloudflare
+	"https://dns.google/dns-query",         // Google
+	"https://dns.quad9.net/dns-query",      // Quad9
+}
+
+// DefaultDoTResolvers is a list of trusted public DNS-over-TLS resolvers.
+var DefaultDoTResolvers = []string{
+	"tls://1.1.1.1", // Cloudflare
+	"tls://8.8.8.8", // Google
+	"tls://9.9.9.9", // Quad9
+}
+
+// DefaultDoQResolvers is a list of trusted public DNS-over-QUIC resolvers.
+var DefaultDoQResolvers = []string{
+	"quic://dns.adguard-dns.com",        // AdGuard
+	"quic://unfiltered.adguard-dns.com", // AdGuard Unfiltered
+}
+
+// The transports that can be used to query the trusted resolvers.
+const (
+	TransportUDP = "udp"
+	TransportTCP = "tcp"
+	TransportDoT = "dot"
+	TransportDoH = "doh"
+	TransportDoQ = "doq"
+)
+
 // PublicResolvers includes the addresses of public resolvers obtained dynamically.
 var PublicResolvers []string
 
@@ -95,12 +132,46 @@ func (c *Config) loadResolverSettings(cfg *ini.File) error {
 		return nil
 	}
 
-	c.Resolvers = stringset.Deduplicate(sec.Key("resolver").ValueWithShadows())
-	if len(c.Resolvers) == 0 {
+	// Accessing the keys creates them, so check for the transport settings first
+	transport := sec.HasKey("transport") || sec.HasKey("doh")
+
+	c.ResolverTransport = strings.ToLower(sec.Key("transport").MustString(TransportUDP))
+	// The doh key predates the transport setting
+	if sec.Key("doh").MustBool(false) {
+		c.ResolverTransport = TransportDoH
+	}
+	switch c.ResolverTransport {
+	case TransportUDP, TransportTCP, TransportDoT, TransportDoH, TransportDoQ:
+	default:
+		return fmt.Errorf("The resolver transport %s is not supported", c.ResolverTransport)
+	}
+
+	c.Resolvers = []string{}
+	// A missing resolver key provides a single empty value
+	for _, r := range stringset.Deduplicate(sec.Key("resolver").ValueWithShadows()) {
+		if r = strings.TrimSpace(r); r != "" {
+			c.Resolvers = append(c.Resolvers, r)
+		}
+	}
+	// The public resolvers are used with the baseline resolvers when a transport is selected instead
+	if len(c.Resolvers) == 0 && !transport {
 		return errors.New("No resolver keys were found in the resolvers section")
 	}
 
 	c.MonitorResolverRate = sec.Key("monitor_resolver_rate").MustBool(true)
+	c.WeightedResolvers = sec.Key("weighted").MustBool(false)
+	c.QuarantineThreshold = sec.Key("quarantine_threshold").MustInt(DefaultQuarantineThreshold)
+	// The cool-down period is provided in minutes, and zero stops the quarantined resolvers
+	if sec.HasKey("quarantine_cooldown") {
+		c.QuarantineCooldown = time.Duration(sec.Key("quarantine_cooldown").MustInt(0)) * time.Minute
+	}
+
+	// The query deadlines are provided in seconds for each priority level
+	for i, key := range []string{"deadline_low", "deadline_normal", "deadline_high", "deadline_critical"} {
+		if secs := sec.Key(key).MustInt(0); secs > 0 {
+			c.QueryDeadlines[i] = time.Duration(secs) * time.Second
+		}
+	}
 	return nil
 }
 
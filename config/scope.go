@@ -61,14 +61,19 @@ func (c *Config) AddDomain(domain string) {
 		c.regexps = make(map[string]*regexp.Regexp)
 	}
 
+	// The domains keep the order they were added in
+	for _, dom := range c.domains {
+		if dom == d {
+			return
+		}
+	}
+
 	// Create the regular expression for this domain
 	c.regexps[d] = dns.SubdomainRegex(d)
 	if c.regexps[d] != nil {
 		// Add the domain string to the list
 		c.domains = append(c.domains, d)
 	}
-
-	c.domains = stringset.Deduplicate(c.domains)
 }
 
 // Domains returns the list of domain names currently in the configuration.
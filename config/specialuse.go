@@ -0,0 +1,107 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caffix/stringset"
+	"github.com/go-ini/ini"
+)
+
+// SpecialUseTLDs are the top-level domains reserved by RFC 6761, RFC 6762 and RFC 7686.
+// Names within these domains are not resolved by the public DNS.
+var SpecialUseTLDs = []string{"example", "invalid", "local", "localhost", "onion", "test"}
+
+// The special-use TLDs that never contain names worth enumerating.
+var unresolvableTLDs = []string{"invalid", "localhost"}
+
+// SpecialUseTLD returns the special-use top-level domain of the name, or an empty string.
+func SpecialUseTLD(name string) string {
+	n := strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	tld := n[strings.LastIndex(n, ".")+1:]
+
+	for _, s := range SpecialUseTLDs {
+		if tld == s {
+			return tld
+		}
+	}
+	return ""
+}
+
+// SpecialUseResolvers returns the resolvers that names within the special-use TLD are sent to.
+func (c *Config) SpecialUseResolvers(tld string) []string {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.specialUseResolvers[strings.ToLower(tld)]
+}
+
+// SetSpecialUseResolvers routes the names within the special-use TLD to the resolvers provided,
+// such as an internal DNS server or a Tor-aware DNS proxy.
+func (c *Config) SetSpecialUseResolvers(tld string, resolvers ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.specialUseResolvers == nil {
+		c.specialUseResolvers = make(map[string][]string)
+	}
+	c.specialUseResolvers[strings.ToLower(strings.Trim(tld, "."))] = stringset.Deduplicate(resolvers)
+}
+
+// SpecialUseRoutes returns the resolvers configured for each special-use TLD.
+func (c *Config) SpecialUseRoutes() map[string][]string {
+	c.Lock()
+	defer c.Unlock()
+
+	routes := make(map[string][]string, len(c.specialUseResolvers))
+	for tld, addrs := range c.specialUseResolvers {
+		routes[tld] = append([]string(nil), addrs...)
+	}
+	return routes
+}
+
+// PassiveOnly returns true when the name is within a special-use TLD without resolvers configured
+// for it. These names are never sent to the public resolvers and are collected passively.
+func (c *Config) PassiveOnly(name string) bool {
+	tld := SpecialUseTLD(name)
+
+	return tld != "" && len(c.SpecialUseResolvers(tld)) == 0
+}
+
+func (c *Config) checkSpecialUse() error {
+	for tld := range c.SpecialUseRoutes() {
+		if SpecialUseTLD(tld) != tld {
+			return fmt.Errorf("Resolvers were provided for the .%s TLD, but only the special-use TLDs "+
+				"(%s) can be routed to specific resolvers", tld, strings.Join(SpecialUseTLDs, ", "))
+		}
+	}
+
+	for _, d := range c.Domains() {
+		tld := SpecialUseTLD(d)
+		if tld == "" {
+			continue
+		}
+		for _, u := range unresolvableTLDs {
+			if tld == u {
+				return fmt.Errorf("The domain name %s is within the special-use TLD .%s, "+
+					"which never contains names worth enumerating", d, tld)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) loadSpecialUseSettings(cfg *ini.File) error {
+	sec, err := cfg.GetSection("resolvers.special_use")
+	if err != nil {
+		return nil
+	}
+
+	for _, key := range sec.Keys() {
+		c.SetSpecialUseResolvers(key.Name(), key.ValueWithShadows()...)
+	}
+	return nil
+}
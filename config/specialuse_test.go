@@ -0,0 +1,64 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpecialUseScope(t *testing.T) {
+	c := NewConfig()
+	c.AddDomains("owasp.org", "hidden.onion", "corp.local")
+	c.SetSpecialUseResolvers("local", "10.0.0.53")
+
+	tests := []struct {
+		name    string
+		passive bool
+	}{
+		{"www.owasp.org", false},
+		{"www.hidden.onion", true},
+		{"printer.corp.local", false},
+	}
+	for _, test := range tests {
+		if got := c.PassiveOnly(test.name); got != test.passive {
+			t.Errorf("%s: expected passive only %t, got %t", test.name, test.passive, got)
+		}
+	}
+
+	if err := c.CheckSettings(); err != nil {
+		t.Errorf("The special-use domain names were not accepted: %v", err)
+	}
+}
+
+func TestSpecialUseValidation(t *testing.T) {
+	invalid := NewConfig()
+	invalid.AddDomain("host.invalid")
+	if err := invalid.CheckSettings(); err == nil {
+		t.Error("A domain name within the .invalid TLD was accepted")
+	}
+
+	route := NewConfig()
+	route.SetSpecialUseResolvers("com", "10.0.0.53")
+	if err := route.CheckSettings(); err == nil {
+		t.Error("Resolvers were accepted for a TLD that is not special-use")
+	}
+}
+
+func TestLoadSpecialUseSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	ini := "[data_sources]\n\n[resolvers]\nresolver = 8.8.8.8\n\n[resolvers.special_use]\nonion = 127.0.0.1:5353\nonion = 127.0.0.1:5354\n"
+	if err := os.WriteFile(path, []byte(ini), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration file: %v", err)
+	}
+
+	c := NewConfig()
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the configuration file: %v", err)
+	}
+	if addrs := c.SpecialUseResolvers("onion"); len(addrs) != 2 {
+		t.Errorf("Expected 2 resolvers for the .onion TLD, got %v", addrs)
+	}
+}
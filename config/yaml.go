@@ -0,0 +1,116 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadYAML parses settings from a YAML file and assigns them to the Config. The document mirrors
+// the INI file: the top-level values are the default settings, each mapping is a section named by
+// its key, nested mappings are the subsections, such as the credentials of a data source, and
+// sequences provide the settings that can be repeated, such as the resolvers.
+func (c *Config) LoadYAML(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to load the configuration file: %v", err)
+	}
+
+	cfg, err := yamlToINI(data, iniLoadOptions)
+	if err != nil {
+		return fmt.Errorf("Failed to load the configuration file: %v", err)
+	}
+
+	named, err := yamlToINI(data, iniNamedLoadOptions)
+	if err != nil {
+		return fmt.Errorf("Failed to load the configuration file: %v", err)
+	}
+	return c.loadSettings(cfg, named)
+}
+
+func isYAMLFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// yamlToINI builds the INI sections from the YAML document, so the settings are assigned to the
+// Config the same way regardless of the file format.
+func yamlToINI(data []byte, opts ini.LoadOptions) (*ini.File, error) {
+	var doc yaml.MapSlice
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	cfg := ini.Empty(opts)
+	if err := addYAMLSection(cfg, ini.DefaultSection, doc); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func addYAMLSection(cfg *ini.File, name string, items yaml.MapSlice) error {
+	sec := cfg.Section(name)
+
+	var children []yaml.MapItem
+	for _, item := range items {
+		key := fmt.Sprint(item.Key)
+
+		switch v := item.Value.(type) {
+		case yaml.MapSlice:
+			children = append(children, yaml.MapItem{Key: key, Value: v})
+		case []interface{}:
+			for _, elem := range v {
+				value, err := yamlScalar(key, elem)
+				if err != nil {
+					return err
+				}
+				if _, err := sec.NewKey(key, value); err != nil {
+					return err
+				}
+			}
+		default:
+			value, err := yamlScalar(key, v)
+			if err != nil {
+				return err
+			}
+			if _, err := sec.NewKey(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, child := range children {
+		childName := child.Key.(string)
+		if name != ini.DefaultSection {
+			childName = name + "." + childName
+		}
+
+		if err := addYAMLSection(cfg, childName, child.Value.(yaml.MapSlice)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func yamlScalar(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("The %s setting must hold a value or a list of values", key)
+}
@@ -0,0 +1,269 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+const testINISettings = `
+mode = active
+output_directory = /tmp/amass
+maximum_dns_queries = 5000
+priority_names = true
+plateau_fraction = 0.25
+log_level = debug
+trace_name = www.owasp.org
+trace_name = vpn.owasp.org
+query_type = A
+query_type = MX
+
+[resolvers]
+weighted = true
+transport = tcp
+quarantine_cooldown = 15
+deadline_high = 90
+resolver = 1.1.1.1
+resolver = 8.8.8.8
+
+[resolvers.special_use]
+onion = 127.0.0.1:5353
+
+[scope]
+cidr = 192.168.1.0/24
+asn = 26808
+port = 80
+port = 443
+
+[scope.domains]
+domain = owasp.org
+domain = appsecusa.org
+
+[scope.blacklisted]
+subdomain = education.appsec-labs.com
+
+[graphdbs]
+local_database = false
+
+[graphdbs.postgres]
+primary = true
+url = postgres://localhost:5432/amass?sslmode=disable
+options = connect_timeout=10
+
+[bruteforce]
+enabled = true
+recursive = false
+minimum_for_recursive = 2
+hit_rate_floor = 0.0001
+mask = [a-z]{3}[0-9]{2}
+
+[alterations]
+enabled = false
+edit_distance = 2
+sequence_margin = 3
+
+[phase_budgets]
+sources = 30
+brute_forcing = 120
+
+[nameservers]
+[nameservers.ExampleDNS]
+name = \.example-dns\.net$
+
+[data_sources]
+minimum_ttl = 1440
+max_record_age = 365
+failure_threshold = 3
+
+[data_sources.disabled]
+data_source = Ask
+data_source = IPv4Info
+
+[data_sources.AlienVault]
+ttl = 4320
+[data_sources.AlienVault.Credentials]
+apikey = fake
+
+[data_sources.C99]
+[data_sources.C99.account1]
+apikey = key1
+[data_sources.C99.account2]
+apikey = key2
+`
+
+const testYAMLSettings = `
+mode: active
+output_directory: /tmp/amass
+maximum_dns_queries: 5000
+priority_names: true
+plateau_fraction: 0.25
+log_level: debug
+trace_name:
+  - www.owasp.org
+  - vpn.owasp.org
+query_type: [A, MX]
+
+resolvers:
+  weighted: true
+  transport: tcp
+  quarantine_cooldown: 15
+  deadline_high: 90
+  resolver:
+    - 1.1.1.1
+    - 8.8.8.8
+  special_use:
+    onion: 127.0.0.1:5353
+
+scope:
+  cidr: 192.168.1.0/24
+  asn: 26808
+  port: [80, 443]
+  domains:
+    domain:
+      - owasp.org
+      - appsecusa.org
+  blacklisted:
+    subdomain: education.appsec-labs.com
+
+graphdbs:
+  local_database: false
+  postgres:
+    primary: true
+    url: postgres://localhost:5432/amass?sslmode=disable
+    options: connect_timeout=10
+
+bruteforce:
+  enabled: true
+  recursive: false
+  minimum_for_recursive: 2
+  hit_rate_floor: 0.0001
+  mask: "[a-z]{3}[0-9]{2}"
+
+alterations:
+  enabled: false
+  edit_distance: 2
+  sequence_margin: 3
+
+phase_budgets:
+  sources: 30
+  brute_forcing: 120
+
+nameservers:
+  ExampleDNS:
+    name: '\.example-dns\.net$'
+
+data_sources:
+  minimum_ttl: 1440
+  max_record_age: 365
+  failure_threshold: 3
+  disabled:
+    data_source: [Ask, IPv4Info]
+  AlienVault:
+    ttl: 4320
+    Credentials:
+      apikey: fake
+  C99:
+    account1:
+      apikey: key1
+    account2:
+      apikey: key2
+`
+
+func loadTestSettings(t *testing.T, name, content string) *Config {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration: %v", err)
+	}
+
+	c := NewConfig()
+	if err := c.LoadSettings(path); err != nil {
+		t.Fatalf("Failed to load the %s settings: %v", name, err)
+	}
+	if err := c.CheckSettings(); err != nil {
+		t.Fatalf("The %s settings were rejected: %v", name, err)
+	}
+	return c
+}
+
+// sameSettings reports whether the configurations hold the same settings. The lists deduplicated
+// while loading are sorted, since the deduplication does not preserve the order.
+func sameSettings(a, b *Config) bool {
+	for _, c := range []*Config{a, b} {
+		sort.Strings(c.TraceNames)
+		sort.Strings(c.Resolvers)
+		sort.Strings(c.Wordlist)
+		sort.Strings(c.AltWordlist)
+		sort.Strings(c.SourceFilter.Sources)
+	}
+	// Each configuration receives a unique identifier and logger
+	b.UUID = a.UUID
+	b.Log = a.Log
+	return reflect.DeepEqual(a, b)
+}
+
+func TestLoadYAML(t *testing.T) {
+	fromINI := loadTestSettings(t, "config.ini", testINISettings)
+	fromYAML := loadTestSettings(t, "config.yaml", testYAMLSettings)
+
+	if !sameSettings(fromINI, fromYAML) {
+		t.Errorf("The YAML settings did not match the INI settings:\nINI:  %+v\nYAML: %+v", fromINI, fromYAML)
+	}
+
+	c := fromYAML
+	if !c.Active || c.MaxDNSQueries != 5000 || c.PlateauFraction != 0.25 || c.ResolverTransport != TransportTCP {
+		t.Error("Failed to load the default and resolver settings")
+	}
+	if !reflect.DeepEqual(c.Domains(), []string{"owasp.org", "appsecusa.org"}) || !reflect.DeepEqual(c.Ports, []int{80, 443}) {
+		t.Errorf("Failed to load the scope settings: %v %v", c.Domains(), c.Ports)
+	}
+	if c.PhaseBudgets["brute_forcing"] != 120*time.Minute || c.MaxRecordAge != 365*24*time.Hour {
+		t.Error("Failed to load the phase budget and data source settings")
+	}
+	if dsc := c.GetDataSourceConfig("C99"); dsc == nil || len(dsc.creds) != 2 {
+		t.Error("Failed to load the credential sets of the data source")
+	}
+	if len(c.GraphDBs) != 1 || c.GraphDBs[0].System != "postgres" || !c.GraphDBs[0].Primary {
+		t.Errorf("Failed to load the graph database settings: %+v", c.GraphDBs)
+	}
+	// The DNS hosting providers keep the case of their names
+	if p := c.NameServerProvider("ns1.example-dns.net"); p != "ExampleDNS" {
+		t.Errorf("Failed to load the name server settings: %s", p)
+	}
+}
+
+func TestLoadYAMLExample(t *testing.T) {
+	fromINI, fromYAML := NewConfig(), NewConfig()
+
+	if err := fromINI.LoadSettings("../examples/config.ini"); err != nil {
+		t.Fatalf("Failed to load the example INI configuration: %v", err)
+	}
+	if err := fromYAML.LoadSettings("../examples/config.yaml"); err != nil {
+		t.Fatalf("Failed to load the example YAML configuration: %v", err)
+	}
+
+	if !sameSettings(fromINI, fromYAML) {
+		t.Error("The example YAML configuration did not match the example INI configuration")
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	for _, content := range []string{
+		"scope: [owasp.org",
+		"resolvers:\n  resolver:\n    - {address: 1.1.1.1}\n",
+	} {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write the configuration: %v", err)
+		}
+
+		if err := NewConfig().LoadSettings(path); err == nil {
+			t.Errorf("The invalid YAML configuration was accepted: %q", content)
+		}
+	}
+}
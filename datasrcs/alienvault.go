@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/net/http"
@@ -78,17 +79,22 @@ func (a *AlienVault) OnRequest(ctx context.Context, args service.Args) {
 }
 
 func (a *AlienVault) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
-	if !a.sys.Config().IsDomainInScope(req.Domain) {
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
 		return
 	}
 
-	_, bus, err := requests.ContextConfigBus(ctx)
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
 
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
+		return
+	}
+
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", a.String(), req.Domain))
+		requests.NewLogEvent(a.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 	a.executeDNSQuery(ctx, req)
 
 	a.CheckRateLimit()
@@ -96,7 +102,8 @@ func (a *AlienVault) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 }
 
 func (a *AlienVault) whoisRequest(ctx context.Context, req *requests.WhoisRequest) {
-	if !a.sys.Config().IsDomainInScope(req.Domain) {
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil || scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
@@ -117,7 +124,8 @@ func (a *AlienVault) executeDNSQuery(ctx context.Context, req *requests.DNSReque
 	u := a.getURL(req.Domain) + "passive_dns"
 	page, err := http.RequestWebPage(ctx, u, nil, a.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 	// Extract the subdomain names and IP addresses from the passive DNS information
@@ -125,32 +133,44 @@ func (a *AlienVault) executeDNSQuery(ctx context.Context, req *requests.DNSReque
 		Subdomains []struct {
 			Hostname string `json:"hostname"`
 			IP       string `json:"address"`
+			First    string `json:"first"`
+			Last     string `json:"last"`
 		} `json:"passive_dns"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	} else if len(m.Subdomains) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The query returned zero results", a.String(), u))
+			requests.NewLogEvent(a.String(), requests.LogWarning, "%s: The query returned zero results", u).With(requests.LogFieldURL, u))
 		return
 	}
 
 	ips := stringset.New()
-	names := stringset.New()
+	names := make(map[string]*avObservation)
 	for _, sub := range m.Subdomains {
 		n := strings.ToLower(sub.Hostname)
+		first, last := parseAVTime(sub.First), parseAVTime(sub.Last)
+		// Skip the records observed before the maximum record age
+		if !re.MatchString(n) || cfg.RecordTooOld(last) {
+			continue
+		}
 
-		if re.MatchString(n) {
-			names.Insert(n)
-			if ip := net.ParseIP(sub.IP); ip != nil {
-				ips.Insert(ip.String())
-			}
+		obs, found := names[n]
+		if !found {
+			obs = new(avObservation)
+			names[n] = obs
+		}
+		obs.merge(first, last)
+
+		if ip := net.ParseIP(sub.IP); ip != nil {
+			ips.Insert(ip.String())
 		}
 	}
 
-	for name := range names {
-		genNewNameEvent(ctx, a.sys, a, name)
+	for name, obs := range names {
+		genObservedNameEvent(ctx, a, name, obs.first, obs.last)
 	}
 
 	for ip := range ips {
@@ -163,9 +183,33 @@ func (a *AlienVault) executeDNSQuery(ctx context.Context, req *requests.DNSReque
 	}
 }
 
+// avObservation is the time range that the passive DNS records of a name were observed within.
+type avObservation struct {
+	first, last time.Time
+}
+
+func (o *avObservation) merge(first, last time.Time) {
+	if !first.IsZero() && (o.first.IsZero() || first.Before(o.first)) {
+		o.first = first
+	}
+	if last.After(o.last) {
+		o.last = last
+	}
+}
+
+// parseAVTime returns the zero time when the AlienVault time cannot be parsed.
+func parseAVTime(s string) time.Time {
+	t, err := time.Parse("2006-01-02T15:04:05", s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
 type avURL struct {
 	Domain   string `json:"domain"`
 	Hostname string `json:"hostname"`
+	Date     string `json:"date"`
 	Result   struct {
 		Worker struct {
 			IP string `json:"ip"`
@@ -188,7 +232,8 @@ func (a *AlienVault) executeURLQuery(ctx context.Context, req *requests.DNSReque
 	u := a.getURL(req.Domain) + "url_list"
 	page, err := http.RequestWebPage(ctx, u, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 	// Extract the subdomain names and IP addresses from the URL information
@@ -200,17 +245,18 @@ func (a *AlienVault) executeURLQuery(ctx context.Context, req *requests.DNSReque
 		URLs     []avURL `json:"url_list"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	} else if len(m.URLs) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The query returned zero results", a.String(), u))
+			requests.NewLogEvent(a.String(), requests.LogWarning, "%s: The query returned zero results", u).With(requests.LogFieldURL, u))
 		return
 	}
 
 	ips := stringset.New()
 	names := stringset.New()
-	extractNamesIPs(m.URLs, names, ips, re)
+	extractNamesIPs(cfg, m.URLs, names, ips, re)
 	// If there are additional pages of URLs, obtain that info as well
 	if m.HasNext {
 		pages := int(math.Ceil(float64(m.FullSize) / float64(m.Limit)))
@@ -220,22 +266,21 @@ func (a *AlienVault) executeURLQuery(ctx context.Context, req *requests.DNSReque
 			page, err = http.RequestWebPage(ctx, pageURL, nil, headers, nil)
 			if err != nil {
 				bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-					fmt.Sprintf("%s: %s: %v", a.String(), pageURL, err))
+					requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", pageURL, err))
 				break
 			}
 
 			if err := json.Unmarshal([]byte(page), &m); err != nil {
 				bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-					fmt.Sprintf("%s: %s: %v", a.String(), pageURL, err))
+					requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", pageURL, err))
 				break
 			} else if len(m.URLs) == 0 {
 				bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-					fmt.Sprintf("%s: %s: The query returned zero results", a.String(), pageURL),
-				)
+					requests.NewLogEvent(a.String(), requests.LogWarning, "%s: The query returned zero results", pageURL))
 				break
 			}
 
-			extractNamesIPs(m.URLs, names, ips, re)
+			extractNamesIPs(cfg, m.URLs, names, ips, re)
 		}
 	}
 
@@ -253,9 +298,13 @@ func (a *AlienVault) executeURLQuery(ctx context.Context, req *requests.DNSReque
 	}
 }
 
-func extractNamesIPs(urls []avURL, names stringset.Set, ips stringset.Set, re *regexp.Regexp) {
+func extractNamesIPs(cfg *config.Config, urls []avURL, names stringset.Set, ips stringset.Set, re *regexp.Regexp) {
 	for _, u := range urls {
 		n := strings.ToLower(u.Hostname)
+		// Skip the URLs crawled before the maximum record age
+		if cfg.RecordTooOld(parseAVTime(u.Date)) {
+			continue
+		}
 
 		if re.MatchString(n) {
 			names.Insert(n)
@@ -267,7 +316,12 @@ func extractNamesIPs(urls []avURL, names stringset.Set, ips stringset.Set, re *r
 }
 
 func (a *AlienVault) executeWhoisQuery(ctx context.Context, req *requests.WhoisRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
@@ -282,7 +336,7 @@ func (a *AlienVault) executeWhoisQuery(ctx context.Context, req *requests.WhoisR
 		page, err := http.RequestWebPage(ctx, pageURL, nil, headers, nil)
 		if err != nil {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: %v", a.String(), pageURL, err))
+				requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", pageURL, err))
 			continue
 		}
 
@@ -292,11 +346,11 @@ func (a *AlienVault) executeWhoisQuery(ctx context.Context, req *requests.WhoisR
 		var domains []record
 		if err := json.Unmarshal([]byte(page), &domains); err != nil {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: %v", a.String(), pageURL, err))
+				requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", pageURL, err))
 			continue
 		}
 		for _, d := range domains {
-			if !cfg.IsDomainInScope(d.Domain) {
+			if scp.Evaluate(d.Domain, nil).Domain == "" {
 				newDomains.Insert(d.Domain)
 			}
 		}
@@ -305,8 +359,7 @@ func (a *AlienVault) executeWhoisQuery(ctx context.Context, req *requests.WhoisR
 
 	if len(newDomains) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: Reverse whois failed to discover new domain names for %s", a.String(), req.Domain),
-		)
+			requests.NewLogEvent(a.String(), requests.LogWarning, "Reverse whois failed to discover new domain names for %s", req.Domain))
 		return
 	}
 
@@ -322,14 +375,20 @@ func (a *AlienVault) queryWhoisForEmails(ctx context.Context, req *requests.Whoi
 	emails := stringset.New()
 	u := a.getWhoisURL(req.Domain)
 
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return emails.Slice()
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return emails.Slice()
 	}
 
 	page, err := http.RequestWebPage(ctx, u, nil, a.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return emails.Slice()
 	}
 
@@ -342,11 +401,12 @@ func (a *AlienVault) queryWhoisForEmails(ctx context.Context, req *requests.Whoi
 		} `json:"data"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", a.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(a.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return emails.Slice()
 	} else if m.Count == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The query returned zero results", a.String(), u))
+			requests.NewLogEvent(a.String(), requests.LogWarning, "%s: The query returned zero results", u).With(requests.LogFieldURL, u))
 		return emails.Slice()
 	}
 
@@ -361,7 +421,7 @@ func (a *AlienVault) queryWhoisForEmails(ctx context.Context, req *requests.Whoi
 
 			// Unfortunately AlienVault doesn't categorize the email addresses so we
 			// have to filter by something we know to avoid adding registrar emails
-			if cfg.IsDomainInScope(d) {
+			if scp.Evaluate(d, nil).Domain != "" {
 				emails.Insert(email)
 			}
 		}
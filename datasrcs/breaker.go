@@ -0,0 +1,139 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+)
+
+// SourceHealth is the state of the circuit breaker of a data source.
+type SourceHealth struct {
+	Source  string `json:"source"`
+	Healthy bool   `json:"healthy"`
+	// The number of times the circuit breaker tripped
+	Trips int `json:"trips"`
+	// The number of requests dropped while the circuit breaker was open
+	Dropped int `json:"dropped"`
+	// The error of the last failed HTTP request
+	LastError string `json:"last_error,omitempty"`
+	// The time the requests are accepted again, when the source is unhealthy
+	Until time.Time `json:"until,omitempty"`
+}
+
+// HealthReporter is implemented by the data sources that track their health.
+type HealthReporter interface {
+	Health() SourceHealth
+}
+
+// Health returns the state of the circuit breaker of the data source, and false when
+// the data source does not track its health.
+func Health(srv service.Service) (SourceHealth, bool) {
+	if hr, ok := srv.(HealthReporter); ok {
+		return hr.Health(), true
+	}
+	return SourceHealth{}, false
+}
+
+// CircuitBreaker wraps a data source and drops its requests for a cool-down period once the
+// HTTP requests made while handling them have failed the threshold number of times in a row.
+// After the cool-down period, a single failure trips the circuit breaker again, and a
+// successful request returns the data source to good health.
+type CircuitBreaker struct {
+	service.Service
+	sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	// Set once the cool-down period has ended, until a request succeeds
+	probing bool
+	health  SourceHealth
+	now     func() time.Time
+}
+
+// NewCircuitBreaker returns the data source wrapped by a CircuitBreaker that trips after the
+// threshold of consecutive failed HTTP requests.
+func NewCircuitBreaker(srv service.Service, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Service:   srv,
+		threshold: threshold,
+		cooldown:  cooldown,
+		health:    SourceHealth{Source: srv.String(), Healthy: true},
+		now:       time.Now,
+	}
+}
+
+// Request implements the Service interface. The request is dropped while the circuit breaker is open.
+func (cb *CircuitBreaker) Request(ctx context.Context, args service.Args) {
+	if !cb.allow() {
+		return
+	}
+
+	cb.Service.Request(http.WithOutcome(ctx, func(err error) {
+		cb.outcome(ctx, err)
+	}), args)
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if cb.health.Healthy || cb.probing {
+		return true
+	}
+	if cb.now().Before(cb.health.Until) {
+		cb.health.Dropped++
+		return false
+	}
+
+	cb.probing = true
+	return true
+}
+
+func (cb *CircuitBreaker) outcome(ctx context.Context, err error) {
+	cb.Lock()
+
+	if err == nil {
+		cb.failures = 0
+		cb.probing = false
+		cb.health.Healthy = true
+		cb.health.Until = time.Time{}
+		cb.Unlock()
+		return
+	}
+
+	cb.failures++
+	cb.health.LastError = err.Error()
+	if !cb.probing && (!cb.health.Healthy || cb.failures < cb.threshold) {
+		cb.Unlock()
+		return
+	}
+
+	ev := requests.NewLogEvent(cb.String(), requests.LogWarning, "The circuit breaker tripped after %d "+
+		"consecutive failed requests (%v), the requests are dropped for %s", cb.failures, err, cb.cooldown)
+	cb.failures = 0
+	cb.probing = false
+	cb.health.Healthy = false
+	cb.health.Trips++
+	cb.health.Until = cb.now().Add(cb.cooldown)
+	cb.Unlock()
+
+	if _, bus, berr := requests.ContextConfigBus(ctx); berr == nil {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, ev)
+	}
+}
+
+// Health implements the HealthReporter interface.
+func (cb *CircuitBreaker) Health() SourceHealth {
+	cb.Lock()
+	defer cb.Unlock()
+
+	return cb.health
+}
@@ -0,0 +1,173 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+)
+
+// BruteMasks is the Service that generates names from the brute forcing masks in the configuration.
+type BruteMasks struct {
+	service.BaseService
+
+	SourceType string
+	sys        systems.System
+	generated  int64
+	keyspace   int64
+}
+
+// MaskStats reports the progress of the BruteMasks service.
+type MaskStats struct {
+	// The number of names generated from the masks
+	Generated int64
+	// The number of names matched by the masks applied so far
+	Keyspace int64
+}
+
+// NewBruteMasks returns he object initialized, but not yet started.
+func NewBruteMasks(sys systems.System) *BruteMasks {
+	b := &BruteMasks{
+		SourceType: requests.BRUTE,
+		sys:        sys,
+	}
+
+	b.BaseService = *service.NewBaseService(b, "Brute Masks")
+	return b
+}
+
+// Description implements the Service interface.
+func (b *BruteMasks) Description() string {
+	return b.SourceType
+}
+
+// OnStart implements the Service interface.
+func (b *BruteMasks) OnStart() error {
+	b.SetRateLimit(1)
+	return nil
+}
+
+// OnRequest implements the Service interface.
+func (b *BruteMasks) OnRequest(ctx context.Context, args service.Args) {
+	if req, ok := args.(*requests.DNSRequest); ok {
+		b.dnsRequest(ctx, req)
+	}
+}
+
+// Stats returns the number of names generated and the keyspace of the masks applied so far.
+func (b *BruteMasks) Stats() MaskStats {
+	return MaskStats{
+		Generated: atomic.LoadInt64(&b.generated),
+		Keyspace:  atomic.LoadInt64(&b.keyspace),
+	}
+}
+
+func (b *BruteMasks) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	// The masks are applied once for each root domain name
+	if cfg.Passive || !cfg.BruteForcing || req.Name != req.Domain {
+		return
+	}
+
+	domain := strings.ToLower(req.Domain)
+	for _, entry := range cfg.BruteMasks {
+		m, base, err := config.ParseBruteMask(entry)
+		if err != nil {
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(b.String(), requests.LogError, "%v", err))
+			continue
+		}
+		if base == "" {
+			base = domain
+		}
+		if base != domain && !strings.HasSuffix(base, "."+domain) {
+			continue
+		}
+
+		if !b.applyMask(ctx, m, base, domain) {
+			return
+		}
+	}
+}
+
+// applyMask generates the names matched by the mask under the base subdomain name.
+// It returns false when the enumeration has ended.
+func (b *BruteMasks) applyMask(ctx context.Context, m *config.Mask, base, domain string) bool {
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return false
+	}
+
+	if b.dynamicWildcard(ctx, base, domain) {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(b.String(), requests.LogWarning, "Skipping the mask %s, since %s has a dynamic DNS wildcard", m, base))
+		return true
+	}
+
+	bus.Publish(requests.LogTopic, eventbus.PriorityLow,
+		requests.NewLogEvent(b.String(), requests.LogInfo, "Generating %d names from the mask %s under %s", m.Keyspace(), m, base))
+	atomic.AddInt64(&b.keyspace, m.Keyspace())
+
+	for i := int64(0); i < m.Keyspace(); i++ {
+		select {
+		case <-ctx.Done():
+			return false
+		default:
+		}
+
+		name := m.Word(i) + "." + base
+		if requests.BruteForceTruncated(ctx, name) {
+			bus.Publish(requests.LogTopic, eventbus.PriorityLow,
+				requests.NewLogEvent(b.String(), requests.LogInfo, "Stopped the mask %s under %s after %d of %d names", m, base, i, m.Keyspace()))
+			return true
+		}
+		if !requests.SkipAttemptedName(ctx, name, b.SourceType, b.String()) {
+			bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+				Name:       name,
+				Domain:     domain,
+				Tag:        b.SourceType,
+				Source:     b.String(),
+				Provenance: m.String(),
+			})
+		}
+		atomic.AddInt64(&b.generated, 1)
+
+		if (i+1)%1000 == 0 {
+			b.CheckRateLimit()
+		}
+	}
+	return true
+}
+
+// dynamicWildcard returns true when the base subdomain name or a parent of it returns
+// unpredictable answers for names that do not exist.
+func (b *BruteMasks) dynamicWildcard(ctx context.Context, base, domain string) bool {
+	pool := b.sys.Pool()
+	if pool == nil {
+		return false
+	}
+
+	var name string
+	for i := 0; i < 10 && name == ""; i++ {
+		name = resolve.UnlikelyName(base)
+	}
+	if name == "" {
+		return false
+	}
+
+	msg := resolve.QueryMsg(name, dns.TypeA)
+	return pool.WildcardType(ctx, msg, domain) == resolve.WildcardTypeDynamic
+}
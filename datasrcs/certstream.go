@@ -0,0 +1,272 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	amasshttp "github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	certStreamURL = "wss://certstream.calidog.io"
+	// The time allowed for the connection to the feed to be established
+	certStreamDialTimeout = 15 * time.Second
+	// The feed sends heartbeats, so a connection that goes quiet for this long has been dropped
+	certStreamReadTimeout = time.Minute
+	certStreamMinBackoff  = time.Second
+	certStreamMaxBackoff  = time.Minute
+)
+
+// CertStream is the Service that handles access to the certstream Certificate Transparency log feed.
+// The names in the certificates logged while an enumeration is running are published as they arrive.
+type CertStream struct {
+	service.BaseService
+	sync.Mutex
+
+	SourceType  string
+	sys         systems.System
+	url         string
+	dialTimeout time.Duration
+	subs        map[*eventbus.EventBus]*certStreamSub
+	// Closed once the last enumeration streaming the feed is done
+	idle     chan struct{}
+	disabled bool
+}
+
+// certStreamSub is an enumeration receiving the names from the feed.
+type certStreamSub struct {
+	ctx context.Context
+	cfg *config.Config
+	bus *eventbus.EventBus
+}
+
+type certStreamMessage struct {
+	MessageType string `json:"message_type"`
+	Data        struct {
+		LeafCert struct {
+			AllDomains []string `json:"all_domains"`
+		} `json:"leaf_cert"`
+	} `json:"data"`
+}
+
+// NewCertStream returns he object initialized, but not yet started.
+func NewCertStream(sys systems.System) *CertStream {
+	c := &CertStream{
+		SourceType:  requests.CERT,
+		sys:         sys,
+		url:         certStreamURL,
+		dialTimeout: certStreamDialTimeout,
+		subs:        make(map[*eventbus.EventBus]*certStreamSub),
+	}
+
+	c.BaseService = *service.NewBaseService(c, "CertStream")
+	return c
+}
+
+// Description implements the Service interface.
+func (c *CertStream) Description() string {
+	return c.SourceType
+}
+
+// OnRequest implements the Service interface. The enumeration receives the names from the feed
+// until its context is done, and the request returns without waiting on the connection.
+func (c *CertStream) OnRequest(ctx context.Context, args service.Args) {
+	if _, ok := args.(*requests.DNSRequest); ok {
+		c.subscribe(ctx)
+	}
+}
+
+func (c *CertStream) subscribe(ctx context.Context) {
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if _, found := c.subs[bus]; found || c.disabled {
+		return
+	}
+
+	sub := &certStreamSub{ctx: ctx, cfg: cfg, bus: bus}
+	c.subs[bus] = sub
+	go c.unsubscribe(sub)
+
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+		go c.stream(c.idle)
+	}
+}
+
+// unsubscribe removes the enumeration once it is done, and stops the stream after the last one.
+func (c *CertStream) unsubscribe(sub *certStreamSub) {
+	select {
+	case <-sub.ctx.Done():
+	case <-c.Done():
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.subs, sub.bus)
+	if len(c.subs) == 0 && c.idle != nil {
+		close(c.idle)
+		c.idle = nil
+	}
+}
+
+// stream reads the feed until the service is stopped or the enumerations are done. The source is
+// disabled when the first connection cannot be established, and reconnects with a backoff after
+// the connection has been dropped.
+func (c *CertStream) stream(idle chan struct{}) {
+	backoff := certStreamMinBackoff
+
+	for connected := false; ; {
+		conn, err := c.dial(idle)
+		if err != nil && c.stopped(idle) {
+			return
+		}
+		if err != nil && !connected {
+			c.disable(err)
+			return
+		}
+
+		if err == nil {
+			connected = true
+			backoff = certStreamMinBackoff
+			c.read(conn, idle)
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-c.Done():
+		case <-idle:
+		case <-t.C:
+		}
+		t.Stop()
+		if c.stopped(idle) {
+			return
+		}
+
+		if backoff *= 2; backoff > certStreamMaxBackoff {
+			backoff = certStreamMaxBackoff
+		}
+	}
+}
+
+// stopped returns true once the service has been stopped or the enumerations are done.
+func (c *CertStream) stopped(idle chan struct{}) bool {
+	select {
+	case <-c.Done():
+		return true
+	case <-idle:
+		return true
+	default:
+	}
+	return false
+}
+
+func (c *CertStream) dial(idle chan struct{}) (*websocket.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.dialTimeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-c.Done():
+		case <-idle:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.url, nil)
+	return conn, err
+}
+
+// read publishes the names from the feed until the connection is dropped or no longer needed.
+func (c *CertStream) read(conn *websocket.Conn, idle chan struct{}) {
+	finished := make(chan struct{})
+	defer close(finished)
+
+	go func() {
+		select {
+		case <-c.Done():
+		case <-idle:
+		case <-finished:
+		}
+		conn.Close()
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(certStreamReadTimeout))
+
+		var msg certStreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return
+		}
+
+		if msg.MessageType == "certificate_update" {
+			c.publish(msg.Data.LeafCert.AllDomains)
+		}
+	}
+}
+
+// publish sends the names within the domains of each enumeration to the enumeration.
+func (c *CertStream) publish(names []string) {
+	c.Lock()
+	subs := make([]*certStreamSub, 0, len(c.subs))
+	for _, sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.Unlock()
+
+	for _, n := range names {
+		name := amasshttp.CleanName(strings.TrimPrefix(n, "*."))
+		if name == "" {
+			continue
+		}
+
+		for _, sub := range subs {
+			if sub.ctx.Err() != nil {
+				continue
+			}
+
+			if domain := sub.cfg.WhichDomain(name); domain != "" {
+				sub.bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+					Name:   name,
+					Domain: domain,
+					Tag:    c.SourceType,
+					Source: c.String(),
+				})
+			}
+		}
+	}
+}
+
+// disable stops the source from streaming the feed, so the enumerations do not wait on it.
+func (c *CertStream) disable(err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.disabled = true
+	for _, sub := range c.subs {
+		sub.bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent(c.String(),
+			requests.LogWarning, "The feed could not be reached and the source was disabled: %v", err))
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/gorilla/websocket"
+)
+
+// certFeed serves a batch of certificate updates on each connection, and drops the connection after
+// all but the last batch.
+type certFeed struct {
+	sync.Mutex
+	*httptest.Server
+	batches [][]string
+	conns   int
+	closed  chan struct{}
+}
+
+func newCertFeed(batches ...[]string) *certFeed {
+	f := &certFeed{batches: batches, closed: make(chan struct{}, len(batches))}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		f.Lock()
+		num := f.conns
+		f.conns++
+		f.Unlock()
+		if num >= len(f.batches) {
+			return
+		}
+
+		_ = conn.WriteJSON(map[string]string{"message_type": "heartbeat"})
+		msg := map[string]interface{}{
+			"message_type": "certificate_update",
+			"data": map[string]interface{}{
+				"leaf_cert": map[string]interface{}{"all_domains": f.batches[num]},
+			},
+		}
+		_ = conn.WriteJSON(msg)
+		if num < len(f.batches)-1 {
+			return
+		}
+
+		// Hold the last connection until the client closes it
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				f.closed <- struct{}{}
+				return
+			}
+		}
+	}))
+	return f
+}
+
+func (f *certFeed) connections() int {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.conns
+}
+
+func certStreamContext(domains ...string) (context.Context, context.CancelFunc, *eventbus.EventBus) {
+	cfg := config.NewConfig()
+	for _, d := range domains {
+		cfg.AddDomain(d)
+	}
+
+	bus := eventbus.NewEventBus()
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, bus
+}
+
+func TestCertStreamReconnects(t *testing.T) {
+	f := newCertFeed(
+		[]string{"www.example.com", "*.api.example.com", "www.other.org"},
+		[]string{"example.com", "mail.example.com"},
+	)
+	defer f.Close()
+
+	ctx, cancel, bus := certStreamContext("example.com")
+	defer cancel()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	var names []string
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if req.Tag == requests.CERT && req.Domain == "example.com" {
+			names = append(names, req.Name)
+		}
+	})
+	requests.WaitForSubscriptions(ctx, bus)
+
+	c := NewCertStream(nil)
+	c.url = "ws" + strings.TrimPrefix(f.URL, "http")
+	if err := c.Start(); err != nil {
+		t.Fatalf("Failed to start the service: %v", err)
+	}
+	c.Request(ctx, &requests.DNSRequest{Domain: "example.com"})
+	// The requests for the other domains of the enumeration share the stream
+	c.Request(ctx, &requests.DNSRequest{Domain: "example.com"})
+
+	var got string
+	for i := 0; i < 100; i++ {
+		lock.Lock()
+		got = strings.Join(names, ",")
+		lock.Unlock()
+		if len(strings.Split(got, ",")) >= 4 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got != "www.example.com,api.example.com,example.com,mail.example.com" {
+		t.Errorf("Unexpected names from the feed: %s", got)
+	}
+	if conns := f.connections(); conns != 2 {
+		t.Errorf("The feed was connected to %d times instead of 2", conns)
+	}
+
+	_ = c.Stop()
+	select {
+	case <-f.closed:
+	case <-time.After(2 * time.Second):
+		t.Error("The connection to the feed was not closed once the service was stopped")
+	}
+}
+
+func TestCertStreamDisabled(t *testing.T) {
+	ctx, cancel, bus := certStreamContext("example.com")
+	defer cancel()
+	defer bus.Stop()
+
+	warned := make(chan struct{}, 1)
+	bus.Subscribe(requests.LogTopic, func(ev *requests.LogEvent) {
+		if ev.Severity == requests.LogWarning {
+			select {
+			case warned <- struct{}{}:
+			default:
+			}
+		}
+	})
+	requests.WaitForSubscriptions(ctx, bus)
+
+	c := NewCertStream(nil)
+	// Nothing accepts connections at the reserved address
+	c.url = "ws://192.0.2.1:80"
+	c.dialTimeout = 200 * time.Millisecond
+	if err := c.Start(); err != nil {
+		t.Fatalf("Failed to start the service: %v", err)
+	}
+	defer func() { _ = c.Stop() }()
+
+	start := time.Now()
+	c.OnRequest(ctx, &requests.DNSRequest{Domain: "example.com"})
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("The request waited %s on the connection", elapsed)
+	}
+
+	select {
+	case <-warned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("No warning was logged for the unreachable feed")
+	}
+
+	c.Lock()
+	disabled := c.disabled
+	c.Unlock()
+	if !disabled {
+		t.Error("The source was not disabled after failing to connect")
+	}
+}
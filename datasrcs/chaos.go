@@ -0,0 +1,218 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	chaosIndexURL = "https://chaos-data.projectdiscovery.io/index.json"
+	chaosAPIURL   = "https://dns.projectdiscovery.io/dns/"
+)
+
+// Chaos is the Service that handles access to the ProjectDiscovery Chaos data source.
+type Chaos struct {
+	service.BaseService
+
+	SourceType string
+	sys        systems.System
+	creds      *config.Credentials
+	indexURL   string
+	apiURL     string
+}
+
+// NewChaos returns the object initialized, but not yet started.
+func NewChaos(sys systems.System) *Chaos {
+	c := &Chaos{
+		SourceType: requests.API,
+		sys:        sys,
+		indexURL:   chaosIndexURL,
+		apiURL:     chaosAPIURL,
+	}
+
+	c.BaseService = *service.NewBaseService(c, "Chaos")
+	return c
+}
+
+// Description implements the Service interface.
+func (c *Chaos) Description() string {
+	return c.SourceType
+}
+
+// OnStart implements the Service interface.
+func (c *Chaos) OnStart() error {
+	c.creds = c.sys.Config().GetDataSourceConfig(c.String()).GetCredentials()
+
+	if c.creds == nil || c.creds.Key == "" {
+		c.sys.Config().Log.Printf("%s: API key data was not provided, so only the public datasets are used", c.String())
+	}
+
+	c.SetRateLimit(10)
+	return nil
+}
+
+// OnRequest implements the Service interface.
+func (c *Chaos) OnRequest(ctx context.Context, args service.Args) {
+	if req, ok := args.(*requests.DNSRequest); ok {
+		c.dnsRequest(ctx, req)
+		c.CheckRateLimit()
+	}
+}
+
+func (c *Chaos) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil || scp.Evaluate(req.Domain, nil).Domain == "" {
+		return
+	}
+
+	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		requests.NewLogEvent(c.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
+
+	for _, u := range c.datasetURLs(ctx, req.Domain) {
+		c.publishDataset(ctx, u)
+	}
+
+	if c.creds != nil && c.creds.Key != "" {
+		c.apiRequest(ctx, req.Domain)
+	}
+}
+
+// datasetURLs returns the URLs of the public dataset archives for the bug bounty programs named
+// after the domain, such as the archive of the Example program for example.com.
+func (c *Chaos) datasetURLs(ctx context.Context, domain string) []string {
+	page, err := http.RequestWebPage(ctx, c.indexURL, nil, nil, nil)
+	if err != nil {
+		c.logError(ctx, c.indexURL, err)
+		return nil
+	}
+
+	var index []struct {
+		Name string `json:"name"`
+		URL  string `json:"URL"`
+	}
+	if err := json.Unmarshal([]byte(page), &index); err != nil {
+		return nil
+	}
+
+	label := chaosProgramName(domain)
+	if label == "" {
+		return nil
+	}
+
+	var urls []string
+	for _, program := range index {
+		if program.URL == "" {
+			continue
+		}
+		if chaosProgramName(program.Name) == label ||
+			chaosProgramName(strings.TrimSuffix(path.Base(program.URL), ".zip")) == label {
+			urls = append(urls, program.URL)
+		}
+	}
+	return urls
+}
+
+// chaosProgramName returns the name of the program without punctuation, or the label of the
+// registered domain name, so the names of the programs and domains can be compared.
+func chaosProgramName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if d, err := publicsuffix.EffectiveTLDPlusOne(name); err == nil {
+		name = strings.SplitN(d, ".", 2)[0]
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// publishDataset decompresses the dataset archive in memory and publishes each name in scope,
+// which the archive provides one per line within a text file for each domain of the program.
+func (c *Chaos) publishDataset(ctx context.Context, u string) {
+	page, err := http.RequestWebPage(ctx, u, nil, nil, nil)
+	if err != nil {
+		c.logError(ctx, u, err)
+		return
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader([]byte(page)), int64(len(page)))
+	if err != nil {
+		c.logError(ctx, u, err)
+		return
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(rc)
+		for scanner.Scan() {
+			if name := http.CleanName(scanner.Text()); name != "" {
+				genNewNameEvent(ctx, c.sys, c, name)
+			}
+		}
+		rc.Close()
+	}
+}
+
+// apiRequest obtains the subdomain names from the API, which requires the key.
+func (c *Chaos) apiRequest(ctx context.Context, domain string) {
+	u := c.apiURL + domain + "/subdomains"
+	page, err := http.RequestWebPage(ctx, u, nil, map[string]string{"Authorization": c.creds.Key}, nil)
+	if err != nil {
+		c.logError(ctx, u, err)
+		return
+	}
+
+	var resp struct {
+		Domain     string   `json:"domain"`
+		Subdomains []string `json:"subdomains"`
+	}
+	if err := json.Unmarshal([]byte(page), &resp); err != nil {
+		return
+	}
+
+	for _, sub := range resp.Subdomains {
+		if sub = strings.TrimSpace(sub); sub != "" {
+			genNewNameEvent(ctx, c.sys, c, http.CleanName(sub+"."+domain))
+		}
+	}
+}
+
+func (c *Chaos) logError(ctx context.Context, u string, err error) {
+	if _, bus, e := requests.ContextConfigBus(ctx); e == nil {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(c.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// chaosServer serves the index of the public datasets, a synthetic dataset archive
+// for the Example program, and the API that requires the key.
+type chaosServer struct {
+	sync.Mutex
+	*httptest.Server
+	hits map[string]int
+}
+
+func newChaosServer(t *testing.T) *chaosServer {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for file, content := range map[string]string{
+		"example.com.txt": "www.example.com\nmail.example.com\n\ndev.example.com\n",
+		"example.net.txt": "www.example.net\n",
+	} {
+		w, err := zw.Create(file)
+		if err != nil {
+			t.Fatalf("Failed to create the dataset archive: %v", err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to create the dataset archive: %v", err)
+	}
+
+	s := &chaosServer{hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		s.hits[r.URL.Path]++
+		s.Unlock()
+
+		switch r.URL.Path {
+		case "/index.json":
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"name": "Example", "URL": s.URL + "/example.zip", "count": 3},
+				{"name": "Other Program", "URL": s.URL + "/other_program.zip", "count": 10},
+			})
+		case "/example.zip":
+			_, _ = w.Write(buf.Bytes())
+		case "/dns/example.com/subdomains":
+			if r.Header.Get("Authorization") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"domain":     "example.com",
+				"subdomains": []string{"api", "vpn"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func (s *chaosServer) count(path string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.hits[path]
+}
+
+func chaosNames(t *testing.T, c *Chaos) []string {
+	ctx, cancel, bus := certStreamContext("example.com")
+	defer cancel()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	var names []string
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if req.Source != "Chaos" || req.Domain != "example.com" {
+			t.Errorf("Unexpected name published: %+v", req)
+		}
+		names = append(names, req.Name)
+	})
+	requests.WaitForSubscriptions(ctx, bus)
+
+	c.dnsRequest(ctx, &requests.DNSRequest{Domain: "example.com"})
+	time.Sleep(200 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	sort.Strings(names)
+	return names
+}
+
+func TestChaosPublicDatasets(t *testing.T) {
+	s := newChaosServer(t)
+	defer s.Close()
+
+	c := NewChaos(nil)
+	c.indexURL = s.URL + "/index.json"
+	c.apiURL = s.URL + "/dns/"
+
+	names := chaosNames(t, c)
+	if got := strings.Join(names, ","); got != "dev.example.com,mail.example.com,www.example.com" {
+		t.Errorf("Unexpected names published from the dataset: %v", names)
+	}
+	if s.count("/other_program.zip") > 0 {
+		t.Error("The dataset of another program was downloaded")
+	}
+	if s.count("/dns/example.com/subdomains") > 0 {
+		t.Error("The API was queried without a key")
+	}
+}
+
+func TestChaosAPIKey(t *testing.T) {
+	s := newChaosServer(t)
+	defer s.Close()
+
+	c := NewChaos(nil)
+	c.indexURL = s.URL + "/index.json"
+	c.apiURL = s.URL + "/dns/"
+	c.creds = &config.Credentials{Key: "secret"}
+
+	names := chaosNames(t, c)
+	expected := "api.example.com,dev.example.com,mail.example.com,vpn.example.com,www.example.com"
+	if got := strings.Join(names, ","); got != expected {
+		t.Errorf("Unexpected names published from the dataset and API: %v", names)
+	}
+}
@@ -5,7 +5,6 @@ package datasrcs
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/requests"
@@ -61,7 +60,12 @@ func (c *Cloudflare) OnRequest(ctx context.Context, args service.Args) {
 }
 
 func (c *Cloudflare) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
@@ -70,31 +74,34 @@ func (c *Cloudflare) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 		return
 	}
 
-	if !cfg.IsDomainInScope(req.Domain) {
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", c.String(), req.Domain))
+		requests.NewLogEvent(c.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	api, err := cloudflare.NewWithAPIToken(c.creds.Key)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", c.String(), err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(c.String(), requests.LogError, "%v", err))
 	}
 
 	zones, err := api.ListZones(req.Domain)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", c.String(), err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(c.String(), requests.LogError, "%v", err))
 	}
 
 	for _, zone := range zones {
 		records, err := api.DNSRecords(zone.ID, cloudflare.DNSRecord{})
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", c.String(), err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(c.String(), requests.LogError, "%v", err))
 		}
 
 		for _, record := range records {
-			if d := cfg.WhichDomain(record.Name); d != "" {
+			if d := scp.Evaluate(record.Name, nil).Domain; d != "" {
 				bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
 					Name:   record.Name,
 					Domain: req.Domain,
@@ -103,7 +110,7 @@ func (c *Cloudflare) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 				})
 			}
 			if record.Type == "CNAME" {
-				if d := cfg.WhichDomain(record.Content); d != "" {
+				if d := scp.Evaluate(record.Content, nil).Domain; d != "" {
 					bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
 						Name:   record.Content,
 						Domain: req.Domain,
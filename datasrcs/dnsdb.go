@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/net/http"
@@ -17,7 +18,6 @@ import (
 	"github.com/OWASP/Amass/v3/systems"
 	"github.com/caffix/eventbus"
 	"github.com/caffix/service"
-	"github.com/caffix/stringset"
 )
 
 // DNSDB is the Service that handles access to the DNSDB data source.
@@ -83,7 +83,12 @@ func (d *DNSDB) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 		return
 	}
 
-	if !cfg.IsDomainInScope(req.Domain) {
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
@@ -93,7 +98,7 @@ func (d *DNSDB) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 
 	numRateLimitChecks(d, 120)
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", d.String(), req.Domain))
+		requests.NewLogEvent(d.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	headers := map[string]string{
 		"X-API-Key":    d.creds.Key,
@@ -104,12 +109,16 @@ func (d *DNSDB) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 	url := d.getURL(req.Domain)
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", d.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(d.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 
-	for _, name := range d.parse(ctx, page, req.Domain) {
-		genNewNameEvent(ctx, d.sys, d, name)
+	for _, r := range d.parse(ctx, page, req.Domain) {
+		if cfg.RecordTooOld(r.LastSeen) {
+			continue
+		}
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, r)
 	}
 }
 
@@ -117,18 +126,18 @@ func (d *DNSDB) getURL(domain string) string {
 	return fmt.Sprintf("https://api.dnsdb.info/lookup/rrset/name/*.%s?limit=10000000", domain)
 }
 
-func (d *DNSDB) parse(ctx context.Context, page, domain string) []string {
+func (d *DNSDB) parse(ctx context.Context, page, domain string) []*requests.DNSRequest {
 	cfg, _, err := requests.ContextConfigBus(ctx)
 	if err != nil {
-		return []string{}
+		return nil
 	}
 
 	re := cfg.DomainRegex(domain)
 	if re == nil {
-		return []string{}
+		return nil
 	}
 
-	unique := stringset.New()
+	unique := make(map[string]*requests.DNSRequest)
 	scanner := bufio.NewScanner(strings.NewReader(page))
 	for scanner.Scan() {
 		// Get the next line of JSON
@@ -138,16 +147,52 @@ func (d *DNSDB) parse(ctx context.Context, page, domain string) []string {
 		}
 
 		var j struct {
-			Name string `json:"rrname"`
+			Name          string `json:"rrname"`
+			TimeFirst     int64  `json:"time_first"`
+			TimeLast      int64  `json:"time_last"`
+			ZoneTimeFirst int64  `json:"zone_time_first"`
+			ZoneTimeLast  int64  `json:"zone_time_last"`
 		}
 		err := json.Unmarshal([]byte(line), &j)
 		if err != nil {
 			continue
 		}
-		if re.MatchString(j.Name) {
-			unique.Insert(j.Name)
+
+		name := strings.TrimSuffix(j.Name, ".")
+		if !re.MatchString(name) {
+			continue
+		}
+
+		req, found := unique[name]
+		if !found {
+			req = &requests.DNSRequest{
+				Name:   name,
+				Domain: domain,
+				Tag:    d.Description(),
+				Source: d.String(),
+			}
+			unique[name] = req
+		}
+		// The records observed within zone files only provide the zone times
+		first, last := j.TimeFirst, j.TimeLast
+		if first == 0 && last == 0 {
+			first, last = j.ZoneTimeFirst, j.ZoneTimeLast
+		}
+		if first > 0 {
+			if t := time.Unix(first, 0).UTC(); req.FirstSeen.IsZero() || t.Before(req.FirstSeen) {
+				req.FirstSeen = t
+			}
+		}
+		if last > 0 {
+			if t := time.Unix(last, 0).UTC(); t.After(req.LastSeen) {
+				req.LastSeen = t
+			}
 		}
 	}
 
-	return unique.Slice()
+	results := make([]*requests.DNSRequest, 0, len(unique))
+	for _, req := range unique {
+		results = append(results, req)
+	}
+	return results
 }
@@ -69,26 +69,28 @@ func (d *DNSDumpster) dnsRequest(ctx context.Context, req *requests.DNSRequest)
 	}
 
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", d.String(), req.Domain))
+		requests.NewLogEvent(d.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	u := "https://dnsdumpster.com/"
 	page, err := amasshttp.RequestWebPage(ctx, u, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", d.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(d.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
 	token := d.getCSRFToken(page)
 	if token == "" {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to obtain the CSRF token", d.String(), u))
+			requests.NewLogEvent(d.String(), requests.LogError, "%s: Failed to obtain the CSRF token", u).With(requests.LogFieldURL, u))
 		return
 	}
 
 	d.CheckRateLimit()
 	page, err = d.postForm(ctx, token, req.Domain)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", d.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(d.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -120,7 +122,7 @@ func (d *DNSDumpster) postForm(ctx context.Context, token, domain string) (strin
 	req, err := http.NewRequestWithContext(ctx, "POST", "https://dnsdumpster.com/", strings.NewReader(params.Encode()))
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: Failed to setup the POST request: %v", d.String(), err))
+			requests.NewLogEvent(d.String(), requests.LogError, "Failed to setup the POST request: %v", err))
 		return "", err
 	}
 	// The CSRF token needs to be sent as a cookie
@@ -141,7 +143,7 @@ func (d *DNSDumpster) postForm(ctx context.Context, token, domain string) (strin
 	resp, err := amasshttp.DefaultClient.Do(req)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: The POST request failed: %v", d.String(), err))
+			requests.NewLogEvent(d.String(), requests.LogError, "The POST request failed: %v", err))
 		return "", err
 	}
 	defer resp.Body.Close()
@@ -150,7 +152,7 @@ func (d *DNSDumpster) postForm(ctx context.Context, token, domain string) (strin
 	in, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("Failed to read response body: %v", err))
+			requests.NewLogEvent(d.String(), requests.LogError, "Failed to read response body: %v", err))
 		return "", err
 	}
 	return string(in), nil
@@ -6,7 +6,6 @@ package datasrcs
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net"
 	"net/url"
 	"regexp"
@@ -131,15 +130,15 @@ func (n *NetworksDB) executeASNAddrQuery(ctx context.Context, addr string) {
 	u := n.getIPURL(addr)
 	page, err := http.RequestWebPage(ctx, u, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
 	matches := networksdbASNLinkRE.FindStringSubmatch(page)
 	if matches == nil || len(matches) < 2 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to extract the autonomous system href", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to extract the autonomous system href", u).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -147,7 +146,8 @@ func (n *NetworksDB) executeASNAddrQuery(ctx context.Context, addr string) {
 	u = networksdbBaseURL + matches[1]
 	page, err = http.RequestWebPage(ctx, u, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -161,16 +161,14 @@ func (n *NetworksDB) executeASNAddrQuery(ctx context.Context, addr string) {
 	matches = networksdbASNRE.FindStringSubmatch(page)
 	if matches == nil || len(matches) < 2 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The regular expression failed to extract the ASN", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: The regular expression failed to extract the ASN", u).With(requests.LogFieldURL, u))
 		return
 	}
 
 	asn, err := strconv.Atoi(strings.TrimSpace(matches[1]))
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to extract a valid ASN", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to extract a valid ASN", u).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -191,15 +189,15 @@ func (n *NetworksDB) executeASNQuery(ctx context.Context, asn int, addr string,
 	u := n.getASNURL(asn)
 	page, err := http.RequestWebPage(ctx, u, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
 	matches := networksdbASNameRE.FindStringSubmatch(page)
 	if matches == nil || len(matches) < 2 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: The regular expression failed to extract the AS name", n.String()),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "The regular expression failed to extract the AS name"))
 		return
 	}
 	name := strings.TrimSpace(matches[1])
@@ -207,8 +205,7 @@ func (n *NetworksDB) executeASNQuery(ctx context.Context, asn int, addr string,
 	matches = networksdbCCRE.FindStringSubmatch(page)
 	if matches == nil || len(matches) < 2 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: The regular expression failed to extract the country code", n.String()),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "The regular expression failed to extract the country code"))
 		return
 	}
 	cc := strings.TrimSpace(matches[1])
@@ -259,8 +256,7 @@ func (n *NetworksDB) executeAPIASNAddrQuery(ctx context.Context, addr string) {
 	_, id := n.apiIPQuery(ctx, addr)
 	if id == "" {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to obtain IP address information", n.String(), addr),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to obtain IP address information", addr))
 		return
 	}
 
@@ -268,8 +264,7 @@ func (n *NetworksDB) executeAPIASNAddrQuery(ctx context.Context, addr string) {
 	asns := n.apiOrgInfoQuery(ctx, id)
 	if len(asns) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to obtain ASNs associated with the organization", n.String(), id),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to obtain ASNs associated with the organization", id))
 		return
 	}
 
@@ -282,8 +277,7 @@ loop:
 		cidrs = n.apiNetblocksQuery(ctx, a)
 		if len(cidrs) == 0 {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %d: Failed to obtain netblocks associated with the ASN", n.String(), a),
-			)
+				requests.NewLogEvent(n.String(), requests.LogError, "%d: Failed to obtain netblocks associated with the ASN", a))
 		}
 
 		for cidr := range cidrs {
@@ -298,8 +292,7 @@ loop:
 
 	if asn == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to obtain the ASN associated with the IP address", n.String(), addr),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to obtain the ASN associated with the IP address", addr))
 		return
 	}
 	n.executeAPIASNQuery(ctx, asn, addr, cidrs)
@@ -318,8 +311,7 @@ func (n *NetworksDB) executeAPIASNQuery(ctx context.Context, asn int, addr strin
 		netblocks.Union(n.apiNetblocksQuery(ctx, asn))
 		if len(netblocks) == 0 {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %d: Failed to obtain netblocks associated with the ASN", n.String(), asn),
-			)
+				requests.NewLogEvent(n.String(), requests.LogError, "%d: Failed to obtain netblocks associated with the ASN", asn))
 			return
 		}
 	}
@@ -342,8 +334,7 @@ func (n *NetworksDB) executeAPIASNQuery(ctx context.Context, asn int, addr strin
 	req := n.apiASNInfoQuery(ctx, asn)
 	if req == nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %d: Failed to obtain ASN information", n.String(), asn),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%d: Failed to obtain ASN information", asn))
 		return
 	}
 
@@ -367,7 +358,8 @@ func (n *NetworksDB) apiIPQuery(ctx context.Context, addr string) (string, strin
 	body := strings.NewReader(params.Encode())
 	page, err := http.RequestWebPage(ctx, u, body, n.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return "", ""
 	}
 
@@ -384,15 +376,16 @@ func (n *NetworksDB) apiIPQuery(ctx context.Context, addr string) (string, strin
 		} `json:"results"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return "", ""
 	} else if m.Error != "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %s", n.String(), u, m.Error))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %s", u, m.Error).With(requests.LogFieldURL, u))
 		return "", ""
 	} else if m.Total == 0 || len(m.Results) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The request returned zero results", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogWarning, "%s: The request returned zero results", u).With(requests.LogFieldURL, u))
 		return "", ""
 	}
 
@@ -415,7 +408,8 @@ func (n *NetworksDB) apiOrgInfoQuery(ctx context.Context, id string) []int {
 	body := strings.NewReader(params.Encode())
 	page, err := http.RequestWebPage(ctx, u, body, n.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return []int{}
 	}
 
@@ -427,15 +421,16 @@ func (n *NetworksDB) apiOrgInfoQuery(ctx context.Context, id string) []int {
 		} `json:"results"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return []int{}
 	} else if m.Error != "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %s", n.String(), u, m.Error))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %s", u, m.Error).With(requests.LogFieldURL, u))
 		return []int{}
 	} else if m.Total == 0 || len(m.Results[0].ASNs) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The request returned zero results", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogWarning, "%s: The request returned zero results", u).With(requests.LogFieldURL, u))
 		return []int{}
 	}
 
@@ -458,7 +453,8 @@ func (n *NetworksDB) apiASNInfoQuery(ctx context.Context, asn int) *requests.ASN
 	body := strings.NewReader(params.Encode())
 	page, err := http.RequestWebPage(ctx, u, body, n.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return nil
 	}
 
@@ -474,15 +470,16 @@ func (n *NetworksDB) apiASNInfoQuery(ctx context.Context, asn int) *requests.ASN
 		} `json:"results"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return nil
 	} else if m.Error != "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %s", n.String(), u, m.Error))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %s", u, m.Error).With(requests.LogFieldURL, u))
 		return nil
 	} else if m.Total == 0 || len(m.Results) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The request returned zero results", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogWarning, "%s: The request returned zero results", u).With(requests.LogFieldURL, u))
 		return nil
 	}
 
@@ -513,7 +510,8 @@ func (n *NetworksDB) apiNetblocksQuery(ctx context.Context, asn int) stringset.S
 	body := strings.NewReader(params.Encode())
 	page, err := http.RequestWebPage(ctx, u, body, n.getHeaders(), nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return netblocks
 	}
 
@@ -525,15 +523,16 @@ func (n *NetworksDB) apiNetblocksQuery(ctx context.Context, asn int) stringset.S
 		} `json:"results"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return netblocks
 	} else if m.Error != "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %s", n.String(), u, m.Error))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %s", u, m.Error).With(requests.LogFieldURL, u))
 		return netblocks
 	} else if m.Total == 0 || len(m.Results) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The request returned zero results", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogWarning, "%s: The request returned zero results", u).With(requests.LogFieldURL, u))
 		return netblocks
 	}
 
@@ -559,11 +558,16 @@ func (n *NetworksDB) getHeaders() map[string]string {
 }
 
 func (n *NetworksDB) whoisRequest(ctx context.Context, req *requests.WhoisRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
 	if err != nil {
 		return
 	}
-	if !cfg.IsDomainInScope(req.Domain) {
+
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil {
+		return
+	}
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
@@ -571,15 +575,15 @@ func (n *NetworksDB) whoisRequest(ctx context.Context, req *requests.WhoisReques
 	u := n.getDomainToIPURL(req.Domain)
 	page, err := http.RequestWebPage(ctx, u, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
 	matches := networksdbIPLinkRE.FindAllStringSubmatch(page, -1)
 	if matches == nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to extract the IP page href", n.String(), u),
-		)
+			requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to extract the IP page href", u).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -594,15 +598,15 @@ func (n *NetworksDB) whoisRequest(ctx context.Context, req *requests.WhoisReques
 		u = networksdbBaseURL + match[1]
 		page, err = http.RequestWebPage(ctx, u, nil, nil, nil)
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 			continue
 		}
 
 		cidrMatch := networksdbIPPageCIDRRE.FindStringSubmatch(page)
 		if cidrMatch == nil || len(cidrMatch) < 2 {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: Failed to extract the CIDR", n.String(), u),
-			)
+				requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to extract the CIDR", u).With(requests.LogFieldURL, u))
 			continue
 		}
 
@@ -617,7 +621,8 @@ func (n *NetworksDB) whoisRequest(ctx context.Context, req *requests.WhoisReques
 
 		page, err = http.RequestWebPage(ctx, u, nil, nil, nil)
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", n.String(), u, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(n.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 			continue
 		}
 
@@ -625,8 +630,7 @@ func (n *NetworksDB) whoisRequest(ctx context.Context, req *requests.WhoisReques
 		tablePos := networksdbTableRE.FindStringIndex(page)
 		if domainsPos == nil || tablePos == nil || len(domainsPos) < 2 || len(tablePos) < 2 {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: Failed to extract the domain section of the page", n.String(), u),
-			)
+				requests.NewLogEvent(n.String(), requests.LogError, "%s: Failed to extract the domain section of the page", u).With(requests.LogFieldURL, u))
 			continue
 		}
 
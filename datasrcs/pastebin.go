@@ -66,12 +66,12 @@ func (p *Pastebin) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 
 	numRateLimitChecks(p, 2)
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", p.String(), req.Domain))
+		requests.NewLogEvent(p.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	ids, err := p.extractIDs(ctx, req.Domain)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: %v", p.String(), req.Domain, err))
+			requests.NewLogEvent(p.String(), requests.LogError, "%s: %v", req.Domain, err))
 		return
 	}
 
@@ -79,7 +79,8 @@ func (p *Pastebin) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 		url := p.webURLDumpData(id)
 		page, err := http.RequestWebPage(ctx, url, nil, nil, nil)
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", p.String(), url, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(p.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 			return
 		}
 
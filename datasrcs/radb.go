@@ -120,7 +120,8 @@ func (r *RADb) executeASNAddrQuery(ctx context.Context, addr string) {
 	headers := map[string]string{"Content-Type": "application/json"}
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 
@@ -134,12 +135,12 @@ func (r *RADb) executeASNAddrQuery(ctx context.Context, addr string) {
 		} `json:"cidr0_cidrs"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	} else if m.ClassName != "ip network" || len(m.CIDRs) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The request returned zero results", r.String(), url),
-		)
+			requests.NewLogEvent(r.String(), requests.LogWarning, "%s: The request returned zero results", url).With(requests.LogFieldURL, url))
 		return
 	}
 
@@ -181,7 +182,8 @@ func (r *RADb) executeASNQuery(ctx context.Context, asn int, addr, prefix string
 	headers := map[string]string{"Content-Type": "application/json"}
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 
@@ -194,12 +196,12 @@ func (r *RADb) executeASNQuery(ctx context.Context, asn int, addr, prefix string
 		}
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	} else if m.ClassName != "autnum" {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The query returned incorrect results", r.String(), url),
-		)
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: The query returned incorrect results", url).With(requests.LogFieldURL, url))
 		return
 	}
 
@@ -228,8 +230,7 @@ func (r *RADb) executeASNQuery(ctx context.Context, asn int, addr, prefix string
 
 	if len(blocks) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: The query returned zero netblocks", r.String(), url),
-		)
+			requests.NewLogEvent(r.String(), requests.LogWarning, "%s: The query returned zero netblocks", url).With(requests.LogFieldURL, url))
 		return
 	}
 
@@ -264,7 +265,8 @@ func (r *RADb) netblocks(ctx context.Context, asn int) stringset.Set {
 	headers := map[string]string{"Content-Type": "application/json"}
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return netblocks
 	}
 
@@ -280,7 +282,8 @@ func (r *RADb) netblocks(ctx context.Context, asn int) stringset.Set {
 		} `json:"arin_originas0_networkSearchResults"`
 	}
 	if err := json.Unmarshal([]byte(page), &m); err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", r.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return netblocks
 	}
 
@@ -309,8 +312,7 @@ func (r *RADb) netblocks(ctx context.Context, asn int) stringset.Set {
 
 	if len(netblocks) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: Failed to acquire netblocks for ASN %d", r.String(), asn),
-		)
+			requests.NewLogEvent(r.String(), requests.LogError, "Failed to acquire netblocks for ASN %d", asn))
 	}
 	return netblocks
 }
@@ -333,7 +335,7 @@ func (r *RADb) ipToASN(ctx context.Context, cidr string) int {
 		resp, err := r.sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.RetryPolicy)
 		if err != nil {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: %v", r.String(), radbWhoisURL, err))
+				requests.NewLogEvent(r.String(), requests.LogError, "%s: %v", radbWhoisURL, err))
 			return 0
 		}
 
@@ -345,7 +347,7 @@ func (r *RADb) ipToASN(ctx context.Context, cidr string) int {
 		ip := ans[0].Data
 		if ip == "" {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: Failed to resolve %s", r.String(), radbWhoisURL))
+				requests.NewLogEvent(r.String(), requests.LogError, "Failed to resolve %s", radbWhoisURL))
 			return 0
 		}
 		r.addr = ip
@@ -356,7 +358,8 @@ func (r *RADb) ipToASN(ctx context.Context, cidr string) int {
 
 	conn, err := amassnet.DialContext(ctx, "tcp", r.addr+":43")
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", r.String(), err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(r.String(), requests.LogError, "%v", err))
 		return 0
 	}
 	defer conn.Close()
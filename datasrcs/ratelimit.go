@@ -0,0 +1,203 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/queue"
+	"github.com/caffix/service"
+)
+
+// ExternalRateLimiter is implemented by the data sources that accept a rate limit from the user.
+type ExternalRateLimiter interface {
+	SetExternalRateLimit(d time.Duration)
+}
+
+// RateLimiter wraps a data source and sends it the requests no faster than the rate limit
+// configured by the user, in place of the rate limit the data source sets for itself.
+// The requests of each caller, identified by the event bus of the context, are held in
+// separate queues and sent in turn, so the enumerations sharing the data source are not
+// delayed behind the requests of the others.
+type RateLimiter struct {
+	service.Service
+	sync.Mutex
+	limit    time.Duration
+	callers  map[interface{}]queue.Queue
+	order    []interface{}
+	next     int
+	signal   chan struct{}
+	done     chan struct{}
+	finished chan struct{}
+	last     time.Time
+	now      func() time.Time
+	after    func(time.Duration) <-chan time.Time
+}
+
+type rateLimitedRequest struct {
+	ctx  context.Context
+	args service.Args
+}
+
+// NewRateLimiter returns the data source wrapped by a RateLimiter that applies the limit once started.
+func NewRateLimiter(srv service.Service, limit time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Service: srv,
+		limit:   limit,
+		callers: make(map[interface{}]queue.Queue),
+		signal:  make(chan struct{}, 1),
+		now:     time.Now,
+		after:   time.After,
+	}
+}
+
+// Start implements the Service interface. The rate limit is applied after the data source has
+// set its own during OnStart.
+func (r *RateLimiter) Start() error {
+	if err := r.Service.Start(); err != nil {
+		return err
+	}
+
+	r.done = make(chan struct{})
+	r.finished = make(chan struct{})
+	go r.processRequests()
+
+	r.SetExternalRateLimit(r.limit)
+	return nil
+}
+
+// Stop implements the Service interface.
+func (r *RateLimiter) Stop() error {
+	if r.done != nil {
+		close(r.done)
+		<-r.finished
+		r.done = nil
+	}
+	return r.Service.Stop()
+}
+
+// SetExternalRateLimit implements the ExternalRateLimiter interface. The requests are passed
+// through without delay at the rate limit of the data source when the duration is zero.
+func (r *RateLimiter) SetExternalRateLimit(d time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.limit = d
+	if d > 0 {
+		r.Service.SetRateLimit(0)
+	}
+}
+
+// Request implements the Service interface. The request is held until the rate limit allows it.
+func (r *RateLimiter) Request(ctx context.Context, args service.Args) {
+	if r.rateLimit() == 0 {
+		r.Service.Request(ctx, args)
+		return
+	}
+
+	r.Lock()
+	caller := ctx.Value(requests.ContextEventBus)
+	q, found := r.callers[caller]
+	if !found {
+		q = queue.NewQueue()
+		r.callers[caller] = q
+		r.order = append(r.order, caller)
+	}
+	q.Append(&rateLimitedRequest{ctx: ctx, args: args})
+	r.Unlock()
+
+	select {
+	case r.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Len implements the Service interface. The requests held by the rate limit are included.
+func (r *RateLimiter) Len() int {
+	r.Lock()
+	defer r.Unlock()
+
+	num := r.Service.Len()
+	for _, q := range r.callers {
+		num += q.Len()
+	}
+	return num
+}
+
+func (r *RateLimiter) rateLimit() time.Duration {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.limit
+}
+
+func (r *RateLimiter) processRequests() {
+	defer close(r.finished)
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-r.signal:
+		}
+
+		for {
+			req := r.nextRequest()
+			if req == nil {
+				break
+			}
+			if req.ctx.Err() != nil {
+				continue
+			}
+			if !r.wait() {
+				return
+			}
+
+			r.Service.Request(req.ctx, req.args)
+		}
+	}
+}
+
+// nextRequest returns the request of the caller following the previous one, or nil when no
+// requests are held. The callers without requests are removed.
+func (r *RateLimiter) nextRequest() *rateLimitedRequest {
+	r.Lock()
+	defer r.Unlock()
+
+	for len(r.order) > 0 {
+		if r.next >= len(r.order) {
+			r.next = 0
+		}
+
+		caller := r.order[r.next]
+		if element, ok := r.callers[caller].Next(); ok {
+			r.next++
+			return element.(*rateLimitedRequest)
+		}
+
+		delete(r.callers, caller)
+		r.order = append(r.order[:r.next], r.order[r.next+1:]...)
+	}
+	return nil
+}
+
+// wait blocks until the rate limit has passed since the previous request, and returns false
+// when the data source is stopped.
+func (r *RateLimiter) wait() bool {
+	if !r.last.IsZero() {
+		if delay := r.rateLimit() - r.now().Sub(r.last); delay > 0 {
+			select {
+			case <-r.done:
+				return false
+			case <-r.after(delay):
+			}
+		}
+	}
+
+	r.last = r.now()
+	return true
+}
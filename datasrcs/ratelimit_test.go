@@ -0,0 +1,193 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package datasrcs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+)
+
+// virtualClock advances the time by the delays waited on, so the rate limits do not slow the tests.
+type virtualClock struct {
+	sync.Mutex
+	t time.Time
+}
+
+func (c *virtualClock) now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.t
+}
+
+func (c *virtualClock) after(d time.Duration) <-chan time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	c.t = c.t.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.t
+	return ch
+}
+
+// pacedSource records the time of each request it receives, and sets its own rate limit.
+type pacedSource struct {
+	service.BaseService
+	sync.Mutex
+	clock   *virtualClock
+	times   []time.Time
+	domains []string
+}
+
+func newPacedSource(clock *virtualClock) *pacedSource {
+	s := &pacedSource{clock: clock}
+
+	s.BaseService = *service.NewBaseService(s, "Paced")
+	return s
+}
+
+func (s *pacedSource) OnStart() error {
+	s.SetRateLimit(1)
+	return nil
+}
+
+func (s *pacedSource) Request(ctx context.Context, args service.Args) {
+	s.Lock()
+	s.times = append(s.times, s.clock.now())
+	if req, ok := args.(*requests.DNSRequest); ok {
+		s.domains = append(s.domains, req.Domain)
+	}
+	s.Unlock()
+
+	s.BaseService.Request(ctx, args)
+}
+
+func (s *pacedSource) received() []time.Time {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]time.Time(nil), s.times...)
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &virtualClock{t: time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)}
+	start := clock.now()
+
+	src := newPacedSource(clock)
+	r := NewRateLimiter(src, 5*time.Second)
+	r.now = clock.now
+	r.after = clock.after
+	if err := r.Start(); err != nil {
+		t.Fatalf("Failed to start the data source: %v", err)
+	}
+	defer func() { _ = r.Stop() }()
+
+	for i := 0; i < 30; i++ {
+		r.Request(context.Background(), &requests.DNSRequest{Domain: "example.com"})
+	}
+
+	var times []time.Time
+	for i := 0; i < 100; i++ {
+		if times = src.received(); len(times) == 30 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(times) != 30 {
+		t.Fatalf("%d of the 30 requests reached the data source", len(times))
+	}
+
+	var inMinute int
+	for i, at := range times {
+		if at.Sub(start) < time.Minute {
+			inMinute++
+		}
+		if i > 0 && at.Sub(times[i-1]) < 5*time.Second {
+			t.Errorf("Request %d was sent %s after the previous request", i, at.Sub(times[i-1]))
+		}
+	}
+	if inMinute > 12 {
+		t.Errorf("The data source was called %d times in the first minute", inMinute)
+	}
+}
+
+func TestRateLimiterCallers(t *testing.T) {
+	clock := &virtualClock{t: time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)}
+
+	src := newPacedSource(clock)
+	r := NewRateLimiter(src, 5*time.Second)
+	r.now = clock.now
+	r.after = clock.after
+
+	// The requests of two enumerations sharing the data source are held before it starts
+	bus1, bus2 := eventbus.NewEventBus(), eventbus.NewEventBus()
+	defer bus1.Stop()
+	defer bus2.Stop()
+	first := context.WithValue(context.Background(), requests.ContextEventBus, bus1)
+	second := context.WithValue(context.Background(), requests.ContextEventBus, bus2)
+	for i := 0; i < 10; i++ {
+		r.Request(first, &requests.DNSRequest{Domain: "example.com"})
+	}
+	for i := 0; i < 2; i++ {
+		r.Request(second, &requests.DNSRequest{Domain: "owasp.org"})
+	}
+	if num := r.Len(); num != 12 {
+		t.Errorf("Expected 12 requests to be held, got %d", num)
+	}
+
+	if err := r.Start(); err != nil {
+		t.Fatalf("Failed to start the data source: %v", err)
+	}
+	defer func() { _ = r.Stop() }()
+
+	var domains []string
+	for i := 0; i < 100; i++ {
+		src.Lock()
+		domains = append([]string(nil), src.domains...)
+		src.Unlock()
+
+		if len(domains) == 12 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(domains) != 12 {
+		t.Fatalf("%d of the 12 requests reached the data source", len(domains))
+	}
+	// The requests of the second caller are not held behind those of the first
+	for i, d := range domains[:4] {
+		if want := []string{"example.com", "owasp.org"}[i%2]; d != want {
+			t.Errorf("Request %d was for %s, expected the callers to take turns: %v", i, d, domains)
+			break
+		}
+	}
+}
+
+func TestRateLimiterPassThrough(t *testing.T) {
+	clock := &virtualClock{t: time.Now()}
+
+	src := newPacedSource(clock)
+	r := NewRateLimiter(src, 0)
+	r.after = func(time.Duration) <-chan time.Time {
+		t.Error("The request was delayed without a rate limit")
+		return time.After(0)
+	}
+	if err := r.Start(); err != nil {
+		t.Fatalf("Failed to start the data source: %v", err)
+	}
+	defer func() { _ = r.Stop() }()
+
+	for i := 0; i < 3; i++ {
+		r.Request(context.Background(), &requests.DNSRequest{Domain: "example.com"})
+	}
+	if num := len(src.received()); num != 3 {
+		t.Errorf("%d of the 3 requests reached the data source without a rate limit", num)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/miekg/dns"
+)
+
+func TestAlterationsTLDSubstitution(t *testing.T) {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", "alt", "alterations.ads"))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.AddDomains("example.com", "example.net", "example.dev")
+	cfg.TLDList = append(cfg.TLDList, "dev", "co")
+	// Only the TLD substitution generates names
+	cfg.FlipWords = false
+	cfg.FlipNumbers = false
+	cfg.AddWords = false
+	cfg.AddNumbers = false
+	cfg.FillSequences = false
+	cfg.EditDistance = 0
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	defer s.luaState.Close()
+
+	bus := eventbus.NewEventBus()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	names := make(map[string]string)
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		names[req.Name] = req.Provenance
+		lock.Unlock()
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	s.resolvedRequest(ctx, &requests.ResolvedRequest{
+		Name:   "foo.example.com",
+		Domain: "example.com",
+		Records: []requests.DNSAnswer{{
+			Name: "foo.example.com",
+			Type: int(dns.TypeA),
+			Data: "192.0.2.1",
+		}},
+	})
+
+	expected := map[string]string{
+		"foo.example.net": "tld_substitution:net",
+		"foo.example.dev": "tld_substitution:dev",
+	}
+	for i := 0; i < 50; i++ {
+		lock.Lock()
+		num := len(names)
+		lock.Unlock()
+		if num >= len(expected) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Allow the out of scope names to be received if they were published
+	time.Sleep(100 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	for name, rule := range expected {
+		if r, found := names[name]; !found || r != rule {
+			t.Errorf("The name %s was not published with the rule %s: %v", name, rule, names)
+		}
+	}
+	if len(names) != len(expected) {
+		t.Errorf("The names outside of the scope were published: %v", names)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+// testWordlist provides the labels discovered by a mock enumeration.
+type testWordlist struct {
+	sync.Mutex
+	labels []string
+}
+
+func (w *testWordlist) DrainLabels() []string {
+	w.Lock()
+	defer w.Unlock()
+
+	labels := w.labels
+	w.labels = nil
+	return labels
+}
+
+func TestBruteForcingDynamicWordlist(t *testing.T) {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", "brute", "bruteforcing.ads"))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("target.com")
+	cfg.AddDomain("other.com")
+	cfg.BruteForcing = true
+	cfg.DynamicWordlist = true
+	cfg.Wordlist = []string{"www", "mail"}
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	defer s.luaState.Close()
+
+	bus := eventbus.NewEventBus()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	names := make(map[string]struct{})
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		names[req.Name] = struct{}{}
+		lock.Unlock()
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	labels := &testWordlist{}
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	ctx = context.WithValue(ctx, requests.ContextWordlist, requests.DynamicWordlist(labels))
+
+	pass := func(domain string, expected ...string) {
+		s.dnsRequest(ctx, &requests.DNSRequest{Domain: domain})
+
+		for i := 0; i < 50; i++ {
+			lock.Lock()
+			num := len(names)
+			lock.Unlock()
+			if num >= len(expected) {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		for _, name := range expected {
+			if _, found := names[name]; !found {
+				t.Errorf("The name %s was not brute forced: %v", name, names)
+			}
+		}
+		if len(names) != len(expected) {
+			t.Errorf("%d names were brute forced instead of %d: %v", len(names), len(expected), names)
+		}
+		names = make(map[string]struct{})
+	}
+
+	pass("target.com", "www.target.com", "mail.target.com")
+	// The label discovered during the enumeration is used by the next pass
+	labels.Lock()
+	labels.labels = []string{"vault", "www"}
+	labels.Unlock()
+	pass("target.com", "vault.target.com", "www.target.com", "mail.target.com")
+	// The drained labels are kept for the later passes
+	pass("other.com", "vault.other.com", "www.other.com", "mail.other.com")
+}
@@ -0,0 +1,188 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	amasshttp "github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+// recordingTransport records the URLs of the requests passed to the fixture transport.
+type recordingTransport struct {
+	sync.Mutex
+	fixtures *fixtureTransport
+	urls     []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.Lock()
+	r.urls = append(r.urls, req.URL.Path+"?"+req.URL.RawQuery)
+	r.Unlock()
+
+	return r.fixtures.RoundTrip(req)
+}
+
+func (r *recordingTransport) requested() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]string(nil), r.urls...)
+}
+
+type censysEvents struct {
+	sync.Mutex
+	names map[string]struct{}
+	asns  []*requests.ASNRequest
+}
+
+func (c *censysEvents) sortedNames() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	var names []string
+	for name := range c.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setupCensys loads the Censys script with the credentials, answers its requests using the recorded
+// responses, and returns the context for the callbacks and the events published by the script.
+func setupCensys(t *testing.T, fixtures map[string]string) (*Script, context.Context, *recordingTransport, *censysEvents, func()) {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", "cert", "censys.ads"))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	rt := &recordingTransport{
+		fixtures: &fixtureTransport{
+			dir:      "censys",
+			key:      "secret",
+			fixtures: fixtures,
+			keyFn: func(req *http.Request) string {
+				if id, pass, ok := req.BasicAuth(); ok && id == "account" {
+					return pass
+				}
+				return ""
+			},
+		},
+	}
+	orig := amasshttp.DefaultClient.Transport
+	amasshttp.DefaultClient.Transport = rt
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	_ = cfg.GetDataSourceConfig("Censys").AddCredentials(&config.Credentials{
+		Name:   "Account",
+		Key:    "account",
+		Secret: "secret",
+	})
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+
+	bus := eventbus.NewEventBus()
+	events := &censysEvents{names: make(map[string]struct{})}
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		events.Lock()
+		events.names[req.Name] = struct{}{}
+		events.Unlock()
+	})
+	bus.Subscribe(requests.NewASNTopic, func(req *requests.ASNRequest) {
+		events.Lock()
+		events.asns = append(events.asns, req)
+		events.Unlock()
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	return s, ctx, rt, events, func() {
+		bus.Stop()
+		s.luaState.Close()
+		amasshttp.DefaultClient.Transport = orig
+	}
+}
+
+// waitForNames returns once the number of names have been published, or the time allowed has passed.
+func waitForNames(events *censysEvents, num int) {
+	for i := 0; i < 50; i++ {
+		if len(events.sortedNames()) >= num {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Allow any remaining events to be delivered
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestCensysCertificates(t *testing.T) {
+	s, ctx, rt, events, cleanup := setupCensys(t, map[string]string{
+		"/api/v2/certificates/search?per_page=100&q=names%3A+owasp.org":                                 "certificates_1.json",
+		"/api/v2/certificates/search?cursor=eyJhZnRlciI6WzJdfQ%3D%3D&per_page=100&q=names%3A+owasp.org": "certificates_2.json",
+	})
+	defer cleanup()
+
+	s.dnsRequest(ctx, &requests.DNSRequest{Domain: "owasp.org"})
+
+	expected := []string{"dev.owasp.org", "mail.owasp.org", "owasp.org", "www.owasp.org"}
+	waitForNames(events, len(expected))
+	// Both pages of the results were requested
+	if got := events.sortedNames(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("The names published were %v, expected %v: requested %v", got, expected, rt.requested())
+	}
+	if urls := rt.requested(); len(urls) != 2 {
+		t.Errorf("Expected 2 pages of results to be requested, got %v", urls)
+	}
+}
+
+func TestCensysHosts(t *testing.T) {
+	s, ctx, rt, events, cleanup := setupCensys(t, map[string]string{
+		"/api/v2/hosts/search?per_page=100&q=autonomous_system.asn%3D19994": "hosts.json",
+		// The recorded page always provides the cursor of another page
+		"/api/v2/hosts/search?cursor=eyJhZnRlciI6WzNdfQ%3D%3D&per_page=100&q=autonomous_system.asn%3D19994": "hosts.json",
+	})
+	defer cleanup()
+
+	s.asnRequest(ctx, &requests.ASNRequest{ASN: 19994})
+
+	expected := []string{"ipv6.owasp.org", "vpn.owasp.org"}
+	waitForNames(events, len(expected))
+	if got := events.sortedNames(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("The names published were %v, expected %v: requested %v", got, expected, rt.requested())
+	}
+	// The pagination stops at the page limit
+	if urls := rt.requested(); len(urls) != 10 {
+		t.Errorf("Expected 10 pages of results to be requested, got %d", len(urls))
+	}
+
+	events.Lock()
+	defer events.Unlock()
+	// Only the BGP prefix is published, and not the addresses of the hosts without one
+	if len(events.asns) != 1 {
+		t.Fatalf("Expected the ASN data to be published once, got %d", len(events.asns))
+	}
+	a := events.asns[0]
+	if a.ASN != 19994 || a.Prefix != "50.57.0.0/16" || a.Address != "50.57.0.0" || a.Description != "RACKSPACE" {
+		t.Errorf("Unexpected ASN data published: %+v", a)
+	}
+	if len(a.Netblocks) != 1 {
+		t.Errorf("Unexpected netblocks published for the ASN: %v", a.Netblocks)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	amasshttp "github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+type observedRange struct {
+	first string
+	last  string
+}
+
+func TestScriptObservationTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		dir      string
+		fixtures map[string]string
+		expected map[string]observedRange
+	}{
+		{
+			name:   "certificate log entries",
+			script: filepath.Join("cert", "crtsh.ads"),
+			dir:    "crtsh",
+			fixtures: map[string]string{
+				"/?q=%25.owasp.org&output=json": "owasp.json",
+			},
+			expected: map[string]observedRange{
+				"owasp.org":      {first: "2021-03-29T12:10:33.457Z", last: "2021-03-29T12:10:33.457Z"},
+				"www.owasp.org":  {first: "2020-01-02T03:04:05Z", last: "2021-03-29T12:10:33.457Z"},
+				"mail.owasp.org": {},
+			},
+		},
+		{
+			name:   "web crawl captures",
+			script: filepath.Join("api", "commoncrawl.ads"),
+			dir:    "commoncrawl",
+			fixtures: map[string]string{
+				"/collinfo.json?": "collinfo.json",
+				"/CC-MAIN-2021-10-index?url=*.owasp.org&output=json&fl=url,timestamp": "index.json",
+			},
+			expected: map[string]observedRange{
+				"www.owasp.org":  {first: "2021-02-25T10:11:12Z", last: "2021-03-01T12:34:56Z"},
+				"wiki.owasp.org": {first: "2021-03-05T00:00:00Z", last: "2021-03-05T00:00:00Z"},
+			},
+		},
+		{
+			name:   "passive DNS records",
+			script: filepath.Join("api", "mnemonic.ads"),
+			dir:    "mnemonic",
+			fixtures: map[string]string{
+				"/pdns/v3/owasp.org?": "owasp.json",
+			},
+			expected: map[string]observedRange{
+				"www.owasp.org": {first: "2020-01-01T00:00:00Z", last: "2021-04-01T00:00:00Z"},
+				"vpn.owasp.org": {first: "2015-01-01T00:00:00Z", last: "2016-01-01T00:00:00Z"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			observed := runObservationScript(t, test.script, test.dir, test.fixtures, len(test.expected))
+
+			if len(observed) != len(test.expected) {
+				t.Errorf("Expected %d names, got %v", len(test.expected), observed)
+			}
+			for name, exp := range test.expected {
+				if got, found := observed[name]; !found || got != exp {
+					t.Errorf("The name %s was observed within %+v, expected %+v", name, got, exp)
+				}
+			}
+		})
+	}
+}
+
+// runObservationScript executes the vertical callback of the script for owasp.org using the fixtures,
+// and returns the time range that the script observed each name within across the published names.
+func runObservationScript(t *testing.T, path, dir string, fixtures map[string]string, num int) map[string]observedRange {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", path))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	orig := amasshttp.DefaultClient.Transport
+	amasshttp.DefaultClient.Transport = &fixtureTransport{dir: dir, fixtures: fixtures}
+	defer func() { amasshttp.DefaultClient.Transport = orig }()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	defer s.luaState.Close()
+
+	bus := eventbus.NewEventBus()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	reqs := make(map[string]*requests.DNSRequest)
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		r, found := reqs[req.Name]
+		if !found {
+			reqs[req.Name] = req
+			return
+		}
+		if !req.FirstSeen.IsZero() && (r.FirstSeen.IsZero() || req.FirstSeen.Before(r.FirstSeen)) {
+			r.FirstSeen = req.FirstSeen
+		}
+		if req.LastSeen.After(r.LastSeen) {
+			r.LastSeen = req.LastSeen
+		}
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	s.dnsRequest(ctx, &requests.DNSRequest{Domain: "owasp.org"})
+
+	for i := 0; i < 50; i++ {
+		lock.Lock()
+		done := len(reqs) >= num
+		lock.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Allow the observations of names already published to arrive
+	time.Sleep(100 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+
+	observed := make(map[string]observedRange, len(reqs))
+	for name, req := range reqs {
+		observed[name] = observedRange{first: format(req.FirstSeen), last: format(req.LastSeen)}
+	}
+	return observed
+}
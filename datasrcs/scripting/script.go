@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/OWASP/Amass/v3/analysis"
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/net/dns"
 	"github.com/OWASP/Amass/v3/requests"
@@ -42,6 +43,12 @@ type Script struct {
 	subre   *regexp.Regexp
 	seconds int
 	cancel  context.CancelFunc
+	// The numeric sequences observed during the enumeration identified by seqUUID
+	seqUUID   string
+	sequences *analysis.NumericSequences
+	// The labels of the names resolved during the enumeration identified by dynUUID
+	dynUUID string
+	dynamic []string
 }
 
 // NewScript returns he object initialized, but not yet started.
@@ -105,10 +112,14 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("datasrc_config", L.NewFunction(s.dataSourceConfig))
 	L.SetGlobal("brute_wordlist", L.NewFunction(s.bruteWordlist))
 	L.SetGlobal("alt_wordlist", L.NewFunction(s.altWordlist))
+	L.SetGlobal("numeric_sequences", L.NewFunction(s.numericSequences))
+	L.SetGlobal("tld_substitutions", L.NewFunction(s.tldSubstitutions))
 	L.SetGlobal("log", L.NewFunction(s.log))
 	L.SetGlobal("find", L.NewFunction(s.find))
 	L.SetGlobal("submatch", L.NewFunction(s.submatch))
 	L.SetGlobal("newname", L.NewFunction(s.newName))
+	L.SetGlobal("archivedname", L.NewFunction(s.archivedName))
+	L.SetGlobal("observedname", L.NewFunction(s.observedName))
 	L.SetGlobal("newaddr", L.NewFunction(s.newAddr))
 	L.SetGlobal("newasn", L.NewFunction(s.newASN))
 	L.SetGlobal("associated", L.NewFunction(s.associated))
@@ -310,7 +321,7 @@ func (s *Script) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 	}
 
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", s.String(), req.Domain))
+		requests.NewLogEvent(s.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	err = L.CallByParam(lua.P{
 		Fn:      s.vertical,
@@ -320,7 +331,7 @@ func (s *Script) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: vertical callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "vertical callback: %v", err))
 	}
 }
 
@@ -354,7 +365,7 @@ func (s *Script) resolvedRequest(ctx context.Context, req *requests.ResolvedRequ
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: resolved callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "resolved callback: %v", err))
 	}
 }
 
@@ -378,7 +389,7 @@ func (s *Script) subdomainRequest(ctx context.Context, req *requests.SubdomainRe
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: subdomain callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "subdomain callback: %v", err))
 	}
 }
 
@@ -402,7 +413,7 @@ func (s *Script) addrRequest(ctx context.Context, req *requests.AddrRequest) {
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: address callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "address callback: %v", err))
 	}
 }
 
@@ -426,7 +437,7 @@ func (s *Script) asnRequest(ctx context.Context, req *requests.ASNRequest) {
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: asn callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "asn callback: %v", err))
 	}
 }
 
@@ -450,6 +461,6 @@ func (s *Script) whoisRequest(ctx context.Context, req *requests.WhoisRequest) {
 
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: horizontal callback: %v", s.String(), err))
+			requests.NewLogEvent(s.String(), requests.LogError, "horizontal callback: %v", err))
 	}
 }
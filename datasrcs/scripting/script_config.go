@@ -4,10 +4,15 @@
 package scripting
 
 import (
+	"strings"
+
+	"github.com/OWASP/Amass/v3/analysis"
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/requests"
 	"github.com/caffix/service"
+	"github.com/caffix/stringset"
 	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/net/publicsuffix"
 )
 
 // Wrapper so that scripts can obtain the configuration for the current enumeration.
@@ -35,6 +40,8 @@ func (s *Script) config(L *lua.LState) int {
 
 	r.RawSetString("event_id", lua.LString(cfg.UUID.String()))
 	r.RawSetString("max_dns_queries", lua.LNumber(cfg.MaxDNSQueries))
+	// The maximum age of the passive DNS records is provided in seconds
+	r.RawSetString("max_record_age", lua.LNumber(cfg.MaxRecordAge.Seconds()))
 
 	scope := L.NewTable()
 	tb := L.NewTable()
@@ -105,6 +112,8 @@ func (s *Script) config(L *lua.LState) int {
 	tb.RawSetString("add_words", lua.LBool(cfg.AddWords))
 	tb.RawSetString("add_numbers", lua.LBool(cfg.AddNumbers))
 	tb.RawSetString("edit_distance", lua.LNumber(cfg.EditDistance))
+	tb.RawSetString("fill_sequences", lua.LBool(cfg.FillSequences))
+	tb.RawSetString("tld_substitution", lua.LBool(cfg.TLDSubstitution))
 	r.RawSetString("alterations", tb)
 
 	L.Push(r)
@@ -155,14 +164,14 @@ func (s *Script) inScope(L *lua.LState) int {
 		return 1
 	}
 
-	cfg, _, err := requests.ContextConfigBus(ctx)
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		L.Push(lua.LFalse)
 		return 1
 	}
 
 	lv := L.Get(2)
-	if sub, ok := lv.(lua.LString); ok && cfg.IsDomainInScope(string(sub)) {
+	if sub, ok := lv.(lua.LString); ok && scp.Evaluate(string(sub), nil).Domain != "" {
 		L.Push(lua.LTrue)
 		return 1
 	}
@@ -186,8 +195,24 @@ func (s *Script) bruteWordlist(L *lua.LState) int {
 	}
 
 	tb := L.NewTable()
+	filter := stringset.New()
+	// The labels of the resolved names are tried before the words of the static wordlist
+	if cfg.DynamicWordlist {
+		if uuid := cfg.UUID.String(); s.dynUUID != uuid {
+			s.dynUUID = uuid
+			s.dynamic = nil
+		}
+
+		s.dynamic = append(s.dynamic, requests.DrainDynamicLabels(ctx)...)
+		for _, label := range s.dynamic {
+			filter.Insert(label)
+			tb.Append(lua.LString(label))
+		}
+	}
 	for _, word := range cfg.Wordlist {
-		tb.Append(lua.LString(word))
+		if !filter.Has(word) {
+			tb.Append(lua.LString(word))
+		}
 	}
 
 	L.Push(tb)
@@ -217,6 +242,84 @@ func (s *Script) altWordlist(L *lua.LState) int {
 	return 1
 }
 
+// Wrapper so that scripts can obtain the names filling the numeric sequences of resolved names.
+func (s *Script) numericSequences(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	cfg, _, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	// The sequences observed are kept for each enumeration
+	if uuid := cfg.UUID.String(); s.sequences == nil || s.seqUUID != uuid {
+		s.seqUUID = uuid
+		s.sequences = analysis.NewNumericSequences(cfg.SequenceMargin, cfg.MaxSequenceNames)
+	}
+
+	tb := L.NewTable()
+	for _, name := range s.sequences.Observe(L.CheckString(2)) {
+		tb.Append(lua.LString(name))
+	}
+
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so that scripts can obtain the names with the top-level domain substituted, mapped to
+// the rule that generated each name.
+func (s *Script) tldSubstitutions(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	cfg, _, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	tb := L.NewTable()
+	for name, tld := range tldSubstitutions(cfg, L.CheckString(2)) {
+		tb.RawSetString(name, lua.LString("tld_substitution:"+tld))
+	}
+
+	L.Push(tb)
+	return 1
+}
+
+// tldSubstitutions returns the name with its public suffix replaced by each TLD of the
+// configuration, mapped to the TLD. Only the names within the root domain names in scope
+// are returned.
+func tldSubstitutions(cfg *config.Config, name string) map[string]string {
+	names := make(map[string]string)
+	name = strings.Trim(strings.ToLower(name), ".")
+
+	suffix, _ := publicsuffix.PublicSuffix(name)
+	if suffix == "" || suffix == name {
+		return names
+	}
+
+	base := strings.TrimSuffix(name, suffix)
+	for _, tld := range cfg.TLDList {
+		if tld == suffix {
+			continue
+		}
+
+		if n := base + tld; cfg.IsDomainInScope(n) {
+			names[n] = tld
+		}
+	}
+	return names
+}
+
 // Wrapper so scripts can set the data source rate limit.
 func (s *Script) setRateLimit(L *lua.LState) int {
 	lv := L.Get(1)
@@ -5,7 +5,6 @@ package scripting
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"strings"
 
@@ -80,7 +79,7 @@ func (s *Script) scrape(L *lua.LState) int {
 		return 1
 	}
 
-	cfg, _, err := requests.ContextConfigBus(ctx)
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		L.Push(lua.LFalse)
 		return 1
@@ -121,13 +120,13 @@ func (s *Script) scrape(L *lua.LState) int {
 	found = false
 	filter := filter.NewStringFilter()
 	for _, name := range s.subre.FindAllString(resp, -1) {
-		if d := cfg.WhichDomain(name); d == "" || d == name {
+		if d := scp.Evaluate(name, nil).Domain; d == "" || d == name {
 			continue
 		}
 
 		found = true
 		if !filter.Duplicate(name) {
-			genNewNameEvent(ctx, s.sys, s, http.CleanName(name))
+			genNewNameEvent(ctx, s.sys, s, http.CleanName(name), s.Description(), "")
 		}
 	}
 
@@ -157,7 +156,8 @@ func (s *Script) req(ctx context.Context, url string, body io.Reader, headers ma
 	resp, err := http.RequestWebPage(ctx, url, nil, headers, auth)
 	if err != nil {
 		if cfg.Verbose {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", s.String(), url, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(s.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		}
 	} else if dsc != nil && dsc.TTL > 0 {
 		_ = s.setCachedResponse(url, resp)
@@ -201,13 +201,14 @@ func (s *Script) crawl(L *lua.LState) int {
 	names, err := http.Crawl(ctx, string(u), cfg.Domains(), int(max), nil)
 	if err != nil {
 		if cfg.Verbose {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", s.String(), u, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(s.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, string(u)))
 		}
 		return 0
 	}
 
 	for _, name := range names {
-		genNewNameEvent(ctx, s.sys, s, http.CleanName(name))
+		genNewNameEvent(ctx, s.sys, s, http.CleanName(name), s.Description(), "")
 	}
 
 	return 0
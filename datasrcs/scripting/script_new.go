@@ -6,6 +6,7 @@ package scripting
 import (
 	"context"
 	"net"
+	"strings"
 	"time"
 
 	amassnet "github.com/OWASP/Amass/v3/net"
@@ -18,24 +19,55 @@ import (
 	lua "github.com/yuin/gopher-lua"
 )
 
-func genNewNameEvent(ctx context.Context, sys systems.System, srv service.Service, name string) {
+func genNewNameEvent(ctx context.Context, sys systems.System, srv service.Service, name, tag, provenance string) {
+	publishName(ctx, srv, &requests.DNSRequest{
+		Name:       name,
+		Tag:        tag,
+		Source:     srv.String(),
+		Provenance: provenance,
+	})
+}
+
+func publishName(ctx context.Context, srv service.Service, req *requests.DNSRequest) {
 	cfg, bus, err := requests.ContextConfigBus(ctx)
 	if err != nil {
 		return
 	}
+	// Names last observed longer ago than the maximum record age are skipped
+	if cfg.RecordTooOld(req.LastSeen) {
+		return
+	}
 
-	if domain := cfg.WhichDomain(name); domain != "" {
-		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
-			Name:   name,
-			Domain: domain,
-			Tag:    srv.Description(),
-			Source: srv.String(),
-		})
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	// Guessed names that have already been attempted are not generated again
+	if requests.SkipAttemptedName(ctx, req.Name, req.Tag, srv.String()) {
+		return
+	}
+
+	if domain := scp.Evaluate(req.Name, nil).Domain; domain != "" {
+		req.Domain = domain
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, req)
 	}
 }
 
-// Wrapper so that scripts can send discovered FQDNs to Amass.
+// Wrapper so that scripts can send discovered FQDNs to Amass. The optional third argument
+// identifies the wordlist entry or alteration rule that generated a guessed name. True is
+// returned when the script should stop generating names.
 func (s *Script) newName(L *lua.LState) int {
+	return s.submitName(L, s.Description())
+}
+
+// Wrapper so that scripts can send FQDNs discovered in historical data, which may no longer exist in
+// DNS, to Amass. The names are published with the archive tag.
+func (s *Script) archivedName(L *lua.LState) int {
+	return s.submitName(L, requests.ARCHIVE)
+}
+
+func (s *Script) submitName(L *lua.LState, tag string) int {
 	ctx, err := extractContext(L.CheckUserData(1))
 	if err != nil {
 		L.Push(lua.LTrue)
@@ -65,11 +97,81 @@ func (s *Script) newName(L *lua.LState) int {
 		return 1
 	}
 
-	genNewNameEvent(ctx, s.sys, s, http.CleanName(name))
+	// The script stops generating names under a subdomain once brute forcing it was truncated
+	if s.SourceType == requests.BRUTE && requests.BruteForceTruncated(ctx, name) {
+		L.Push(lua.LTrue)
+		return 1
+	}
+
+	var provenance string
+	if p, ok := L.Get(3).(lua.LString); ok {
+		provenance = string(p)
+	}
+
+	genNewNameEvent(ctx, s.sys, s, http.CleanName(name), tag, provenance)
 	L.Push(lua.LFalse)
 	return 1
 }
 
+// Wrapper so that scripts can send FQDNs discovered in passive DNS data, certificate logs or web
+// crawls to Amass, along with the first and last times the name was observed. The times are provided
+// as seconds since the Unix epoch, or as strings in one of the observedLayouts.
+func (s *Script) observedName(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		return 0
+	}
+
+	if err = checkContextExpired(ctx); err != nil {
+		return 0
+	}
+
+	n, ok := L.Get(2).(lua.LString)
+	if !ok {
+		return 0
+	}
+
+	name := s.subre.FindString(string(n))
+	if name == "" {
+		return 0
+	}
+
+	publishName(ctx, s, &requests.DNSRequest{
+		Name:      http.CleanName(name),
+		Tag:       s.Description(),
+		Source:    s.String(),
+		FirstSeen: observedTime(L.Get(3)),
+		LastSeen:  observedTime(L.Get(4)),
+	})
+	return 0
+}
+
+// The layouts of the observation times provided as strings, which are in UTC unless the layout
+// includes the time zone. The data sources commonly omit the time zone of their timestamps.
+var observedLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"20060102150405",
+}
+
+func observedTime(lv lua.LValue) time.Time {
+	switch v := lv.(type) {
+	case lua.LNumber:
+		if v > 0 {
+			return time.Unix(int64(v), 0).UTC()
+		}
+	case lua.LString:
+		for _, layout := range observedLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(string(v))); err == nil {
+				return t.UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
 // Wrapper so that scripts can send discovered IP addresses to Amass.
 func (s *Script) newAddr(L *lua.LState) int {
 	ctx, err := extractContext(L.CheckUserData(1))
@@ -77,7 +179,12 @@ func (s *Script) newAddr(L *lua.LState) int {
 		return 0
 	}
 
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return 0
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return 0
 	}
@@ -111,7 +218,7 @@ func (s *Script) newAddr(L *lua.LState) int {
 	}
 
 	name := string(sub)
-	if domain := cfg.WhichDomain(name); domain != "" {
+	if domain := scp.Evaluate(name, nil).Domain; domain != "" {
 		bus.Publish(requests.NewAddrTopic, eventbus.PriorityHigh, &requests.AddrRequest{
 			Address: addr,
 			Domain:  domain,
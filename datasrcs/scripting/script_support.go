@@ -78,7 +78,7 @@ func (s *Script) log(L *lua.LState) int {
 	}
 
 	if msg, ok := lv.(lua.LString); ok {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, s.String()+": "+string(msg))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent(s.String(), requests.LogInfo, "%s", msg))
 	}
 	return 0
 }
@@ -0,0 +1,167 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	amasshttp "github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+)
+
+type testSystem struct {
+	cfg *config.Config
+}
+
+func (t *testSystem) Config() *config.Config                   { return t.cfg }
+func (t *testSystem) Pool() resolve.Resolver                   { return nil }
+func (t *testSystem) Cache() *requests.ASNCache                { return nil }
+func (t *testSystem) AddSource(srv service.Service) error      { return nil }
+func (t *testSystem) AddAndStart(srv service.Service) error    { return nil }
+func (t *testSystem) DataSources() []service.Service           { return nil }
+func (t *testSystem) SetDataSources(sources []service.Service) {}
+func (t *testSystem) GraphDatabases() []*netmap.Graph          { return nil }
+func (t *testSystem) GetMemoryUsage() uint64                   { return 0 }
+func (t *testSystem) SelfTest(ctx context.Context) *systems.SelfTestReport {
+	return nil
+}
+func (t *testSystem) Shutdown() error { return nil }
+
+// fixtureTransport answers the requests with the recorded responses of the API, selected by the URL.
+// The responses are read from the dir within the testdata directory. The key is expected in the
+// APIKEY header, unless the transport provides another way to read the key from the request.
+type fixtureTransport struct {
+	dir      string
+	key      string
+	fixtures map[string]string
+	keyFn    func(req *http.Request) string
+}
+
+func (f *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := http.StatusNotFound
+	body := []byte(`{"message":"not found"}`)
+
+	key := req.Header.Get("APIKEY")
+	if f.keyFn != nil {
+		key = f.keyFn(req)
+	}
+
+	if file, found := f.fixtures[req.URL.Path+"?"+req.URL.RawQuery]; found && key != f.key {
+		status = http.StatusForbidden
+		body = []byte(`{"message":"You are not allowed to access this resource"}`)
+	} else if found {
+		if data, err := ioutil.ReadFile(filepath.Join("testdata", f.dir, file)); err == nil {
+			status = http.StatusOK
+			body = data
+		}
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestSecurityTrailsHistoricalNames(t *testing.T) {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", "api", "securitytrails.ads"))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	orig := amasshttp.DefaultClient.Transport
+	amasshttp.DefaultClient.Transport = &fixtureTransport{
+		dir: "securitytrails",
+		key: "secret",
+		fixtures: map[string]string{
+			"/v1/domain/owasp.org/subdomains?children_only=false":                       "subdomains.json",
+			"/v1/domain/owasp.org/subdomains?children_only=false&include_inactive=true": "subdomains_inactive.json",
+			"/v1/history/owasp.org/dns/a?":                                              "history_a.json",
+		},
+	}
+	defer func() { amasshttp.DefaultClient.Transport = orig }()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	_ = cfg.GetDataSourceConfig("SecurityTrails").AddCredentials(&config.Credentials{
+		Name: "Account",
+		Key:  "secret",
+	})
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	defer s.luaState.Close()
+
+	bus := eventbus.NewEventBus()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	names := make(map[string]string)
+	addrs := make(map[string]string)
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		names[req.Name] = req.Tag
+		lock.Unlock()
+	})
+	bus.Subscribe(requests.NewAddrTopic, func(req *requests.AddrRequest) {
+		lock.Lock()
+		addrs[req.Address] = req.Domain
+		lock.Unlock()
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	s.dnsRequest(ctx, &requests.DNSRequest{Domain: "owasp.org"})
+
+	expected := map[string]string{
+		"www.owasp.org":     requests.API,
+		"mail.owasp.org":    requests.API,
+		"legacy.owasp.org":  requests.ARCHIVE,
+		"old.vpn.owasp.org": requests.ARCHIVE,
+	}
+	for i := 0; i < 50; i++ {
+		lock.Lock()
+		done := len(names) == len(expected) && len(addrs) == 3
+		lock.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(names) != len(expected) {
+		t.Errorf("Expected %d names, got %v", len(expected), names)
+	}
+	for name, tag := range expected {
+		if got, found := names[name]; !found || got != tag {
+			t.Errorf("The name %s was published with the tag %q, expected %q", name, got, tag)
+		}
+	}
+	for _, addr := range []string{"104.22.27.77", "172.67.10.39", "50.57.120.84"} {
+		if domain, found := addrs[addr]; !found || domain != "owasp.org" {
+			t.Errorf("The historical address %s was not published for the domain", addr)
+		}
+	}
+}
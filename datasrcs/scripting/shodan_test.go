@@ -0,0 +1,106 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package scripting
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	amasshttp "github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+func TestShodanASNNames(t *testing.T) {
+	script, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "scripts", "api", "shodan.ads"))
+	if err != nil {
+		t.Fatalf("Failed to read the script: %v", err)
+	}
+
+	orig := amasshttp.DefaultClient.Transport
+	amasshttp.DefaultClient.Transport = &fixtureTransport{
+		dir: "shodan",
+		key: "secret",
+		fixtures: map[string]string{
+			"/shodan/host/search?key=secret&query=asn:AS13335": "search.json",
+			"/shodan/host/50.57.120.84?key=secret":             "host.json",
+		},
+		keyFn: func(req *http.Request) string { return req.URL.Query().Get("key") },
+	}
+	defer func() { amasshttp.DefaultClient.Transport = orig }()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	_ = cfg.GetDataSourceConfig("Shodan").AddCredentials(&config.Credentials{
+		Name: "Account",
+		Key:  "secret",
+	})
+
+	s := NewScript(string(script), &testSystem{cfg: cfg})
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	defer s.luaState.Close()
+
+	bus := eventbus.NewEventBus()
+	defer bus.Stop()
+
+	var lock sync.Mutex
+	names := make(map[string]struct{})
+	var asns []*requests.ASNRequest
+	bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) {
+		lock.Lock()
+		names[req.Name] = struct{}{}
+		lock.Unlock()
+	})
+	bus.Subscribe(requests.NewASNTopic, func(req *requests.ASNRequest) {
+		lock.Lock()
+		asns = append(asns, req)
+		lock.Unlock()
+	})
+	requests.WaitForSubscriptions(context.Background(), bus)
+
+	ctx := context.WithValue(context.Background(), requests.ContextConfig, cfg)
+	ctx = context.WithValue(ctx, requests.ContextEventBus, bus)
+	// The request for the ASN and the request providing only the address are both answered
+	s.asnRequest(ctx, &requests.ASNRequest{ASN: 13335})
+	s.asnRequest(ctx, &requests.ASNRequest{Address: "50.57.120.84"})
+
+	expected := []string{"mail.owasp.org", "owasp.org", "vpn.owasp.org", "www.owasp.org"}
+	for i := 0; i < 50; i++ {
+		lock.Lock()
+		done := len(names) >= len(expected)
+		lock.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Allow any ASN events published to be delivered
+	time.Sleep(100 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	var got []string
+	for name := range names {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("The names published were %v, expected %v", got, expected)
+	}
+	// The endpoints do not provide the announced prefixes, so no ASN data is published
+	if len(asns) > 0 {
+		t.Errorf("The ASN data was published without the announced prefixes: %+v", asns[0])
+	}
+}
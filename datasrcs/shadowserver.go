@@ -143,32 +143,28 @@ func (s *ShadowServer) origin(ctx context.Context, addr string) *requests.ASNReq
 	resp, err := s.sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.RetryPolicy)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query error: %v", s.String(), name, err),
-		)
+			requests.NewLogEvent(s.String(), requests.LogError, "%s: DNS TXT record query error: %v", name, err))
 		return nil
 	}
 
 	ans := resolve.ExtractAnswers(resp)
 	if len(ans) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query returned zero answers", s.String(), name),
-		)
+			requests.NewLogEvent(s.String(), requests.LogError, "%s: DNS TXT record query returned zero answers", name))
 		return nil
 	}
 
 	fields := strings.Split(strings.Trim(ans[0].Data, "\""), " | ")
 	if len(fields) < 4 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response", s.String(), name),
-		)
+			requests.NewLogEvent(s.String(), requests.LogError, "%s: Failed to parse the origin response", name))
 		return nil
 	}
 
 	asn, err := strconv.Atoi(strings.TrimSpace(fields[0]))
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response: %v", s.String(), name, err),
-		)
+			requests.NewLogEvent(s.String(), requests.LogError, "%s: Failed to parse the origin response: %v", name, err))
 		return nil
 	}
 
@@ -202,7 +198,7 @@ func (s *ShadowServer) netblocks(ctx context.Context, asn int) stringset.Set {
 		resp, err := s.sys.Pool().Query(ctx, msg, resolve.PriorityCritical, resolve.RetryPolicy)
 		if err != nil {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: %s: %v", s.String(), ShadowServerWhoisURL, err))
+				requests.NewLogEvent(s.String(), requests.LogError, "%s: %v", ShadowServerWhoisURL, err).With(requests.LogFieldURL, ShadowServerWhoisURL))
 			return netblocks
 		}
 
@@ -214,8 +210,7 @@ func (s *ShadowServer) netblocks(ctx context.Context, asn int) stringset.Set {
 		ip := ans[0].Data
 		if ip == "" {
 			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-				fmt.Sprintf("%s: Failed to resolve %s", s.String(), ShadowServerWhoisURL),
-			)
+				requests.NewLogEvent(s.String(), requests.LogError, "Failed to resolve %s", ShadowServerWhoisURL))
 			return netblocks
 		}
 		s.addr = ip
@@ -226,7 +221,8 @@ func (s *ShadowServer) netblocks(ctx context.Context, asn int) stringset.Set {
 
 	conn, err := amassnet.DialContext(ctx, "tcp", s.addr+":43")
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", s.String(), err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(s.String(), requests.LogError, "%v", err))
 		return netblocks
 	}
 	defer conn.Close()
@@ -244,7 +240,7 @@ func (s *ShadowServer) netblocks(ctx context.Context, asn int) stringset.Set {
 
 	if len(netblocks) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: Failed to acquire netblocks for ASN %d", s.String(), asn))
+			requests.NewLogEvent(s.String(), requests.LogError, "Failed to acquire netblocks for ASN %d", asn))
 	}
 	return netblocks
 }
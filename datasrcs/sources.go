@@ -6,6 +6,7 @@ package datasrcs
 import (
 	"context"
 	"sort"
+	"time"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/datasrcs/scripting"
@@ -20,6 +21,9 @@ import (
 func GetAllSources(sys systems.System) []service.Service {
 	srvs := []service.Service{
 		NewAlienVault(sys),
+		NewBruteMasks(sys),
+		NewCertStream(sys),
+		NewChaos(sys),
 		NewCloudflare(sys),
 		NewDNSDB(sys),
 		NewDNSDumpster(sys),
@@ -42,6 +46,20 @@ func GetAllSources(sys systems.System) []service.Service {
 		}
 	}
 
+	// The data sources with a rate limit provided by the user are paced by it
+	for i, srv := range srvs {
+		if limit := sys.Config().DataSourceRateLimit(srv.String()); limit > 0 {
+			srvs[i] = NewRateLimiter(srv, limit)
+		}
+	}
+
+	// The requests for the data sources that keep failing are dropped for a cool-down period
+	if cfg := sys.Config(); cfg.SourceFailureThreshold > 0 {
+		for i, srv := range srvs {
+			srvs[i] = NewCircuitBreaker(srv, cfg.SourceFailureThreshold, cfg.SourceCooldown)
+		}
+	}
+
 	sort.Slice(srvs, func(i, j int) bool {
 		return srvs[i].String() < srvs[j].String()
 	})
@@ -78,12 +96,17 @@ func SelectedDataSources(cfg *config.Config, avail []service.Service) []service.
 }
 
 func genNewNameEvent(ctx context.Context, sys systems.System, srv service.Service, name string) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
 
-	if domain := cfg.WhichDomain(name); domain != "" {
+	if domain := scp.Evaluate(name, nil).Domain; domain != "" {
 		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
 			Name:   name,
 			Domain: domain,
@@ -93,6 +116,31 @@ func genNewNameEvent(ctx context.Context, sys systems.System, srv service.Servic
 	}
 }
 
+// genObservedNameEvent publishes the name discovered in passive DNS data along with the time range
+// it was observed within, unless it was last observed longer ago than the maximum record age.
+func genObservedNameEvent(ctx context.Context, srv service.Service, name string, first, last time.Time) {
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil || cfg.RecordTooOld(last) {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if domain := scp.Evaluate(name, nil).Domain; domain != "" {
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+			Name:      name,
+			Domain:    domain,
+			Tag:       srv.Description(),
+			Source:    srv.String(),
+			FirstSeen: first,
+			LastSeen:  last,
+		})
+	}
+}
+
 func numRateLimitChecks(srv service.Service, num int) {
 	for i := 0; i < num; i++ {
 		srv.CheckRateLimit()
@@ -5,7 +5,6 @@ package datasrcs
 
 import (
 	"context"
-	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -100,8 +99,7 @@ func (t *TeamCymru) origin(ctx context.Context, addr string) *requests.ASNReques
 		name = amassdns.IPv6NibbleFormat(ip.String()) + ".origin6.asn.cymru.com"
 	} else {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the IP address", t.String(), addr),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: Failed to parse the IP address", addr))
 		return nil
 	}
 
@@ -109,32 +107,28 @@ func (t *TeamCymru) origin(ctx context.Context, addr string) *requests.ASNReques
 	resp, err := t.sys.Pool().Query(ctx, msg, resolve.PriorityCritical, resolve.RetryPolicy)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query error: %v", t.String(), name, err),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: DNS TXT record query error: %v", name, err))
 		return nil
 	}
 
 	ans := resolve.ExtractAnswers(resp)
 	if len(ans) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query returned zero answers", t.String(), name),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: DNS TXT record query returned zero answers", name))
 		return nil
 	}
 
 	fields := strings.Split(ans[0].Data, " | ")
 	if len(fields) < 5 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response", t.String(), name),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: Failed to parse the origin response", name))
 		return nil
 	}
 
 	asn, err := strconv.Atoi(strings.TrimSpace(fields[0]))
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response: %v", t.String(), name, err),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: Failed to parse the origin response: %v", name, err))
 		return nil
 	}
 
@@ -168,32 +162,28 @@ func (t *TeamCymru) asnLookup(ctx context.Context, asn int) *requests.ASNRequest
 	resp, err := t.sys.Pool().Query(ctx, msg, resolve.PriorityCritical, resolve.RetryPolicy)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query error: %v", t.String(), name, err),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: DNS TXT record query error: %v", name, err))
 		return nil
 	}
 
 	ans := resolve.ExtractAnswers(resp)
 	if len(ans) == 0 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: DNS TXT record query returned zero answers", t.String(), name),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: DNS TXT record query returned zero answers", name))
 		return nil
 	}
 
 	fields := strings.Split(ans[0].Data, " | ")
 	if len(fields) < 5 {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response", t.String(), name),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: Failed to parse the origin response", name))
 		return nil
 	}
 
 	pASN, err := strconv.Atoi(strings.TrimSpace(fields[0]))
 	if err != nil || asn != pASN {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("%s: %s: Failed to parse the origin response: %v", t.String(), name, err),
-		)
+			requests.NewLogEvent(t.String(), requests.LogError, "%s: Failed to parse the origin response: %v", name, err))
 		return nil
 	}
 
@@ -101,7 +101,7 @@ func (t *Twitter) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 
 	numRateLimitChecks(t, 2)
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", t.String(), req.Domain))
+		requests.NewLogEvent(t.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	searchParams := &twitter.SearchTweetParams{
 		Query: req.Domain,
@@ -109,7 +109,8 @@ func (t *Twitter) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 	}
 	search, _, err := t.client.Search.Tweets(searchParams)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", t.String(), err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(t.String(), requests.LogError, "%v", err))
 		return
 	}
 
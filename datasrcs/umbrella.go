@@ -95,25 +95,31 @@ func (u *Umbrella) OnRequest(ctx context.Context, args service.Args) {
 }
 
 func (u *Umbrella) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
 	if u.creds == nil || u.creds.Key == "" {
 		return
 	}
-	if !cfg.IsDomainInScope(req.Domain) {
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", u.String(), req.Domain))
+		requests.NewLogEvent(u.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	headers := u.restHeaders()
 	url := u.restDNSURL(req.Domain)
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 	// Extract the subdomain names from the REST API results
@@ -147,7 +153,8 @@ func (u *Umbrella) addrRequest(ctx context.Context, req *requests.AddrRequest) {
 	url := u.restAddrURL(req.Address)
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 	// Extract the subdomain names from the REST API results
@@ -192,7 +199,8 @@ func (u *Umbrella) executeASNAddrQuery(ctx context.Context, req *requests.ASNReq
 	url := u.restAddrToASNURL(req.Address)
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 	// Extract the AS information from the REST API results
@@ -255,7 +263,8 @@ func (u *Umbrella) executeASNQuery(ctx context.Context, req *requests.ASNRequest
 	url := u.restASNToCIDRsURL(req.ASN)
 	page, err := http.RequestWebPage(ctx, url, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 	// Extract the netblock information from the REST API results
@@ -361,13 +370,15 @@ func (u *Umbrella) queryWhois(ctx context.Context, domain string) *whoisRecord {
 	u.CheckRateLimit()
 	record, err := http.RequestWebPage(ctx, whoisURL, nil, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), whoisURL, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", whoisURL, err).With(requests.LogFieldURL, whoisURL))
 		return nil
 	}
 
 	err = json.Unmarshal([]byte(record), &whois)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), whoisURL, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", whoisURL, err).With(requests.LogFieldURL, whoisURL))
 		return nil
 	}
 	return &whois
@@ -389,13 +400,15 @@ func (u *Umbrella) queryReverseWhois(ctx context.Context, apiURL string) []strin
 		fullAPIURL := fmt.Sprintf("%s&offset=%d", apiURL, count)
 		record, err := http.RequestWebPage(ctx, fullAPIURL, nil, headers, nil)
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), apiURL, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", apiURL, err).With(requests.LogFieldURL, apiURL))
 			return domains.Slice()
 		}
 
 		err = json.Unmarshal([]byte(record), &whois)
 		if err != nil {
-			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), apiURL, err))
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", apiURL, err).With(requests.LogFieldURL, apiURL))
 			return domains.Slice()
 		}
 
@@ -417,25 +430,30 @@ func (u *Umbrella) queryReverseWhois(ctx context.Context, apiURL string) []strin
 }
 
 func (u *Umbrella) validateScope(ctx context.Context, input string) bool {
-	cfg, _, err := requests.ContextConfigBus(ctx)
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return false
 	}
-	if input != "" && cfg.IsDomainInScope(input) {
+	if input != "" && scp.Evaluate(input, nil).Domain != "" {
 		return true
 	}
 	return false
 }
 
 func (u *Umbrella) whoisRequest(ctx context.Context, req *requests.WhoisRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
 	if u.creds == nil || u.creds.Key == "" {
 		return
 	}
-	if !cfg.IsDomainInScope(req.Domain) {
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
@@ -449,7 +467,7 @@ func (u *Umbrella) whoisRequest(ctx context.Context, req *requests.WhoisRequest)
 	if len(emails) > 0 {
 		emailURL := u.reverseWhoisByEmailURL(emails...)
 		for _, d := range u.queryReverseWhois(ctx, emailURL) {
-			if !cfg.IsDomainInScope(d) {
+			if scp.Evaluate(d, nil).Domain == "" {
 				domains.Insert(d)
 			}
 		}
@@ -464,7 +482,7 @@ func (u *Umbrella) whoisRequest(ctx context.Context, req *requests.WhoisRequest)
 	if len(nameservers) > 0 {
 		nsURL := u.reverseWhoisByNSURL(nameservers...)
 		for _, d := range u.queryReverseWhois(ctx, nsURL) {
-			if !cfg.IsDomainInScope(d) {
+			if scp.Evaluate(d, nil).Domain == "" {
 				domains.Insert(d)
 			}
 		}
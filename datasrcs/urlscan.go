@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/net/http"
@@ -77,18 +78,22 @@ func (u *URLScan) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 
 	numRateLimitChecks(u, 1)
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", u.String(), req.Domain))
+		requests.NewLogEvent(u.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	url := u.searchURL(req.Domain)
 	page, err := http.RequestWebPage(ctx, url, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return
 	}
 	// Extract the subdomain names from the REST API results
 	var results struct {
 		Results []struct {
-			ID string `json:"_id"`
+			ID   string `json:"_id"`
+			Task struct {
+				Time time.Time `json:"time"`
+			} `json:"task"`
 		} `json:"results"`
 		Total int `json:"total"`
 	}
@@ -96,30 +101,38 @@ func (u *URLScan) dnsRequest(ctx context.Context, req *requests.DNSRequest) {
 		return
 	}
 
-	var ids []string
+	// The time of each scan is kept, so the names are tagged with the most recent scan they were found in
+	scans := make(map[string]time.Time)
 	if results.Total > 0 {
 		for _, result := range results.Results {
-			ids = append(ids, result.ID)
+			// Skip the scans performed before the maximum record age
+			if !cfg.RecordTooOld(result.Task.Time) {
+				scans[result.ID] = result.Task.Time
+			}
 		}
 	} else {
 		if id := u.attemptSubmission(ctx, req.Domain); id != "" {
-			ids = []string{id}
+			scans[id] = time.Now()
 		}
 	}
 
-	subs := stringset.New()
-	for _, id := range ids {
+	subs := make(map[string]time.Time)
+	for id, scanned := range scans {
 		set, err := u.getSubsFromResult(ctx, id)
 		if err != nil {
 			break
 		}
 
-		subs.Union(set)
+		for name := range set {
+			if last, found := subs[name]; !found || scanned.After(last) {
+				subs[name] = scanned
+			}
+		}
 	}
 
-	for name := range subs {
+	for name, last := range subs {
 		if re.MatchString(name) {
-			genNewNameEvent(ctx, u.sys, u, name)
+			genObservedNameEvent(ctx, u, name, time.Time{}, last)
 		}
 	}
 }
@@ -136,7 +149,8 @@ func (u *URLScan) getSubsFromResult(ctx context.Context, id string) (stringset.S
 	url := u.resultURL(id)
 	page, err := http.RequestWebPage(ctx, url, nil, nil, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return subs, errors.New("HTTP request failed")
 	}
 	// Extract the subdomain names from the REST API results
@@ -172,7 +186,8 @@ func (u *URLScan) attemptSubmission(ctx context.Context, domain string) string {
 	body := strings.NewReader(u.submitBody(domain))
 	page, err := http.RequestWebPage(ctx, url, body, headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", u.String(), url, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(u.String(), requests.LogError, "%s: %v", url, err).With(requests.LogFieldURL, url))
 		return ""
 	}
 
@@ -109,19 +109,24 @@ func (w *WhoisXML) OnRequest(ctx context.Context, args service.Args) {
 }
 
 func (w *WhoisXML) whoisRequest(ctx context.Context, req *requests.WhoisRequest) {
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	scp, err := requests.ScopeFromContext(ctx)
 	if err != nil {
 		return
 	}
 	if w.creds == nil || w.creds.Key == "" {
 		return
 	}
-	if !cfg.IsDomainInScope(req.Domain) {
+	if scp.Evaluate(req.Domain, nil).Domain == "" {
 		return
 	}
 
 	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-		fmt.Sprintf("Querying %s for %s subdomains", w.String(), req.Domain))
+		requests.NewLogEvent(w.String(), requests.LogInfo, "Querying for %s subdomains", req.Domain).With(requests.LogFieldDomain, req.Domain))
 
 	numRateLimitChecks(w, 9)
 	u := w.getReverseWhoisURL(req.Domain)
@@ -136,7 +141,8 @@ func (w *WhoisXML) whoisRequest(ctx context.Context, req *requests.WhoisRequest)
 
 	page, err := http.RequestWebPage(ctx, u, bytes.NewReader(jr), headers, nil)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %s: %v", w.String(), u, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(w.String(), requests.LogError, "%s: %v", u, err).With(requests.LogFieldURL, u))
 		return
 	}
 
@@ -145,7 +151,7 @@ func (w *WhoisXML) whoisRequest(ctx context.Context, req *requests.WhoisRequest)
 	err = json.NewDecoder(strings.NewReader(page)).Decode(&q)
 	if err != nil {
 		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("Failed to decode json in WhoisXML.\nErr:%s", err))
+			requests.NewLogEvent(w.String(), requests.LogError, "Failed to decode the JSON: %v", err))
 		return
 	}
 
@@ -5,17 +5,16 @@ package enum
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/OWASP/Amass/v3/filter"
 	"github.com/OWASP/Amass/v3/net/http"
 	"github.com/OWASP/Amass/v3/requests"
 	"github.com/caffix/eventbus"
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/caffix/resolve"
-	"github.com/miekg/dns"
 )
 
 // activeTask is the task that handles all requests related to active enumeration within the pipeline.
@@ -23,6 +22,10 @@ type activeTask struct {
 	enum      *Enumeration
 	queue     queue.Queue
 	tokenPool chan struct{}
+	// Limits the JavaScript assets and source maps downloaded from each host
+	scripts *http.ScriptBudget
+	// The zones that have had their NSEC3 hash chain walked
+	nsec3Zones *filter.StringFilter
 }
 
 type taskArgs struct {
@@ -43,9 +46,11 @@ func newActiveTask(e *Enumeration, max int) *activeTask {
 	}
 
 	a := &activeTask{
-		enum:      e,
-		queue:     queue.NewQueue(),
-		tokenPool: tokenPool,
+		enum:       e,
+		queue:      queue.NewQueue(),
+		tokenPool:  tokenPool,
+		scripts:    http.NewScriptBudget(http.DefaultScriptHostBudget),
+		nsec3Zones: filter.NewStringFilter(),
 	}
 
 	go a.processQueue()
@@ -65,6 +70,10 @@ func (a *activeTask) Process(ctx context.Context, data pipeline.Data, tp pipelin
 	}
 
 	var ok bool
+	if !a.enum.phaseBudgets.Allow(ActiveProbes) {
+		return data, nil
+	}
+
 	switch data.(type) {
 	case *requests.DNSRequest:
 		ok = true
@@ -146,24 +155,29 @@ func (a *activeTask) crawlName(ctx context.Context, req *requests.DNSRequest, tp
 			protocol = "https://"
 		}
 		u := protocol + req.Name + ":" + strconv.Itoa(port)
-		names, err := http.Crawl(ctx, u, cfg.Domains(), 50, a.enum.crawlFilter)
-		if err != nil {
-			if cfg.Verbose {
-				cfg.Log.Printf("Active Crawl: %v", err)
-			}
-			continue
+		names, scripts, err := http.CrawlWithScripts(ctx, u, cfg.Domains(), 50, a.enum.crawlFilter)
+		if err != nil && cfg.Verbose {
+			cfg.Log.Printf("Active Crawl: %v", err)
 		}
 
-		for _, name := range names {
-			if n := strings.TrimSpace(name); n != "" {
-				if domain := cfg.WhichDomain(n); domain != "" {
-					pipeline.SendData(ctx, "new", &requests.DNSRequest{
-						Name:   n,
-						Domain: domain,
-						Tag:    requests.CRAWL,
-						Source: "Active Crawl",
-					}, tp)
-				}
+		a.sendCrawlNames(ctx, names, "Active Crawl", tp)
+		if len(scripts) > 0 {
+			names = http.ScanScripts(ctx, scripts, cfg.Domains(), a.scripts, a.enum.crawlFilter)
+			a.sendCrawlNames(ctx, names, "JS Analysis", tp)
+		}
+	}
+}
+
+func (a *activeTask) sendCrawlNames(ctx context.Context, names []string, source string, tp pipeline.TaskParams) {
+	for _, name := range names {
+		if n := strings.TrimSpace(name); n != "" {
+			if domain := a.enum.Scope.Evaluate(n, nil).Domain; domain != "" {
+				pipeline.SendData(ctx, "new", &requests.DNSRequest{
+					Name:   n,
+					Domain: domain,
+					Tag:    requests.CRAWL,
+					Source: source,
+				}, tp)
 			}
 		}
 	}
@@ -176,15 +190,17 @@ func (a *activeTask) certEnumeration(ctx context.Context, req *requests.AddrRequ
 		return
 	}
 
-	for _, name := range http.PullCertificateNames(ctx, req.Address, a.enum.Config.Ports) {
+	names := a.enum.certServerNames(req)
+	for _, cn := range http.PullCertificateNamesSNI(ctx, req.Address, a.enum.Config.Ports, names) {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
 
-		if n := strings.TrimSpace(name); n != "" {
-			if domain := a.enum.Config.WhichDomain(n); domain != "" {
+		if n := strings.TrimSpace(cn.Name); n != "" {
+			if domain := a.enum.Scope.Evaluate(n, nil).Domain; domain != "" {
+				a.enum.certNameFound(cn)
 				pipeline.SendData(ctx, "new", &requests.DNSRequest{
 					Name:   n,
 					Domain: domain,
@@ -208,16 +224,17 @@ func (a *activeTask) zoneTransfer(ctx context.Context, req *requests.ZoneXFRRequ
 		return
 	}
 
-	addr, err := a.nameserverAddr(ctx, req.Server)
+	addr, err := nameserverAddr(ctx, a.enum.pool(), req.Server)
 	if addr == "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("DNS: Zone XFR failed: %v", err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogError,
+			"Zone XFR failed: %v", err).With(requests.LogFieldDomain, req.Domain).With(requests.LogFieldResolver, req.Server))
 		return
 	}
 
 	reqs, err := ZoneTransfer(req.Name, req.Domain, addr)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("DNS: Zone XFR failed: %s: %v", req.Server, err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogError,
+			"Zone XFR failed: %s: %v", req.Server, err).With(requests.LogFieldDomain, req.Domain).With(requests.LogFieldResolver, addr))
 		return
 	}
 
@@ -229,14 +246,15 @@ func (a *activeTask) zoneTransfer(ctx context.Context, req *requests.ZoneXFRRequ
 func (a *activeTask) zoneWalk(ctx context.Context, req *requests.ZoneXFRRequest, tp pipeline.TaskParams) {
 	defer func() { a.tokenPool <- struct{}{} }()
 
-	cfg, bus, err := requests.ContextConfigBus(ctx)
+	_, bus, err := requests.ContextConfigBus(ctx)
 	if err != nil {
 		return
 	}
 
-	addr, err := a.nameserverAddr(ctx, req.Server)
+	addr, err := nameserverAddr(ctx, a.enum.pool(), req.Server)
 	if addr == "" {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("DNS: Zone Walk failed: %v", err))
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogError,
+			"Zone Walk failed: %v", err).With(requests.LogFieldDomain, req.Domain).With(requests.LogFieldResolver, req.Server))
 		return
 	}
 
@@ -248,49 +266,41 @@ func (a *activeTask) zoneWalk(ctx context.Context, req *requests.ZoneXFRRequest,
 
 	names, _, err := resolve.NsecTraversal(ctx, r, req.Name, resolve.PriorityHigh)
 	if err != nil {
-		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
-			fmt.Sprintf("DNS: Zone Walk failed: %s: %v", req.Name, err))
-		return
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogError,
+			"Zone Walk failed: %s: %v", req.Name, err).With(requests.LogFieldDomain, req.Domain).With(requests.LogFieldResolver, addr))
 	}
 
 	for _, nsec := range names {
 		name := resolve.RemoveLastDot(nsec.NextDomain)
 
-		if domain := cfg.WhichDomain(name); domain != "" {
-			pipeline.SendData(ctx, "new", &requests.DNSRequest{
+		if domain := a.enum.Scope.Evaluate(name, nil).Domain; domain != "" {
+			bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
 				Name:   name,
 				Domain: domain,
 				Tag:    requests.DNS,
 				Source: "NSEC Walk",
-			}, tp)
+			})
 		}
 	}
+	// Zones signed using NSEC3 do not provide an NSEC chain, so their NSEC3 hash chain is walked once
+	if len(names) == 0 && !a.nsec3Zones.Duplicate(req.Name) {
+		a.nsec3Walk(ctx, r, req.Name)
+	}
 }
 
-func (a *activeTask) nameserverAddr(ctx context.Context, server string) (string, error) {
-	var err error
-	var found bool
-	var qtype uint16
-	var resp *dns.Msg
-
-	for _, t := range []uint16{dns.TypeA, dns.TypeAAAA} {
-		msg := resolve.QueryMsg(server, t)
-
-		resp, err = a.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.RetryPolicy)
-		if err == nil && resp != nil && len(resp.Answer) > 0 {
-			qtype = t
-			found = true
-			break
-		}
-	}
-	if !found {
-		return "", fmt.Errorf("DNS server %s has no A or AAAA records", server)
+func (a *activeTask) nsec3Walk(ctx context.Context, r resolve.Resolver, zone string) {
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
 	}
 
-	rr := resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype)
-	if len(rr) == 0 {
-		return "", fmt.Errorf("DNS server %s has no A or AAAA records", server)
+	reqs, signed, err := a.enum.nsec3Traversal(ctx, r, zone, resolve.PriorityHigh)
+	if err != nil && signed {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogError,
+			"NSEC3 Walk failed: %s: %v", zone, err).With(requests.LogFieldDomain, zone))
 	}
 
-	return rr[0].Data, nil
+	for _, req := range reqs {
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, req)
+	}
 }
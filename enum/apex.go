@@ -0,0 +1,144 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+	"github.com/caffix/pipeline"
+	"github.com/caffix/resolve"
+	"github.com/cayleygraph/quad"
+	"github.com/miekg/dns"
+)
+
+// The property predicate used to store notable TXT records on the apex domain nodes.
+const txtHighlightPredicate = "txt_highlight"
+
+// TXT records worth reporting for an apex domain, such as email policy and site verification tokens.
+var txtHighlightRE = regexp.MustCompile(`(?i)^(v=spf1|v=dmarc1|v=stsv1|ms=|[a-z0-9-]+-(site-|domain-)?verification=)`)
+
+// apexQueries resolves the TXT records of the apex domain and ensures the
+// domain is recorded as a first-class name in the enumeration graph.
+func (dt *dNSTask) apexQueries(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) {
+	cfg, _, err := requests.ContextConfigBus(ctx)
+	if err != nil || !cfg.ApexRecords || req.Name != req.Domain {
+		return
+	}
+
+	if _, err := dt.enum.Graph.UpsertFQDN(req.Name, "DNS", cfg.UUID.String()); err != nil {
+		_ = dt.enum.graphFailure(err)
+		return
+	}
+
+	msg := resolve.QueryMsg(req.Name, dns.TypeTXT)
+	resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy)
+	if err != nil {
+		dt.handleResolverError(ctx, err)
+		return
+	}
+
+	rr := resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeTXT)
+	for _, a := range rr {
+		if txt := strings.TrimSpace(a.Data); txtHighlightRE.MatchString(txt) {
+			_ = dt.enum.graphFailure(dt.enum.Graph.UpsertProperty(netmap.Node(req.Name), txtHighlightPredicate, txt))
+		}
+	}
+
+	// The TXT records can contain names and addresses of interest
+	if len(rr) > 0 {
+		pipeline.SendData(ctx, "store", &requests.DNSRequest{
+			Name:    req.Name,
+			Domain:  req.Domain,
+			Records: convertAnswers(rr),
+			Tag:     requests.DNS,
+			Source:  "DNS",
+		}, tp)
+	}
+}
+
+// ApexOutput returns an output record for each domain name in scope of the enumeration, including
+// the addresses, name servers, mail servers and notable TXT records of the apex domain.
+func (e *Enumeration) ApexOutput() []*requests.Output {
+	if !e.Config.ApexRecords {
+		return nil
+	}
+
+	var output []*requests.Output
+	uuid := e.Config.UUID.String()
+	for _, domain := range e.Config.Domains() {
+		node := netmap.Node(domain)
+		o := &requests.Output{
+			Name:   domain,
+			Domain: domain,
+			Tag:    requests.DNS,
+		}
+
+		if srcs, err := e.Graph.NodeSources(node, uuid); err == nil {
+			o.Sources = srcs
+		}
+		o.Consistency, o.AnswerSets = ReadConsistency(e.Graph, domain)
+
+		if pairs, err := e.Graph.NamesToAddrs(uuid, domain); err == nil {
+			for _, p := range pairs {
+				info := requests.AddressInfo{Address: net.ParseIP(p.Addr)}
+
+				if r := e.Sys.Cache().AddrSearch(p.Addr); r != nil {
+					_, info.Netblock, _ = net.ParseCIDR(r.Prefix)
+					info.ASN = r.ASN
+					info.CIDRStr = r.Prefix
+					info.Description = r.Description
+				}
+				o.Addresses = append(o.Addresses, info)
+			}
+		}
+
+		o.NS = e.apexTargets(node, "ns_record")
+		o.MX = e.apexTargets(node, "mx_record")
+		if props, err := e.Graph.ReadProperties(node, txtHighlightPredicate); err == nil {
+			for _, p := range props {
+				o.TXT = append(o.TXT, quad.ToString(p.Value))
+			}
+			sort.Strings(o.TXT)
+		}
+
+		output = append(output, o)
+	}
+	return output
+}
+
+// IsApexOutput returns true when the output is for a domain name in scope of the enumeration
+// that is reported separately by the apex domain records.
+func (e *Enumeration) IsApexOutput(o *requests.Output) bool {
+	if !e.Config.ApexRecords {
+		return false
+	}
+
+	for _, d := range e.Config.Domains() {
+		if o.Name == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Enumeration) apexTargets(node netmap.Node, predicate string) []string {
+	edges, err := e.Graph.ReadOutEdges(node, predicate)
+	if err != nil {
+		return nil
+	}
+
+	var targets []string
+	for _, edge := range edges {
+		targets = append(targets, e.Graph.NodeToID(edge.To))
+	}
+
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,72 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/netmap"
+)
+
+func TestApexOutput(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "owasp-amass.com")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	uuid := cfg.UUID.String()
+	_ = e.Graph.UpsertA("owasp.org", "104.22.26.77", "DNS", uuid)
+	_ = e.Graph.UpsertNS("owasp.org", "ns1.example.net", "DNS", uuid)
+	_ = e.Graph.UpsertMX("owasp.org", "mx.example.net", "DNS", uuid)
+	_ = e.Graph.UpsertProperty(netmap.Node("owasp.org"), txtHighlightPredicate, "v=spf1 -all")
+
+	output := e.ApexOutput()
+	if len(output) != 2 {
+		t.Fatalf("Expected an output record for each domain, got %d", len(output))
+	}
+
+	for _, o := range output {
+		if o.Name != "owasp.org" {
+			// The apex is reported even when nothing was discovered
+			if len(o.Addresses) != 0 || len(o.NS) != 0 {
+				t.Errorf("Unexpected data for %s: %+v", o.Name, o)
+			}
+			continue
+		}
+
+		if len(o.Addresses) != 1 || o.Addresses[0].Address.String() != "104.22.26.77" {
+			t.Errorf("Unexpected addresses for the apex: %v", o.Addresses)
+		}
+		if len(o.NS) != 1 || o.NS[0] != "ns1.example.net" {
+			t.Errorf("Unexpected name servers for the apex: %v", o.NS)
+		}
+		if len(o.MX) != 1 || o.MX[0] != "mx.example.net" {
+			t.Errorf("Unexpected mail servers for the apex: %v", o.MX)
+		}
+		if len(o.TXT) != 1 || o.TXT[0] != "v=spf1 -all" {
+			t.Errorf("Unexpected TXT highlights for the apex: %v", o.TXT)
+		}
+	}
+
+	cfg.ApexRecords = false
+	if output := e.ApexOutput(); len(output) != 0 {
+		t.Errorf("Apex records were returned after being disabled")
+	}
+}
+
+func TestTXTHighlights(t *testing.T) {
+	for txt, expected := range map[string]bool{
+		"v=spf1 include:_spf.google.com ~all":     true,
+		"google-site-verification=abc123":         true,
+		"facebook-domain-verification=xyz":        true,
+		"MS=ms12345678":                           true,
+		"some random text about the organization": false,
+	} {
+		if txtHighlightRE.MatchString(txt) != expected {
+			t.Errorf("The TXT record %q expected to be a highlight: %t", txt, expected)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+func TestSkipAttemptedNames(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	e.nameSrc = newEnumSource(e, 10)
+	e.setupContext(context.Background())
+	defer e.stop()
+
+	if !e.nameSrc.accept("www.owasp.org", requests.API, "Mock", true) {
+		t.Fatal("The input source did not accept the first occurrence of the name")
+	}
+
+	var lock sync.Mutex
+	published := make(map[string]int)
+	fn := func(req *requests.DNSRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		published[req.Name]++
+	}
+	e.Bus.Subscribe(requests.NewNameTopic, fn)
+	defer e.Bus.Unsubscribe(requests.NewNameTopic, fn)
+	requests.WaitForSubscriptions(e.ctx, e.Bus)
+
+	// Emulate a guessing source generating names, as the scripting data sources do
+	for _, name := range []string{"www.owasp.org", "dev.owasp.org", "www.owasp.org"} {
+		if requests.SkipAttemptedName(e.ctx, name, requests.ALT, "Alterations") {
+			continue
+		}
+
+		e.Bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+			Name:   name,
+			Domain: "owasp.org",
+			Tag:    requests.ALT,
+			Source: "Alterations",
+		})
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if num := published["www.owasp.org"]; num != 0 {
+		t.Errorf("The already attempted name was published %d times", num)
+	}
+	if num := published["dev.owasp.org"]; num != 1 {
+		t.Errorf("The new name was published %d times, expected once", num)
+	}
+	if num := e.SkippedRegenerations()["Alterations"]; num != 2 {
+		t.Errorf("Expected 2 skipped regenerations, got %d", num)
+	}
+	// Names from trusted sources are never skipped
+	if requests.SkipAttemptedName(e.ctx, "www.owasp.org", requests.CERT, "Mock") {
+		t.Error("The name from a trusted source was skipped")
+	}
+}
+
+func TestNameFilterRotation(t *testing.T) {
+	size := filterMaxSize
+	filterMaxSize = 16
+	defer func() { filterMaxSize = size }()
+
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	e.nameSrc = newEnumSource(e, 10)
+	for i := 0; i < int(filterMaxSize); i++ {
+		if !e.nameSrc.accept(fmt.Sprintf("host%d.owasp.org", i), requests.CERT, "Mock", true) {
+			t.Fatalf("The input source did not accept the name %d", i)
+		}
+	}
+
+	stats := e.NameFilterStats()
+	if stats.Rotations != 1 || stats.Previous != filterMaxSize {
+		t.Fatalf("The name filter was not rotated: %+v", stats)
+	}
+	// The names accepted just before the rotation are still filtered
+	for _, i := range []int{0, int(filterMaxSize) - 1} {
+		if name := fmt.Sprintf("host%d.owasp.org", i); e.nameSrc.accept(name, requests.CERT, "Mock", true) {
+			t.Errorf("The name %s was accepted again after the rotation", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"errors"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/resolvers"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+)
+
+// BlockedPredicate is the property of FQDN nodes that holds the reason the resolvers gave for not
+// answering the name in their Extended DNS Errors, either resolvers.EDEBlocked or resolvers.EDEFiltered.
+const BlockedPredicate = "dns_blocked"
+
+// blockedCategory returns the category of the Extended DNS Errors when each resolver asked
+// reported the name as blocked or filtered.
+func blockedCategory(err error) string {
+	var rerr *resolve.ResolveError
+
+	if errors.As(err, &rerr) {
+		switch rerr.Rcode {
+		case resolvers.BlockedRcode:
+			return resolvers.EDEBlocked
+		case resolvers.FilteredRcode:
+			return resolvers.EDEFiltered
+		}
+	}
+	return ""
+}
+
+// nameBlocked stores the name the resolvers refused to answer, so it is flagged in the
+// graph instead of being mistaken for a name that does not exist.
+func (e *Enumeration) nameBlocked(req *requests.DNSRequest, category string) {
+	if _, err := e.Graph.UpsertFQDN(req.Name, req.Source, e.Config.UUID.String()); err != nil {
+		_ = e.graphFailure(err)
+		return
+	}
+
+	_ = e.graphFailure(e.Graph.UpsertProperty(netmap.Node(req.Name), BlockedPredicate, category))
+}
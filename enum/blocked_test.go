@@ -0,0 +1,68 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/resolvers"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/cayleygraph/quad"
+	"github.com/miekg/dns"
+)
+
+// blockingResolver reports the names as blocked, as the pool does once each resolver asked
+// returned an Extended DNS Error, and leaves the other queries to the countingResolver.
+type blockingResolver struct {
+	*countingResolver
+	blocked map[string]struct{}
+}
+
+func (r *blockingResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	name := strings.TrimSuffix(msg.Question[0].Name, ".")
+	if _, found := r.blocked[name]; !found {
+		return r.countingResolver.Query(ctx, msg, priority, retry)
+	}
+
+	r.countingResolver.Lock()
+	r.queries[name]++
+	r.countingResolver.Unlock()
+	return nil, &resolve.ResolveError{Err: name + " was reported as blocked", Rcode: resolvers.BlockedRcode}
+}
+
+func TestBlockedNamesFlagged(t *testing.T) {
+	sys, pool := newResumeSystem()
+	defer stopSources(sys)
+
+	sys.pool = &blockingResolver{
+		countingResolver: pool,
+		blocked:          map[string]struct{}{"casino.owasp.org": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org", "casino.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	if err := e.EnablePhases(Resolution); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+	runResume(t, e)
+
+	if pool.count("casino.owasp.org") == 0 {
+		t.Fatal("The blocked name was not queried")
+	}
+	props, err := e.Graph.ReadProperties(netmap.Node("casino.owasp.org"), BlockedPredicate)
+	if err != nil || len(props) != 1 || quad.NativeOf(props[0].Value) != resolvers.EDEBlocked {
+		t.Errorf("The blocked name was not flagged in the graph: %v", props)
+	}
+	if props, _ := e.Graph.ReadProperties(netmap.Node("www.owasp.org"), BlockedPredicate); len(props) != 0 {
+		t.Errorf("The resolved name was flagged as blocked: %v", props)
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/datasrcs"
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+)
+
+// The file in the output directory that stores the historical yield of the data sources.
+const sourceYieldFile = "source_yield.json"
+
+// sourceYield is the number of resolved names contributed by each data source, keyed by source and domain.
+type sourceYield map[string]map[string]int
+
+func loadSourceYield(dir string) (sourceYield, error) {
+	y := make(sourceYield)
+	if dir == "" {
+		return y, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, sourceYieldFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return y, nil
+		}
+		return y, err
+	}
+
+	if err := json.Unmarshal(data, &y); err != nil {
+		return make(sourceYield), fmt.Errorf("Failed to parse the data source yield statistics: %v", err)
+	}
+	return y, nil
+}
+
+func (y sourceYield) save(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(y)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, sourceYieldFile), data, 0644)
+}
+
+func (y sourceYield) add(source, domain string, num int) {
+	source = strings.ToLower(source)
+	if _, found := y[source]; !found {
+		y[source] = make(map[string]int)
+	}
+	y[source][domain] += num
+}
+
+// total returns the names contributed by the source across the domains provided.
+func (y sourceYield) total(source string, domains []string) int {
+	var num int
+
+	for _, d := range domains {
+		num += y[strings.ToLower(source)][d]
+	}
+	return num
+}
+
+// SourceBudget is the number of requests a data source may receive during the enumeration.
+type SourceBudget struct {
+	Source    string `json:"source"`
+	Allocated int    `json:"allocated"`
+	Used      int    `json:"used"`
+}
+
+// sourceBudgets enforces the per-run request budgets of the data sources.
+// A nil sourceBudgets places no limits on the data sources.
+type sourceBudgets struct {
+	sync.Mutex
+	budgets map[string]*SourceBudget
+	// Sources that have not received a request since the last reallocation
+	idle map[string]bool
+	// Sources that were denied a request since the last reallocation
+	denied map[string]bool
+	weight map[string]int
+	// When the last reallocation was performed
+	reallocated time.Time
+}
+
+// newSourceBudgets allocates the total number of requests to the sources proportionally to
+// their historical yield. Every source is given one unit of weight, so that sources without
+// a history still receive part of the budget.
+func newSourceBudgets(total int, sources []string, yield sourceYield, domains []string) *sourceBudgets {
+	if total <= 0 || len(sources) == 0 {
+		return nil
+	}
+
+	b := &sourceBudgets{
+		budgets: make(map[string]*SourceBudget),
+		idle:    make(map[string]bool),
+		denied:  make(map[string]bool),
+		weight:  make(map[string]int),
+	}
+
+	var sum int
+	for _, src := range sources {
+		w := yield.total(src, domains) + 1
+
+		b.weight[src] = w
+		sum += w
+	}
+
+	for _, src := range sources {
+		alloc := total * b.weight[src] / sum
+		if alloc < 1 {
+			alloc = 1
+		}
+
+		b.budgets[src] = &SourceBudget{Source: src, Allocated: alloc}
+		b.idle[src] = true
+	}
+	return b
+}
+
+// Allow returns true and consumes one request when the source has budget remaining.
+func (b *sourceBudgets) Allow(source string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	sb, found := b.budgets[source]
+	if !found {
+		return true
+	}
+
+	b.idle[source] = false
+	if sb.Used >= sb.Allocated {
+		b.denied[source] = true
+		return false
+	}
+
+	sb.Used++
+	return true
+}
+
+// Due returns true once the share of the period has passed since the last reallocation, or since
+// the start when there has not been one. Without a period, the time elapsed since the start is
+// used, so the idle sources have longer to use their budget as the enumeration progresses.
+func (b *sourceBudgets) Due(now, start time.Time, period time.Duration, share float64) bool {
+	if b == nil {
+		return false
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	last := b.reallocated
+	if last.Before(start) {
+		last = start
+	}
+	if period <= 0 {
+		period = now.Sub(start)
+	}
+	return now.Sub(last) >= time.Duration(share*float64(period))
+}
+
+// Reallocate moves the unused budget of sources that have been idle since the last reallocation
+// to the sources that exhausted their budget, proportionally to the historical yield. The number
+// of requests moved is returned.
+func (b *sourceBudgets) Reallocate(now time.Time) int {
+	if b == nil {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.reallocated = now
+
+	var unused, sum int
+	var needy []string
+	for src, sb := range b.budgets {
+		if b.denied[src] {
+			needy = append(needy, src)
+			sum += b.weight[src]
+		} else if b.idle[src] && sb.Allocated > sb.Used {
+			unused += sb.Allocated - sb.Used
+		}
+	}
+
+	var moved int
+	if len(needy) > 0 && unused > 0 {
+		sort.Strings(needy)
+
+		for src, sb := range b.budgets {
+			if !b.denied[src] && b.idle[src] {
+				sb.Allocated = sb.Used
+			}
+		}
+		for i, src := range needy {
+			share := unused * b.weight[src] / sum
+			// The remainder of the integer division goes to the last source
+			if i == len(needy)-1 {
+				share = unused - moved
+			}
+
+			b.budgets[src].Allocated += share
+			moved += share
+		}
+	}
+
+	for src := range b.budgets {
+		b.idle[src] = true
+		b.denied[src] = false
+	}
+	return moved
+}
+
+// Budgets returns the current budgets of the data sources sorted by name.
+func (b *sourceBudgets) Budgets() []SourceBudget {
+	if b == nil {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	var budgets []SourceBudget
+	for _, sb := range b.budgets {
+		budgets = append(budgets, *sb)
+	}
+
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].Source < budgets[j].Source
+	})
+	return budgets
+}
+
+// reallocateBudgets gives the unused data source budget to the sources that need it, once the
+// configured share of the data sources phase budget, or of the time elapsed, has passed.
+func (e *Enumeration) reallocateBudgets(now time.Time) {
+	start, period := e.phaseBudgets.Budget(Sources)
+	if period <= 0 {
+		start = e.started
+	} else if start.IsZero() {
+		// The data sources have not received a request yet
+		return
+	}
+	if !e.budgets.Due(now, start, period, e.Config.SourceBudgetReallocation) {
+		return
+	}
+
+	if moved := e.budgets.Reallocate(now); moved > 0 {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityLow,
+			fmt.Sprintf("Reallocated %d unused data source requests to the sources with exhausted budgets", moved))
+	}
+}
+
+// SourceBudgets returns the request budget consumption of each data source.
+// Nothing is returned when the enumeration is not budgeting the data sources.
+func (e *Enumeration) SourceBudgets() []SourceBudget {
+	return e.budgets.Budgets()
+}
+
+func (e *Enumeration) setupSourceBudgets() {
+	if e.Config.SourceBudget <= 0 {
+		return
+	}
+
+	var names []string
+	for _, src := range e.srcs {
+		names = append(names, src.String())
+	}
+
+	yield, err := loadSourceYield(config.OutputDirectory(e.Config.Dir))
+	if err != nil {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, err.Error())
+	}
+
+	e.budgets = newSourceBudgets(e.Config.SourceBudget, names, yield, e.Config.Domains())
+}
+
+// sourceRequest sends the request to the data source when its budget allows.
+// The HTTP traffic generated while handling the request is attributed to the source.
+// The request is held while the enumeration is paused, and dropped once the time budget
+// of the phase the data source belongs to has expired.
+func (e *Enumeration) sourceRequest(src service.Service, args service.Args) {
+	if !e.waitWhilePaused() {
+		return
+	}
+	if e.traffic.Benched(src.String()) || !e.budgets.Allow(src.String()) {
+		return
+	}
+	if !e.phaseBudgets.Allow(sourcePhase(src)) {
+		return
+	}
+
+	src.Request(http.WithTraffic(e.ctx, e.traffic, src.String()), args)
+}
+
+// SourceTraffic returns the HTTP requests and bytes transferred by each data source so far.
+// It can be called while the enumeration is running to obtain a progress snapshot.
+func (e *Enumeration) SourceTraffic() []http.SourceTraffic {
+	return e.traffic.Traffic()
+}
+
+// SidelinedSources returns the health of the data sources that had their circuit breaker trip
+// during the enumeration, sorted by name.
+func (e *Enumeration) SidelinedSources() []datasrcs.SourceHealth {
+	var sidelined []datasrcs.SourceHealth
+
+	for _, src := range e.srcs {
+		if h, ok := datasrcs.Health(src); ok && h.Trips > 0 {
+			sidelined = append(sidelined, h)
+		}
+	}
+
+	sort.Slice(sidelined, func(i, j int) bool {
+		return sidelined[i].Source < sidelined[j].Source
+	})
+	return sidelined
+}
+
+func (e *Enumeration) setupSourceTraffic() {
+	e.traffic = http.NewTrafficMeter()
+	e.traffic.OnBench = func(source string, ceiling int64) {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf(
+			"%s: Reached the ceiling of %d HTTP requests and was benched for the remainder of the enumeration", source, ceiling))
+	}
+
+	for _, src := range e.srcs {
+		if dsc := e.Config.GetDataSourceConfig(src.String()); dsc != nil && dsc.MaxRequests > 0 {
+			e.traffic.SetCeiling(src.String(), int64(dsc.MaxRequests))
+		}
+	}
+}
+
+// recordYield counts a resolved name contributed by the data source during this enumeration.
+func (e *Enumeration) recordYield(req *requests.DNSRequest) {
+	e.stats.resolved(req.Source)
+	if e.Config.Passive || req.Source == "" || req.Domain == "" {
+		return
+	}
+
+	e.yieldLock.Lock()
+	defer e.yieldLock.Unlock()
+
+	e.yield.add(req.Source, req.Domain, 1)
+}
+
+// saveSourceYield adds the yield of this enumeration to the statistics stored in the output directory.
+func (e *Enumeration) saveSourceYield() {
+	e.yieldLock.Lock()
+	defer e.yieldLock.Unlock()
+
+	if len(e.yield) == 0 {
+		return
+	}
+
+	dir := config.OutputDirectory(e.Config.Dir)
+	yield, err := loadSourceYield(dir)
+	if err != nil {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, err.Error())
+	}
+
+	for src, domains := range e.yield {
+		for d, num := range domains {
+			yield.add(src, d, num)
+		}
+	}
+
+	if err := yield.save(dir); err != nil {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			fmt.Sprintf("Failed to save the data source yield statistics: %v", err))
+	}
+}
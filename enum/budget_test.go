@@ -0,0 +1,209 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/datasrcs"
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/service"
+)
+
+func TestSourceBudgets(t *testing.T) {
+	var b *sourceBudgets
+	// A nil set of budgets places no limits on the data sources
+	if !b.Allow("Paid") {
+		t.Errorf("Unlimited budgets denied a request")
+	}
+
+	yield := make(sourceYield)
+	yield.add("Paid", "owasp.org", 8)
+	yield.add("Paid", "example.com", 100)
+	yield.add("Free", "owasp.org", 0)
+
+	b = newSourceBudgets(100, []string{"Paid", "Free"}, yield, []string{"owasp.org"})
+	budgets := b.Budgets()
+	// Weights of 9 and 1 for the owasp.org domain
+	if budgets[0].Source != "Free" || budgets[0].Allocated != 10 || budgets[1].Allocated != 90 {
+		t.Errorf("Unexpected allocations: %v", budgets)
+	}
+
+	for i := 0; i < 90; i++ {
+		if !b.Allow("Paid") {
+			t.Errorf("Request %d was denied within the budget", i)
+		}
+	}
+	if b.Allow("Paid") {
+		t.Errorf("The request exceeding the budget was allowed")
+	}
+	if !b.Allow("Unknown") {
+		t.Errorf("A source without a budget was denied")
+	}
+
+	if moved := b.Reallocate(time.Now()); moved != 10 {
+		t.Errorf("Expected 10 requests to be reallocated, got %d", moved)
+	}
+	if !b.Allow("Paid") {
+		t.Errorf("The reallocated budget was not available")
+	}
+	if budgets = b.Budgets(); budgets[0].Allocated != 0 || budgets[1].Allocated != 100 {
+		t.Errorf("Unexpected allocations after the reallocation: %v", budgets)
+	}
+}
+
+func TestSourceBudgetsDue(t *testing.T) {
+	var b *sourceBudgets
+	start := time.Now()
+	if b.Due(start.Add(time.Hour), start, 0, 0.5) {
+		t.Errorf("Unlimited budgets were due for a reallocation")
+	}
+
+	b = newSourceBudgets(100, []string{"Paid", "Free"}, make(sourceYield), []string{"owasp.org"})
+	// With a phase budget of one hour, the reallocations are 30 minutes apart
+	if b.Due(start.Add(25*time.Minute), start, time.Hour, 0.5) {
+		t.Errorf("The reallocation was due before half of the phase budget had passed")
+	}
+	if !b.Due(start.Add(30*time.Minute), start, time.Hour, 0.5) {
+		t.Errorf("The reallocation was not due once half of the phase budget had passed")
+	}
+	b.Reallocate(start.Add(30 * time.Minute))
+	if b.Due(start.Add(55*time.Minute), start, time.Hour, 0.5) {
+		t.Errorf("The reallocation was due too soon after the last one")
+	}
+
+	// Without a phase budget, the reallocations are spaced by half of the time elapsed
+	b = newSourceBudgets(100, []string{"Paid", "Free"}, make(sourceYield), []string{"owasp.org"})
+	if !b.Due(start.Add(5*time.Minute), start, 0, 0.5) {
+		t.Errorf("The first reallocation was not due")
+	}
+	b.Reallocate(start.Add(5 * time.Minute))
+	if !b.Due(start.Add(10*time.Minute), start, 0, 0.5) {
+		t.Errorf("The reallocation was not due after half of the time elapsed")
+	}
+	b.Reallocate(start.Add(10 * time.Minute))
+	if b.Due(start.Add(15*time.Minute), start, 0, 0.5) {
+		t.Errorf("The reallocation was due before half of the time elapsed")
+	}
+	if !b.Due(start.Add(20*time.Minute), start, 0, 0.5) {
+		t.Errorf("The reallocation was not due after half of the time elapsed")
+	}
+}
+
+func TestSourceYieldPersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	yield := make(sourceYield)
+	yield.add("Paid", "owasp.org", 5)
+	if err := yield.save(dir); err != nil {
+		t.Fatalf("Failed to save the yield: %v", err)
+	}
+
+	loaded, err := loadSourceYield(dir)
+	if err != nil {
+		t.Fatalf("Failed to load the yield: %v", err)
+	}
+	if num := loaded.total("PAID", []string{"owasp.org", "example.com"}); num != 5 {
+		t.Errorf("Expected a yield of 5, got %d", num)
+	}
+}
+
+// webSource requests the web page for each request it receives.
+type webSource struct {
+	service.BaseService
+	url  string
+	done chan struct{}
+}
+
+func newWebSource(url string) *webSource {
+	w := &webSource{url: url, done: make(chan struct{}, 10)}
+
+	w.BaseService = *service.NewBaseService(w, "Web")
+	return w
+}
+
+func (w *webSource) OnRequest(ctx context.Context, args service.Args) {
+	_, _ = http.RequestWebPage(ctx, w.url, nil, nil, nil)
+	w.done <- struct{}{}
+}
+
+func TestSidelinedSources(t *testing.T) {
+	var hits, healthy int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	web := newWebSource(srv.URL)
+	cooldown := 200 * time.Millisecond
+	cb := datasrcs.NewCircuitBreaker(web, 3, cooldown)
+
+	cfg := config.NewConfig()
+	sys := newMockSystem(cfg)
+	_ = sys.AddAndStart(cb)
+	defer func() { _ = cb.Stop() }()
+
+	e := NewEnumeration(cfg, sys)
+	e.setupContext(context.Background())
+	defer func() {
+		e.stop()
+		e.Close()
+	}()
+
+	request := func() bool {
+		cb.Request(e.ctx, &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"})
+		select {
+		case <-web.done:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if !request() {
+			t.Fatalf("The request %d was dropped before the circuit breaker tripped", i+1)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		cb.Request(e.ctx, &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"})
+	}
+	if h := cb.Health(); h.Healthy || h.Trips != 1 || h.Dropped != 2 || h.LastError == "" {
+		t.Errorf("Unexpected health after the circuit breaker tripped: %+v", h)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("Expected the dropped requests to not reach the server, got %d requests", n)
+	}
+
+	// A single failure after the cool-down period trips the circuit breaker again
+	time.Sleep(cooldown)
+	if !request() {
+		t.Fatal("The request was dropped after the cool-down period")
+	}
+	if h := cb.Health(); h.Healthy || h.Trips != 2 {
+		t.Errorf("The circuit breaker did not trip after the failure that followed the cool-down: %+v", h)
+	}
+
+	// A successful request returns the source to good health
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(cooldown)
+	if !request() {
+		t.Fatal("The request was dropped after the second cool-down period")
+	}
+
+	sidelined := e.SidelinedSources()
+	if len(sidelined) != 1 || sidelined[0].Source != "Web" || !sidelined[0].Healthy || sidelined[0].Trips != 2 {
+		t.Errorf("Unexpected sidelined sources: %+v", sidelined)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// Once a proper subdomain has been capped, one in this many of its untrusted discoveries is processed.
+const cappedSampleRate = 10
+
+// CappedSubdomain describes a proper subdomain that reached the maximum number of resolved names.
+type CappedSubdomain struct {
+	Name string `json:"name"`
+	// The names resolved within the subdomain, including the sampled discoveries
+	Resolved int `json:"resolved"`
+	// The untrusted discoveries made after the subdomain was capped
+	Seen int `json:"seen"`
+	// The discoveries that were sampled and processed
+	Sampled int `json:"sampled"`
+	// The number of names the subdomain would have without the cap
+	Estimate int `json:"estimate"`
+}
+
+// subdomainCap tracks the discoveries made under a capped proper subdomain.
+type subdomainCap struct {
+	seen    int
+	sampled int
+}
+
+// sample returns true when the discovery under the subdomain should be processed.
+// This method is only called by the timesManager goroutine.
+func (r *subdomainTask) sample(sub string, times int, caps map[string]*subdomainCap) bool {
+	max := r.enum.Config.MaxNamesPerSubdomain
+	if max <= 0 || times < max {
+		return true
+	}
+
+	c, found := caps[sub]
+	if !found {
+		c = new(subdomainCap)
+		caps[sub] = c
+	}
+
+	c.seen++
+	if (c.seen-1)%cappedSampleRate != 0 {
+		return false
+	}
+
+	c.sampled++
+	return true
+}
+
+func cappedSubdomains(max int, subdomains map[string]int, caps map[string]*subdomainCap) []CappedSubdomain {
+	var capped []CappedSubdomain
+
+	for sub, c := range caps {
+		times := subdomains[sub]
+		estimate := times
+		// Extrapolate the rate of resolution among the sampled discoveries to the skipped discoveries
+		if skipped := c.seen - c.sampled; skipped > 0 && c.sampled > 0 && times > max {
+			rate := float64(times-max) / float64(c.sampled)
+			if rate > 1 {
+				rate = 1
+			}
+			estimate += int(float64(skipped) * rate)
+		}
+
+		capped = append(capped, CappedSubdomain{
+			Name:     sub,
+			Resolved: times,
+			Seen:     c.seen,
+			Sampled:  c.sampled,
+			Estimate: estimate,
+		})
+	}
+
+	sort.Slice(capped, func(i, j int) bool {
+		return capped[i].Name < capped[j].Name
+	})
+	return capped
+}
+
+// sampleDiscovery returns true when the newly discovered name should be processed by the enumeration.
+// Trusted names and names outside of capped proper subdomains are always processed.
+func (r *subdomainTask) sampleDiscovery(name, domain, tag string) bool {
+	if r.enum.Config.MaxNamesPerSubdomain <= 0 || requests.TrustedTag(tag) {
+		return true
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return true
+	}
+	// Only the proper subdomains below the root domain name are capped
+	sub := strings.Join(labels[1:], ".")
+	if sub == domain || !strings.HasSuffix(sub, "."+domain) {
+		return true
+	}
+
+	ch := make(chan bool, 2)
+	select {
+	case <-r.stopped:
+		return true
+	case r.sampleChan <- &sampleReq{Sub: sub, Ch: ch}:
+	}
+	return <-ch
+}
+
+// CappedSubdomains returns the proper subdomains that reached the maximum number of resolved names.
+func (e *Enumeration) CappedSubdomains() []CappedSubdomain {
+	if e.subTask == nil || e.Config.MaxNamesPerSubdomain <= 0 {
+		return nil
+	}
+
+	ch := make(chan []CappedSubdomain, 2)
+	select {
+	case <-e.subTask.stopped:
+		return e.subTask.finalCaps
+	case e.subTask.capsChan <- ch:
+	}
+	return <-ch
+}
@@ -0,0 +1,71 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestSubdomainCap(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxNamesPerSubdomain = 5
+
+	e := &Enumeration{Config: cfg}
+	e.subTask = newSubdomainTask(e)
+
+	domain := "owasp.org"
+	sub := "users.owasp.org"
+	// Discoveries are processed until the subdomain reaches the cap
+	for i := 0; i < cfg.MaxNamesPerSubdomain; i++ {
+		name := fmt.Sprintf("u%d.%s", i, sub)
+
+		if !e.subTask.sampleDiscovery(name, domain, requests.API) {
+			t.Errorf("%s was not processed before the cap was reached", name)
+		}
+		e.subTask.timesForSubdomain(sub, "Brute Forcing")
+	}
+
+	var processed int
+	for i := 0; i < 100; i++ {
+		if e.subTask.sampleDiscovery(fmt.Sprintf("v%d.%s", i, sub), domain, requests.API) {
+			processed++
+			// Half of the sampled discoveries resolve
+			if processed%2 == 0 {
+				e.subTask.timesForSubdomain(sub, "Brute Forcing")
+			}
+		}
+	}
+	if processed != 100/cappedSampleRate {
+		t.Errorf("Expected %d of the discoveries to be sampled, got %d", 100/cappedSampleRate, processed)
+	}
+
+	if !e.subTask.sampleDiscovery("admin."+sub, domain, requests.CERT) {
+		t.Errorf("The trusted discovery was not processed")
+	}
+	if !e.subTask.sampleDiscovery("www."+domain, domain, requests.API) {
+		t.Errorf("The discovery below the root domain name was not processed")
+	}
+	if !e.subTask.sampleDiscovery("u1.other.owasp.org", domain, requests.API) {
+		t.Errorf("The discovery in another subdomain was not processed")
+	}
+
+	e.subTask.Stop()
+	capped := e.CappedSubdomains()
+	if len(capped) != 1 {
+		t.Fatalf("Expected one capped subdomain, got %d", len(capped))
+	}
+
+	c := capped[0]
+	if c.Name != sub || c.Resolved != 10 || c.Seen != 100 || c.Sampled != 10 {
+		t.Errorf("Unexpected capped subdomain %+v", c)
+	}
+	// Half of the 90 skipped discoveries are expected to resolve
+	if c.Estimate != 55 {
+		t.Errorf("Expected an estimate of 55 names, got %d", c.Estimate)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/cayleygraph/quad"
+)
+
+// CertSNIPredicate is the property of FQDN nodes that records the TLS handshakes returning a
+// certificate containing the name, formatted as "address:port|server name". The server name
+// is empty when the handshake was performed without SNI.
+const CertSNIPredicate = "cert_sni"
+
+// The most names resolving to an address that are used as SNI server names for the address.
+const maxCertServerNames = 25
+
+// certSNILog tracks the handshakes that provided each name found within the certificates.
+type certSNILog struct {
+	sync.Mutex
+	names map[string]stringset.Set
+}
+
+func newCertSNILog() *certSNILog {
+	return &certSNILog{names: make(map[string]stringset.Set)}
+}
+
+func (c *certSNILog) add(name, value string) {
+	c.Lock()
+	defer c.Unlock()
+
+	set, found := c.names[name]
+	if !found {
+		set = stringset.New()
+		c.names[name] = set
+	}
+	set.Insert(value)
+}
+
+func (c *certSNILog) get(name string) []string {
+	c.Lock()
+	defer c.Unlock()
+
+	var values []string
+	if set, found := c.names[name]; found {
+		values = set.Slice()
+		sort.Strings(values)
+	}
+	return values
+}
+
+func (c *certSNILog) all() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	names := make([]string, 0, len(c.names))
+	for name := range c.names {
+		names = append(names, name)
+	}
+	return names
+}
+
+func certSNIValue(cn *http.CertificateName) string {
+	return net.JoinHostPort(cn.Address, strconv.Itoa(cn.Port)) + "|" + cn.ServerName
+}
+
+// certServerNames returns the SNI server names used for the address: the domain names in scope,
+// and the names resolving to the address in the graph.
+func (e *Enumeration) certServerNames(req *requests.AddrRequest) []string {
+	names := stringset.New(e.Config.Domains()...)
+	if req.Domain != "" {
+		names.Insert(req.Domain)
+	}
+
+	var count int
+	in, _ := e.Graph.ReadInEdges(netmap.Node(req.Address), "a_record", "aaaa_record")
+	for _, edge := range in {
+		if count >= maxCertServerNames {
+			break
+		}
+
+		if name := e.Graph.NodeToID(edge.From); name != "" && e.Scope.Evaluate(name, nil).Domain != "" {
+			if !names.Has(name) {
+				names.Insert(name)
+				count++
+			}
+		}
+	}
+
+	results := names.Slice()
+	sort.Strings(results)
+	return results
+}
+
+// certNameFound records the handshake that provided the name found within a certificate.
+func (e *Enumeration) certNameFound(cn *http.CertificateName) {
+	detail := "without SNI"
+	if cn.ServerName != "" {
+		detail = "SNI " + cn.ServerName
+	}
+
+	e.trace(cn.Name, TraceSeen, "Active Cert", fmt.Sprintf("%s:%d %s", cn.Address, cn.Port, detail))
+	e.certSNI.add(cn.Name, certSNIValue(cn))
+}
+
+// storeAllCertSNI attaches the handshakes that provided the names to their FQDN nodes.
+func (e *Enumeration) storeAllCertSNI() {
+	for _, name := range e.certSNI.all() {
+		node, err := e.Graph.ReadNode(name, netmap.TypeFQDN)
+		if err != nil {
+			continue
+		}
+
+		for _, value := range e.certSNI.get(name) {
+			if err := e.graphFailure(e.Graph.UpsertProperty(node, CertSNIPredicate, value)); err != nil {
+				e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", e.Graph.String(), err))
+			}
+		}
+	}
+}
+
+// ReadCertSNI returns the handshakes that provided certificates containing the name, formatted
+// as "address:port|server name".
+func ReadCertSNI(g *netmap.Graph, name string) []string {
+	node, err := g.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return nil
+	}
+
+	props, err := g.ReadProperties(node, CertSNIPredicate)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	for _, p := range props {
+		values = append(values, quad.ToString(p.Value))
+	}
+	sort.Strings(values)
+	return values
+}
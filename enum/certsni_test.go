@@ -0,0 +1,60 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/net/http"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestCertServerNames(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "appsecusa.org")
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	uuid := cfg.UUID.String()
+	for _, name := range []string{"www.owasp.org", "vpn.owasp.org", "www.example.com"} {
+		if err := e.Graph.UpsertA(name, "72.237.4.113", "DNS", uuid); err != nil {
+			t.Fatalf("Failed to insert the A record: %v", err)
+		}
+	}
+	_ = e.Graph.UpsertA("mail.owasp.org", "72.237.4.114", "DNS", uuid)
+
+	names := e.certServerNames(&requests.AddrRequest{Address: "72.237.4.113", Domain: "owasp.org"})
+	// The names out of scope and the names resolving to other addresses are not used
+	expected := []string{"appsecusa.org", "owasp.org", "vpn.owasp.org", "www.owasp.org"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the server names %v, got %v", expected, names)
+	}
+}
+
+func TestStoreCertSNI(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	e.certNameFound(&http.CertificateName{Name: "www.owasp.org", Address: "72.237.4.113", Port: 443, ServerName: "owasp.org"})
+	e.certNameFound(&http.CertificateName{Name: "www.owasp.org", Address: "72.237.4.114", Port: 25})
+	e.certNameFound(&http.CertificateName{Name: "vpn.owasp.org", Address: "72.237.4.113", Port: 443})
+
+	if _, err := e.Graph.UpsertFQDN("www.owasp.org", "Active Cert", cfg.UUID.String()); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+	e.storeAllCertSNI()
+
+	expected := []string{"72.237.4.113:443|owasp.org", "72.237.4.114:25|"}
+	if values := ReadCertSNI(e.Graph, "www.owasp.org"); !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected the handshakes %v, got %v", expected, values)
+	}
+	// The names that were not stored are skipped
+	if values := ReadCertSNI(e.Graph, "vpn.owasp.org"); len(values) != 0 {
+		t.Errorf("The handshakes were stored for the missing name: %v", values)
+	}
+}
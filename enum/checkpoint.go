@@ -0,0 +1,208 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/filter"
+	"github.com/caffix/stringset"
+)
+
+const checkpointVersion = 1
+
+// checkpoint is the serialized state of an interrupted enumeration.
+type checkpoint struct {
+	Version     int                 `json:"version"`
+	Created     time.Time           `json:"created"`
+	Domains     []string            `json:"domains"`
+	DomainIndex int                 `json:"domain_index"`
+	Filter      *filter.BloomFilter `json:"resolved_filter"`
+	Names       []string            `json:"names"`
+}
+
+// checkpointState tracks the progress that is saved by the checkpoints of the enumeration.
+type checkpointState struct {
+	sync.Mutex
+	// The root domain names in the order they are released to the enumeration
+	domains []string
+	// The number of root domain names released to the enumeration
+	domainIdx int
+	restored  *checkpoint
+}
+
+// releaseOrder returns the order of the root domain names released by the enumeration.
+// The configuration does not preserve the order, so the order is fixed by the first call.
+func (c *checkpointState) releaseOrder(cfg *config.Config) []string {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.domains == nil {
+		c.domains = cfg.Domains()
+	}
+	return c.domains
+}
+
+func (c *checkpointState) setDomainIndex(idx int) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.domainIdx = idx
+}
+
+func (c *checkpointState) domainIndex() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.domainIdx
+}
+
+// pendingDomains returns the number of root domain names not yet released to the input source.
+func (c *checkpointState) pendingDomains(cfg *config.Config) int {
+	c.Lock()
+	defer c.Unlock()
+
+	domains := c.domains
+	if domains == nil {
+		domains = cfg.Domains()
+	}
+	if n := len(domains) - c.domainIdx; n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (c *checkpointState) loaded() *checkpoint {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.restored
+}
+
+// SaveCheckpoint writes the progress of the enumeration to a gzip-compressed JSON file at the path
+// provided: the filter of the resolved names, the names stored in the enumeration graph and the
+// number of root domain names already released. It can be called while the enumeration is running.
+func (e *Enumeration) SaveCheckpoint(path string) error {
+	bf, ok := e.resolvedFilter.(*filter.BloomFilter)
+	if !ok {
+		return errors.New("The filter of the resolved names cannot be saved")
+	}
+
+	cp := &checkpoint{
+		Version:     checkpointVersion,
+		Created:     time.Now(),
+		Domains:     e.checkpoints.releaseOrder(e.Config),
+		DomainIndex: e.checkpoints.domainIndex(),
+		Filter:      bf,
+		Names:       e.Graph.EventFQDNs(e.Config.UUID.String()),
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// The checkpoint replaces the previous file only once it has been completely written
+	f, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(cp); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write the checkpoint: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write the checkpoint: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadCheckpoint restores the progress saved by SaveCheckpoint, so that Start resumes the
+// enumeration without processing the names already handled and releases the remaining root
+// domain names. It must be called before Start, and the enumeration must be configured with
+// the same root domain names as the enumeration that saved the checkpoint.
+func (e *Enumeration) LoadCheckpoint(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("Failed to read the checkpoint: %v", err)
+	}
+	defer zr.Close()
+
+	cp := &checkpoint{Filter: filter.NewBloomFilter(1)}
+	if err := json.NewDecoder(zr).Decode(cp); err != nil {
+		return fmt.Errorf("Failed to parse the checkpoint: %v", err)
+	}
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("The checkpoint version %d is not supported", cp.Version)
+	}
+
+	if !sameDomains(e.Config.Domains(), cp.Domains) {
+		return errors.New("The checkpoint was saved by an enumeration of different domains")
+	}
+	if cp.DomainIndex < 0 || cp.DomainIndex > len(cp.Domains) {
+		return fmt.Errorf("The checkpoint domain index %d is not valid", cp.DomainIndex)
+	}
+
+	e.resolvedFilter = cp.Filter
+	e.checkpoints.Lock()
+	// The remaining domains are released in the order saved by the checkpoint
+	e.checkpoints.domains = cp.Domains
+	e.checkpoints.domainIdx = cp.DomainIndex
+	e.checkpoints.restored = cp
+	e.checkpoints.Unlock()
+	return nil
+}
+
+// restoreCheckpoint marks the names processed before the checkpoint as already accepted
+// by the input source, so they are not queried again when reported by the data sources.
+func (e *Enumeration) restoreCheckpoint() {
+	cp := e.checkpoints.loaded()
+	if cp == nil {
+		return
+	}
+
+	e.nameSrc.Lock()
+	defer e.nameSrc.Unlock()
+
+	for _, name := range cp.Names {
+		e.nameSrc.filter.Duplicate(name + strconv.FormatBool(true))
+	}
+}
+
+func sameDomains(domains, saved []string) bool {
+	set := stringset.New(domains...)
+	if len(saved) != set.Len() {
+		return false
+	}
+
+	for _, d := range saved {
+		if !set.Has(d) {
+			return false
+		}
+		set.Remove(d)
+	}
+	return true
+}
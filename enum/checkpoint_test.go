@@ -0,0 +1,132 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// smallFilters keeps the bloom filters written to the checkpoints small for the tests.
+func smallFilters() func() {
+	size := filterMaxSize
+	filterMaxSize = 1 << 16
+	return func() { filterMaxSize = size }
+}
+
+func TestCheckpointResume(t *testing.T) {
+	defer smallFilters()()
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("Failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "enum.ckpt")
+
+	sys := newMockSystem(config.NewConfig())
+	_ = sys.AddAndStart(newMockSource())
+	defer func() {
+		for _, src := range sys.DataSources() {
+			_ = src.Stop()
+		}
+	}()
+
+	newCfg := func() *config.Config {
+		cfg := config.NewConfig()
+		cfg.Passive = true
+		cfg.AddDomains("owasp.org", "owasp-amass.com")
+		return cfg
+	}
+
+	// The first enumeration handles the names of the first domain before it is interrupted
+	first := NewEnumeration(newCfg(), sys)
+	defer first.Close()
+	first.nameSrc = newEnumSource(first, 10)
+	first.setupContext(context.Background())
+
+	order := first.checkpoints.releaseOrder(first.Config)
+	done := order[0]
+
+	filter := first.makeFilterTaskFunc()
+	for _, label := range []string{"", "www.", "mail.", "vpn."} {
+		req := &requests.DNSRequest{Name: label + done, Domain: done, Tag: requests.API, Source: "Mock"}
+
+		first.nameSrc.dataSourceName(req)
+		if data, _ := filter.Process(first.ctx, req, nil); data != nil {
+			_, _ = first.Graph.UpsertFQDN(req.Name, req.Source, first.Config.UUID.String())
+		}
+	}
+	first.checkpoints.setDomainIndex(1)
+	if err := first.SaveCheckpoint(path); err != nil {
+		t.Fatalf("Failed to save the checkpoint: %v", err)
+	}
+	first.stop()
+
+	second := NewEnumeration(newCfg(), sys)
+	defer second.Close()
+	if err := second.LoadCheckpoint(path); err != nil {
+		t.Fatalf("Failed to load the checkpoint: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := second.Start(ctx); err != nil {
+		t.Fatalf("The resumed enumeration returned an error: %v", err)
+	}
+
+	var resumed int
+	for _, name := range second.Graph.EventFQDNs(second.Config.UUID.String()) {
+		if strings.HasSuffix(name, done) {
+			t.Errorf("The resumed enumeration processed %s again", name)
+			continue
+		}
+		resumed++
+	}
+	if resumed == 0 {
+		t.Errorf("The resumed enumeration did not process the remaining domain")
+	}
+	if !second.Attempted("www." + done) {
+		t.Errorf("The names handled before the checkpoint were not restored")
+	}
+	// Names reported again by the data sources are not accepted
+	if second.nameSrc.accept("mail."+done, requests.CERT, "Mock", true) {
+		t.Errorf("The name handled before the checkpoint was accepted again")
+	}
+}
+
+func TestCheckpointDifferentDomains(t *testing.T) {
+	defer smallFilters()()
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("Failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "enum.ckpt")
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+	if err := e.SaveCheckpoint(path); err != nil {
+		t.Fatalf("Failed to save the checkpoint: %v", err)
+	}
+
+	other := config.NewConfig()
+	other.AddDomain("owasp-amass.com")
+	o := NewEnumeration(other, newMockSystem(other))
+	defer o.Close()
+	if err := o.LoadCheckpoint(path); err == nil {
+		t.Errorf("Expected an error for the checkpoint of different domains")
+	}
+}
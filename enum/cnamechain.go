@@ -0,0 +1,220 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/cayleygraph/quad"
+)
+
+// DanglingPredicate is the property of FQDN nodes that CNAME records point at, which holds the
+// enumerations that found the name to not exist.
+const DanglingPredicate = "cname_nxdomain"
+
+// The flags of the CNAME chains that indicate a subdomain takeover candidate.
+const (
+	// The chain terminates in a name that does not exist
+	ChainNXDOMAIN = "nxdomain"
+	// The chain points into the domain of a provider commonly affected by subdomain takeovers
+	ChainProvider = "provider"
+)
+
+// The most CNAME records followed for each chain, which also stops the loops within the records.
+const maxCNAMEChain = 10
+
+// DefaultTakeoverDomains maps the domains of the providers commonly affected by subdomain
+// takeovers to the provider names. The names within these domains are flagged when at the
+// end of a CNAME chain, since the resource can be unclaimed while the record remains.
+var DefaultTakeoverDomains = map[string]string{
+	"herokuapp.com":          "Heroku",
+	"herokudns.com":          "Heroku",
+	"herokussl.com":          "Heroku",
+	"github.io":              "GitHub Pages",
+	"s3.amazonaws.com":       "Amazon S3",
+	"cloudfront.net":         "Amazon CloudFront",
+	"elasticbeanstalk.com":   "AWS Elastic Beanstalk",
+	"azurewebsites.net":      "Microsoft Azure",
+	"cloudapp.net":           "Microsoft Azure",
+	"cloudapp.azure.com":     "Microsoft Azure",
+	"trafficmanager.net":     "Microsoft Azure",
+	"blob.core.windows.net":  "Microsoft Azure",
+	"azureedge.net":          "Microsoft Azure",
+	"fastly.net":             "Fastly",
+	"myshopify.com":          "Shopify",
+	"bitbucket.io":           "Bitbucket",
+	"ghost.io":               "Ghost",
+	"pantheonsite.io":        "Pantheon",
+	"zendesk.com":            "Zendesk",
+	"wordpress.com":          "WordPress",
+	"tumblr.com":             "Tumblr",
+	"surge.sh":               "Surge",
+	"readme.io":              "ReadMe",
+	"helpscoutdocs.com":      "Help Scout",
+	"unbouncepages.com":      "Unbounce",
+	"netlify.app":            "Netlify",
+	"netlify.com":            "Netlify",
+	"firebaseapp.com":        "Firebase",
+	"storage.googleapis.com": "Google Cloud Storage",
+	"statuspage.io":          "Statuspage",
+	"uservoice.com":          "UserVoice",
+	"freshdesk.com":          "Freshdesk",
+	"helpjuice.com":          "Helpjuice",
+	"feedpress.me":           "FeedPress",
+	"createsend.com":         "Campaign Monitor",
+	"strikinglydns.com":      "Strikingly",
+	"wpengine.com":           "WP Engine",
+	"webflow.io":             "Webflow",
+	"wixdns.net":             "Wix",
+	"ngrok.io":               "Ngrok",
+}
+
+// CNAMEChain is the chain of CNAME records starting at a name, and the flag of the chain when
+// the name is a subdomain takeover candidate.
+type CNAMEChain struct {
+	Name string
+	// The names the CNAME records point at, in the order followed
+	Targets []string
+	// ChainNXDOMAIN, ChainProvider or empty when the chain was not flagged
+	Flag string
+	// The provider of the domain the chain points into, which can be set for both flags
+	Provider string
+}
+
+// TakeoverDomainProvider returns the provider of the takeover-prone domain containing the name,
+// or an empty string when the name is not within one of the DefaultTakeoverDomains.
+func TakeoverDomainProvider(name string) string {
+	name = strings.Trim(strings.ToLower(name), ".")
+
+	labels := strings.Split(name, ".")
+	// The most specific domain is selected
+	for i := range labels {
+		if provider, found := DefaultTakeoverDomains[strings.Join(labels[i:], ".")]; found {
+			return provider
+		}
+	}
+	return ""
+}
+
+// ReadCNAMEChain follows the CNAME records starting at the name in the graph, and flags the
+// chain when the last name was found to not exist by the enumeration identified by the uuid,
+// or when the chain points into a takeover-prone provider domain. Nil is returned when the
+// name is not an alias.
+func ReadCNAMEChain(g *netmap.Graph, uuid, name string) *CNAMEChain {
+	chain := &CNAMEChain{Name: name}
+
+	seen := stringset.New(name)
+	for cur := name; len(chain.Targets) < maxCNAMEChain; {
+		edges, err := g.ReadOutEdges(netmap.Node(cur), "cname_record")
+		if err != nil || len(edges) == 0 {
+			break
+		}
+
+		target := g.NodeToID(edges[0].To)
+		if target == "" || seen.Has(target) {
+			break
+		}
+		seen.Insert(target)
+		chain.Targets = append(chain.Targets, target)
+		cur = target
+	}
+	if len(chain.Targets) == 0 {
+		return nil
+	}
+
+	for _, target := range chain.Targets {
+		if provider := TakeoverDomainProvider(target); provider != "" {
+			chain.Flag = ChainProvider
+			chain.Provider = provider
+		}
+	}
+	if readDangling(g, uuid, chain.Targets[len(chain.Targets)-1]) {
+		chain.Flag = ChainNXDOMAIN
+	}
+	return chain
+}
+
+// readDangling returns true when the enumeration identified by the uuid found the name to not exist.
+func readDangling(g *netmap.Graph, uuid, name string) bool {
+	node, err := g.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return false
+	}
+
+	props, err := g.ReadProperties(node, DanglingPredicate)
+	if err != nil {
+		return false
+	}
+
+	for _, p := range props {
+		if quad.ToString(p.Value) == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// cnameTargetMissing flags the name that does not exist when a CNAME record points at it, and
+// reports the names within scope that are aliases of the name as subdomain takeover candidates.
+func (e *Enumeration) cnameTargetMissing(name string) {
+	node, err := e.Graph.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return
+	}
+	if n, err := e.Graph.CountInEdges(node, "cname_record"); err != nil || n == 0 {
+		return
+	}
+
+	uuid := e.Config.UUID.String()
+	if err := e.graphFailure(e.Graph.UpsertProperty(node, DanglingPredicate, uuid)); err != nil {
+		return
+	}
+
+	provider := TakeoverDomainProvider(name)
+	for _, alias := range e.cnameAliases(name) {
+		if e.Scope.Evaluate(alias, nil).Domain == "" {
+			continue
+		}
+
+		msg := fmt.Sprintf("DNS: The CNAME chain of %s terminates in NXDOMAIN at %s", alias, name)
+		if provider != "" {
+			msg += fmt.Sprintf(" (%s)", provider)
+		}
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, msg)
+	}
+}
+
+// cnameAliases returns the names with CNAME records leading to the name.
+func (e *Enumeration) cnameAliases(name string) []string {
+	var aliases []string
+
+	seen := stringset.New(name)
+	next := []string{name}
+	for depth := 0; depth < maxCNAMEChain && len(next) > 0; depth++ {
+		var found []string
+
+		for _, cur := range next {
+			edges, err := e.Graph.ReadInEdges(netmap.Node(cur), "cname_record")
+			if err != nil {
+				continue
+			}
+
+			for _, edge := range edges {
+				if alias := e.Graph.NodeToID(edge.From); alias != "" && !seen.Has(alias) {
+					seen.Insert(alias)
+					found = append(found, alias)
+				}
+			}
+		}
+
+		aliases = append(aliases, found...)
+		next = found
+	}
+	return aliases
+}
@@ -0,0 +1,177 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+// nxdomainResolver retries the NXDOMAIN responses once, as the resolver pool does, so the
+// names without records are confirmed to not exist.
+type nxdomainResolver struct {
+	*cnameResolver
+}
+
+func (r *nxdomainResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	resp, err := r.cnameResolver.Query(ctx, msg, priority, retry)
+
+	var rerr *resolve.ResolveError
+	if retry != nil && errors.As(err, &rerr) && rerr.Rcode == dns.RcodeNameError {
+		retry(1, priority, resp)
+	}
+	return resp, err
+}
+
+func TestTakeoverDomainProvider(t *testing.T) {
+	tests := map[string]string{
+		"owasp-prod.herokudns.com": "Heroku",
+		"OWASP.GitHub.IO.":         "GitHub Pages",
+		"assets.s3.amazonaws.com":  "Amazon S3",
+		"github.io":                "GitHub Pages",
+		"amazonaws.com":            "",
+		"www.owasp.org":            "",
+		"notgithub.io":             "",
+	}
+
+	for name, expected := range tests {
+		if provider := TakeoverDomainProvider(name); provider != expected {
+			t.Errorf("%s returned the provider %q instead of %q", name, provider, expected)
+		}
+	}
+}
+
+func TestReadCNAMEChain(t *testing.T) {
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer g.Close()
+
+	for _, link := range [][]string{
+		{"www.owasp.org", "edge.owasp.org"},
+		{"edge.owasp.org", "owasp.github.io"},
+		{"api.owasp.org", "api.example.net"},
+		{"loop.owasp.org", "back.owasp.org"},
+		{"back.owasp.org", "loop.owasp.org"},
+	} {
+		if err := g.UpsertCNAME(link[0], link[1], "DNS", "event"); err != nil {
+			t.Fatalf("Failed to insert the CNAME record: %v", err)
+		}
+	}
+
+	chain := ReadCNAMEChain(g, "event", "www.owasp.org")
+	if chain == nil || strings.Join(chain.Targets, ",") != "edge.owasp.org,owasp.github.io" {
+		t.Fatalf("Unexpected CNAME chain: %+v", chain)
+	}
+	if chain.Flag != ChainProvider || chain.Provider != "GitHub Pages" {
+		t.Errorf("The chain into the provider domain was not flagged: %+v", chain)
+	}
+
+	if chain := ReadCNAMEChain(g, "event", "api.owasp.org"); chain == nil || chain.Flag != "" {
+		t.Errorf("The chain of the existing name was flagged: %+v", chain)
+	}
+	if err := g.UpsertProperty(netmap.Node("api.example.net"), DanglingPredicate, "prior"); err != nil {
+		t.Fatalf("Failed to insert the property: %v", err)
+	}
+	// The names found to not exist by other enumerations are not flagged
+	if chain := ReadCNAMEChain(g, "event", "api.owasp.org"); chain.Flag != "" {
+		t.Errorf("The chain was flagged for the NXDOMAIN of another enumeration: %+v", chain)
+	}
+	if chain := ReadCNAMEChain(g, "prior", "api.owasp.org"); chain.Flag != ChainNXDOMAIN {
+		t.Errorf("The chain terminating in NXDOMAIN was not flagged: %+v", chain)
+	}
+
+	if chain := ReadCNAMEChain(g, "event", "loop.owasp.org"); chain == nil || len(chain.Targets) != 1 {
+		t.Errorf("The CNAME loop was followed: %+v", chain)
+	}
+	if chain := ReadCNAMEChain(g, "event", "edge.example.net"); chain != nil {
+		t.Errorf("A chain was returned for a name without CNAME records: %+v", chain)
+	}
+}
+
+func TestCNAMEChainTakeovers(t *testing.T) {
+	sys, pool := newResumeSystem()
+	defer stopSources(sys)
+
+	pool.addrs["owasp.github.io"] = "185.199.108.153"
+	pool.addrs["lb.example.net"] = "72.237.4.117"
+	sys.pool = &nxdomainResolver{
+		cnameResolver: &cnameResolver{
+			countingResolver: pool,
+			aliases: map[string]string{
+				"api.owasp.org":  "owasp-prod.herokudns.com",
+				"docs.owasp.org": "owasp.github.io",
+				"app.owasp.org":  "edge.owasp.org",
+				"edge.owasp.org": "lb.example.net",
+			},
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"api.owasp.org", "docs.owasp.org", "app.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	var lock sync.Mutex
+	var logs []string
+	collect := func(msg string) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		logs = append(logs, msg)
+	}
+	e.Bus.Subscribe(requests.LogTopic, collect)
+	defer e.Bus.Unsubscribe(requests.LogTopic, collect)
+	requests.WaitForSubscriptions(context.Background(), e.Bus)
+
+	if err := e.EnablePhases(Resolution); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+	runResume(t, e)
+
+	uuid := e.Config.UUID.String()
+	tests := []struct {
+		name     string
+		targets  string
+		flag     string
+		provider string
+	}{
+		{"api.owasp.org", "owasp-prod.herokudns.com", ChainNXDOMAIN, "Heroku"},
+		{"docs.owasp.org", "owasp.github.io", ChainProvider, "GitHub Pages"},
+		// The link outside of scope is stored from the answer
+		{"app.owasp.org", "edge.owasp.org,lb.example.net", "", ""},
+	}
+	for _, test := range tests {
+		chain := ReadCNAMEChain(e.Graph, uuid, test.name)
+		if chain == nil {
+			t.Errorf("The CNAME chain of %s was not stored", test.name)
+			continue
+		}
+		if targets := strings.Join(chain.Targets, ","); targets != test.targets ||
+			chain.Flag != test.flag || chain.Provider != test.provider {
+			t.Errorf("Unexpected CNAME chain of %s: %+v", test.name, chain)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	var reported bool
+	for _, msg := range logs {
+		if strings.Contains(msg, "CNAME chain of api.owasp.org terminates in NXDOMAIN at owasp-prod.herokudns.com (Heroku)") {
+			reported = true
+		}
+	}
+	if !reported {
+		t.Errorf("The dangling CNAME chain was not reported: %v", logs)
+	}
+}
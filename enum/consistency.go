@@ -0,0 +1,325 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/caffix/stringset"
+	"github.com/cayleygraph/quad"
+	"github.com/miekg/dns"
+)
+
+// The properties of FQDN nodes that hold the outcome of verifying the answers across distinct
+// resolvers. The answer sets are only stored for the names with answers that disagree.
+const (
+	ConsistencyPredicate = "answer_consistency"
+	AnswerSetPredicate   = "answer_set"
+)
+
+// The outcomes of verifying the answers for a name across distinct resolvers.
+const (
+	ConsistencyAgreed       = "agreed"
+	ConsistencyDisagreed    = "disagreed"
+	ConsistencyInconclusive = "inconclusive"
+)
+
+// The number of distinct resolvers that answer for each verified name.
+const consistencyResolvers = 3
+
+// The record types queried for each verified name.
+var consistencyTypes = []uint16{dns.TypeA, dns.TypeAAAA}
+
+// NameConsistency is the outcome of verifying the answers for a name across distinct resolvers.
+type NameConsistency struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// The normalized answers from each resolver that responded, keyed by the resolver address
+	Answers map[string][]string `json:"answers"`
+}
+
+// ConsistencyReport summarizes the verification of the answers across distinct resolvers,
+// including the query cost of the verification.
+type ConsistencyReport struct {
+	Resolvers    []string `json:"resolvers"`
+	Names        int      `json:"names"`
+	Disagreed    int      `json:"disagreed"`
+	Inconclusive int      `json:"inconclusive"`
+	// The queries sent, which never exceed the maximum allowed by the selected names
+	Queries    int `json:"queries"`
+	MaxQueries int `json:"max_queries"`
+}
+
+// consistencyState keeps the outcome of the verification for the enumeration.
+type consistencyState struct {
+	sync.Mutex
+	report  *ConsistencyReport
+	results []*NameConsistency
+}
+
+// newTrustedResolver returns the resolver used to query the address directly.
+func (e *Enumeration) newTrustedResolver(addr string) resolve.Resolver {
+	if e.trustedResolver != nil {
+		return e.trustedResolver(addr)
+	}
+	return systems.NewTrustedResolver(e.Config, addr, config.DefaultQueriesPerBaselineResolver)
+}
+
+// verifyConsistency queries the apex domain names and the names reported by the most data sources
+// through distinct resolvers, bypassing the resolver pool, and records whether the answers agree.
+func (e *Enumeration) verifyConsistency(ctx context.Context) {
+	if !e.Config.VerifyConsistency || e.Config.Passive {
+		return
+	}
+
+	var addrs []string
+	seen := stringset.New()
+	for _, addr := range systems.TrustedResolverAddrs(e.Config) {
+		if len(addrs) < consistencyResolvers && !seen.Has(addr) {
+			seen.Insert(addr)
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) < consistencyResolvers {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("Consistency: %d distinct "+
+			"resolvers are required to verify the answers, only %d are available", consistencyResolvers, len(addrs)))
+		return
+	}
+
+	var rs []resolve.Resolver
+	for _, addr := range addrs {
+		r := e.newTrustedResolver(addr)
+		if r == nil {
+			e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+				fmt.Sprintf("Consistency: Failed to setup the resolver %s", addr))
+			return
+		}
+		defer r.Stop()
+		rs = append(rs, r)
+	}
+
+	names := e.consistencyNames()
+	report := &ConsistencyReport{
+		Resolvers:  addrs,
+		MaxQueries: len(names) * len(rs) * len(consistencyTypes),
+	}
+
+	var results []*NameConsistency
+	for _, name := range names {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		nc, queries := verifyName(ctx, name, addrs, rs)
+		report.Queries += queries
+		report.Names++
+		switch nc.Status {
+		case ConsistencyDisagreed:
+			report.Disagreed++
+		case ConsistencyInconclusive:
+			report.Inconclusive++
+		}
+
+		e.storeConsistency(nc)
+		results = append(results, nc)
+	}
+
+	e.consistency.Lock()
+	e.consistency.report = report
+	e.consistency.results = results
+	e.consistency.Unlock()
+
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("Consistency: Verified %d names "+
+		"across %d resolvers using %d of %d queries: %d disagreed and %d were inconclusive", report.Names,
+		len(rs), report.Queries, report.MaxQueries, report.Disagreed, report.Inconclusive))
+}
+
+// consistencyNames returns the apex domain names, followed by no more than ConsistencyNames of the
+// other names, selected by the number of data sources that reported them.
+func (e *Enumeration) consistencyNames() []string {
+	uuid := e.Config.UUID.String()
+	apexes := e.Config.Domains()
+	sort.Strings(apexes)
+
+	type candidate struct {
+		name    string
+		sources int
+	}
+
+	var others []candidate
+	if e.Config.ConsistencyNames > 0 {
+		apexSet := stringset.New(apexes...)
+
+		for _, name := range e.Graph.EventFQDNs(uuid) {
+			if apexSet.Has(name) || !e.Config.IsDomainInScope(name) {
+				continue
+			}
+			if srcs, err := e.Graph.NodeSources(netmap.Node(name), uuid); err == nil {
+				others = append(others, candidate{name: name, sources: len(srcs)})
+			}
+		}
+	}
+
+	sort.Slice(others, func(i, j int) bool {
+		if others[i].sources != others[j].sources {
+			return others[i].sources > others[j].sources
+		}
+		return others[i].name < others[j].name
+	})
+	if len(others) > e.Config.ConsistencyNames {
+		others = others[:e.Config.ConsistencyNames]
+	}
+
+	names := apexes
+	for _, c := range others {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// verifyName sends each query for the name once to every resolver, and returns the outcome
+// along with the number of queries sent.
+func verifyName(ctx context.Context, name string, addrs []string, rs []resolve.Resolver) (*NameConsistency, int) {
+	var wg sync.WaitGroup
+	answers := make([][]string, len(rs))
+	responded := make([]bool, len(rs))
+	queries := make([]int, len(rs))
+
+	for i, r := range rs {
+		wg.Add(1)
+
+		go func(i int, r resolve.Resolver) {
+			defer wg.Done()
+
+			set := stringset.New()
+			for _, t := range consistencyTypes {
+				queries[i]++
+				// The query is not retried, which bounds the cost of the verification
+				resp, err := r.Query(ctx, resolve.QueryMsg(name, t), resolve.PriorityHigh, nil)
+
+				var rerr *resolve.ResolveError
+				if err != nil && !(errors.As(err, &rerr) && rerr.Rcode == dns.RcodeNameError) {
+					return
+				}
+				if err == nil && resp != nil {
+					for _, a := range resolve.AnswersByType(resolve.ExtractAnswers(resp), t) {
+						set.Insert(strings.ToLower(strings.Trim(a.Data, ".")))
+					}
+				}
+			}
+
+			answers[i] = set.Slice()
+			sort.Strings(answers[i])
+			responded[i] = true
+		}(i, r)
+	}
+	wg.Wait()
+
+	nc := &NameConsistency{
+		Name:    name,
+		Status:  ConsistencyAgreed,
+		Answers: make(map[string][]string),
+	}
+
+	var total int
+	first := -1
+	for i := range rs {
+		total += queries[i]
+		if !responded[i] {
+			continue
+		}
+
+		nc.Answers[addrs[i]] = answers[i]
+		if first < 0 {
+			first = i
+		} else if strings.Join(answers[i], ",") != strings.Join(answers[first], ",") {
+			nc.Status = ConsistencyDisagreed
+		}
+	}
+	if len(nc.Answers) < 2 {
+		nc.Status = ConsistencyInconclusive
+	}
+	return nc, total
+}
+
+// storeConsistency attaches the outcome of the verification to the FQDN node of the name.
+func (e *Enumeration) storeConsistency(nc *NameConsistency) {
+	node, err := e.Graph.ReadNode(nc.Name, netmap.TypeFQDN)
+	if err != nil {
+		return
+	}
+
+	if err := e.graphFailure(e.Graph.UpsertProperty(node, ConsistencyPredicate, nc.Status)); err != nil {
+		return
+	}
+	if nc.Status != ConsistencyDisagreed {
+		return
+	}
+
+	for addr, answers := range nc.Answers {
+		set := addr + "=" + strings.Join(answers, ",")
+
+		if err := e.graphFailure(e.Graph.UpsertProperty(node, AnswerSetPredicate, set)); err != nil {
+			return
+		}
+	}
+}
+
+// ConsistencyReport returns the summary of the answer verification, or nil when the verification
+// was not performed.
+func (e *Enumeration) ConsistencyReport() *ConsistencyReport {
+	e.consistency.Lock()
+	defer e.consistency.Unlock()
+
+	return e.consistency.report
+}
+
+// ConsistencyResults returns the outcome of the answer verification for each verified name.
+func (e *Enumeration) ConsistencyResults() []*NameConsistency {
+	e.consistency.Lock()
+	defer e.consistency.Unlock()
+
+	return append([]*NameConsistency(nil), e.consistency.results...)
+}
+
+// ReadConsistency returns the outcome of the answer verification stored on the FQDN node, and the
+// sorted answer sets of the resolvers when the answers disagreed.
+func ReadConsistency(g *netmap.Graph, name string) (string, []string) {
+	node, err := g.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return "", nil
+	}
+
+	props, err := g.ReadProperties(node, ConsistencyPredicate, AnswerSetPredicate)
+	if err != nil {
+		return "", nil
+	}
+
+	var status string
+	var sets []string
+	for _, p := range props {
+		switch p.Predicate {
+		case ConsistencyPredicate:
+			status = quad.ToString(p.Value)
+		case AnswerSetPredicate:
+			sets = append(sets, quad.ToString(p.Value))
+		}
+	}
+
+	sort.Strings(sets)
+	return status, sets
+}
@@ -0,0 +1,101 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/resolve"
+)
+
+func TestVerifyConsistency(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	// SetResolvers does not preserve the order of the resolvers
+	cfg.Resolvers = []string{"192.0.2.1", "192.0.2.2", "192.0.2.2", "192.0.2.3", "192.0.2.4"}
+	cfg.VerifyConsistency = true
+	cfg.ConsistencyNames = 2
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	// The third resolver returns a different address for www.owasp.org
+	answers := map[string]map[string]string{
+		"192.0.2.1": {"owasp.org": "72.237.4.113", "www.owasp.org": "72.237.4.113", "mail.owasp.org": "72.237.4.114"},
+		"192.0.2.2": {"owasp.org": "72.237.4.113", "www.owasp.org": "72.237.4.113", "mail.owasp.org": "72.237.4.114"},
+		"192.0.2.3": {"owasp.org": "72.237.4.113", "www.owasp.org": "10.0.0.1", "mail.owasp.org": "72.237.4.114"},
+	}
+	e.trustedResolver = func(addr string) resolve.Resolver {
+		if addrs, found := answers[addr]; found {
+			return &mockResolver{addrs: addrs}
+		}
+		t.Errorf("The verification used the unexpected resolver %s", addr)
+		return nil
+	}
+
+	uuid := cfg.UUID.String()
+	for name, srcs := range map[string][]string{
+		"owasp.org":      {"DNS"},
+		"www.owasp.org":  {"DNS", "Mock", "Other"},
+		"mail.owasp.org": {"DNS", "Mock"},
+		// Reported by the fewest data sources, so the name is not selected
+		"ftp.owasp.org": {"DNS"},
+	} {
+		for _, src := range srcs {
+			if _, err := e.Graph.UpsertFQDN(name, src, uuid); err != nil {
+				t.Fatalf("Failed to insert %s: %v", name, err)
+			}
+		}
+	}
+
+	e.verifyConsistency(context.Background())
+
+	report := e.ConsistencyReport()
+	if report == nil {
+		t.Fatal("The verification did not produce a report")
+	}
+	if report.Names != 3 || report.Disagreed != 1 || report.Inconclusive != 0 {
+		t.Errorf("Unexpected consistency report: %+v", report)
+	}
+	if report.Queries != 18 || report.MaxQueries != 18 || len(report.Resolvers) != 3 {
+		t.Errorf("Expected 18 queries across three distinct resolvers: %+v", report)
+	}
+
+	results := e.ConsistencyResults()
+	if len(results) != 3 || results[0].Name != "owasp.org" || results[1].Name != "www.owasp.org" || results[2].Name != "mail.owasp.org" {
+		t.Fatalf("Unexpected names verified: %+v", results)
+	}
+
+	if status, sets := ReadConsistency(e.Graph, "www.owasp.org"); status != ConsistencyDisagreed || len(sets) != 3 ||
+		sets[2] != "192.0.2.3=10.0.0.1" {
+		t.Errorf("Unexpected consistency of www.owasp.org: %s %v", status, sets)
+	}
+	if status, sets := ReadConsistency(e.Graph, "owasp.org"); status != ConsistencyAgreed || len(sets) != 0 {
+		t.Errorf("Unexpected consistency of owasp.org: %s %v", status, sets)
+	}
+	if status, _ := ReadConsistency(e.Graph, "ftp.owasp.org"); status != "" {
+		t.Errorf("The name beyond the cap was verified: %s", status)
+	}
+}
+
+func TestVerifyConsistencyResolvers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.SetResolvers("192.0.2.1", "192.0.2.2")
+	cfg.VerifyConsistency = true
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+	e.trustedResolver = func(addr string) resolve.Resolver {
+		t.Errorf("The resolver %s was created without three distinct resolvers", addr)
+		return nil
+	}
+
+	e.verifyConsistency(context.Background())
+	if e.ConsistencyReport() != nil {
+		t.Error("The answers were verified without three distinct resolvers")
+	}
+}
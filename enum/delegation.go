@@ -0,0 +1,190 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/caffix/stringset"
+	"github.com/miekg/dns"
+)
+
+// The property predicates used to store delegation findings on zone nodes.
+const (
+	delegationMismatchPredicate = "delegation_mismatch"
+	missingGluePredicate        = "missing_glue"
+)
+
+// DelegationFinding describes an inconsistency between the delegation of a zone in the
+// parent zone and the NS records served by the zone itself.
+type DelegationFinding struct {
+	Zone string `json:"zone"`
+	// Name servers only listed in the parent zone delegation
+	ParentOnly []string `json:"parent_only,omitempty"`
+	// Name servers only listed in the NS records of the child zone
+	ChildOnly []string `json:"child_only,omitempty"`
+	// In-bailiwick name servers without glue records in the parent zone
+	MissingGlue []string `json:"missing_glue,omitempty"`
+}
+
+// DelegationFindings returns the delegation inconsistencies discovered during the enumeration.
+func (e *Enumeration) DelegationFindings() []*DelegationFinding {
+	e.delegationLock.Lock()
+	defer e.delegationLock.Unlock()
+
+	findings := append([]*DelegationFinding(nil), e.delegations...)
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Zone < findings[j].Zone
+	})
+	return findings
+}
+
+// submitDelegationCheck checks the delegation of the zone without blocking the caller.
+func (dt *dNSTask) submitDelegationCheck(ctx context.Context, zone string, childNS []string) {
+	dt.delegations.submit(ctx, func() {
+		dt.checkDelegation(ctx, zone, childNS)
+	})
+}
+
+// waitDelegationChecks blocks until the submitted zones have had their delegation checked.
+func (dt *dNSTask) waitDelegationChecks() {
+	dt.delegations.wait()
+}
+
+// checkDelegation compares the NS records of the zone with the delegation served by the
+// authoritative servers of the parent zone. The queries are sent directly to the parent
+// zone servers, so the check is only performed in active mode.
+func (dt *dNSTask) checkDelegation(ctx context.Context, zone string, childNS []string) {
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil || !cfg.Active || len(childNS) == 0 {
+		return
+	}
+
+	parent := parentZone(zone)
+	if parent == "" {
+		return
+	}
+
+	msg := resolve.QueryMsg(parent, dns.TypeNS)
+	resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy)
+	if err != nil {
+		dt.handleResolverError(ctx, err)
+		return
+	}
+
+	var referral *dns.Msg
+	for _, a := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeNS) {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		addr, err := nameserverAddr(ctx, dt.enum.pool(), a.Data)
+		if err != nil {
+			continue
+		}
+
+		m := resolve.QueryMsg(zone, dns.TypeNS)
+		// The parent zone servers provide a referral rather than recursion
+		m.RecursionDesired = false
+		if r, err := directQuery(ctx, addr, m); err == nil && r.Rcode == dns.RcodeSuccess {
+			referral = r
+			break
+		}
+	}
+	if referral == nil {
+		return
+	}
+
+	finding := compareDelegation(zone, referral, childNS)
+	if finding == nil {
+		return
+	}
+
+	node := netmap.Node(zone)
+	for _, ns := range finding.ParentOnly {
+		_ = dt.enum.graphFailure(dt.enum.Graph.UpsertProperty(node, delegationMismatchPredicate, "parent_only:"+ns))
+	}
+	for _, ns := range finding.ChildOnly {
+		_ = dt.enum.graphFailure(dt.enum.Graph.UpsertProperty(node, delegationMismatchPredicate, "child_only:"+ns))
+	}
+	for _, ns := range finding.MissingGlue {
+		_ = dt.enum.graphFailure(dt.enum.Graph.UpsertProperty(node, missingGluePredicate, ns))
+	}
+
+	dt.enum.delegationLock.Lock()
+	dt.enum.delegations = append(dt.enum.delegations, finding)
+	dt.enum.delegationLock.Unlock()
+
+	bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogWarning,
+		"The delegation of zone %s is inconsistent with the parent zone %s", zone, parent).With(requests.LogFieldDomain, zone))
+}
+
+// compareDelegation returns the differences between the referral from the parent zone and
+// the NS records of the child zone, or nil when the delegation is consistent.
+func compareDelegation(zone string, referral *dns.Msg, childNS []string) *DelegationFinding {
+	parentNS := stringset.New()
+	for _, rr := range append(referral.Ns, referral.Answer...) {
+		if ns, ok := rr.(*dns.NS); ok && strings.EqualFold(resolve.RemoveLastDot(ns.Hdr.Name), zone) {
+			parentNS.Insert(strings.ToLower(resolve.RemoveLastDot(ns.Ns)))
+		}
+	}
+	if parentNS.Len() == 0 {
+		return nil
+	}
+
+	glue := stringset.New()
+	for _, rr := range referral.Extra {
+		switch v := rr.(type) {
+		case *dns.A:
+			glue.Insert(strings.ToLower(resolve.RemoveLastDot(v.Hdr.Name)))
+		case *dns.AAAA:
+			glue.Insert(strings.ToLower(resolve.RemoveLastDot(v.Hdr.Name)))
+		}
+	}
+
+	child := stringset.New()
+	for _, ns := range childNS {
+		child.Insert(strings.ToLower(resolve.RemoveLastDot(ns)))
+	}
+
+	finding := &DelegationFinding{Zone: zone}
+	for _, ns := range parentNS.Slice() {
+		if !child.Has(ns) {
+			finding.ParentOnly = append(finding.ParentOnly, ns)
+		}
+		// Name servers within the delegated zone cannot be resolved without glue
+		if (ns == zone || strings.HasSuffix(ns, "."+zone)) && !glue.Has(ns) {
+			finding.MissingGlue = append(finding.MissingGlue, ns)
+		}
+	}
+	for _, ns := range child.Slice() {
+		if !parentNS.Has(ns) {
+			finding.ChildOnly = append(finding.ChildOnly, ns)
+		}
+	}
+
+	if len(finding.ParentOnly) == 0 && len(finding.ChildOnly) == 0 && len(finding.MissingGlue) == 0 {
+		return nil
+	}
+
+	sort.Strings(finding.ParentOnly)
+	sort.Strings(finding.ChildOnly)
+	sort.Strings(finding.MissingGlue)
+	return finding
+}
+
+func parentZone(zone string) string {
+	if idx := strings.Index(zone, "."); idx >= 0 && idx < len(zone)-1 {
+		return zone[idx+1:]
+	}
+	return ""
+}
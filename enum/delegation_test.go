@@ -0,0 +1,57 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestCompareDelegation(t *testing.T) {
+	referral := new(dns.Msg)
+	for _, rr := range []string{
+		"owasp.org. 3600 IN NS ns1.owasp.org.",
+		"owasp.org. 3600 IN NS ns2.owasp.org.",
+		"owasp.org. 3600 IN NS ns.example.net.",
+	} {
+		r, _ := dns.NewRR(rr)
+		referral.Ns = append(referral.Ns, r)
+	}
+	glue, _ := dns.NewRR("ns1.owasp.org. 3600 IN A 192.168.1.1")
+	referral.Extra = append(referral.Extra, glue)
+
+	finding := compareDelegation("owasp.org", referral, []string{"ns1.owasp.org.", "ns2.owasp.org", "ns3.owasp.org"})
+	if finding == nil {
+		t.Fatal("The inconsistent delegation was not detected")
+	}
+	if !reflect.DeepEqual(finding.ParentOnly, []string{"ns.example.net"}) {
+		t.Errorf("Unexpected parent only name servers: %v", finding.ParentOnly)
+	}
+	if !reflect.DeepEqual(finding.ChildOnly, []string{"ns3.owasp.org"}) {
+		t.Errorf("Unexpected child only name servers: %v", finding.ChildOnly)
+	}
+	if !reflect.DeepEqual(finding.MissingGlue, []string{"ns2.owasp.org"}) {
+		t.Errorf("Unexpected name servers missing glue: %v", finding.MissingGlue)
+	}
+
+	consistent := compareDelegation("owasp.org", referral, []string{"ns1.owasp.org", "ns2.owasp.org", "ns.example.net"})
+	if consistent == nil || len(consistent.ParentOnly) != 0 || len(consistent.ChildOnly) != 0 {
+		t.Errorf("Unexpected mismatches for matching NS sets: %+v", consistent)
+	}
+}
+
+func TestParentZone(t *testing.T) {
+	for zone, expected := range map[string]string{
+		"owasp.org":     "org",
+		"dev.owasp.org": "owasp.org",
+		"org":           "",
+		"":              "",
+	} {
+		if p := parentZone(zone); p != expected {
+			t.Errorf("Expected the parent of %q to be %q, got %q", zone, expected, p)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// ArtifactDelivery is the kind of the delivery journal artifact described by the Manifest.
+const ArtifactDelivery = "delivery"
+
+// The destinations of the output records delivered by the amass enum command.
+const (
+	DeliveryJSON   = "json"
+	DeliveryStdout = "stdout"
+	DeliveryText   = "text"
+)
+
+// DeliveryRecord is an entry of the DeliveryJournal: the output record for the name was delivered
+// to the destination during the enumeration identified by the event.
+type DeliveryRecord struct {
+	Name        string    `json:"name"`
+	Event       string    `json:"event"`
+	Destination string    `json:"destination"`
+	Delivered   time.Time `json:"delivered"`
+}
+
+// DeliveryJournal is the append-only file of the output records delivered to each destination,
+// which allows later enumerations to only deliver the records that were not delivered before.
+// A record is appended once the delivery has completed, so a crash can repeat the delivery of
+// the record in flight, but the records journaled before the crash are not delivered again.
+type DeliveryJournal struct {
+	sync.Mutex
+	path      string
+	file      *os.File
+	delivered map[string]*DeliveryRecord
+	// The lines of the file, including the duplicate and incomplete lines
+	lines int
+}
+
+func deliveryKey(dest, name string) string {
+	return dest + " " + strings.ToLower(name)
+}
+
+// OpenDeliveryJournal loads the records of the delivery journal at the path provided, creating
+// the file when it does not exist. The line left incomplete by a crash is discarded, and the file
+// is compacted when it holds lines that are no longer needed.
+func OpenDeliveryJournal(path string) (*DeliveryJournal, error) {
+	j := &DeliveryJournal{
+		path:      path,
+		delivered: make(map[string]*DeliveryRecord),
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	if j.lines > len(j.delivered) {
+		if err := j.Compact(); err != nil {
+			return nil, err
+		}
+		return j, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = f
+	return j, nil
+}
+
+func (j *DeliveryJournal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		j.lines++
+
+		var rec DeliveryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Name == "" || rec.Destination == "" {
+			continue
+		}
+		j.delivered[deliveryKey(rec.Destination, rec.Name)] = &rec
+	}
+	return scanner.Err()
+}
+
+// Delivered returns true when the output record for the name has been delivered to the destination.
+func (j *DeliveryJournal) Delivered(dest, name string) bool {
+	j.Lock()
+	defer j.Unlock()
+
+	_, found := j.delivered[deliveryKey(dest, name)]
+	return found
+}
+
+// Record appends the delivery of the output record for the name to the journal.
+func (j *DeliveryJournal) Record(dest, name, event string) error {
+	j.Lock()
+	defer j.Unlock()
+
+	key := deliveryKey(dest, name)
+	if _, found := j.delivered[key]; found {
+		return nil
+	}
+	if j.file == nil {
+		return errors.New("The delivery journal has been closed")
+	}
+
+	rec := &DeliveryRecord{
+		Name:        name,
+		Event:       event,
+		Destination: dest,
+		Delivered:   time.Now().UTC(),
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// Each record is written as a single line, so a crash can only leave the last line incomplete
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	j.delivered[key] = rec
+	j.lines++
+	return nil
+}
+
+// Records returns the records of the journal sorted by destination and name.
+func (j *DeliveryJournal) Records() []*DeliveryRecord {
+	j.Lock()
+	defer j.Unlock()
+
+	return j.sortedRecords()
+}
+
+func (j *DeliveryJournal) sortedRecords() []*DeliveryRecord {
+	recs := make([]*DeliveryRecord, 0, len(j.delivered))
+	for _, rec := range j.delivered {
+		r := *rec
+		recs = append(recs, &r)
+	}
+
+	sort.Slice(recs, func(i, k int) bool {
+		if recs[i].Destination != recs[k].Destination {
+			return recs[i].Destination < recs[k].Destination
+		}
+		return recs[i].Name < recs[k].Name
+	})
+	return recs
+}
+
+// Compact rewrites the journal with a single line for each delivered record, removing the
+// duplicate lines and the lines left incomplete by a crash. The journal is only replaced once
+// the new file has been completely written.
+func (j *DeliveryJournal) Compact() error {
+	j.Lock()
+	defer j.Unlock()
+
+	dir := filepath.Dir(j.path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, rec := range j.sortedRecords() {
+		if err := enc.Encode(rec); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	j.file = f
+	j.lines = len(j.delivered)
+	return nil
+}
+
+// Close writes the journal to stable storage and closes the file.
+func (j *DeliveryJournal) Close() error {
+	j.Lock()
+	defer j.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	_ = j.file.Sync()
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// ResetDeliveryJournal removes the delivery journal at the path provided, so that every output
+// record is delivered again by the next enumeration.
+func ResetDeliveryJournal(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// deliveryState tracks the records suppressed by the delivery journal of the enumeration.
+type deliveryState struct {
+	sync.Mutex
+	journal    *DeliveryJournal
+	suppressed map[string]int
+}
+
+// OpenDeliveryJournal opens the delivery journal at the path provided for the enumeration. The
+// output records already journaled for a destination are not delivered to it again, but remain in
+// the enumeration graph. The journal is closed by Close.
+func (e *Enumeration) OpenDeliveryJournal(path string) error {
+	j, err := OpenDeliveryJournal(path)
+	if err != nil {
+		return err
+	}
+
+	e.delivery.Lock()
+	old := e.delivery.journal
+	e.delivery.journal = j
+	e.delivery.Unlock()
+
+	if old != nil {
+		_ = old.Close()
+	}
+	e.AddArtifact(ArtifactDelivery, path)
+	return nil
+}
+
+// DeliverOutput calls the deliver function with the output record, unless the delivery journal
+// shows the record was already delivered to the destination. The delivery is journaled once the
+// deliver function returns without an error.
+func (e *Enumeration) DeliverOutput(dest string, o *requests.Output, deliver func(*requests.Output) error) error {
+	if o == nil {
+		return nil
+	}
+
+	e.delivery.Lock()
+	j := e.delivery.journal
+	if j != nil && j.Delivered(dest, o.Name) {
+		if e.delivery.suppressed == nil {
+			e.delivery.suppressed = make(map[string]int)
+		}
+		e.delivery.suppressed[dest]++
+		e.delivery.Unlock()
+		return nil
+	}
+	e.delivery.Unlock()
+
+	if err := deliver(o); err != nil {
+		return err
+	}
+	if j != nil {
+		return j.Record(dest, o.Name, e.Config.UUID.String())
+	}
+	return nil
+}
+
+// SuppressedOutput returns the number of output records that were not delivered to each
+// destination, since the delivery journal shows they were delivered by an earlier enumeration.
+func (e *Enumeration) SuppressedOutput() map[string]int {
+	e.delivery.Lock()
+	defer e.delivery.Unlock()
+
+	suppressed := make(map[string]int, len(e.delivery.suppressed))
+	for dest, count := range e.delivery.suppressed {
+		suppressed[dest] = count
+	}
+	return suppressed
+}
+
+func (e *Enumeration) closeDeliveryJournal() {
+	e.delivery.Lock()
+	j := e.delivery.journal
+	e.delivery.journal = nil
+	e.delivery.Unlock()
+
+	if j != nil {
+		_ = j.Close()
+	}
+}
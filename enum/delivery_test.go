@@ -0,0 +1,210 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// deliveryRun performs an enumeration over the mock data with the delivery journal, and delivers
+// the output records for the names selected to the text destination and the JSON output.
+func deliveryRun(t *testing.T, path string, selected func(string) bool) (*Enumeration, []string, *bytes.Buffer) {
+	sys := newPhaseSystem(newPhaseSource("API", requests.API))
+	t.Cleanup(func() { stopSources(sys) })
+
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, sys)
+	if err := e.OpenDeliveryJournal(path); err != nil {
+		t.Fatalf("Failed to open the delivery journal: %v", err)
+	}
+	runPhases(t, e, Sources, Resolution)
+	// The JSON output is set once the enumeration is done, so only the selected records are written
+	buf := new(bytes.Buffer)
+	e.JSONOutput = buf
+
+	names := e.Graph.EventFQDNs(cfg.UUID.String())
+	sort.Strings(names)
+
+	var text []string
+	for _, name := range names {
+		if !e.Config.IsDomainInScope(name) || !selected(name) {
+			continue
+		}
+
+		o := &requests.Output{Name: name, Domain: "owasp.org"}
+		if err := e.DeliverOutput(DeliveryText, o, func(o *requests.Output) error {
+			text = append(text, o.Name)
+			return nil
+		}); err != nil {
+			t.Fatalf("Failed to deliver %s: %v", name, err)
+		}
+		if err := e.writeJSONOutput(o); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	_ = e.FlushJSONOutput()
+	return e, text, buf
+}
+
+func TestDeliveryJournalCrashReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delivery.jsonl")
+
+	// The first run crashes after delivering the output record for the root domain name
+	e1, text, _ := deliveryRun(t, path, func(name string) bool { return name == "owasp.org" })
+	if len(text) != 1 {
+		t.Fatalf("The first run delivered unexpected records: %v", text)
+	}
+	// The journal is not closed, and the record in flight is left incomplete
+	e1.delivery.Lock()
+	crashed := e1.delivery.journal
+	e1.delivery.journal = nil
+	e1.delivery.Unlock()
+	defer crashed.file.Close()
+	e1.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open the journal: %v", err)
+	}
+	_, _ = f.WriteString(`{"name":"www.owasp.org","event":"`)
+	f.Close()
+
+	// The second run delivers the same mock data
+	e2, text, buf := deliveryRun(t, path, func(string) bool { return true })
+	defer e2.Close()
+
+	if len(text) != 1 || text[0] != "www.owasp.org" {
+		t.Errorf("The second run delivered unexpected text records: %v", text)
+	}
+	if out := buf.String(); strings.Contains(out, `"name":"owasp.org"`) || !strings.Contains(out, `"name":"www.owasp.org"`) {
+		t.Errorf("The second run wrote unexpected JSON records: %s", out)
+	}
+	if s := e2.SuppressedOutput(); s[DeliveryText] != 1 || s[DeliveryJSON] != 1 {
+		t.Errorf("Unexpected suppressed records: %v", s)
+	}
+	// The suppressed records remain in the graph
+	if !hasName(e2, "owasp.org") || !hasName(e2, "www.owasp.org") {
+		t.Error("The names of the second run are missing from the graph")
+	}
+
+	e2.closeDeliveryJournal()
+	j, err := OpenDeliveryJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open the journal after the second run: %v", err)
+	}
+	defer j.Close()
+
+	recs := j.Records()
+	if len(recs) != 4 {
+		t.Fatalf("Expected four journaled records, got %+v", recs)
+	}
+	for _, rec := range recs {
+		event := e2.Config.UUID.String()
+		if rec.Name == "owasp.org" {
+			event = e1.Config.UUID.String()
+		}
+		if rec.Event != event {
+			t.Errorf("The record %s for %s has the unexpected event %s", rec.Name, rec.Destination, rec.Event)
+		}
+	}
+	// The incomplete line was removed by the compaction when the journal was opened
+	if data, err := ioutil.ReadFile(path); err != nil || strings.Count(string(data), "\n") != 4 ||
+		!strings.HasSuffix(string(data), "\n") {
+		t.Errorf("The journal was not compacted: %q", data)
+	}
+}
+
+func TestDeliveryJournalFailedDelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delivery.jsonl")
+
+	cfg := config.NewConfig()
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+	if err := e.OpenDeliveryJournal(path); err != nil {
+		t.Fatalf("Failed to open the delivery journal: %v", err)
+	}
+
+	o := &requests.Output{Name: "www.owasp.org", Domain: "owasp.org"}
+	if err := e.DeliverOutput(DeliveryText, o, func(*requests.Output) error {
+		return errors.New("failed")
+	}); err == nil {
+		t.Error("The error of the failed delivery was not returned")
+	}
+
+	var delivered int
+	deliver := func(*requests.Output) error {
+		delivered++
+		return nil
+	}
+	// The failed delivery was not journaled, so the record is delivered once
+	for i := 0; i < 2; i++ {
+		_ = e.DeliverOutput(DeliveryText, o, deliver)
+	}
+	// Each destination is journaled separately
+	_ = e.DeliverOutput(DeliveryStdout, o, deliver)
+	if delivered != 2 {
+		t.Errorf("Expected the record to be delivered to two destinations, got %d deliveries", delivered)
+	}
+}
+
+func TestDeliveryJournalCompactAndReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delivery.jsonl")
+
+	// Duplicate lines are left by enumerations that share the journal concurrently
+	line := `{"name":"www.owasp.org","event":"1","destination":"text","delivered":"2021-04-01T00:00:00Z"}` + "\n"
+	if err := ioutil.WriteFile(path, []byte(line+line+line), 0644); err != nil {
+		t.Fatalf("Failed to write the journal: %v", err)
+	}
+
+	j, err := OpenDeliveryJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open the journal: %v", err)
+	}
+	if !j.Delivered(DeliveryText, "WWW.owasp.org") || j.Delivered(DeliveryJSON, "www.owasp.org") {
+		t.Error("Unexpected deliveries loaded from the journal")
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != line {
+		t.Errorf("The duplicate lines were not compacted: %q", data)
+	}
+
+	if err := j.Record(DeliveryJSON, "www.owasp.org", "2"); err != nil {
+		t.Fatalf("Failed to record the delivery: %v", err)
+	}
+	if err := j.Compact(); err != nil {
+		t.Fatalf("Failed to compact the journal: %v", err)
+	}
+	if err := j.Record(DeliveryStdout, "www.owasp.org", "2"); err != nil {
+		t.Fatalf("Failed to record the delivery after the compaction: %v", err)
+	}
+	j.Close()
+
+	if j, err = OpenDeliveryJournal(path); err != nil || len(j.Records()) != 3 {
+		t.Fatalf("Unexpected journal after the compaction: %v", err)
+	}
+	j.Close()
+
+	if err := ResetDeliveryJournal(path); err != nil {
+		t.Fatalf("Failed to reset the journal: %v", err)
+	}
+	if err := ResetDeliveryJournal(path); err != nil {
+		t.Errorf("Resetting the missing journal returned an error: %v", err)
+	}
+	if j, err = OpenDeliveryJournal(path); err != nil || len(j.Records()) != 0 {
+		t.Fatalf("The journal was not reset: %v", err)
+	}
+	j.Close()
+}
@@ -5,11 +5,16 @@ package enum
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 
 	amassdns "github.com/OWASP/Amass/v3/net/dns"
 	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/resolvers"
+	"github.com/OWASP/Amass/v3/scope"
 	"github.com/caffix/eventbus"
 	"github.com/caffix/pipeline"
 	"github.com/caffix/resolve"
@@ -24,14 +29,102 @@ var InitialQueryTypes = []uint16{
 	dns.TypeAAAA,
 }
 
+// The number of zones fingerprinted, and checked for delegation inconsistencies, at the same time.
+const maxZoneChecks = 10
+
 // dNSTask is the task that handles all DNS name resolution requests within the pipeline.
 type dNSTask struct {
-	enum *Enumeration
+	enum         *Enumeration
+	types        []uint16
+	zones        *zoneLimiter
+	fingerprints *zoneChecks
+	delegations  *zoneChecks
 }
 
 // newDNSTask returns a dNSTask specific to the provided Enumeration.
 func newDNSTask(e *Enumeration) *dNSTask {
-	return &dNSTask{enum: e}
+	return &dNSTask{
+		enum:         e,
+		types:        queryTypes(e.Config.QueryTypes),
+		zones:        newZoneLimiter(e.Config.MaxQueriesPerDomain),
+		fingerprints: newZoneChecks(),
+		delegations:  newZoneChecks(),
+	}
+}
+
+// zoneChecks runs the checks of the zones outside of the pipeline, since the checks send their
+// queries to the name servers directly, and waiting on them would stall the DNS task.
+type zoneChecks struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+func newZoneChecks() *zoneChecks {
+	return &zoneChecks{sem: make(chan struct{}, maxZoneChecks)}
+}
+
+// submit runs the check without blocking the caller, once fewer than maxZoneChecks are running.
+func (zc *zoneChecks) submit(ctx context.Context, check func()) {
+	zc.wg.Add(1)
+	go func() {
+		defer zc.wg.Done()
+
+		select {
+		case <-ctx.Done():
+			return
+		case zc.sem <- struct{}{}:
+		}
+		defer func() { <-zc.sem }()
+
+		// The slot may have been acquired after the context expired
+		if ctx.Err() == nil {
+			check()
+		}
+	}()
+}
+
+// wait blocks until the submitted checks have completed.
+func (zc *zoneChecks) wait() {
+	zc.wg.Wait()
+}
+
+// queryTypes returns the DNS record types queried for each discovered name. The CNAME type is
+// queried first, since its answers end the queries for the name. InitialQueryTypes is returned
+// when none of the configured types are valid.
+func queryTypes(names []string) []uint16 {
+	var cname bool
+	var types []uint16
+	for _, name := range names {
+		t, found := dns.StringToType[strings.ToUpper(strings.TrimSpace(name))]
+		if !found || t == dns.TypeNone {
+			continue
+		}
+
+		var dup bool
+		for _, existing := range types {
+			if existing == t {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+
+		if t == dns.TypeCNAME {
+			cname = true
+			continue
+		}
+		types = append(types, t)
+	}
+
+	if cname {
+		types = append([]uint16{dns.TypeCNAME}, types...)
+	}
+	if len(types) == 0 {
+		return InitialQueryTypes
+	}
+	return types
 }
 
 func (dt *dNSTask) makeBlacklistTaskFunc() pipeline.TaskFunc {
@@ -64,10 +157,11 @@ func (dt *dNSTask) makeBlacklistTaskFunc() pipeline.TaskFunc {
 			return data, nil
 		}
 
-		if name != "" && !dt.enum.Config.Blacklisted(name) {
+		if name != "" && dt.enum.Scope.Evaluate(name, nil).Reason != scope.ReasonBlacklisted {
 			return data, nil
 		}
 
+		dt.enum.releaseLane(data)
 		return nil, nil
 	})
 }
@@ -99,7 +193,13 @@ func (dt *dNSTask) makeRootTaskFunc() pipeline.TaskFunc {
 			return data, nil
 		}
 
+		// Names within special-use TLDs without resolvers are not queried
+		if dt.enum.Config.PassiveOnly(r.Name) {
+			return data, nil
+		}
+
 		dt.subdomainQueries(ctx, r, tp)
+		dt.apexQueries(ctx, r, tp)
 		dt.queryServiceNames(ctx, r, tp)
 		return data, nil
 	})
@@ -107,6 +207,9 @@ func (dt *dNSTask) makeRootTaskFunc() pipeline.TaskFunc {
 
 // Process implements the pipeline Task interface.
 func (dt *dNSTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.TaskParams) (pipeline.Data, error) {
+	// The DNS query slot of the lane is available once the data has been processed
+	defer dt.enum.releaseLane(data)
+
 	select {
 	case <-ctx.Done():
 		return nil, nil
@@ -115,6 +218,17 @@ func (dt *dNSTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 
 	switch v := data.(type) {
 	case *requests.DNSRequest:
+		if v != nil && dt.enum.Config.PassiveOnly(v.Name) {
+			dt.enum.storePassiveName(v)
+			return nil, nil
+		}
+		// The names provided with their records are not queried again
+		if v != nil && len(v.Records) > 0 {
+			return dt.processResolvedRequest(ctx, v)
+		}
+		if !dt.enum.phaseBudgets.Allow(Resolution) {
+			return nil, nil
+		}
 		return dt.processDNSRequest(ctx, v, tp)
 	case *requests.AddrRequest:
 		if dt.reverseDNSQuery(ctx, v.Address, tp) || v.InScope {
@@ -130,17 +244,36 @@ func (dt *dNSTask) processDNSRequest(ctx context.Context, req *requests.DNSReque
 	if req == nil || !req.Valid() {
 		return nil, nil
 	}
+	// The brute forced names are dropped once the subdomain has been truncated for a low hit rate
+	if req.Tag == requests.BRUTE && dt.enum.BruteTruncated(bruteSubdomain(req.Name)) {
+		dt.enum.trace(req.Name, TraceResolved, "", "brute forcing truncated")
+		return nil, nil
+	}
+	lane := nameLane(req.Tag)
+	// The name no longer exists when each query type returns NXDOMAIN from multiple resolvers
+	negative := true
+	var wildcard bool
+	// Set when the resolvers reported the name as blocked or filtered instead of answering
+	var blocked string
+	// The additional queries sent for the addresses rotated by a DNS load balancer
+	var samples int
 loop:
-	for _, t := range InitialQueryTypes {
+	for _, t := range dt.types {
 		select {
 		case <-ctx.Done():
+			negative = false
 			break loop
 		default:
 		}
+		if !dt.zones.wait(ctx, req.Name, req.Domain) {
+			negative = false
+			break loop
+		}
 
 		var nxdomain bool
 		msg := resolve.QueryMsg(req.Name, t)
-		resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityLow, func(times, priority int, m *dns.Msg) bool {
+		dt.enum.laneQuery(lane)
+		resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityLow, func(times, priority int, m *dns.Msg) bool {
 			// Try one more time if we receive NXDOMAIN
 			if m.Rcode == dns.RcodeNameError && !nxdomain {
 				nxdomain = true
@@ -149,10 +282,21 @@ loop:
 			return resolve.PoolRetryPolicy(times, priority, m)
 		})
 
+		if !confirmedNXDOMAIN(err, nxdomain) {
+			negative = false
+		}
+
 		if err == nil && resp != nil && len(resp.Answer) > 0 {
-			if !requests.TrustedTag(req.Tag) &&
-				dt.enum.Sys.Pool().WildcardType(ctx, resp, req.Domain) != resolve.WildcardTypeNone {
-				break
+			if !requests.TrustedTag(req.Tag) {
+				if wtype := dt.enum.pool().WildcardType(ctx, resp, req.Domain); wtype != resolve.WildcardTypeNone {
+					dt.enum.trace(req.Name, TraceWildcard, "", "filtered "+dns.TypeToString[t]+" answers")
+					dt.enum.wildcards.add(req.Name)
+					dt.enum.wildcardDetected(ctx, req, resp, wtype)
+					dt.enum.laneWildcard(lane)
+					wildcard = true
+					break
+				}
+				dt.enum.trace(req.Name, TraceWildcard, "", "passed "+dns.TypeToString[t]+" answers")
 			}
 
 			ans := resolve.ExtractAnswers(resp)
@@ -169,17 +313,51 @@ loop:
 			if t == dns.TypeCNAME {
 				break
 			}
+			if dt.rotating(resp, t, len(rr)) {
+				num, records := dt.sampleRotation(ctx, req, t, lane)
+				req.Records = append(req.Records, records...)
+				samples += num
+			}
 		} else {
 			if err != nil && err.Error() == "All resolvers have been stopped" {
 				return nil, err
 			}
+			// The enumeration gave up on the name, so the remaining types are not queried
+			var cerr *resolvers.ContextError
+			if errors.As(err, &cerr) {
+				break
+			}
+			if cat := blockedCategory(err); cat != "" {
+				blocked = cat
+			}
 			dt.handleResolverError(ctx, err)
 		}
 	}
 
+	if ctx.Err() == nil {
+		dt.enum.observeBrute(req, len(req.Records) > 0 && !wildcard)
+	}
 	if len(req.Records) > 0 {
+		if !wildcard {
+			dt.enum.words.add(req.Tag, req.Provenance)
+			dt.enum.laneResolved(lane)
+		}
+		if samples > 0 {
+			dt.enum.nameRotating(req, samples)
+		}
+		dt.enum.trace(req.Name, TraceResolved, "", fmt.Sprintf("%d records", len(req.Records)))
 		return req, nil
 	}
+	if blocked != "" {
+		dt.enum.nameBlocked(req, blocked)
+		dt.enum.trace(req.Name, TraceResolved, "", "reported as "+blocked+" by the resolvers")
+		return nil, nil
+	}
+	if negative {
+		dt.enum.nameDisappeared(req.Name, req.Domain)
+		dt.enum.cnameTargetMissing(req.Name)
+	}
+	dt.enum.trace(req.Name, TraceResolved, "", "no records")
 	return nil, nil
 }
 
@@ -194,21 +372,37 @@ func (dt *dNSTask) handleResolverError(ctx context.Context, e error) {
 		return
 	}
 
-	if rcode := rerr.Rcode; !cfg.Verbose && (rcode == resolve.TimeoutRcode ||
-		rcode == resolve.ResolverErrRcode || rcode == dns.RcodeNameError || rcode == dns.RcodeServerFailure) {
-		return
+	sev := requests.LogWarning
+	if rcode := rerr.Rcode; rcode == resolve.TimeoutRcode || rcode == resolvers.DeadlineRcode ||
+		rcode == resolvers.SpecialUseRcode || rcode == resolve.ResolverErrRcode ||
+		rcode == resolvers.BlockedRcode || rcode == resolvers.FilteredRcode ||
+		rcode == dns.RcodeNameError || rcode == dns.RcodeServerFailure {
+		if !cfg.Verbose {
+			return
+		}
+		sev = requests.LogInfo
 	}
 
-	bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("DNS: %v", e))
+	rcode := strconv.Itoa(rerr.Rcode)
+	if s, found := dns.RcodeToString[rerr.Rcode]; found {
+		rcode = s
+	}
+	bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		requests.NewLogEvent("DNS", sev, "%v", e).With(requests.LogFieldRcode, rcode))
 }
 
 func (dt *dNSTask) subdomainQueries(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) {
+	if !dt.zones.wait(ctx, req.Name, req.Domain) {
+		return
+	}
+
 	msg := resolve.QueryMsg(req.Name, dns.TypeNS)
 	// Obtain the DNS answers for the NS records related to the domain
-	if resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
+	if resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
 		ans := resolve.ExtractAnswers(resp)
 		rr := resolve.AnswersByType(ans, dns.TypeNS)
 
+		var servers []string
 		for _, a := range rr {
 			pipeline.SendData(ctx, "active", &requests.ZoneXFRRequest{
 				Name:   req.Name,
@@ -218,15 +412,26 @@ func (dt *dNSTask) subdomainQueries(ctx context.Context, req *requests.DNSReques
 				Source: "DNS",
 			}, tp)
 
+			servers = append(servers, a.Data)
 			req.Records = append(req.Records, convertAnswers([]*resolve.ExtractedAnswer{a})...)
 		}
+
+		if len(servers) > 0 {
+			dt.zones.addZone(req.Name, servers)
+			dt.submitFingerprint(ctx, req.Name, servers)
+			dt.submitDelegationCheck(ctx, req.Name, servers)
+		}
 	} else {
 		dt.handleResolverError(ctx, err)
 	}
 
+	if !dt.zones.wait(ctx, req.Name, req.Domain) {
+		return
+	}
+
 	msg = resolve.QueryMsg(req.Name, dns.TypeMX)
 	// Obtain the DNS answers for the MX records related to the domain
-	if resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
+	if resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
 		ans := resolve.ExtractAnswers(resp)
 		rr := resolve.AnswersByType(ans, dns.TypeMX)
 
@@ -235,9 +440,13 @@ func (dt *dNSTask) subdomainQueries(ctx context.Context, req *requests.DNSReques
 		dt.handleResolverError(ctx, err)
 	}
 
+	if !dt.zones.wait(ctx, req.Name, req.Domain) {
+		return
+	}
+
 	msg = resolve.QueryMsg(req.Name, dns.TypeSOA)
 	// Obtain the DNS answers for the SOA records related to the domain
-	if resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
+	if resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
 		ans := resolve.ExtractAnswers(resp)
 		rr := resolve.AnswersByType(ans, dns.TypeSOA)
 
@@ -251,9 +460,13 @@ func (dt *dNSTask) subdomainQueries(ctx context.Context, req *requests.DNSReques
 		dt.handleResolverError(ctx, err)
 	}
 
+	if !dt.zones.wait(ctx, req.Name, req.Domain) {
+		return
+	}
+
 	msg = resolve.QueryMsg(req.Name, dns.TypeSPF)
 	// Obtain the DNS answers for the SPF records related to the domain
-	if resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
+	if resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityHigh, resolve.PoolRetryPolicy); err == nil {
 		ans := resolve.ExtractAnswers(resp)
 		rr := resolve.AnswersByType(ans, dns.TypeSPF)
 
@@ -276,8 +489,12 @@ func (dt *dNSTask) queryServiceNames(ctx context.Context, req *requests.DNSReque
 		}
 
 		srvName := name + "." + req.Name
+		if !dt.zones.wait(ctx, srvName, req.Domain) {
+			return
+		}
+
 		msg := resolve.QueryMsg(srvName, dns.TypeSRV)
-		if resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityLow,
+		if resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityLow,
 			resolve.PoolRetryPolicy); err == nil && len(resp.Answer) > 0 {
 			ans := resolve.ExtractAnswers(resp)
 			if len(ans) == 0 {
@@ -300,7 +517,7 @@ func (dt *dNSTask) queryServiceNames(ctx context.Context, req *requests.DNSReque
 				continue
 			}
 
-			if dt.enum.Sys.Pool().WildcardType(ctx, resp, req.Domain) == resolve.WildcardTypeNone {
+			if dt.enum.pool().WildcardType(ctx, resp, req.Domain) == resolve.WildcardTypeNone {
 				pipeline.SendData(ctx, "filter", req, tp)
 			}
 		} else {
@@ -322,7 +539,9 @@ func (dt *dNSTask) reverseDNSQuery(ctx context.Context, addr string, tp pipeline
 	}
 
 	var nxdomain bool
-	resp, err := dt.enum.Sys.Pool().Query(ctx, msg, resolve.PriorityLow, func(times, priority int, m *dns.Msg) bool {
+	// The addresses are processed on the trusted lane
+	dt.enum.laneQuery(LaneTrusted)
+	resp, err := dt.enum.pool().Query(ctx, msg, resolve.PriorityLow, func(times, priority int, m *dns.Msg) bool {
 		// Try one more time if we receive NXDOMAIN
 		if m.Rcode == dns.RcodeNameError && !nxdomain {
 			nxdomain = true
@@ -354,7 +573,7 @@ func (dt *dNSTask) reverseDNSQuery(ctx context.Context, addr string, tp pipeline
 	}
 
 	// Check that the name discovered is in scope
-	d := dt.enum.Config.WhichDomain(answer)
+	d := dt.enum.Scope.Evaluate(answer, nil).Domain
 	if d == "" {
 		return false
 	}
@@ -0,0 +1,78 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestQueryTypes(t *testing.T) {
+	tests := []struct {
+		names    []string
+		expected []uint16
+	}{
+		{nil, InitialQueryTypes},
+		{[]string{"BOGUS", ""}, InitialQueryTypes},
+		{[]string{"A", "srv", " MX "}, []uint16{dns.TypeA, dns.TypeSRV, dns.TypeMX}},
+		{[]string{"A", "AAAA", "CNAME", "A"}, []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeAAAA}},
+		{[]string{"TXT", "bogus"}, []uint16{dns.TypeTXT}},
+	}
+
+	for _, test := range tests {
+		if got := queryTypes(test.names); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%v: expected %v, got %v", test.names, test.expected, got)
+		}
+	}
+}
+
+func TestZoneChecksLimit(t *testing.T) {
+	zc := newZoneChecks()
+
+	var running, most, completed int32
+	release := make(chan struct{})
+	for i := 0; i < 3*maxZoneChecks; i++ {
+		// The caller is not blocked while the checks wait on the name servers
+		zc.submit(context.Background(), func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&most)
+				if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+					break
+				}
+			}
+
+			<-release
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&completed, 1)
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	zc.wait()
+
+	if c := atomic.LoadInt32(&completed); c != 3*maxZoneChecks {
+		t.Errorf("The wait returned after %d of %d checks completed", c, 3*maxZoneChecks)
+	}
+	if m := atomic.LoadInt32(&most); m != maxZoneChecks {
+		t.Errorf("Expected %d checks running at the same time, got %d", maxZoneChecks, m)
+	}
+
+	// The checks are not run once the context has been cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ran bool
+	zc.submit(ctx, func() { ran = true })
+	zc.wait()
+	if ran {
+		t.Error("The check was run after the context was cancelled")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/stringset"
+)
+
+// The number of distinct labels collected from the resolved names during the enumeration.
+var maxDynamicLabels = 10000
+
+// DynamicWordlistManager collects the leftmost labels of the resolved names, so the brute forcing
+// of the other subdomains tries the labels already in use by the target first.
+type DynamicWordlistManager struct {
+	sync.Mutex
+	seen    stringset.Set
+	pending stringset.Set
+}
+
+// NewDynamicWordlistManager returns an initialized DynamicWordlistManager.
+func NewDynamicWordlistManager() *DynamicWordlistManager {
+	return &DynamicWordlistManager{
+		seen:    stringset.New(),
+		pending: stringset.New(),
+	}
+}
+
+// AddName collects the leftmost label of the name resolved under the domain. The root domain
+// names and the labels already collected are ignored.
+func (m *DynamicWordlistManager) AddName(name, domain string) {
+	name = strings.ToLower(strings.Trim(name, "."))
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	if domain == "" || !strings.HasSuffix(name, "."+domain) {
+		return
+	}
+
+	label := name[:strings.Index(name, ".")]
+	if label == "" || strings.Contains(label, "*") {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.seen.Has(label) || m.seen.Len() >= maxDynamicLabels {
+		return
+	}
+	m.seen.Insert(label)
+	m.pending.Insert(label)
+}
+
+// Drain returns the labels collected since the previous call, sorted.
+func (m *DynamicWordlistManager) Drain() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	labels := m.pending.Slice()
+	m.pending = stringset.New()
+
+	sort.Strings(labels)
+	return labels
+}
+
+// labelResolved collects the label of the resolved name for the dynamic wordlist.
+func (e *Enumeration) labelResolved(req *requests.DNSRequest) {
+	if e.dynWords != nil {
+		e.dynWords.AddName(req.Name, req.Domain)
+	}
+}
+
+// DrainLabels implements the requests.DynamicWordlist interface. The labels of the names resolved
+// since the previous call are returned when the dynamic wordlist is enabled.
+func (e *Enumeration) DrainLabels() []string {
+	if e.dynWords == nil {
+		return nil
+	}
+	return e.dynWords.Drain()
+}
@@ -0,0 +1,55 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+)
+
+func TestDynamicWordlistManager(t *testing.T) {
+	m := NewDynamicWordlistManager()
+
+	m.AddName("vault.owasp.org", "owasp.org")
+	m.AddName("API.dev.owasp.org.", "owasp.org")
+	m.AddName("owasp.org", "owasp.org")
+	m.AddName("*.owasp.org", "owasp.org")
+	m.AddName("www.example.com", "owasp.org")
+	if labels := m.Drain(); !reflect.DeepEqual(labels, []string{"api", "vault"}) {
+		t.Errorf("Unexpected labels collected: %v", labels)
+	}
+
+	// The labels already drained are not collected again
+	m.AddName("vault.dev.owasp.org", "owasp.org")
+	m.AddName("staging.owasp.org", "owasp.org")
+	if labels := m.Drain(); !reflect.DeepEqual(labels, []string{"staging"}) {
+		t.Errorf("Unexpected labels collected after the drain: %v", labels)
+	}
+	if labels := m.Drain(); len(labels) != 0 {
+		t.Errorf("The labels were not drained: %v", labels)
+	}
+}
+
+func TestDynamicWordlistLabels(t *testing.T) {
+	sys, _ := newResumeSystem()
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.DynamicWordlist = true
+	cfg.ProvidedNames = []string{"www.owasp.org", "api.dev.owasp.org", "missing.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	if err := e.EnablePhases(Resolution); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+	runResume(t, e)
+
+	if labels := e.DrainLabels(); !reflect.DeepEqual(labels, []string{"api", "www"}) {
+		t.Errorf("Unexpected labels from the resolved names: %v", labels)
+	}
+}
@@ -5,28 +5,44 @@ package enum
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net"
 	"sync"
+	"time"
 
 	"github.com/OWASP/Amass/v3/config"
 	"github.com/OWASP/Amass/v3/datasrcs"
 	"github.com/OWASP/Amass/v3/filter"
+	"github.com/OWASP/Amass/v3/graphio"
+	"github.com/OWASP/Amass/v3/net/http"
 	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/scope"
 	"github.com/OWASP/Amass/v3/systems"
 	"github.com/caffix/eventbus"
 	"github.com/caffix/netmap"
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
+	"github.com/caffix/resolve"
 	"github.com/caffix/service"
 )
 
 var filterMaxSize int64 = 1 << 23
 
+// The in-memory graphs are created one at a time, since creating each graph sets package variables of
+// the graph library, and the workers of a coordinated enumeration create the enumerations concurrently.
+var graphMemoryLock sync.Mutex
+
 // Enumeration is the object type used to execute a DNS enumeration.
 type Enumeration struct {
 	Config         *config.Config
+	Scope          *scope.Scope
 	Bus            *eventbus.EventBus
 	Sys            systems.System
 	Graph          *netmap.Graph
+	Transforms     []OutputTransform
+	Takeovers      *TakeoverDetector // Checks the resolved names for unclaimed resources in active enumerations
+	JSONOutput     io.Writer         // Receives the output records as newline-delimited JSON when set
 	closedOnce     sync.Once
 	logQueue       queue.Queue
 	ctx            context.Context
@@ -38,20 +54,81 @@ type Enumeration struct {
 	nameSrc        *enumSource
 	subTask        *subdomainTask
 	dnsTask        *dNSTask
+	rates          *discoveryRate
+	stopLock       sync.Mutex
+	stopReason     string
+	budgets        *sourceBudgets
+	traffic        *http.TrafficMeter
+	yieldLock      sync.Mutex
+	yield          sourceYield
+	failures       failures
+	delegationLock sync.Mutex
+	delegations    []*DelegationFinding
+	skipLock       sync.Mutex
+	skipped        map[string]int64
+	traces         *nameTrace
+	seeds          []*requests.AddrRequest
+	resolved       []*requests.DNSRequest
+	wildcards      *wildcardLog
+	known          *knownNames
+	stats          *sourceStats
+	words          *productiveWords
+	phases         phaseSet
+	checkpoints    checkpointState
+	jsonOut        jsonOutput
+	output         *enumOutput
+	metrics        processMetrics
+	streams        outputStreams
+	journal        graphJournal
+	pause          pauseState
+	artifactLock   sync.Mutex
+	artifacts      map[string]string
+	started        time.Time
+	phaseBudgets   *phaseBudgets
+	consistency    consistencyState
+	delivery       deliveryState
+	bruteTrunc     *bruteTruncation
+	observed       *observations
+	takeovers      *takeoverLog
+	lanes          map[string]*laneCounters
+	certSNI        *certSNILog
+	dynWords       *DynamicWordlistManager
+	forwarder      *logForwarder
+	coord          *coordination
+	sweepRanges    []*net.IPNet
+	// Provides the time to the phase budgets, which use time.Now when nil
+	clock func() time.Time
+	// Provides the resolvers that verify the answer consistency, which are created from the
+	// configuration when nil
+	trustedResolver func(addr string) resolve.Resolver
 }
 
 // NewEnumeration returns an initialized Enumeration that has not been started yet.
 func NewEnumeration(cfg *config.Config, sys systems.System) *Enumeration {
 	e := &Enumeration{
 		Config:         cfg,
+		Scope:          scope.New(cfg),
 		Sys:            sys,
 		Bus:            eventbus.NewEventBus(),
-		Graph:          netmap.NewGraph(netmap.NewCayleyGraphMemory()),
+		Graph:          newMemoryGraph(),
 		srcs:           datasrcs.SelectedDataSources(cfg, sys.DataSources()),
 		logQueue:       queue.NewQueue(),
 		done:           make(chan struct{}),
 		resolvedFilter: filter.NewBloomFilter(filterMaxSize),
 		crawlFilter:    filter.NewStringFilter(),
+		yield:          make(sourceYield),
+		skipped:        make(map[string]int64),
+		traces:         newNameTrace(cfg.TraceNames),
+		wildcards:      newWildcardLog(),
+		known:          newKnownNames(),
+		stats:          newSourceStats(),
+		words:          newProductiveWords(),
+		observed:       newObservations(),
+		takeovers:      newTakeoverLog(),
+		lanes:          newLaneCounters(),
+		certSNI:        newCertSNILog(),
+		rates:          newDiscoveryRate(cfg.PlateauFraction, cfg.PlateauBuckets, time.Now()),
+		output:         newEnumOutput(sys.DataSources()),
 	}
 
 	if cfg.Passive {
@@ -60,14 +137,28 @@ func NewEnumeration(cfg *config.Config, sys systems.System) *Enumeration {
 
 	e.dnsTask = newDNSTask(e)
 	e.subTask = newSubdomainTask(e)
+	if cfg.DynamicWordlist {
+		e.dynWords = NewDynamicWordlistManager()
+	}
+	if cfg.Active {
+		e.Takeovers = NewTakeoverDetector(sys.Cache())
+	}
 	return e
 }
 
+func newMemoryGraph() *netmap.Graph {
+	graphMemoryLock.Lock()
+	defer graphMemoryLock.Unlock()
+
+	return netmap.NewGraph(netmap.NewCayleyGraphMemory())
+}
+
 // Close cleans up resources instantiated by the Enumeration.
 func (e *Enumeration) Close() {
 	e.closedOnce.Do(func() {
 		e.Bus.Stop()
 		e.Graph.Close()
+		e.closeDeliveryJournal()
 	})
 }
 
@@ -77,17 +168,59 @@ func (e *Enumeration) stop() {
 	})
 }
 
-// Start begins the vertical domain correlation process.
-func (e *Enumeration) Start(ctx context.Context) error {
+// Start begins the vertical domain correlation process. The Result summarizes the
+// enumeration and is returned alongside any ConfigError, StartupError or RuntimeError.
+// The Manifest of the run is written to the output directory before Start returns.
+func (e *Enumeration) Start(ctx context.Context) (*Result, error) {
+	started := time.Now()
+	result, err := e.start(ctx)
+	// The remaining output is delivered once the enumeration has stored its findings
+	e.finishOutput()
+
+	if merr := e.writeManifest(e.manifest(started, result, err)); merr != nil && e.Config.Log != nil {
+		e.Config.Log.Printf("Failed to write the run manifest: %v", merr)
+	}
+	return result, err
+}
+
+func (e *Enumeration) start(ctx context.Context) (*Result, error) {
 	if err := e.Config.CheckSettings(); err != nil {
-		return err
+		return e.failures.result(), &ConfigError{Cause: err}
+	}
+	// Without the data sources, a passive enumeration has nothing to discover names with
+	if e.Config.Passive && !e.hasSources() {
+		return e.failures.result(), &ConfigError{Cause: errors.New("No data sources were selected for the passive enumeration")}
+	}
+	if err := e.checkComponents(); err != nil {
+		return e.failures.result(), err
+	}
+	if err := e.checkResumeEvent(); err != nil {
+		return e.failures.result(), &ConfigError{Cause: err}
+	}
+	if err := e.setupPhaseBudgets(); err != nil {
+		return e.failures.result(), &ConfigError{Cause: err}
+	}
+	if err := e.setupLogForwarder(); err != nil {
+		return e.failures.result(), &ConfigError{Cause: err}
 	}
+	// The remaining log events are forwarded once the enumeration is done
+	defer e.closeLogForwarder()
 
-	max := e.Config.MaxDNSQueries
+	e.logSpecialUseScope()
+	e.started = time.Now()
+	max := e.dnsQueryLimit()
+	// The context is ready before the goroutines of the input source are started
+	e.setupContext(ctx)
 	// The pipeline input source will receive all the names
 	e.nameSrc = newEnumSource(e, max)
-	e.startupAndCleanup(ctx)
+	e.metrics.setSource(e.nameSrc, e.started)
+	e.restoreCheckpoint()
+	e.setupSourceBudgets()
+	e.setupSourceTraffic()
+	e.setupBruteTruncation()
+	e.startupAndCleanup()
 	defer e.stop()
+	e.restoreResumeEvent()
 
 	var stages []pipeline.Stage
 	if !e.Config.Passive {
@@ -100,8 +233,10 @@ func (e *Enumeration) Start(ctx context.Context) error {
 
 	stages = append(stages, pipeline.FIFO("filter", e.makeFilterTaskFunc()))
 
-	if !e.Config.Passive {
+	if !e.Config.Passive || e.phase(AddressProcessing) {
 		stages = append(stages, pipeline.DynamicPool("store", newDataManager(e), 50))
+	}
+	if !e.Config.Passive {
 		stages = append(stages, pipeline.FIFO("", e.subTask))
 	}
 	if e.Config.Active {
@@ -117,40 +252,124 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	 */
 	go e.submitKnownNames()
 	e.submitProvidedNames()
+	e.submitResolvedNames()
 	e.submitDomainNames()
+	e.submitSeedAddresses()
+	e.submitSweepRanges()
 	e.submitASNs()
 
-	return pipeline.NewPipeline(stages...).Execute(e.ctx, e.nameSrc, e.makeOutputSink())
+	if err := pipeline.NewPipeline(stages...).Execute(e.ctx, e.nameSrc, e.makeOutputSink()); err != nil {
+		e.failures.add(componentPipeline, err)
+	}
+	e.saveSourceYield()
+	// The names submitted for takeover detection are checked before the enumeration completes
+	if e.Takeovers != nil {
+		e.Takeovers.wait()
+	}
+	// The providers and the delegation findings of the zones are stored before the enumeration completes
+	if e.dnsTask != nil {
+		e.dnsTask.waitFingerprints()
+		e.dnsTask.waitDelegationChecks()
+	}
+	e.verifyConsistency(e.ctx)
+	// Attach the wildcards to the subdomains discovered after their detection
+	e.storeWildcards()
+	// Attach the observation dates to the names stored after they were reported
+	e.storeAllObservations()
+	// Record the handshakes that provided the names found within the certificates
+	e.storeAllCertSNI()
+	storageErr := e.closeGraphJournal()
+
+	result := e.failures.result()
+	if storageErr != nil {
+		result.StorageDegraded = true
+		result.Journal = storageErr.Journal
+	}
+	if err := e.failures.cause(componentPipeline); err != nil {
+		return result, &RuntimeError{Phase: PhasePipeline, Cause: err}
+	}
+	if storageErr != nil {
+		return result, &RuntimeError{Phase: PhaseStorage, Cause: storageErr}
+	}
+	if err := e.failures.cause(componentGraph); err != nil {
+		return result, &RuntimeError{Phase: PhaseStorage, Cause: err}
+	}
+	return result, nil
+}
+
+// checkComponents verifies that the components required by the enumeration are available.
+func (e *Enumeration) checkComponents() error {
+	if e.Graph == nil {
+		return &StartupError{Component: "graph", Cause: errors.New("The enumeration graph was not created")}
+	}
+	if !e.Config.Passive && e.Sys.Pool() == nil {
+		return &StartupError{Component: "resolvers", Cause: errors.New("The system did not provide a resolver pool")}
+	}
+	return nil
+}
+
+// hasSources returns true when the enumeration sends requests to data sources. Without them, the
+// enumeration only verifies the provided and generated names, and skips the source dispatch.
+func (e *Enumeration) hasSources() bool {
+	return len(e.srcs) > 0
+}
+
+// dnsQueryLimit returns the maximum number of concurrent DNS queries for this enumeration.
+// The System configuration provides the ceiling, and the resolvers of the System enforce it
+// across all the enumerations sharing the System.
+func (e *Enumeration) dnsQueryLimit() int {
+	max := e.Config.MaxDNSQueries
+
+	if sc := e.Sys.Config(); sc != nil && sc != e.Config && sc.MaxDNSQueries > 0 {
+		if max <= 0 || max > sc.MaxDNSQueries {
+			max = sc.MaxDNSQueries
+		}
+	}
+	if max <= 0 {
+		max = config.DefaultQueriesPerBaselineResolver
+	}
+	return max
 }
 
-func (e *Enumeration) startupAndCleanup(ctx context.Context) {
+func (e *Enumeration) startupAndCleanup() {
 	/*
 	 * These events are important to the engine in order to receive data,
 	 * logs, and notices about discoveries made during the enumeration
 	 */
 	e.Bus.Subscribe(requests.NewNameTopic, e.nameSrc.dataSourceName)
 	e.Bus.Subscribe(requests.LogTopic, e.queueLog)
-	if !e.Config.Passive {
+	e.Bus.Subscribe(requests.WildcardDiscoveredTopic, e.storeWildcard)
+	e.Bus.Subscribe(requests.TakeoverTopic, e.storeTakeover)
+	if e.phase(AddressProcessing) {
 		e.Bus.Subscribe(requests.NewAddrTopic, e.nameSrc.dataSourceAddr)
 		e.Bus.Subscribe(requests.NewASNTopic, e.Sys.Cache().Update)
 	}
 
-	e.setupContext(ctx)
+	// The data sources publish names as soon as the domain names are submitted to them
+	requests.WaitForSubscriptions(e.ctx, e.Bus)
 	go e.periodicLogging()
+	go e.monitorDiscoveryRate()
+	go e.sampleMetrics()
+	go e.processOutput()
 
 	go func() {
 		<-e.done
 		e.Bus.Unsubscribe(requests.NewNameTopic, e.nameSrc.dataSourceName)
 		e.Bus.Unsubscribe(requests.LogTopic, e.queueLog)
+		e.Bus.Unsubscribe(requests.WildcardDiscoveredTopic, e.storeWildcard)
+		e.Bus.Unsubscribe(requests.TakeoverTopic, e.storeTakeover)
 
-		if !e.Config.Passive {
+		if e.phase(AddressProcessing) {
 			e.Bus.Unsubscribe(requests.NewAddrTopic, e.nameSrc.dataSourceAddr)
 			e.Bus.Unsubscribe(requests.NewASNTopic, e.Sys.Cache().Update)
+		}
+		if !e.Config.Passive {
 			e.nameSrc.Stop()
 			e.subTask.Stop()
 		}
 
 		e.writeLogs(true)
+		_ = e.FlushJSONOutput()
 	}()
 }
 
@@ -167,12 +386,49 @@ func (e *Enumeration) setupContext(ctx context.Context) {
 
 	newctx = context.WithValue(newctx, requests.ContextConfig, e.Config)
 	newctx = context.WithValue(newctx, requests.ContextEventBus, e.Bus)
+	newctx = context.WithValue(newctx, requests.ContextScope, e.Scope)
+	newctx = context.WithValue(newctx, requests.ContextAttempted, requests.AttemptedNames(e))
+	newctx = context.WithValue(newctx, requests.ContextBruteForce, requests.BruteForceProgress(e))
+	newctx = context.WithValue(newctx, requests.ContextWordlist, requests.DynamicWordlist(e))
 	e.ctx = newctx
 }
 
-// Release the root domain names to the input source and each data source.
+// Attempted implements the requests.AttemptedNames interface. It returns true when the
+// name has already been accepted by the enumeration or resolved.
+func (e *Enumeration) Attempted(name string) bool {
+	if e.resolvedFilter.Has(name) {
+		return true
+	}
+	return e.nameSrc != nil && e.nameSrc.attempted(name)
+}
+
+// Skipped implements the requests.AttemptedNames interface.
+func (e *Enumeration) Skipped(source string) {
+	e.skipLock.Lock()
+	defer e.skipLock.Unlock()
+
+	e.skipped[source]++
+}
+
+// SkippedRegenerations returns the number of attempted names that each guessing source did not generate again.
+func (e *Enumeration) SkippedRegenerations() map[string]int64 {
+	e.skipLock.Lock()
+	defer e.skipLock.Unlock()
+
+	counts := make(map[string]int64, len(e.skipped))
+	for src, num := range e.skipped {
+		counts[src] = num
+	}
+	return counts
+}
+
+// Release the root domain names to the input source and each data source. An enumeration
+// resumed from a checkpoint begins with the first domain not released before the checkpoint.
 func (e *Enumeration) submitDomainNames() {
-	for _, domain := range e.Config.Domains() {
+	domains := e.checkpoints.releaseOrder(e.Config)
+
+	for idx := e.checkpoints.domainIndex(); idx < len(domains); idx++ {
+		domain := domains[idx]
 		req := &requests.DNSRequest{
 			Name:   domain,
 			Domain: domain,
@@ -182,8 +438,9 @@ func (e *Enumeration) submitDomainNames() {
 
 		e.nameSrc.dataSourceName(req)
 		for _, src := range e.srcs {
-			src.Request(e.ctx, req.Clone().(*requests.DNSRequest))
+			e.sourceRequest(src, req.Clone().(*requests.DNSRequest))
 		}
+		e.checkpoints.setDomainIndex(idx + 1)
 	}
 }
 
@@ -194,7 +451,7 @@ func (e *Enumeration) submitASNs() {
 		req := &requests.ASNRequest{ASN: asn}
 
 		for _, src := range e.srcs {
-			src.Request(e.ctx, req.Clone().(*requests.ASNRequest))
+			e.sourceRequest(src, req.Clone().(*requests.ASNRequest))
 		}
 	}
 }
@@ -206,13 +463,20 @@ func (e *Enumeration) makeOutputSink() pipeline.SinkFunc {
 		}
 
 		req, ok := data.(*requests.DNSRequest)
-		if !ok || req == nil || req.Name == "" {
+		if !ok || req == nil || req.Name == "" || e.staleName(req.Name) {
 			return nil
 		}
 
-		if e.Config.IsDomainInScope(req.Name) {
-			if _, err := e.Graph.UpsertFQDN(req.Name, req.Source, e.Config.UUID.String()); err != nil {
+		if e.Scope.Evaluate(req.Name, nil).Domain != "" {
+			if err := e.graphWrite(&graphio.JournalOp{
+				Op:   graphio.OpFQDN,
+				Args: []string{req.Name, req.Source, e.Config.UUID.String()},
+			}); err != nil {
+				_ = e.graphFailure(err)
 				e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, err.Error())
+			} else {
+				e.trace(req.Name, TraceStored, req.Source, "")
+				e.storeObservations(req.Name)
 			}
 		}
 		return nil
@@ -242,6 +506,13 @@ func (e *Enumeration) makeFilterTaskFunc() pipeline.TaskFunc {
 		}
 
 		if name != "" && !e.resolvedFilter.Duplicate(name) {
+			if req, ok := data.(*requests.DNSRequest); ok {
+				if e.Scope.Evaluate(req.Name, nil).Domain != "" {
+					e.failures.addName()
+				}
+				e.countDiscovery(req.Tag)
+				e.recordYield(req)
+			}
 			return data, nil
 		}
 		return nil, nil
@@ -0,0 +1,156 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/systems"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+)
+
+type mockSystem struct {
+	cfg   *config.Config
+	cache *requests.ASNCache
+	srcs  []service.Service
+	dbs   []*netmap.Graph
+	pool  resolve.Resolver
+}
+
+func newMockSystem(cfg *config.Config) *mockSystem {
+	return &mockSystem{
+		cfg:   cfg,
+		cache: requests.NewASNCache(),
+	}
+}
+
+func (m *mockSystem) Config() *config.Config                   { return m.cfg }
+func (m *mockSystem) Pool() resolve.Resolver                   { return m.pool }
+func (m *mockSystem) Cache() *requests.ASNCache                { return m.cache }
+func (m *mockSystem) AddSource(srv service.Service) error      { m.srcs = append(m.srcs, srv); return nil }
+func (m *mockSystem) AddAndStart(srv service.Service) error    { _ = srv.Start(); return m.AddSource(srv) }
+func (m *mockSystem) DataSources() []service.Service           { return m.srcs }
+func (m *mockSystem) SetDataSources(sources []service.Service) { m.srcs = sources }
+func (m *mockSystem) GraphDatabases() []*netmap.Graph          { return m.dbs }
+func (m *mockSystem) GetMemoryUsage() uint64                   { return 0 }
+func (m *mockSystem) SelfTest(ctx context.Context) *systems.SelfTestReport {
+	return systems.RunSelfTest(ctx, m)
+}
+func (m *mockSystem) Shutdown() error { return nil }
+
+// addMockInfrastructure provides the infrastructure announcing the addresses returned by the
+// mock resolvers, since the output records of the enumeration only include announced addresses.
+func addMockInfrastructure(sys *mockSystem) {
+	sys.cache.Update(&requests.ASNRequest{
+		Address:     "72.237.4.113",
+		ASN:         26808,
+		Prefix:      "72.237.4.0/24",
+		Description: "UTICA-COLLEGE",
+	})
+}
+
+// mockSource returns a few subdomain names for each domain it receives.
+type mockSource struct {
+	service.BaseService
+}
+
+func newMockSource() *mockSource {
+	m := new(mockSource)
+
+	m.BaseService = *service.NewBaseService(m, "Mock")
+	return m
+}
+
+func (m *mockSource) Description() string {
+	return requests.API
+}
+
+func (m *mockSource) OnRequest(ctx context.Context, args service.Args) {
+	req, ok := args.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, label := range []string{"www", "mail", "vpn"} {
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+			Name:   label + "." + req.Domain,
+			Domain: req.Domain,
+			Tag:    m.Description(),
+			Source: m.String(),
+		})
+	}
+}
+
+func TestConcurrentEnumerations(t *testing.T) {
+	syscfg := config.NewConfig()
+	sys := newMockSystem(syscfg)
+	_ = sys.AddAndStart(newMockSource())
+	defer func() {
+		for _, src := range sys.DataSources() {
+			_ = src.Stop()
+		}
+	}()
+
+	domains := []string{"owasp.org", "owasp-amass.com"}
+	enums := make([]*Enumeration, len(domains))
+	for i, d := range domains {
+		cfg := config.NewConfig()
+		cfg.Passive = true
+		cfg.AddDomain(d)
+
+		enums[i] = NewEnumeration(cfg, sys)
+		defer enums[i].Close()
+	}
+
+	var wg sync.WaitGroup
+	for _, e := range enums {
+		wg.Add(1)
+
+		go func(e *Enumeration) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			if _, err := e.Start(ctx); err != nil {
+				t.Errorf("The enumeration returned an error: %v", err)
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	for i, e := range enums {
+		var count int
+
+		for _, name := range e.Graph.EventFQDNs(e.Config.UUID.String()) {
+			if e.Config.IsDomainInScope(name) {
+				count++
+				continue
+			}
+
+			for j, d := range domains {
+				if j != i && enums[j].Config.WhichDomain(name) == d {
+					t.Errorf("The %s enumeration discovered the %s name %s", domains[i], d, name)
+				}
+			}
+		}
+
+		if count == 0 {
+			t.Errorf("The %s enumeration did not discover any names", domains[i])
+		}
+	}
+}
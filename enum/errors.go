@@ -0,0 +1,145 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// The phases of the enumeration reported by a RuntimeError.
+const (
+	PhasePipeline     = "pipeline"
+	PhaseStorage      = "storage"
+	PhaseCoordination = "coordination"
+)
+
+// ConfigError is returned by Start when the configuration is invalid. Nothing was executed.
+type ConfigError struct {
+	Cause error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("Invalid enumeration configuration: %v", e.Cause)
+}
+
+// Unwrap returns the cause of the error.
+func (e *ConfigError) Unwrap() error { return e.Cause }
+
+// StartupError is returned by Start when a component required by the enumeration
+// could not be started. Nothing was discovered.
+type StartupError struct {
+	Component string
+	Cause     error
+}
+
+func (e *StartupError) Error() string {
+	return fmt.Sprintf("The enumeration failed to start the %s: %v", e.Component, e.Cause)
+}
+
+// Unwrap returns the cause of the error.
+func (e *StartupError) Unwrap() error { return e.Cause }
+
+// RuntimeError is returned by Start when a failure occurred after the enumeration began.
+// The Result returned alongside the error indicates whether partial results exist.
+type RuntimeError struct {
+	Phase string
+	Cause error
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("The enumeration failed during the %s phase: %v", e.Phase, e.Cause)
+}
+
+// Unwrap returns the cause of the error.
+func (e *RuntimeError) Unwrap() error { return e.Cause }
+
+// Result summarizes the enumeration returned by Start, including when Start fails.
+type Result struct {
+	// The number of unique in-scope names discovered
+	Names int
+	// False when writes to the enumeration graph failed
+	GraphIntact bool
+	// True when the graph writes were saved to a journal after the enumeration graph failed
+	StorageDegraded bool
+	// The journal holding the graph writes that were not replayed into the enumeration graph
+	Journal string
+	// The components that failed during the enumeration
+	FailedComponents []string
+}
+
+// Partial returns true when the enumeration failed but discovered names that can be salvaged.
+func (r *Result) Partial() bool {
+	return r.Names > 0 && len(r.FailedComponents) > 0
+}
+
+// failures tracks the components that failed during the enumeration.
+type failures struct {
+	sync.Mutex
+	names    int
+	graphErr error
+	causes   map[string]error
+}
+
+func (f *failures) addName() {
+	f.Lock()
+	defer f.Unlock()
+
+	f.names++
+}
+
+func (f *failures) add(component string, err error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.causes == nil {
+		f.causes = make(map[string]error)
+	}
+	// Keep the first failure of each component
+	if _, found := f.causes[component]; !found {
+		f.causes[component] = err
+	}
+}
+
+func (f *failures) result() *Result {
+	f.Lock()
+	defer f.Unlock()
+
+	_, graphFailed := f.causes[componentGraph]
+	r := &Result{
+		Names:       f.names,
+		GraphIntact: !graphFailed,
+	}
+
+	for c := range f.causes {
+		r.FailedComponents = append(r.FailedComponents, c)
+	}
+	sort.Strings(r.FailedComponents)
+	return r
+}
+
+func (f *failures) cause(component string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.causes[component]
+}
+
+// The components tracked by the enumeration failures.
+const (
+	componentGraph        = "graph"
+	componentPipeline     = "pipeline"
+	componentCoordination = "coordination"
+	// Prefixes the ID of each failed assignment of a coordinated enumeration
+	componentAssignment = "assignment"
+)
+
+// graphFailure records a failed graph write and returns the error provided.
+func (e *Enumeration) graphFailure(err error) error {
+	if err != nil {
+		e.failures.add(componentGraph, err)
+	}
+	return err
+}
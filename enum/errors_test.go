@@ -0,0 +1,93 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/netmap"
+)
+
+func TestStartConfigError(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Passive = true
+	cfg.BruteForcing = true
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	result, err := e.Start(context.Background())
+	var cerr *ConfigError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	if result == nil || result.Partial() || result.Names != 0 {
+		t.Errorf("The invalid configuration returned unexpected results: %v", result)
+	}
+}
+
+func TestStartStartupError(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	// The mock system does not provide a resolver pool
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	result, err := e.Start(context.Background())
+	var serr *StartupError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Expected a StartupError, got %v", err)
+	}
+	if serr.Component != "resolvers" {
+		t.Errorf("Expected the resolvers component to fail, got %s", serr.Component)
+	}
+	if result == nil || result.Partial() {
+		t.Errorf("The startup failure returned unexpected results: %v", result)
+	}
+}
+
+func TestStartRuntimeError(t *testing.T) {
+	if raceEnabled {
+		t.Skip("The bolt graph database cannot be used with the race detector")
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	_ = sys.AddAndStart(newMockSource())
+	defer func() {
+		for _, src := range sys.DataSources() {
+			_ = src.Stop()
+		}
+	}()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Passive = true
+
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+	// Writes to the closed graph database will fail
+	e.Graph = netmap.NewGraph(netmap.NewCayleyGraph("local", t.TempDir(), ""))
+	e.Graph.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := e.Start(ctx)
+	var rerr *RuntimeError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Expected a RuntimeError, got %v", err)
+	}
+	if rerr.Phase != PhaseStorage {
+		t.Errorf("Expected the %s phase to fail, got %s", PhaseStorage, rerr.Phase)
+	}
+	if result.GraphIntact || !result.Partial() {
+		t.Errorf("Expected partial results without an intact graph: %+v", result)
+	}
+}
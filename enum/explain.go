@@ -0,0 +1,218 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/OWASP/Amass/v3/filter"
+	"github.com/OWASP/Amass/v3/net/dns"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/scope"
+	"github.com/caffix/netmap"
+)
+
+// The gates checked by ExplainName, in the order names pass through them during the enumeration.
+const (
+	GateScope     = "scope"
+	GateSyntax    = "syntax"
+	GateService   = "service label"
+	GateBlacklist = "blacklist"
+	GateAttempted = "attempted"
+	GateWildcard  = "wildcard"
+	GateGraph     = "graph"
+)
+
+// The verdicts reached by ExplainName.
+const (
+	VerdictRejected     = "rejected"
+	VerdictDiscovered   = "discovered"
+	VerdictUnresolved   = "attempted without being discovered"
+	VerdictNotGenerated = "never generated"
+)
+
+var explainSubRE = dns.AnySubdomainRegex()
+
+// GateResult is the outcome of checking a name against one of the enumeration gates.
+type GateResult struct {
+	Gate   string `json:"gate"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Explanation describes why a name was, or would be, included or rejected by the enumeration.
+type Explanation struct {
+	Name    string `json:"name"`
+	Domain  string `json:"domain,omitempty"`
+	Verdict string `json:"verdict"`
+	// The gate that rejected the name, when the verdict is VerdictRejected
+	RejectedBy string       `json:"rejected_by,omitempty"`
+	Gates      []GateResult `json:"gates"`
+}
+
+// String renders the explanation with one line for each gate checked.
+func (x *Explanation) String() string {
+	var b strings.Builder
+
+	verdict := x.Verdict
+	if x.RejectedBy != "" {
+		verdict += " by the " + x.RejectedBy + " gate"
+	}
+	fmt.Fprintf(&b, "%s: %s\n", x.Name, verdict)
+
+	for _, g := range x.Gates {
+		status := "pass"
+		if !g.Passed {
+			status = "FAIL"
+		}
+
+		line := fmt.Sprintf("\t[%s] %s", status, g.Gate)
+		if g.Detail != "" {
+			line += ": " + g.Detail
+		}
+		fmt.Fprintln(&b, line)
+	}
+	return b.String()
+}
+
+// ExplainName checks the name against the scope, validity checks, blacklist, attempted names,
+// wildcard decisions and graph of the enumeration, and reports which gate rejected the name.
+// ExplainName can be used during the enumeration or after it has finished.
+func (e *Enumeration) ExplainName(name string) *Explanation {
+	name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	decision := e.Scope.Evaluate(name, nil)
+	x := &Explanation{
+		Name:   name,
+		Domain: decision.Domain,
+	}
+
+	reject := func(gate, detail string) *Explanation {
+		x.Gates = append(x.Gates, GateResult{Gate: gate, Detail: detail})
+		x.Verdict = VerdictRejected
+		x.RejectedBy = gate
+		return x
+	}
+	pass := func(gate, detail string) {
+		x.Gates = append(x.Gates, GateResult{Gate: gate, Passed: true, Detail: detail})
+	}
+
+	if x.Domain == "" {
+		return reject(GateScope, "the name is not within a domain in scope")
+	}
+	pass(GateScope, "within "+x.Domain)
+
+	if explainSubRE.FindString(name) != name {
+		return reject(GateSyntax, "the name is not a valid DNS name")
+	}
+	pass(GateSyntax, "")
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "_tcp" || label == "_udp" || label == "_tls" {
+			return reject(GateService, "service names are not evaluated further")
+		}
+	}
+	pass(GateService, "")
+
+	if decision.Reason == scope.ReasonBlacklisted {
+		return reject(GateBlacklist, "the name is within a blacklisted subdomain")
+	}
+	pass(GateBlacklist, "")
+
+	attempted := e.Attempted(name)
+	if attempted {
+		pass(GateAttempted, "the name was generated or received by the enumeration")
+	} else {
+		pass(GateAttempted, "the name has not been attempted")
+	}
+
+	if e.wildcards.filtered(name) {
+		return reject(GateWildcard, "the DNS answers matched a wildcard")
+	} else if parent := e.wildcards.parent(name); parent != "" {
+		pass(GateWildcard, "names under "+parent+" have matched a wildcard")
+	} else {
+		pass(GateWildcard, "")
+	}
+
+	if srcs, err := e.Graph.NodeSources(netmap.Node(name), e.Config.UUID.String()); err == nil && len(srcs) > 0 {
+		pass(GateGraph, "discovered by "+strings.Join(srcs, ", "))
+		x.Verdict = VerdictDiscovered
+		return x
+	}
+
+	x.Gates = append(x.Gates, GateResult{Gate: GateGraph, Detail: "the name is not in the enumeration graph"})
+	x.Verdict = VerdictNotGenerated
+	if attempted {
+		x.Verdict = VerdictUnresolved
+	}
+	return x
+}
+
+// wildcardLog keeps the names filtered by DNS wildcard detection, the subdomains they were found
+// under and the subdomains found to have a DNS wildcard.
+type wildcardLog struct {
+	sync.Mutex
+	names      filter.Filter
+	parents    map[string]int
+	detections map[string]*requests.WildcardRequest
+}
+
+func newWildcardLog() *wildcardLog {
+	return &wildcardLog{
+		names:      filter.NewBloomFilter(filterMaxSize),
+		parents:    make(map[string]int),
+		detections: make(map[string]*requests.WildcardRequest),
+	}
+}
+
+// detected records the DNS wildcard and returns true when it is the first found within the subdomain.
+func (w *wildcardLog) detected(req *requests.WildcardRequest) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, found := w.detections[req.Subdomain]; found {
+		return false
+	}
+
+	w.detections[req.Subdomain] = req
+	return true
+}
+
+func (w *wildcardLog) add(name string) {
+	name = strings.ToLower(name)
+
+	w.Lock()
+	defer w.Unlock()
+
+	w.names.Duplicate(name)
+	if idx := strings.Index(name, "."); idx > 0 {
+		w.parents[name[idx+1:]]++
+	}
+}
+
+func (w *wildcardLog) filtered(name string) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.names.Has(name)
+}
+
+// parent returns the closest parent of the name with other names filtered by wildcard detection.
+func (w *wildcardLog) parent(name string) string {
+	w.Lock()
+	defer w.Unlock()
+
+	for sub := name; ; {
+		idx := strings.Index(sub, ".")
+		if idx < 0 {
+			return ""
+		}
+
+		sub = sub[idx+1:]
+		if w.parents[sub] > 0 {
+			return sub
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func newExplainEnum(t *testing.T) *Enumeration {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.AddDomain("owasp.org")
+	cfg.Blacklist = []string{"internal.owasp.org"}
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	e.nameSrc = newEnumSource(e, 10)
+	e.setupContext(context.Background())
+	t.Cleanup(func() {
+		e.stop()
+		e.Close()
+	})
+	return e
+}
+
+func TestExplainNameRejections(t *testing.T) {
+	e := newExplainEnum(t)
+	e.wildcards.add("random.dev.owasp.org")
+
+	tests := []struct {
+		name string
+		gate string
+	}{
+		{"www.example.com", GateScope},
+		{"bad_label!.owasp.org", GateSyntax},
+		{"_sip._tcp.owasp.org", GateService},
+		{"admin.internal.owasp.org", GateBlacklist},
+		{"random.dev.owasp.org", GateWildcard},
+	}
+
+	for _, test := range tests {
+		x := e.ExplainName(test.name)
+
+		if x.Verdict != VerdictRejected || x.RejectedBy != test.gate {
+			t.Errorf("%s: expected rejection by the %s gate, got %s by %s", test.name, test.gate, x.Verdict, x.RejectedBy)
+		}
+		if last := x.Gates[len(x.Gates)-1]; last.Gate != test.gate || last.Passed {
+			t.Errorf("%s: the rejecting gate was not the last gate checked", test.name)
+		}
+	}
+}
+
+func TestExplainNameVerdicts(t *testing.T) {
+	e := newExplainEnum(t)
+
+	if x := e.ExplainName("admin.owasp.org"); x.Verdict != VerdictNotGenerated {
+		t.Errorf("Expected the name to have never been generated, got %s", x.Verdict)
+	}
+
+	e.nameSrc.accept("vpn.owasp.org", requests.ALT, "Alterations", true)
+	if x := e.ExplainName("vpn.owasp.org"); x.Verdict != VerdictUnresolved {
+		t.Errorf("Expected the name to be attempted without being discovered, got %s", x.Verdict)
+	}
+
+	// A wildcard found under a parent subdomain is reported without rejecting the name
+	e.wildcards.add("random.dev.owasp.org")
+	if _, err := e.Graph.UpsertFQDN("www.dev.owasp.org", "Mock", e.Config.UUID.String()); err != nil {
+		t.Fatalf("Failed to insert the name into the graph: %v", err)
+	}
+
+	x := e.ExplainName("WWW.dev.owasp.org.")
+	if x.Verdict != VerdictDiscovered || x.Domain != "owasp.org" {
+		t.Errorf("Expected the name to be discovered within owasp.org, got %s within %s", x.Verdict, x.Domain)
+	}
+
+	out := x.String()
+	for _, want := range []string{"www.dev.owasp.org: discovered", "names under dev.owasp.org", "discovered by Mock"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("The rendered explanation is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/api"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/queue"
+	"google.golang.org/grpc"
+)
+
+// The time allowed for the clients to receive the remaining output once the enumeration is done.
+const grpcShutdownTimeout = 5 * time.Second
+
+// outputStreams holds the gRPC server and the output waiting to be sent to each of its clients,
+// and the findings waiting to be sent to the leader of a coordinated enumeration.
+type outputStreams struct {
+	sync.Mutex
+	server   *grpc.Server
+	addr     string
+	watchers map[queue.Queue]struct{}
+	findings queue.Queue
+}
+
+// grpcService implements the Amass gRPC service for the enumeration.
+type grpcService struct {
+	api.UnimplementedAmassServer
+	enum *Enumeration
+}
+
+// StartGRPCServer starts a gRPC server listening on the address provided that streams the output
+// records of the enumeration to each client of the Watch RPC. The streams are closed and the server
+// is shut down once the remaining output has been sent after the enumeration is done. An error is returned when the address cannot be listened on.
+func (e *Enumeration) StartGRPCServer(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Failed to start the gRPC server: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	api.RegisterAmassServer(srv, &grpcService{enum: e})
+
+	e.streams.Lock()
+	if e.streams.server != nil {
+		e.streams.Unlock()
+		ln.Close()
+		return fmt.Errorf("The gRPC server is already listening on %s", e.streams.addr)
+	}
+	e.streams.server = srv
+	e.streams.addr = ln.Addr().String()
+	e.streams.Unlock()
+
+	go func() { _ = srv.Serve(ln) }()
+	go e.stopGRPCServer(srv)
+	return nil
+}
+
+func (e *Enumeration) stopGRPCServer(srv *grpc.Server) {
+	<-e.output.finished
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	t := time.NewTimer(grpcShutdownTimeout)
+	defer t.Stop()
+
+	select {
+	case <-stopped:
+	case <-t.C:
+		srv.Stop()
+	}
+}
+
+// Watch implements the Amass gRPC service by sending the output of the enumeration to the client
+// until the enumeration is done.
+func (s *grpcService) Watch(req *api.WatchRequest, stream api.Amass_WatchServer) error {
+	e := s.enum
+	q := e.addWatcher()
+	defer e.removeWatcher(q)
+
+	send := func() error {
+		for {
+			element, ok := q.Next()
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(element.(*api.Output)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-e.output.finished:
+			// The remaining output of the enumeration is sent before the stream is closed
+			return send()
+		case <-q.Signal():
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (e *Enumeration) addWatcher() queue.Queue {
+	e.streams.Lock()
+	defer e.streams.Unlock()
+
+	q := queue.NewQueue()
+	if e.streams.watchers == nil {
+		e.streams.watchers = make(map[queue.Queue]struct{})
+	}
+	e.streams.watchers[q] = struct{}{}
+	return q
+}
+
+func (e *Enumeration) removeWatcher(q queue.Queue) {
+	e.streams.Lock()
+	defer e.streams.Unlock()
+
+	delete(e.streams.watchers, q)
+}
+
+// sendOutput sends the output record to each client of the gRPC server, and to the leader when the
+// enumeration performs an assignment of a coordinated enumeration.
+func (e *Enumeration) sendOutput(o *requests.Output) {
+	e.streams.Lock()
+	defer e.streams.Unlock()
+
+	if e.streams.findings != nil {
+		e.streams.findings.Append(o)
+	}
+	if len(e.streams.watchers) == 0 {
+		return
+	}
+
+	out := api.NewOutput(o)
+	for q := range e.streams.watchers {
+		q.Append(out)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/api"
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/stringset"
+	"google.golang.org/grpc"
+)
+
+func watchOutput(t *testing.T, ctx context.Context, addr string) <-chan []*api.Output {
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Failed to connect to the gRPC server: %v", err)
+	}
+
+	stream, err := api.NewAmassClient(conn).Watch(ctx, &api.WatchRequest{})
+	if err != nil {
+		conn.Close()
+		t.Fatalf("Failed to watch the output: %v", err)
+	}
+
+	ch := make(chan []*api.Output, 1)
+	go func() {
+		defer conn.Close()
+
+		var output []*api.Output
+		for {
+			o, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("The stream failed: %v", err)
+				}
+				break
+			}
+			output = append(output, o)
+		}
+		ch <- output
+	}()
+	return ch
+}
+
+func TestGRPCServer(t *testing.T) {
+	sys, _ := newResumeSystem()
+	defer stopSources(sys)
+	addMockInfrastructure(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org", "mail.owasp.org", "ftp.owasp.org", "missing.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	if err := e.EnablePhases(Resolution); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+	if err := e.StartGRPCServer("127.0.0.1:0"); err != nil {
+		t.Fatalf("Failed to start the gRPC server: %v", err)
+	}
+	if err := e.StartGRPCServer("127.0.0.1:0"); err == nil {
+		t.Errorf("A second gRPC server was started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.streams.Lock()
+	addr := e.streams.addr
+	e.streams.Unlock()
+
+	// Each of the clients receives all the output
+	clients := []<-chan []*api.Output{watchOutput(t, ctx, addr), watchOutput(t, ctx, addr)}
+	for {
+		e.streams.Lock()
+		watchers := len(e.streams.watchers)
+		e.streams.Unlock()
+
+		if watchers == len(clients) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("The clients did not start watching the output")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	runResume(t, e)
+	expected := stringset.New()
+	for _, name := range e.Graph.EventFQDNs(e.Config.UUID.String()) {
+		if e.Scope.Evaluate(name, nil).Domain != "" {
+			expected.Insert(name)
+		}
+	}
+	if expected.Len() == 0 {
+		t.Fatalf("No names were stored by the enumeration")
+	}
+
+	for i, ch := range clients {
+		var output []*api.Output
+		// The streams are closed once the enumeration is done
+		select {
+		case output = <-ch:
+		case <-ctx.Done():
+			t.Fatalf("The stream of client %d was not closed", i)
+		}
+
+		received := stringset.New()
+		for _, o := range output {
+			// The output records are deduplicated
+			if received.Has(o.Name) {
+				t.Errorf("Client %d received %s more than once", i, o.Name)
+			}
+			received.Insert(o.Name)
+
+			if o.Name == "www.owasp.org" && (o.Domain != "owasp.org" || len(o.Addresses) != 1 ||
+				o.Addresses[0].Address != "72.237.4.113" || o.Addresses[0].Asn != 26808) {
+				t.Errorf("Client %d received incomplete output: %v", i, o)
+			}
+		}
+
+		missing := stringset.New(expected.Slice()...)
+		missing.Subtract(received)
+		if missing.Len() > 0 || received.Has("missing.owasp.org") {
+			t.Errorf("Client %d received %v instead of %v", i, received.Slice(), expected.Slice())
+		}
+	}
+}
@@ -21,23 +21,20 @@ import (
 )
 
 const (
-	minWaitForData   = 15 * time.Second
-	maxWaitForData   = 30 * time.Second
-	defaultSweepSize = 100
-	activeSweepSize  = 200
+	minWaitForData = 15 * time.Second
+	maxWaitForData = 30 * time.Second
 )
 
 // enumSource handles the filtering and release of new Data in the enumeration.
 type enumSource struct {
 	sync.Mutex
 	enum        *Enumeration
-	queue       queue.Queue
+	queue       *NameManager
 	dups        queue.Queue
-	sweeps      queue.Queue
-	filter      filter.Filter
+	sweeps      *sweepScheduler
+	filter      *filter.RotatingBloomFilter
 	sweepFilter filter.Filter
 	subre       *regexp.Regexp
-	count       int64
 	done        chan struct{}
 	maxSlots    int
 	timeout     time.Duration
@@ -47,16 +44,25 @@ type enumSource struct {
 func newEnumSource(e *Enumeration, slots int) *enumSource {
 	r := &enumSource{
 		enum:        e,
-		queue:       queue.NewQueue(),
 		dups:        queue.NewQueue(),
-		sweeps:      queue.NewQueue(),
-		filter:      filter.NewBloomFilter(filterMaxSize),
+		sweeps:      newSweepScheduler(e.Config.SweepBlockPrefix, e.Config.SweepRate),
+		filter:      filter.NewRotatingBloomFilter(filterMaxSize),
 		sweepFilter: filter.NewBloomFilter(filterMaxSize),
 		subre:       dns.AnySubdomainRegex(),
 		done:        make(chan struct{}),
 		maxSlots:    slots,
 		timeout:     minWaitForData,
 	}
+	var trusted queue.Queue = queue.NewQueue()
+	// Names from trusted sources are released before the names from scraping and guessing
+	if e.Config.PriorityNames {
+		trusted = NewPriorityNameManager()
+	}
+	// The lanes share the DNS query slots, which are not used without DNS resolution
+	if e.Config.Passive {
+		slots = 0
+	}
+	r.queue = NewNameManager(trusted, slots, e.Config.UntrustedQueryShare)
 
 	if !e.Config.Passive {
 		r.timeout = maxWaitForData
@@ -68,18 +74,23 @@ func newEnumSource(e *Enumeration, slots int) *enumSource {
 }
 
 func (r *enumSource) Stop() {
-	r.filter = filter.NewBloomFilter(1)
+	r.Lock()
+	// The name filter is kept to answer the attempted names queries after the enumeration
 	r.sweepFilter = filter.NewBloomFilter(1)
+	r.Unlock()
+
 	r.queue.Process(func(e interface{}) {})
 	r.dups.Process(func(e interface{}) {})
-	r.sweeps.Process(func(e interface{}) {})
+	r.sweeps.clear()
 }
 
 func (r *enumSource) dataSourceName(req *requests.DNSRequest) {
 	if req == nil || req.Name == "" {
 		return
 	}
-	if r.enum.Config.IsDomainInScope(req.Name) {
+
+	r.enum.stats.submitted(req.Source)
+	if r.enum.Scope.Evaluate(req.Name, nil).Domain != "" {
 		r.pipelineData(r.enum.ctx, req, nil)
 	}
 }
@@ -129,12 +140,33 @@ func (r *enumSource) newName(ctx context.Context, req *requests.DNSRequest, tp p
 		}
 	}
 
+	r.enum.trace(req.Name, TraceSeen, req.Source, req.Tag)
+	// The observation dates are kept for each source reporting the name
+	r.enum.observeName(req)
 	if r.accept(req.Name, req.Tag, req.Source, true) {
+		r.enum.stats.accepted(req.Source)
+		// Untrusted discoveries within capped subdomains are sampled
+		if r.enum.subTask != nil && !r.enum.subTask.sampleDiscovery(req.Name, req.Domain, req.Tag) {
+			return
+		}
+
+		r.enum.trace(req.Name, TraceQueued, req.Source, "")
 		r.queue.Append(req)
 	}
 }
 
 func (r *enumSource) newAddr(ctx context.Context, req *requests.AddrRequest, tp pipeline.TaskParams) {
+	if !r.enum.phase(AddressProcessing) {
+		return
+	}
+	// Without DNS resolution, the addresses provided by the data sources are stored directly
+	if tp == nil && !r.enum.phase(Resolution) {
+		if req.Domain != "" && r.accept(req.Address, req.Tag, req.Source, false) {
+			req.InScope = true
+			r.queue.Append(req)
+		}
+		return
+	}
 	if !req.InScope || tp == nil || !r.accept(req.Address, req.Tag, req.Source, false) {
 		return
 	}
@@ -142,8 +174,8 @@ func (r *enumSource) newAddr(ctx context.Context, req *requests.AddrRequest, tp
 	r.sendAddr(ctx, req, tp)
 	// Does the address fall into a reserved address range?
 	if yes, _ := amassnet.IsReservedAddress(req.Address); !yes {
-		// Queue the request for later use in reverse DNS sweeps
-		r.sweeps.Append(req)
+		// Schedule the reverse DNS sweep of the block containing the address
+		r.sweeps.add(req, r.addrNetblock(req.Address))
 	}
 }
 
@@ -161,12 +193,6 @@ func (r *enumSource) accept(s, tag, source string, name bool) bool {
 	r.Lock()
 	defer r.Unlock()
 
-	// Check if it's time to reset our bloom filter due to number of elements seen
-	if r.count >= filterMaxSize {
-		r.count = 0
-		r.filter = filter.NewBloomFilter(filterMaxSize)
-	}
-
 	trusted := requests.TrustedTag(tag)
 	// Do not submit names from untrusted sources, after already receiving the name
 	// from a trusted source
@@ -192,11 +218,27 @@ func (r *enumSource) accept(s, tag, source string, name bool) bool {
 		}
 		return false
 	}
-
-	r.count++
 	return true
 }
 
+// attempted returns true if the name has already been accepted by the input source.
+func (r *enumSource) attempted(name string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.filter.Has(name+strconv.FormatBool(true)) || r.filter.Has(name+strconv.FormatBool(false))
+}
+
+// NameFilterStats returns the number of names held by the filters of the names accepted for DNS
+// resolution, and the estimated rate of false positives. It can be called while the enumeration
+// is running to obtain a progress snapshot.
+func (e *Enumeration) NameFilterStats() filter.BloomStats {
+	if e.nameSrc == nil {
+		return filter.BloomStats{}
+	}
+	return e.nameSrc.filter.Stats()
+}
+
 // Next implements the pipeline InputSource interface.
 func (r *enumSource) Next(ctx context.Context) bool {
 	select {
@@ -205,7 +247,8 @@ func (r *enumSource) Next(ctx context.Context) bool {
 	default:
 	}
 
-	if !r.queue.Empty() {
+	// No names are released while the enumeration is paused
+	if r.enum.waitWhilePaused() && r.queue.Ready() {
 		return true
 	}
 
@@ -223,10 +266,34 @@ func (r *enumSource) Next(ctx context.Context) bool {
 		case <-r.done:
 			return false
 		case <-t.C:
+			// The time spent paused does not count toward the wait for new data
+			if r.enum.Paused() {
+				if r.enum.waitWhilePaused() {
+					t.Reset(r.timeout)
+				}
+				continue
+			}
+			// The names waiting for the DNS query slots of their lane are not a lack of data
+			if !r.queue.Empty() {
+				t.Reset(r.timeout)
+				continue
+			}
 			close(r.done)
 			return false
 		case <-r.queue.Signal():
-			if !r.queue.Empty() {
+			if r.enum.Paused() {
+				if !r.enum.waitWhilePaused() {
+					continue
+				}
+				if !t.Stop() {
+					select {
+					case <-t.C:
+					default:
+					}
+				}
+				t.Reset(r.timeout)
+			}
+			if r.queue.Ready() {
 				return true
 			}
 		}
@@ -256,13 +323,16 @@ func (r *enumSource) checkForData() {
 	t := time.NewTicker(500 * time.Millisecond)
 	defer t.Stop()
 
-	worth := 50 * len(r.enum.Sys.DataSources())
+	worth := 50 * len(r.enum.srcs)
 	if r.enum.Config.Alterations {
 		worth += 1000
 	}
 	if r.enum.Config.BruteForcing && r.enum.Config.MinForRecursive == 0 {
 		worth += len(r.enum.Config.Wordlist)
 	}
+	if worth == 0 {
+		worth = 1
+	}
 
 	for {
 		select {
@@ -273,8 +343,12 @@ func (r *enumSource) checkForData() {
 		case <-r.done:
 			return
 		case <-t.C:
+			// The data sources are not contacted while the enumeration is paused
+			if r.enum.Paused() {
+				continue
+			}
 			if needed := required - r.queue.Len(); needed > 0 {
-				if gen := r.requestSweeps(needed); needed-gen > 0 {
+				if gen := r.requestSweeps(needed); needed-gen > 0 && r.enum.hasSources() {
 					num := 1
 
 					if n := needed / worth; n > num {
@@ -357,70 +431,29 @@ loop:
 func (r *enumSource) requestSweeps(num int) int {
 	var count int
 
-	for count < num {
-		e, ok := r.sweeps.Next()
-		if !ok {
-			break
-		}
-
-		if a, good := e.(*requests.AddrRequest); good {
-			// Generate the additional addresses to sweep across
-			count += r.sweepAddrs(r.enum.ctx, a)
-		}
+	if !r.enum.phaseBudgets.Allow(AddressProcessing) {
+		return count
 	}
 
-	return count
-}
-
-func (r *enumSource) sweepAddrs(ctx context.Context, req *requests.AddrRequest) int {
-	size := defaultSweepSize
-	if r.enum.Config.Active {
-		size = activeSweepSize
-	}
-
-	cidr := r.addrCIDR(req.Address)
-	// Get information about nearby IP addresses
-	ips := amassnet.CIDRSubset(cidr, req.Address, size)
-
-	var count int
-	for _, ip := range ips {
-		select {
-		case <-ctx.Done():
-			return count
-		default:
-		}
+	r.Lock()
+	defer r.Unlock()
 
-		if a := ip.String(); !r.sweepFilter.Duplicate(a) {
+	for _, req := range r.sweeps.next(num) {
+		// The blocks limited to smaller netblocks can overlap the blocks swept before
+		if !r.sweepFilter.Duplicate(req.Address) {
 			count++
-			r.queue.Append(&requests.AddrRequest{
-				Address: a,
-				Domain:  req.Domain,
-				Tag:     req.Tag,
-				Source:  req.Source,
-			})
+			r.queue.Append(req)
 		}
 	}
 	return count
 }
 
-func (r *enumSource) addrCIDR(addr string) *net.IPNet {
+// addrNetblock returns the netblock containing the address, or nil when it is not yet known.
+func (r *enumSource) addrNetblock(addr string) *net.IPNet {
 	if asn := r.enum.Sys.Cache().AddrSearch(addr); asn != nil {
 		if _, cidr, err := net.ParseCIDR(asn.Prefix); err == nil {
 			return cidr
 		}
 	}
-
-	var mask net.IPMask
-	ip := net.ParseIP(addr)
-	if amassnet.IsIPv6(ip) {
-		mask = net.CIDRMask(64, 128)
-	} else {
-		mask = net.CIDRMask(18, 32)
-	}
-	ip = ip.Mask(mask)
-
-	return &net.IPNet{
-		IP:   ip,
-		Mask: mask,
-	}
+	return nil
 }
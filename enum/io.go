@@ -14,6 +14,8 @@ import (
 func (e *Enumeration) submitKnownNames() {
 	filter := filter.NewStringFilter()
 	srcTags := make(map[string]string)
+	// Names already handled before the checkpoint or the resumed event are not submitted again
+	resumed := e.checkpoints.loaded() != nil || e.Config.ResumeEvent != ""
 
 	for _, src := range e.Sys.DataSources() {
 		srcTags[src.String()] = src.Description()
@@ -28,11 +30,13 @@ func (e *Enumeration) submitKnownNames() {
 				default:
 				}
 
-				if filter.Duplicate(name) {
+				if filter.Duplicate(name) || (resumed && e.resolvedFilter.Has(name)) {
 					continue
 				}
 
-				if domain := e.Config.WhichDomain(name); domain != "" {
+				if domain := e.Scope.Evaluate(name, nil).Domain; domain != "" {
+					e.known.add(name)
+
 					if srcs, err := g.NodeSources(netmap.Node(name), event); err == nil {
 						src := srcs[0]
 						tag := srcTags[src]
@@ -52,7 +56,7 @@ func (e *Enumeration) submitKnownNames() {
 
 func (e *Enumeration) submitProvidedNames() {
 	for _, name := range e.Config.ProvidedNames {
-		if domain := e.Config.WhichDomain(name); domain != "" {
+		if domain := e.Scope.Evaluate(name, nil).Domain; domain != "" {
 			e.nameSrc.dataSourceName(&requests.DNSRequest{
 				Name:   name,
 				Domain: domain,
@@ -63,8 +67,21 @@ func (e *Enumeration) submitProvidedNames() {
 	}
 }
 
-func (e *Enumeration) queueLog(msg string) {
-	e.logQueue.Append(msg)
+// queueLog accepts both the structured events and the plaintext messages published on the
+// LogTopic, and drops the events below the minimum severity of the configuration.
+func (e *Enumeration) queueLog(msg interface{}) {
+	ev := requests.ToLogEvent(msg)
+	if ev == nil {
+		return
+	}
+
+	if min, _ := requests.ParseLogSeverity(e.Config.LogLevel); ev.Severity < min {
+		return
+	}
+	e.logQueue.Append(ev)
+	if e.forwarder != nil {
+		e.forwarder.add(ev)
+	}
 }
 
 func (e *Enumeration) writeLogs(all bool) {
@@ -80,7 +97,7 @@ func (e *Enumeration) writeLogs(all bool) {
 		}
 
 		if e.Config.Log != nil {
-			e.Config.Log.Print(msg.(string))
+			e.Config.Log.Print(msg.(*requests.LogEvent).String())
 		}
 
 		if !all && i >= num {
@@ -89,16 +106,32 @@ func (e *Enumeration) writeLogs(all bool) {
 	}
 }
 
+// The number of periodic log writes between the reports of the name filter health.
+const filterHealthInterval = 12
+
 func (e *Enumeration) periodicLogging() {
 	t := time.NewTimer(5 * time.Second)
 
-	for {
+	for i := 1; ; i++ {
 		select {
 		case <-e.done:
 			return
 		case <-t.C:
+			if i%filterHealthInterval == 0 {
+				e.logFilterHealth()
+			}
 			e.writeLogs(false)
 			t.Reset(5 * time.Second)
 		}
 	}
 }
+
+// logFilterHealth reports the names held by the filters of the input source, since the estimated
+// false positive rate grows as the filters fill up.
+func (e *Enumeration) logFilterHealth() {
+	stats := e.NameFilterStats()
+
+	e.queueLog(requests.NewLogEvent("Filter", requests.LogDebug,
+		"%d names accepted, %d names in the previous filter, %d rotations, %.4f%% estimated false positives",
+		stats.Count, stats.Previous, stats.Rotations, stats.FalsePositiveRate*100))
+}
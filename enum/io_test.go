@@ -0,0 +1,59 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+func TestQueueLogEvents(t *testing.T) {
+	var buf bytes.Buffer
+
+	cfg := config.NewConfig()
+	cfg.Log = log.New(&buf, "", 0)
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	// Both the plaintext messages and the structured events are accepted from the bus
+	e.Bus.Subscribe(requests.LogTopic, e.queueLog)
+	requests.WaitForSubscriptions(context.Background(), e.Bus)
+
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, "The enumeration has been paused")
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		requests.NewLogEvent("DNS", requests.LogWarning, "lame delegation").With(requests.LogFieldDomain, "owasp.org"))
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		requests.NewLogEvent("DNS", requests.LogDebug, "NXDOMAIN for www.owasp.org"))
+	time.Sleep(250 * time.Millisecond)
+	e.writeLogs(true)
+
+	if got, expected := buf.String(), "The enumeration has been paused\nDNS: lame delegation\n"; got != expected {
+		t.Errorf("Expected the log %q, got %q", expected, got)
+	}
+
+	// The events below the minimum severity of the configuration are not written
+	buf.Reset()
+	cfg = config.NewConfig()
+	cfg.Log = log.New(&buf, "", 0)
+	cfg.LogLevel = config.LogLevelError
+	e = NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	e.queueLog("Umbrella: Querying for owasp.org subdomains")
+	e.queueLog(requests.NewLogEvent("DNS", requests.LogWarning, "lame delegation"))
+	e.queueLog(requests.NewLogEvent("Umbrella", requests.LogError, "%s: %v", "https://umbrella.com", "403 Forbidden"))
+	e.writeLogs(true)
+
+	if got := buf.String(); strings.Count(got, "\n") != 1 || got != "Umbrella: https://umbrella.com: 403 Forbidden\n" {
+		t.Errorf("Unexpected log with the error level: %q", got)
+	}
+}
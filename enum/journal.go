@@ -0,0 +1,259 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/graphio"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+// The number of consecutive failed graph writes before the writes are saved to a journal.
+var maxGraphFailures = 5
+
+// The time between the attempts to replay the journal into the recovered graph.
+var graphRecoveryInterval = 30 * time.Second
+
+// DegradedStorageError is the cause of the RuntimeError returned when the enumeration graph
+// failed and the graph writes were saved to a journal.
+type DegradedStorageError struct {
+	// The journal left for import when the graph did not recover, otherwise empty
+	Journal string
+	// The number of graph writes saved to the journal
+	Journaled int
+	Cause     error
+}
+
+func (e *DegradedStorageError) Error() string {
+	if e.Journal == "" {
+		return fmt.Sprintf("The graph storage was degraded: %v: the %d journaled writes were replayed once the storage recovered",
+			e.Cause, e.Journaled)
+	}
+	return fmt.Sprintf("The graph storage was degraded: %v: %d writes were saved to the journal %s, which can be imported using 'amass db -import'",
+		e.Cause, e.Journaled, e.Journal)
+}
+
+// Unwrap returns the cause of the error.
+func (e *DegradedStorageError) Unwrap() error { return e.Cause }
+
+// graphJournal saves the graph writes to an append-only journal once the graph fails repeatedly.
+type graphJournal struct {
+	sync.Mutex
+	// Replaces the graph writes when set
+	apply    func(*graphio.JournalOp) error
+	failures []*graphio.JournalOp
+	path     string
+	file     *os.File
+	count    int
+	cause    error
+	// Set once the enumeration is done, so the journal left for import is not replaced
+	closed bool
+}
+
+// graphWrite performs the graph write. Once the writes have failed maxGraphFailures times in a row,
+// the writes are saved to the journal until the journal can be replayed into the graph.
+func (e *Enumeration) graphWrite(op *graphio.JournalOp) error {
+	j := &e.journal
+	j.Lock()
+	defer j.Unlock()
+
+	if j.file != nil {
+		return e.journalWrite(op)
+	}
+
+	err := e.applyGraphOp(op)
+	if err == nil {
+		e.markSeen(op)
+		// The writes that failed before are attempted again
+		for _, failed := range j.failures {
+			if e.applyGraphOp(failed) == nil {
+				e.markSeen(failed)
+			}
+		}
+		j.failures = nil
+		return nil
+	}
+
+	if j.closed {
+		return err
+	}
+
+	j.failures = append(j.failures, op)
+	if len(j.failures) < maxGraphFailures {
+		return err
+	}
+	if jerr := e.openJournal(err); jerr != nil {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("Failed to create the graph journal: %v", jerr))
+		j.failures = nil
+		return err
+	}
+
+	for _, failed := range j.failures {
+		_ = e.journalWrite(failed)
+	}
+	j.failures = nil
+	return err
+}
+
+// infrastructureWrite performs the graph write for the infrastructure of the address, and the
+// write of the country code for the autonomous system when it is known.
+func (e *Enumeration) infrastructureWrite(asn int, desc, cc, addr, cidr, source string) error {
+	if err := e.graphWrite(&graphio.JournalOp{
+		Op:   graphio.OpInfrastructure,
+		Args: []string{desc, addr, cidr, source, e.Config.UUID.String()},
+		ASN:  asn,
+	}); err != nil || cc == "" {
+		return err
+	}
+
+	return e.graphWrite(&graphio.JournalOp{
+		Op:   graphio.OpCountry,
+		Args: []string{cc},
+		ASN:  asn,
+	})
+}
+
+func (e *Enumeration) applyGraphOp(op *graphio.JournalOp) error {
+	if e.journal.apply != nil {
+		return e.journal.apply(op)
+	}
+	return graphio.ApplyJournalOp(e.Graph, op)
+}
+
+func (e *Enumeration) openJournal(cause error) error {
+	j := &e.journal
+	dir := config.OutputDirectory(e.Config.Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	uuid := e.Config.UUID.String()
+	path := filepath.Join(dir, "graph_journal_"+uuid+".jsonl")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := graphio.WriteJournalHeader(f, uuid); err != nil {
+		f.Close()
+		return err
+	}
+
+	j.path = path
+	j.file = f
+	if j.cause == nil {
+		j.cause = cause
+	}
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		fmt.Sprintf("The graph failed %d consecutive writes: %v: the writes are saved to %s", maxGraphFailures, cause, path))
+
+	go e.recoverGraph(graphRecoveryInterval)
+	return nil
+}
+
+func (e *Enumeration) journalWrite(op *graphio.JournalOp) error {
+	if err := json.NewEncoder(e.journal.file).Encode(op); err != nil {
+		return err
+	}
+
+	e.journal.count++
+	return nil
+}
+
+// recoverGraph periodically attempts to replay the journal into the graph until it succeeds.
+func (e *Enumeration) recoverGraph(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-t.C:
+			e.journal.Lock()
+			recovered := e.replayJournal()
+			e.journal.Unlock()
+
+			if recovered {
+				return
+			}
+		}
+	}
+}
+
+// replayJournal performs the journaled writes and removes the journal once all the writes
+// have succeeded. The writes are idempotent, so a partial replay is performed again later.
+func (e *Enumeration) replayJournal() bool {
+	j := &e.journal
+	if j.file == nil {
+		return true
+	}
+
+	f, err := os.Open(j.path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	// Skip the journal header
+	var header json.RawMessage
+	if err := dec.Decode(&header); err != nil {
+		return false
+	}
+
+	var replayed int
+	for {
+		var op graphio.JournalOp
+
+		if err := dec.Decode(&op); err == io.EOF {
+			break
+		} else if err != nil {
+			return false
+		}
+		if err := e.applyGraphOp(&op); err != nil {
+			return false
+		}
+		replayed++
+	}
+
+	j.file.Close()
+	j.file = nil
+	_ = os.Remove(j.path)
+	j.path = ""
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+		fmt.Sprintf("The graph recovered and %d journaled writes were replayed", replayed))
+	return true
+}
+
+// closeGraphJournal makes a final attempt to replay the journal into the graph. A DegradedStorageError
+// is returned when writes were saved to the journal, identifying the journal left for import.
+func (e *Enumeration) closeGraphJournal() *DegradedStorageError {
+	j := &e.journal
+	j.Lock()
+	defer j.Unlock()
+
+	j.closed = true
+	if j.cause == nil {
+		return nil
+	}
+	if !e.replayJournal() {
+		j.file.Close()
+		j.file = nil
+	}
+
+	return &DegradedStorageError{
+		Journal:   j.path,
+		Journaled: j.count,
+		Cause:     j.cause,
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/graphio"
+	"github.com/caffix/netmap"
+)
+
+// failingGraph fails the graph writes until the time to recover, and never recovers when zero.
+func failingGraph(e *Enumeration, recover time.Time) func(*graphio.JournalOp) error {
+	return func(op *graphio.JournalOp) error {
+		if recover.IsZero() || time.Now().Before(recover) {
+			return errors.New("the graph database is unavailable")
+		}
+		return graphio.ApplyJournalOp(e.Graph, op)
+	}
+}
+
+func newJournalTestEnumeration(t *testing.T) *Enumeration {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	e.setupContext(context.Background())
+	t.Cleanup(func() {
+		e.stop()
+		e.Close()
+	})
+	return e
+}
+
+func writeJournalTestNames(t *testing.T, e *Enumeration, first, n int) {
+	for i := first; i < first+n; i++ {
+		_ = e.graphWrite(&graphio.JournalOp{
+			Op:   graphio.OpA,
+			Args: []string{"host" + strconv.Itoa(i) + ".owasp.org", "72.237.4." + strconv.Itoa(i), "DNS", e.Config.UUID.String()},
+		})
+	}
+}
+
+func countJournalTestNames(g *netmap.Graph, uuid string) int {
+	var count int
+
+	for _, name := range g.EventFQDNs(uuid) {
+		if strings.HasPrefix(name, "host") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGraphJournalRecovery(t *testing.T) {
+	savedInterval := graphRecoveryInterval
+	graphRecoveryInterval = 50 * time.Millisecond
+	defer func() { graphRecoveryInterval = savedInterval }()
+
+	e := newJournalTestEnumeration(t)
+	e.journal.apply = failingGraph(e, time.Now().Add(200*time.Millisecond))
+
+	writeJournalTestNames(t, e, 0, 2*maxGraphFailures)
+	e.journal.Lock()
+	path, count := e.journal.path, e.journal.count
+	e.journal.Unlock()
+	if path == "" || count != 2*maxGraphFailures {
+		t.Fatalf("Expected %d writes in the journal, got %d in %q", 2*maxGraphFailures, count, path)
+	}
+
+	// The journal is replayed once the graph recovers
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e.journal.Lock()
+		replayed := e.journal.file == nil
+		e.journal.Unlock()
+		if replayed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The journal was not replayed after the graph recovered")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("The replayed journal was not removed")
+	}
+	if n := countJournalTestNames(e.Graph, e.Config.UUID.String()); n != 2*maxGraphFailures {
+		t.Errorf("Expected %d names in the graph after the replay, got %d", 2*maxGraphFailures, n)
+	}
+
+	err := e.closeGraphJournal()
+	if err == nil {
+		t.Fatal("The degraded storage was not reported")
+	}
+	if err.Journal != "" || err.Journaled != 2*maxGraphFailures {
+		t.Errorf("Unexpected degraded storage error: %v", err)
+	}
+}
+
+func TestGraphJournalImport(t *testing.T) {
+	e := newJournalTestEnumeration(t)
+	e.journal.apply = failingGraph(e, time.Time{})
+
+	// The failed writes are journaled once the consecutive failures reach the threshold
+	writeJournalTestNames(t, e, 0, maxGraphFailures-1)
+	if e.journal.file != nil {
+		t.Fatal("The journal was opened before the failure threshold")
+	}
+	writeJournalTestNames(t, e, maxGraphFailures-1, maxGraphFailures+2)
+
+	err := e.closeGraphJournal()
+	if err == nil || err.Journal == "" {
+		t.Fatalf("The journal was not left for import: %v", err)
+	}
+
+	f, ferr := os.Open(err.Journal)
+	if ferr != nil {
+		t.Fatalf("Failed to open the journal: %v", ferr)
+	}
+	defer f.Close()
+
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer g.Close()
+	stats, ierr := graphio.ImportJSON(g, f)
+	if ierr != nil {
+		t.Fatalf("Failed to import the journal: %v", ierr)
+	}
+	if stats.Operations != 2*maxGraphFailures+1 || len(stats.Events) != 1 {
+		t.Errorf("Unexpected import of the journal: %+v", stats)
+	}
+	if n := countJournalTestNames(g, e.Config.UUID.String()); n != 2*maxGraphFailures+1 {
+		t.Errorf("Expected %d names from the journal, got %d", 2*maxGraphFailures+1, n)
+	}
+}
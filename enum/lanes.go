@@ -0,0 +1,284 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/queue"
+)
+
+// The processing lanes of the enumeration input source.
+const (
+	// LaneTrusted processes the names provided by the trusted and API sources, and the addresses
+	LaneTrusted = "trusted"
+	// LaneUntrusted processes the names from scraping, brute forcing, alterations and guessing
+	LaneUntrusted = "untrusted"
+)
+
+// nameLane returns the processing lane for the names discovered using the tag.
+func nameLane(tag string) string {
+	if nameTagPriority(tag) == queue.PriorityLow {
+		return LaneUntrusted
+	}
+	return LaneTrusted
+}
+
+// LaneStats holds the measurements of a processing lane in the enumeration.
+type LaneStats struct {
+	// Names waiting in the lane
+	Queued int
+	// Names released by the lane that are still being processed
+	InFlight int
+	// The DNS query slots reserved for the lane, or zero when the lane is not limited
+	Slots int
+	// Names released by the lane
+	Released int64
+	// DNS queries sent for the names of the lane
+	Queries int64
+	// Names of the lane that resolved without matching a wildcard
+	Resolved int64
+	// Names of the lane discarded for matching a wildcard
+	WildcardDiscards int64
+}
+
+// NameManager is the queue of the enumeration input source. It implements queue.Queue using
+// a trusted and an untrusted lane, each with its share of the concurrent DNS query slots. The
+// untrusted lane never exceeds its share, while the trusted lane can borrow the idle slots of
+// the untrusted lane, so the trusted names are never held behind a full untrusted lane.
+type NameManager struct {
+	sync.Mutex
+	trusted   queue.Queue
+	untrusted queue.Queue
+	signal    chan struct{}
+	total     int
+	slots     map[string]int
+	inflight  map[string]int
+	released  map[string]int64
+	pending   map[interface{}]string
+}
+
+// NewNameManager returns an empty NameManager using the queue provided for the trusted lane.
+// The share is the fraction of the total slots reserved for the untrusted lane, and each lane
+// keeps at least one slot. The lanes are not limited when total is zero or less.
+func NewNameManager(trusted queue.Queue, total int, share float64) *NameManager {
+	n := &NameManager{
+		trusted:   trusted,
+		untrusted: queue.NewQueue(),
+		signal:    make(chan struct{}, 1),
+		slots:     make(map[string]int),
+		inflight:  make(map[string]int),
+		released:  make(map[string]int64),
+		pending:   make(map[interface{}]string),
+	}
+
+	if total <= 0 {
+		return n
+	}
+	if total < 2 {
+		total = 2
+	}
+
+	untrusted := int(math.Round(float64(total) * share))
+	if untrusted < 1 {
+		untrusted = 1
+	} else if untrusted > total-1 {
+		untrusted = total - 1
+	}
+
+	n.total = total
+	n.slots[LaneUntrusted] = untrusted
+	n.slots[LaneTrusted] = total - untrusted
+	return n
+}
+
+// Append implements the queue.Queue interface. The names are routed using the request tag,
+// and the other data is added to the trusted lane.
+func (n *NameManager) Append(data interface{}) {
+	n.lane(data).Append(data)
+	n.notify()
+}
+
+// AppendPriority implements the queue.Queue interface.
+func (n *NameManager) AppendPriority(data interface{}, priority int) {
+	n.lane(data).AppendPriority(data, priority)
+	n.notify()
+}
+
+func (n *NameManager) lane(data interface{}) queue.Queue {
+	if req, ok := data.(*requests.DNSRequest); ok && req != nil && nameLane(req.Tag) == LaneUntrusted {
+		return n.untrusted
+	}
+	return n.trusted
+}
+
+func (n *NameManager) notify() {
+	select {
+	case n.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Signal implements the queue.Queue interface.
+func (n *NameManager) Signal() <-chan struct{} {
+	return n.signal
+}
+
+// Next implements the queue.Queue interface. The trusted lane is checked first, and nothing
+// is returned while the lanes holding data have no available slots.
+func (n *NameManager) Next() (interface{}, bool) {
+	n.Lock()
+	defer n.Unlock()
+
+	for _, lane := range []string{LaneTrusted, LaneUntrusted} {
+		if !n.available(lane) {
+			continue
+		}
+
+		q := n.queue(lane)
+		if data, ok := q.Next(); ok {
+			if n.total > 0 {
+				n.pending[data] = lane
+				n.inflight[lane]++
+			}
+			n.released[lane]++
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// Ready returns true when a lane holds data and has an available slot.
+func (n *NameManager) Ready() bool {
+	n.Lock()
+	defer n.Unlock()
+
+	return n.available(LaneTrusted) || n.available(LaneUntrusted)
+}
+
+// available must be called while holding the lock.
+func (n *NameManager) available(lane string) bool {
+	if n.queue(lane).Empty() {
+		return false
+	}
+	if n.total <= 0 {
+		return true
+	}
+
+	busy := n.inflight[LaneTrusted] + n.inflight[LaneUntrusted]
+	if lane == LaneTrusted {
+		return n.inflight[lane] < n.slots[lane] || (n.untrusted.Empty() && busy < n.total)
+	}
+	return n.inflight[lane] < n.slots[lane] && busy < n.total
+}
+
+func (n *NameManager) queue(lane string) queue.Queue {
+	if lane == LaneUntrusted {
+		return n.untrusted
+	}
+	return n.trusted
+}
+
+// Release returns the slot used by the data to its lane once the processing is complete.
+// Data that was not returned by Next is ignored.
+func (n *NameManager) Release(data interface{}) {
+	n.Lock()
+	lane, found := n.pending[data]
+	if found {
+		delete(n.pending, data)
+		n.inflight[lane]--
+	}
+	n.Unlock()
+
+	if found {
+		n.notify()
+	}
+}
+
+// Process implements the queue.Queue interface.
+func (n *NameManager) Process(callback func(interface{})) {
+	n.trusted.Process(callback)
+	n.untrusted.Process(callback)
+}
+
+// Empty implements the queue.Queue interface.
+func (n *NameManager) Empty() bool {
+	return n.Len() == 0
+}
+
+// Len implements the queue.Queue interface.
+func (n *NameManager) Len() int {
+	return n.trusted.Len() + n.untrusted.Len()
+}
+
+// stats returns the queue measurements of the lane.
+func (n *NameManager) stats(lane string) LaneStats {
+	n.Lock()
+	defer n.Unlock()
+
+	return LaneStats{
+		Queued:   n.queue(lane).Len(),
+		InFlight: n.inflight[lane],
+		Slots:    n.slots[lane],
+		Released: n.released[lane],
+	}
+}
+
+// laneCounters holds the DNS measurements of a processing lane, updated atomically.
+type laneCounters struct {
+	queries   int64
+	resolved  int64
+	wildcards int64
+}
+
+func newLaneCounters() map[string]*laneCounters {
+	return map[string]*laneCounters{
+		LaneTrusted:   new(laneCounters),
+		LaneUntrusted: new(laneCounters),
+	}
+}
+
+func (e *Enumeration) laneQuery(lane string) {
+	atomic.AddInt64(&e.lanes[lane].queries, 1)
+}
+
+func (e *Enumeration) laneResolved(lane string) {
+	atomic.AddInt64(&e.lanes[lane].resolved, 1)
+}
+
+func (e *Enumeration) laneWildcard(lane string) {
+	atomic.AddInt64(&e.lanes[lane].wildcards, 1)
+}
+
+// releaseLane returns the DNS query slot used by the data to the input source.
+func (e *Enumeration) releaseLane(data interface{}) {
+	if e.nameSrc != nil {
+		e.nameSrc.queue.Release(data)
+	}
+}
+
+// LaneStats returns the measurements of the trusted and untrusted processing lanes, keyed by
+// the lane name. It can be called while the enumeration is running to obtain a progress snapshot.
+func (e *Enumeration) LaneStats() map[string]LaneStats {
+	e.metrics.Lock()
+	src := e.metrics.src
+	e.metrics.Unlock()
+
+	stats := make(map[string]LaneStats, len(e.lanes))
+	for lane, c := range e.lanes {
+		var st LaneStats
+		if src != nil {
+			st = src.queue.stats(lane)
+		}
+
+		st.Queries = atomic.LoadInt64(&c.queries)
+		st.Resolved = atomic.LoadInt64(&c.resolved)
+		st.WildcardDiscards = atomic.LoadInt64(&c.wildcards)
+		stats[lane] = st
+	}
+	return stats
+}
@@ -0,0 +1,305 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/queue"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+)
+
+func TestNameLane(t *testing.T) {
+	for tag, lane := range map[string]string{
+		requests.DNS:    LaneTrusted,
+		requests.CERT:   LaneTrusted,
+		requests.API:    LaneTrusted,
+		requests.SCRAPE: LaneUntrusted,
+		requests.BRUTE:  LaneUntrusted,
+		requests.ALT:    LaneUntrusted,
+		requests.GUESS:  LaneUntrusted,
+	} {
+		if l := nameLane(tag); l != lane {
+			t.Errorf("The %s names were assigned to the %s lane instead of the %s lane", tag, l, lane)
+		}
+	}
+}
+
+func fillLanes(n *NameManager, count int) {
+	for i := 0; i < count; i++ {
+		n.Append(&requests.DNSRequest{Name: fmt.Sprintf("brute%d.owasp.org", i), Tag: requests.BRUTE})
+		n.Append(&requests.DNSRequest{Name: fmt.Sprintf("api%d.owasp.org", i), Tag: requests.API})
+	}
+}
+
+// drainLanes returns the data released by the NameManager, keyed by the lane of the data.
+func drainLanes(n *NameManager) map[string][]interface{} {
+	released := make(map[string][]interface{})
+
+	for n.Ready() {
+		data, ok := n.Next()
+		if !ok {
+			break
+		}
+
+		lane := LaneTrusted
+		if req, good := data.(*requests.DNSRequest); good {
+			lane = nameLane(req.Tag)
+		}
+		released[lane] = append(released[lane], data)
+	}
+	return released
+}
+
+func TestNameManagerShares(t *testing.T) {
+	n := NewNameManager(queue.NewQueue(), 8, 0.25)
+	if n.slots[LaneTrusted] != 6 || n.slots[LaneUntrusted] != 2 {
+		t.Fatalf("The slots were not shared by the ratio: %v", n.slots)
+	}
+
+	fillLanes(n, 10)
+	released := drainLanes(n)
+	// Both lanes are saturated, so each is held to its share of the slots
+	if l := len(released[LaneTrusted]); l != 6 {
+		t.Errorf("The trusted lane released %d names instead of 6", l)
+	}
+	if l := len(released[LaneUntrusted]); l != 2 {
+		t.Errorf("The untrusted lane released %d names instead of 2", l)
+	}
+	if n.Ready() {
+		t.Errorf("The NameManager is ready after all the slots were used")
+	}
+
+	// A released trusted slot is not taken by the untrusted lane
+	n.Release(released[LaneTrusted][0])
+	if data, ok := n.Next(); !ok || data.(*requests.DNSRequest).Tag != requests.API {
+		t.Errorf("The trusted slot was not used by the trusted lane")
+	}
+	n.Release(released[LaneUntrusted][0])
+	if data, ok := n.Next(); !ok || data.(*requests.DNSRequest).Tag != requests.BRUTE {
+		t.Errorf("The untrusted slot was not used by the untrusted lane")
+	}
+
+	// Data that was not released by the NameManager does not return a slot
+	n.Release(&requests.DNSRequest{Name: "www.owasp.org"})
+	if n.Ready() {
+		t.Errorf("The NameManager is ready after releasing unknown data")
+	}
+
+	st := n.stats(LaneUntrusted)
+	if st.InFlight != 2 || st.Slots != 2 || st.Released != 3 || st.Queued != 7 {
+		t.Errorf("Unexpected untrusted lane measurements: %+v", st)
+	}
+}
+
+func TestNameManagerTrustedNotBlocked(t *testing.T) {
+	n := NewNameManager(queue.NewQueue(), 8, 0.5)
+
+	// The untrusted lane is saturated before the trusted names arrive
+	for i := 0; i < 20; i++ {
+		n.Append(&requests.DNSRequest{Name: fmt.Sprintf("scrape%d.owasp.org", i), Tag: requests.SCRAPE})
+	}
+	if released := drainLanes(n); len(released[LaneUntrusted]) != 4 {
+		t.Fatalf("The untrusted lane released %d names instead of 4", len(released[LaneUntrusted]))
+	}
+
+	n.Append(&requests.DNSRequest{Name: "www.owasp.org", Tag: requests.CERT})
+	n.Append(&requests.AddrRequest{Address: "72.237.4.113"})
+	if released := drainLanes(n); len(released[LaneTrusted]) != 2 {
+		t.Errorf("The trusted lane was blocked behind the full untrusted lane")
+	}
+}
+
+func TestNameManagerBorrowing(t *testing.T) {
+	n := NewNameManager(queue.NewQueue(), 8, 0.25)
+
+	for i := 0; i < 10; i++ {
+		n.Append(&requests.DNSRequest{Name: fmt.Sprintf("api%d.owasp.org", i), Tag: requests.API})
+	}
+	// The idle untrusted slots are borrowed by the trusted lane
+	borrowed := drainLanes(n)[LaneTrusted]
+	if len(borrowed) != 8 {
+		t.Errorf("The trusted lane released %d names instead of 8", len(borrowed))
+	}
+
+	// The untrusted lane never exceeds the total number of slots
+	n.Append(&requests.DNSRequest{Name: "brute.owasp.org", Tag: requests.BRUTE})
+	if n.Ready() {
+		t.Errorf("The untrusted lane was ready while the trusted lane used all the slots")
+	}
+	n.Release(borrowed[0])
+	if released := drainLanes(n); len(released[LaneUntrusted]) != 1 {
+		t.Errorf("The untrusted lane did not use the returned slot")
+	}
+}
+
+func TestNameManagerUnlimited(t *testing.T) {
+	n := NewNameManager(queue.NewQueue(), 0, 0.25)
+
+	fillLanes(n, 10)
+	released := drainLanes(n)
+	if len(released[LaneTrusted]) != 10 || len(released[LaneUntrusted]) != 10 {
+		t.Errorf("The lanes were limited without DNS query slots")
+	}
+	if !n.Empty() || len(n.pending) != 0 {
+		t.Errorf("The released data was tracked without DNS query slots")
+	}
+}
+
+// laneResolver answers the A queries of all names after a delay, and records the
+// highest number of concurrent queries for the names of each lane.
+type laneResolver struct {
+	mockResolver
+	sync.Mutex
+	inflight map[string]int
+	peak     map[string]int
+}
+
+func newLaneResolver() *laneResolver {
+	return &laneResolver{
+		inflight: make(map[string]int),
+		peak:     make(map[string]int),
+	}
+}
+
+func (r *laneResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	name := strings.TrimSuffix(msg.Question[0].Name, ".")
+
+	var lane string
+	if strings.HasPrefix(name, "brute") {
+		lane = LaneUntrusted
+	} else if strings.HasPrefix(name, "api") {
+		lane = LaneTrusted
+	}
+
+	if lane != "" {
+		r.Lock()
+		r.inflight[lane]++
+		if r.inflight[lane] > r.peak[lane] {
+			r.peak[lane] = r.inflight[lane]
+		}
+		r.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		r.Lock()
+		r.inflight[lane]--
+		r.Unlock()
+	}
+
+	resp := msg.Copy()
+	resp.Response = true
+	if lane != "" && msg.Question[0].Qtype == dns.TypeA {
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: msg.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   []byte{72, 237, 4, 113},
+		})
+		return resp, nil
+	}
+
+	resp.Rcode = dns.RcodeNameError
+	return resp, &resolve.ResolveError{Err: "NXDOMAIN", Rcode: dns.RcodeNameError}
+}
+
+func (r *laneResolver) peaks() map[string]int {
+	r.Lock()
+	defer r.Unlock()
+
+	peaks := make(map[string]int)
+	for lane, p := range r.peak {
+		peaks[lane] = p
+	}
+	return peaks
+}
+
+// laneSource reports the brute forced and API names for each domain it receives.
+type laneSource struct {
+	service.BaseService
+	count int
+}
+
+func newLaneSource(count int) *laneSource {
+	s := &laneSource{count: count}
+
+	s.BaseService = *service.NewBaseService(s, "Lanes")
+	return s
+}
+
+func (s *laneSource) Description() string {
+	return requests.API
+}
+
+func (s *laneSource) OnRequest(ctx context.Context, args service.Args) {
+	req, ok := args.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	// Allow the event bus to register the subscriptions of the enumeration
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < s.count; i++ {
+		for _, tag := range []string{requests.BRUTE, requests.API} {
+			bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+				Name:   fmt.Sprintf("%s%d.%s", strings.ToLower(tag), i, req.Domain),
+				Domain: req.Domain,
+				Tag:    tag,
+				Source: s.String(),
+			})
+		}
+	}
+}
+
+func TestLaneSharesUnderSaturation(t *testing.T) {
+	src := newLaneSource(40)
+	sys := newMockSystem(config.NewConfig())
+	pool := newLaneResolver()
+	sys.pool = pool
+	_ = sys.AddAndStart(src)
+	defer func() { _ = src.Stop() }()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.MaxDNSQueries = 8
+	cfg.UntrustedQueryShare = 0.25
+	cfg.Alterations = false
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+	defer cancel()
+	if _, err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration returned an error: %v", err)
+	}
+
+	peaks := pool.peaks()
+	if p := peaks[LaneUntrusted]; p < 1 || p > 2 {
+		t.Errorf("The untrusted lane reached %d concurrent queries with a share of 2 slots", p)
+	}
+	if p := peaks[LaneTrusted]; p <= 2 || p > 8 {
+		t.Errorf("The trusted lane reached %d concurrent queries with a share of 6 slots", p)
+	}
+
+	lanes := e.LaneStats()
+	if st := lanes[LaneUntrusted]; st.Resolved != 40 || st.Queries < 40 || st.Slots != 2 || st.InFlight != 0 {
+		t.Errorf("Unexpected untrusted lane measurements: %+v", st)
+	}
+	if st := lanes[LaneTrusted]; st.Resolved != 40 || st.Queries < 40 || st.Slots != 6 || st.InFlight != 0 {
+		t.Errorf("Unexpected trusted lane measurements: %+v", st)
+	}
+}
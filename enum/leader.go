@@ -0,0 +1,624 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/graphio"
+	amassnet "github.com/OWASP/Amass/v3/net"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// The kinds of work the leader of a coordinated enumeration assigns to the workers.
+const (
+	// AssignDomain enumerates a root domain name using the data sources and each DNS phase
+	// selected for the enumeration, other than brute forcing.
+	AssignDomain = "domain"
+	// AssignBrute brute forces the root domain names using a shard of the wordlist.
+	AssignBrute = "brute"
+	// AssignSweep performs the reverse DNS sweep of a range of the addresses in scope.
+	AssignSweep = "sweep"
+)
+
+// The paths of the requests the workers send to the leader.
+const (
+	coordRegisterPath = "/v1/register"
+	coordAssignPath   = "/v1/assign"
+	coordFindingsPath = "/v1/findings"
+	coordCompletePath = "/v1/complete"
+	coordReleasePath  = "/v1/release"
+	coordFailPath     = "/v1/fail"
+)
+
+const (
+	// The netblocks in scope are swept in ranges no larger than the IPv4 prefix length
+	sweepRangePrefix = 20
+	// The most bits of the prefix length each netblock is extended by, so the larger netblocks
+	// are not split into more ranges than this allows
+	maxSweepRangeBits = 12
+	// The largest request accepted from the workers
+	maxCoordRequestSize = 32 << 20
+	// The time the leader keeps answering the workers once the assignments are completed, so the
+	// workers waiting for work learn that the enumeration is done
+	coordDrainTime = 2 * assignmentPollInterval
+	// The times an assignment is released by the workers failing to perform it before the
+	// assignment is failed instead of issued again
+	maxAssignmentReleases = 3
+)
+
+// Assignment is a part of a coordinated enumeration that the leader issues to a worker.
+type Assignment struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// The root domain names in scope of the assignment
+	Domains []string `json:"domains"`
+	// The shard of the wordlist, and the masks, brute forced by the assignment
+	Words []string `json:"words,omitempty"`
+	Masks []string `json:"masks,omitempty"`
+	// The range of addresses swept by the assignment
+	CIDR string `json:"cidr,omitempty"`
+	// The settings of the leader that the assignment is performed with
+	Passive        bool     `json:"passive,omitempty"`
+	Active         bool     `json:"active,omitempty"`
+	Alterations    bool     `json:"alterations,omitempty"`
+	Recursive      bool     `json:"recursive,omitempty"`
+	Blacklist      []string `json:"blacklist,omitempty"`
+	IncludeSources bool     `json:"include_sources,omitempty"`
+	Sources        []string `json:"sources,omitempty"`
+	// The number of times the assignment has been issued, and the time the worker holds the
+	// assignment without reporting to the leader before it is issued again
+	Attempt int           `json:"attempt"`
+	Lease   time.Duration `json:"lease"`
+}
+
+// workerMessage identifies the worker, and the assignment the findings were discovered by,
+// in the requests sent to the leader.
+type workerMessage struct {
+	Worker     string             `json:"worker"`
+	Assignment string             `json:"assignment,omitempty"`
+	Findings   []*requests.Output `json:"findings,omitempty"`
+}
+
+// issuedAssignment is an assignment held by a worker until the lease expires.
+type issuedAssignment struct {
+	assignment *Assignment
+	worker     string
+	expires    time.Time
+}
+
+// coordination tracks the assignments of a coordinated enumeration. Each assignment is pending until
+// issued to a worker, and issued again when the worker does not report before the lease expires or
+// registers again after a restart. An assignment released too many times by the workers failing to
+// perform it, or that cannot be performed, is failed. The assignments are done once each has been
+// completed or failed.
+type coordination struct {
+	sync.Mutex
+	lease     time.Duration
+	now       func() time.Time
+	pending   []*Assignment
+	issued    map[string]*issuedAssignment
+	completed map[string]*Assignment
+	releases  map[string]int
+	failed    map[string]error
+	total     int
+	done      chan struct{}
+	doneOnce  sync.Once
+	log       func(format string, v ...interface{})
+}
+
+func newCoordination(work []*Assignment, lease time.Duration) *coordination {
+	if lease <= 0 {
+		lease = config.DefaultCoordinationLease
+	}
+
+	c := &coordination{
+		lease:     lease,
+		now:       time.Now,
+		pending:   work,
+		issued:    make(map[string]*issuedAssignment),
+		completed: make(map[string]*Assignment),
+		releases:  make(map[string]int),
+		failed:    make(map[string]error),
+		total:     len(work),
+		done:      make(chan struct{}),
+		log:       func(format string, v ...interface{}) {},
+	}
+	if c.total == 0 {
+		c.finish()
+	}
+	return c
+}
+
+func (c *coordination) finish() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// register issues the assignments held by the worker again, since a worker registers when it starts.
+func (c *coordination) register(worker string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for id, is := range c.issued {
+		if is.worker == worker {
+			c.reissue(id, is, "the worker restarted")
+		}
+	}
+}
+
+// reissue returns the assignment to the front of the pending assignments. The lock must be held.
+func (c *coordination) reissue(id string, is *issuedAssignment, reason string) {
+	delete(c.issued, id)
+	c.pending = append([]*Assignment{is.assignment}, c.pending...)
+	c.log("The assignment %s held by worker %s will be issued again: %s", id, is.worker, reason)
+}
+
+// next issues the next pending assignment to the worker. The assignment is nil when no assignment
+// is pending, and done is true once all the assignments have been completed.
+func (c *coordination) next(worker string) (a *Assignment, done bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.now()
+	for id, is := range c.issued {
+		if now.After(is.expires) {
+			c.reissue(id, is, "the lease expired")
+		}
+	}
+
+	if c.finished() {
+		return nil, true
+	}
+	if len(c.pending) == 0 {
+		return nil, false
+	}
+
+	a = c.pending[0]
+	c.pending = c.pending[1:]
+	a.Attempt++
+	a.Lease = c.lease
+	c.issued[a.ID] = &issuedAssignment{
+		assignment: a,
+		worker:     worker,
+		expires:    now.Add(c.lease),
+	}
+
+	c.log("The assignment %s was issued to worker %s", a.ID, worker)
+	issued := *a
+	return &issued, false
+}
+
+// renew extends the lease of the assignment held by the worker.
+func (c *coordination) renew(worker, id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if is, found := c.issued[id]; found && is.worker == worker {
+		is.expires = c.now().Add(c.lease)
+	}
+}
+
+// release issues the assignment held by the worker again, since the worker failed to perform it.
+// The assignment is failed once it has been released maxAssignmentReleases times.
+func (c *coordination) release(worker, id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	is, found := c.issued[id]
+	if !found || is.worker != worker {
+		return
+	}
+
+	c.releases[id]++
+	if n := c.releases[id]; n >= maxAssignmentReleases {
+		c.failAssignment(id, is, fmt.Errorf("The workers failed to perform the assignment %d times", n))
+		return
+	}
+	c.reissue(id, is, "the worker failed to perform it")
+}
+
+// fail records that the assignment held by the worker cannot be performed, so it is not issued again.
+func (c *coordination) fail(worker, id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if is, found := c.issued[id]; found && is.worker == worker {
+		c.failAssignment(id, is, fmt.Errorf("The worker %s could not perform the assignment", worker))
+	}
+}
+
+// failAssignment removes the issued assignment from the work of the coordination. The lock must be held.
+func (c *coordination) failAssignment(id string, is *issuedAssignment, err error) {
+	delete(c.issued, id)
+	c.failed[id] = err
+	c.log("The assignment %s held by worker %s failed: %v", id, is.worker, err)
+	if c.finished() {
+		c.finish()
+	}
+}
+
+// finished returns true once each assignment has been completed or failed. The lock must be held.
+func (c *coordination) finished() bool {
+	return len(c.completed)+len(c.failed) == c.total
+}
+
+// failures returns the causes of the failed assignments, keyed by the assignment IDs.
+func (c *coordination) failures() map[string]error {
+	c.Lock()
+	defer c.Unlock()
+
+	failed := make(map[string]error, len(c.failed))
+	for id, err := range c.failed {
+		failed[id] = err
+	}
+	return failed
+}
+
+// complete records that the assignment has been performed, including when it has already been issued
+// again, since the findings of each worker are merged.
+func (c *coordination) complete(worker, id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, found := c.completed[id]; found {
+		return
+	}
+
+	var a *Assignment
+	if is, found := c.issued[id]; found {
+		delete(c.issued, id)
+		a = is.assignment
+	}
+	for i, p := range c.pending {
+		if p.ID == id {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			a = p
+			break
+		}
+	}
+	if a == nil {
+		return
+	}
+
+	c.completed[id] = a
+	c.log("The assignment %s was completed by worker %s: %d of %d assignments completed",
+		id, worker, len(c.completed), c.total)
+	if c.finished() {
+		c.finish()
+	}
+}
+
+// partitionWork splits the enumeration into assignments: each root domain name, each shard of the
+// brute forcing wordlist, and each range of the netblocks and addresses in scope.
+func partitionWork(cfg *config.Config) []*Assignment {
+	domains := cfg.Domains()
+	settings := Assignment{
+		Passive:        cfg.Passive,
+		Active:         cfg.Active,
+		Alterations:    cfg.Alterations,
+		Recursive:      cfg.Recursive,
+		Blacklist:      cfg.Blacklist,
+		IncludeSources: cfg.SourceFilter.Include,
+		Sources:        cfg.SourceFilter.Sources,
+	}
+
+	var work []*Assignment
+	add := func(a Assignment) {
+		work = append(work, &a)
+	}
+
+	for _, d := range domains {
+		a := settings
+		a.ID = AssignDomain + ":" + d
+		a.Kind = AssignDomain
+		a.Domains = []string{d}
+		add(a)
+	}
+	if cfg.Passive || len(domains) == 0 {
+		return work
+	}
+
+	if cfg.BruteForcing {
+		size := cfg.WordlistShardSize
+		if size <= 0 {
+			size = config.DefaultWordlistShardSize
+		}
+
+		for i := 0; i < len(cfg.Wordlist); i += size {
+			end := i + size
+			if end > len(cfg.Wordlist) {
+				end = len(cfg.Wordlist)
+			}
+
+			a := settings
+			a.ID = fmt.Sprintf("%s:%d", AssignBrute, i/size+1)
+			a.Kind = AssignBrute
+			a.Domains = domains
+			a.Words = cfg.Wordlist[i:end]
+			// The masks are expanded once, by the first shard
+			if i == 0 {
+				a.Masks = cfg.BruteMasks
+			}
+			add(a)
+		}
+	}
+
+	var cidrs []*net.IPNet
+	for _, cidr := range cfg.CIDRs {
+		cidrs = append(cidrs, sweepRanges(cidr)...)
+	}
+	for _, addr := range cfg.Addresses {
+		bits := 32
+		if amassnet.IsIPv6(addr) {
+			bits = 128
+		}
+		cidrs = append(cidrs, &net.IPNet{IP: addr, Mask: net.CIDRMask(bits, bits)})
+	}
+	for _, cidr := range cidrs {
+		a := settings
+		a.ID = AssignSweep + ":" + cidr.String()
+		a.Kind = AssignSweep
+		a.Domains = domains
+		a.CIDR = cidr.String()
+		add(a)
+	}
+	return work
+}
+
+// sweepRanges splits the IPv4 netblock into ranges with the sweepRangePrefix length, unless that
+// would extend the prefix length by more than maxSweepRangeBits. IPv6 netblocks are not split,
+// since the larger IPv6 netblocks are sampled instead of swept.
+func sweepRanges(cidr *net.IPNet) []*net.IPNet {
+	ones, bits := cidr.Mask.Size()
+	if bits != 32 || ones >= sweepRangePrefix {
+		return []*net.IPNet{cidr}
+	}
+
+	prefix := sweepRangePrefix
+	if prefix-ones > maxSweepRangeBits {
+		prefix = ones + maxSweepRangeBits
+	}
+
+	var ranges []*net.IPNet
+	size := uint32(1) << uint(32-prefix)
+	first := cidr.IP.Mask(cidr.Mask).To4()
+	start := uint32(first[0])<<24 | uint32(first[1])<<16 | uint32(first[2])<<8 | uint32(first[3])
+	for i := uint32(0); i < uint32(1)<<uint(prefix-ones); i++ {
+		n := start + i*size
+		ranges = append(ranges, &net.IPNet{
+			IP:   net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).To4(),
+			Mask: net.CIDRMask(prefix, 32),
+		})
+	}
+	return ranges
+}
+
+// Coordinate performs the enumeration as the leader of a coordinated enumeration. The work is
+// partitioned into assignments for the root domain names, the shards of the brute forcing wordlist
+// and the ranges of the netblocks in scope, which are issued to the workers connecting to the listener.
+// The findings streamed back by the workers are merged into the graph event of the enumeration, and
+// Coordinate returns once each assignment has been completed or failed. The failed assignments are
+// reported in the FailedComponents of the Result. The workers authenticate using the CoordinationToken
+// of the configuration.
+func (e *Enumeration) Coordinate(ctx context.Context, ln net.Listener) (*Result, error) {
+	started := time.Now()
+	result, err := e.coordinate(ctx, ln)
+	// The remaining output is delivered once the findings of the workers have been merged
+	e.finishOutput()
+
+	if merr := e.writeManifest(e.manifest(started, result, err)); merr != nil && e.Config.Log != nil {
+		e.Config.Log.Printf("Failed to write the run manifest: %v", merr)
+	}
+	return result, err
+}
+
+func (e *Enumeration) coordinate(ctx context.Context, ln net.Listener) (*Result, error) {
+	if err := e.Config.CheckSettings(); err != nil {
+		ln.Close()
+		return e.failures.result(), &ConfigError{Cause: err}
+	}
+	if e.Config.CoordinationToken == "" {
+		ln.Close()
+		return e.failures.result(), &ConfigError{Cause: errors.New("A token is required to authenticate the workers")}
+	}
+
+	e.started = time.Now()
+	e.setupContext(ctx)
+	defer e.stop()
+
+	c := newCoordination(partitionWork(e.Config), e.Config.CoordinationLease)
+	if e.Config.Log != nil {
+		c.log = e.Config.Log.Printf
+	}
+	e.coord = c
+	go e.processOutput()
+
+	srv := &http.Server{Handler: e.coordinationHandler(c)}
+	failed := make(chan error, 1)
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			failed <- err
+		}
+	}()
+
+	var err error
+	select {
+	case <-c.done:
+		t := time.NewTimer(coordDrainTime)
+		select {
+		case <-t.C:
+		case <-e.ctx.Done():
+		}
+		t.Stop()
+	case <-e.ctx.Done():
+	case err = <-failed:
+		e.failures.add(componentCoordination, err)
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), grpcShutdownTimeout)
+	defer cancel()
+	_ = srv.Shutdown(sctx)
+
+	abandoned := c.failures()
+	for id, ferr := range abandoned {
+		e.failures.add(componentAssignment+" "+id, ferr)
+	}
+
+	result := e.failures.result()
+	if err != nil {
+		return result, &RuntimeError{Phase: PhaseCoordination, Cause: err}
+	}
+	if err := e.failures.cause(componentGraph); err != nil {
+		return result, &RuntimeError{Phase: PhaseStorage, Cause: err}
+	}
+	if len(abandoned) > 0 {
+		return result, &RuntimeError{
+			Phase: PhaseCoordination,
+			Cause: fmt.Errorf("%d of %d assignments failed", len(abandoned), c.total),
+		}
+	}
+	return result, nil
+}
+
+// coordinationHandler serves the requests of the workers authenticated with the token.
+func (e *Enumeration) coordinationHandler(c *coordination) http.Handler {
+	token := []byte("Bearer " + e.Config.CoordinationToken)
+	handle := func(serve func(w http.ResponseWriter, msg *workerMessage)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), token) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var msg workerMessage
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCoordRequestSize)).Decode(&msg); err != nil ||
+				strings.TrimSpace(msg.Worker) == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			serve(w, &msg)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(coordRegisterPath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		c.register(msg.Worker)
+	}))
+	mux.HandleFunc(coordAssignPath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		a, done := c.next(msg.Worker)
+		if done {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if a == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(a)
+	}))
+	mux.HandleFunc(coordFindingsPath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		c.renew(msg.Worker, msg.Assignment)
+		for _, o := range msg.Findings {
+			e.mergeFinding(o)
+		}
+	}))
+	mux.HandleFunc(coordCompletePath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		c.complete(msg.Worker, msg.Assignment)
+	}))
+	mux.HandleFunc(coordReleasePath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		c.release(msg.Worker, msg.Assignment)
+	}))
+	mux.HandleFunc(coordFailPath, handle(func(w http.ResponseWriter, msg *workerMessage) {
+		c.fail(msg.Worker, msg.Assignment)
+	}))
+	return mux
+}
+
+// mergeFinding stores the name and addresses discovered by a worker in the graph event of the
+// enumeration, which provides the output of the enumeration. The filter shared by the workers
+// drops the names and addresses already stored.
+func (e *Enumeration) mergeFinding(o *requests.Output) {
+	if o == nil {
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(o.Name))
+	domain := e.Scope.Evaluate(name, nil).Domain
+	if domain == "" {
+		return
+	}
+
+	source := "DNS"
+	if len(o.Sources) > 0 && o.Sources[0] != "" {
+		source = o.Sources[0]
+	}
+	uuid := e.Config.UUID.String()
+
+	if !e.resolvedFilter.Duplicate(name) {
+		if err := e.graphWrite(&graphio.JournalOp{
+			Op:   graphio.OpFQDN,
+			Args: []string{name, source, uuid},
+		}); err != nil {
+			_ = e.graphFailure(err)
+			return
+		}
+		e.failures.addName()
+	}
+
+	for _, a := range o.Addresses {
+		if a.Address == nil || e.resolvedFilter.Duplicate(name+"|"+a.Address.String()) {
+			continue
+		}
+
+		op := graphio.OpA
+		if amassnet.IsIPv6(a.Address) {
+			op = graphio.OpAAAA
+		}
+		if err := e.graphWrite(&graphio.JournalOp{
+			Op:   op,
+			Args: []string{name, a.Address.String(), source, uuid},
+		}); err != nil {
+			_ = e.graphFailure(err)
+			continue
+		}
+		e.mergeInfrastructure(a, source)
+	}
+}
+
+// mergeInfrastructure stores the infrastructure announcing the address discovered by a worker,
+// so the output of the enumeration provides it.
+func (e *Enumeration) mergeInfrastructure(a requests.AddressInfo, source string) {
+	if a.ASN == 0 || a.CIDRStr == "" {
+		return
+	}
+
+	addr := a.Address.String()
+	e.Sys.Cache().Update(&requests.ASNRequest{
+		Address:     addr,
+		ASN:         a.ASN,
+		Prefix:      a.CIDRStr,
+		Description: a.Description,
+		Tag:         requests.RIR,
+		Source:      source,
+	})
+	_ = e.graphFailure(e.infrastructureWrite(a.ASN, a.Description, "", addr, a.CIDRStr, source))
+}
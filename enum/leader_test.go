@@ -0,0 +1,439 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+)
+
+// coordResolver answers the A queries for the names it knows, and the PTR queries for the addresses.
+type coordResolver struct {
+	mockResolver
+	ptrs map[string]string
+}
+
+func (r *coordResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	q := msg.Question[0]
+	if q.Qtype != dns.TypePTR {
+		return r.mockResolver.Query(ctx, msg, priority, retry)
+	}
+
+	resp := msg.Copy()
+	resp.Response = true
+	if name, found := r.ptrs[strings.TrimSuffix(q.Name, ".")]; found {
+		resp.Answer = append(resp.Answer, &dns.PTR{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 300},
+			Ptr: dns.Fqdn(name),
+		})
+		return resp, nil
+	}
+
+	resp.Rcode = dns.RcodeNameError
+	return resp, &resolve.ResolveError{Err: "NXDOMAIN", Rcode: dns.RcodeNameError}
+}
+
+// coordSource reports the mail and vpn subdomains of each domain.
+type coordSource struct {
+	service.BaseService
+}
+
+func newCoordSource() *coordSource {
+	s := new(coordSource)
+
+	s.BaseService = *service.NewBaseService(s, "Coord")
+	return s
+}
+
+func (s *coordSource) Description() string {
+	return requests.API
+}
+
+func (s *coordSource) OnRequest(ctx context.Context, args service.Args) {
+	req, ok := args.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	// Allow the event bus to register the subscriptions of the enumeration
+	time.Sleep(100 * time.Millisecond)
+
+	for _, label := range []string{"mail", "vpn"} {
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+			Name:   label + "." + req.Domain,
+			Domain: req.Domain,
+			Tag:    requests.API,
+			Source: s.String(),
+		})
+	}
+}
+
+// coordBrute guesses the names of each root domain using the wordlist of the enumeration.
+type coordBrute struct {
+	service.BaseService
+}
+
+func newCoordBrute() *coordBrute {
+	b := new(coordBrute)
+
+	b.BaseService = *service.NewBaseService(b, "Brute")
+	return b
+}
+
+func (b *coordBrute) Description() string {
+	return requests.BRUTE
+}
+
+func (b *coordBrute) OnRequest(ctx context.Context, args service.Args) {
+	req, ok := args.(*requests.DNSRequest)
+	if !ok || req.Name != req.Domain {
+		return
+	}
+
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	for _, word := range cfg.Wordlist {
+		bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+			Name:   word + "." + req.Domain,
+			Domain: req.Domain,
+			Tag:    requests.BRUTE,
+			Source: b.String(),
+		})
+	}
+}
+
+func newCoordSystem() *mockSystem {
+	addrs := make(map[string]string)
+	for _, d := range []string{"owasp.org", "owasp-amass.com"} {
+		for _, label := range []string{"", "www.", "mail.", "vpn.", "ftp.", "dev."} {
+			addrs[label+d] = "72.237.4.113"
+		}
+	}
+	addrs["gw.owasp.org"] = "203.0.113.5"
+
+	sys := newMockSystem(config.NewConfig())
+	sys.pool = &coordResolver{
+		mockResolver: mockResolver{addrs: addrs},
+		ptrs:         map[string]string{"5.113.0.203.in-addr.arpa": "gw.owasp.org"},
+	}
+	addMockInfrastructure(sys)
+	_ = sys.AddAndStart(newCoordSource())
+	_ = sys.AddAndStart(newCoordBrute())
+	return sys
+}
+
+func coordRequest(t *testing.T, u, token string, msg *workerMessage) *http.Response {
+	body, _ := json.Marshal(msg)
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create the request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send the request to the leader: %v", err)
+	}
+	return resp
+}
+
+func TestCoordinatedEnumeration(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomains("owasp.org", "owasp-amass.com")
+	cfg.BruteForcing = true
+	cfg.Wordlist = []string{"ftp", "dev", "www", "missing1", "missing2"}
+	cfg.WordlistShardSize = 2
+	_, cidr, _ := net.ParseCIDR("203.0.113.0/28")
+	cfg.CIDRs = []*net.IPNet{cidr}
+	cfg.CoordinationToken = "secret"
+	cfg.CoordinationLease = 10 * time.Second
+
+	leader := NewEnumeration(cfg, newMockSystem(config.NewConfig()))
+	defer leader.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen for the workers: %v", err)
+	}
+	u := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	type outcome struct {
+		result *Result
+		err    error
+	}
+	finished := make(chan outcome, 1)
+	go func() {
+		result, err := leader.Coordinate(ctx, ln)
+		finished <- outcome{result: result, err: err}
+	}()
+
+	// The requests without the token are rejected
+	if resp := coordRequest(t, u+coordAssignPath, "wrong", &workerMessage{Worker: "worker-1"}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("The leader returned status %d for the request without the token", resp.StatusCode)
+	}
+	// An assignment is held by the first worker before it restarts
+	resp := coordRequest(t, u+coordAssignPath, "secret", &workerMessage{Worker: "worker-1"})
+	var abandoned Assignment
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&abandoned) != nil {
+		t.Fatalf("The leader did not issue an assignment: status %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"worker-1", "worker-2"} {
+		wcfg := config.NewConfig()
+		wcfg.Dir = t.TempDir()
+		wcfg.CoordinationToken = "secret"
+		sys := newCoordSystem()
+		defer stopSources(sys)
+
+		w, err := NewWorker(wcfg, sys, u)
+		if err != nil {
+			t.Fatalf("Failed to create the worker: %v", err)
+		}
+		w.ID = id
+		w.Timeout = 1500 * time.Millisecond
+
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+
+			if err := w.Run(ctx); err != nil {
+				t.Errorf("The worker %s failed: %v", w.ID, err)
+			}
+		}(w)
+	}
+
+	var out outcome
+	select {
+	case out = <-finished:
+	case <-ctx.Done():
+		t.Fatalf("The coordinated enumeration did not complete")
+	}
+	wg.Wait()
+	if out.err != nil {
+		t.Fatalf("The coordinated enumeration returned an error: %v", out.err)
+	}
+
+	// Each domain, wordlist shard and sweep range was assigned
+	leader.coord.Lock()
+	completed := len(leader.coord.completed)
+	var attempts int
+	if a, found := leader.coord.completed[abandoned.ID]; found {
+		attempts = a.Attempt
+	}
+	leader.coord.Unlock()
+	if completed != 6 {
+		t.Errorf("Expected 6 completed assignments, got %d", completed)
+	}
+	// The assignment held before the restart was issued again
+	if attempts != 2 {
+		t.Errorf("The abandoned assignment %s was issued %d times", abandoned.ID, attempts)
+	}
+
+	var names []string
+	for _, name := range leader.Graph.EventFQDNs(cfg.UUID.String()) {
+		if leader.Scope.Evaluate(name, nil).Domain != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var expected []string
+	for _, d := range []string{"owasp.org", "owasp-amass.com"} {
+		for _, label := range []string{"", "www.", "mail.", "vpn.", "ftp.", "dev."} {
+			expected = append(expected, label+d)
+		}
+	}
+	expected = append(expected, "gw.owasp.org")
+	sort.Strings(expected)
+
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("The graph event contains %v, expected %v", names, expected)
+	}
+	// The names found by several assignments are counted once
+	if out.result.Names != len(expected) {
+		t.Errorf("The result counted %d names, expected %d", out.result.Names, len(expected))
+	}
+}
+
+func TestCoordinationReissue(t *testing.T) {
+	now := time.Now()
+	work := []*Assignment{{ID: "domain:owasp.org"}, {ID: "domain:owasp-amass.com"}}
+	c := newCoordination(work, time.Minute)
+	c.now = func() time.Time { return now }
+
+	first, _ := c.next("worker-1")
+	second, _ := c.next("worker-2")
+	if first == nil || second == nil || first.ID == second.ID {
+		t.Fatalf("The assignments were not issued to both workers")
+	}
+	if a, done := c.next("worker-3"); a != nil || done {
+		t.Errorf("An assignment was issued while each was held")
+	}
+
+	// The assignment held by a worker that does not report is issued again once the lease expires
+	c.renew("worker-2", second.ID)
+	now = now.Add(2 * time.Minute)
+	c.renew("worker-2", second.ID)
+	a, _ := c.next("worker-3")
+	if a == nil || a.ID != first.ID || a.Attempt != 2 {
+		t.Fatalf("The expired assignment was not issued again: %+v", a)
+	}
+
+	// The assignments held by a worker are issued again when it restarts
+	c.register("worker-2")
+	if a, _ := c.next("worker-4"); a == nil || a.ID != second.ID || a.Attempt != 2 {
+		t.Fatalf("The assignment of the restarted worker was not issued again: %+v", a)
+	}
+
+	// The assignment released by the worker that failed to perform it is issued again
+	c.release("worker-2", second.ID)
+	if a, _ := c.next("worker-5"); a != nil {
+		t.Fatalf("The assignment released by a worker not holding it was issued again: %+v", a)
+	}
+	c.release("worker-4", second.ID)
+	if a, _ := c.next("worker-5"); a == nil || a.ID != second.ID || a.Attempt != 3 {
+		t.Fatalf("The released assignment was not issued again: %+v", a)
+	}
+
+	c.complete("worker-1", first.ID)
+	c.complete("worker-5", second.ID)
+	select {
+	case <-c.done:
+	default:
+		t.Errorf("The coordination was not done after each assignment was completed")
+	}
+	if _, done := c.next("worker-1"); !done {
+		t.Errorf("The workers were not told that the coordination is done")
+	}
+}
+
+func TestCoordinationFailedAssignments(t *testing.T) {
+	work := []*Assignment{{ID: "domain:owasp.org"}, {ID: "brute:1"}, {ID: "sweep:203.0.113.0/28"}}
+	c := newCoordination(work, time.Minute)
+
+	// The assignment released by each worker failing to perform it is failed after the last release
+	for i := 1; i <= maxAssignmentReleases; i++ {
+		a, _ := c.next("worker-1")
+		if a == nil || a.ID != "domain:owasp.org" || a.Attempt != i {
+			t.Fatalf("The released assignment was not issued again: %+v", a)
+		}
+		c.release("worker-1", a.ID)
+	}
+
+	// The assignment the worker cannot perform is failed without being issued again
+	a, _ := c.next("worker-2")
+	if a == nil || a.ID != "brute:1" {
+		t.Fatalf("The next assignment was not issued: %+v", a)
+	}
+	c.fail("worker-3", a.ID)
+	c.fail("worker-2", a.ID)
+
+	last, done := c.next("worker-2")
+	if last == nil || last.ID != "sweep:203.0.113.0/28" || done {
+		t.Fatalf("The failed assignments were issued again: %+v", last)
+	}
+	c.complete("worker-2", last.ID)
+	select {
+	case <-c.done:
+	default:
+		t.Errorf("The coordination was not done after each assignment was completed or failed")
+	}
+
+	failed := c.failures()
+	if len(failed) != 2 || failed["domain:owasp.org"] == nil || failed["brute:1"] == nil {
+		t.Errorf("The failed assignments were not reported: %v", failed)
+	}
+}
+
+func TestRetryableAssignmentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&ConfigError{Cause: errors.New("invalid phase")}, false},
+		{&RuntimeError{Phase: PhaseStorage, Cause: errors.New("graph failure")}, false},
+		{fmt.Errorf("wrapped: %w", &ConfigError{Cause: errors.New("invalid phase")}), false},
+		{&RuntimeError{Phase: PhasePipeline, Cause: errors.New("pipeline failure")}, true},
+		{&StartupError{Component: "resolvers", Cause: errors.New("no resolvers")}, true},
+		{context.DeadlineExceeded, true},
+	} {
+		if got := retryable(tc.err); got != tc.retryable {
+			t.Errorf("retryable(%v) returned %t, expected %t", tc.err, got, tc.retryable)
+		}
+	}
+}
+
+func TestPartitionWork(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "owasp-amass.com")
+	cfg.BruteForcing = true
+	cfg.Wordlist = []string{"a", "b", "c", "d", "e"}
+	cfg.BruteMasks = []string{"vpn?d"}
+	cfg.WordlistShardSize = 2
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/18")
+	cfg.CIDRs = []*net.IPNet{cidr}
+	cfg.Addresses = []net.IP{net.ParseIP("192.0.2.1")}
+
+	var ids []string
+	for _, a := range partitionWork(cfg) {
+		ids = append(ids, a.ID)
+
+		if a.Kind == AssignBrute && (len(a.Domains) != 2 || (a.ID == "brute:1") != (len(a.Masks) == 1)) {
+			t.Errorf("Unexpected brute forcing assignment: %+v", a)
+		}
+	}
+
+	expected := []string{
+		"domain:owasp.org", "domain:owasp-amass.com",
+		"brute:1", "brute:2", "brute:3",
+		"sweep:10.0.0.0/20", "sweep:10.0.16.0/20", "sweep:10.0.32.0/20", "sweep:10.0.48.0/20",
+		"sweep:192.0.2.1/32",
+	}
+	if strings.Join(ids, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the assignments %v, got %v", expected, ids)
+	}
+
+	// Passive enumerations are only partitioned by the root domain names
+	cfg.Passive = true
+	if work := partitionWork(cfg); len(work) != 2 {
+		t.Errorf("Expected 2 assignments for the passive enumeration, got %d", len(work))
+	}
+	// The larger netblocks are split into a limited number of ranges
+	_, cidr, _ = net.ParseCIDR("16.0.0.0/4")
+	if ranges := sweepRanges(cidr); len(ranges) != 1<<maxSweepRangeBits || ranges[1].String() != "16.1.0.0/16" {
+		t.Errorf("Unexpected ranges for the large netblock: %d", len(ranges))
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// The time allowed for each send to the collector, and for the final flush once the enumeration is done.
+const (
+	logForwardSendTimeout  = 10 * time.Second
+	logForwardFlushTimeout = 15 * time.Second
+)
+
+// logForwarder ships the log events of the enumeration to an external collector in batches.
+// The events are buffered while the collector is not reachable, and the oldest events are
+// dropped once the buffer is full. Identical events are buffered once within the window.
+type logForwarder struct {
+	sync.Mutex
+	send     func([]*requests.LogEvent) error
+	batch    int
+	interval time.Duration
+	max      int
+	window   time.Duration
+	buffer   []*requests.LogEvent
+	seen     map[string]time.Time
+	dropped  int
+	signal   chan struct{}
+	done     chan struct{}
+	finished chan struct{}
+}
+
+func newLogForwarder(cfg *config.Config) (*logForwarder, error) {
+	u, err := config.ParseLogForwardURL(cfg.LogForwardURL)
+	if err != nil {
+		return nil, err
+	}
+
+	send := httpLogSender(u.String())
+	if strings.EqualFold(u.Scheme, "udp") {
+		send = syslogSender(u.Host)
+	}
+	return startLogForwarder(send, cfg.LogForwardBatchSize, cfg.LogForwardInterval,
+		cfg.LogForwardBuffer, cfg.LogForwardDedup), nil
+}
+
+func startLogForwarder(send func([]*requests.LogEvent) error, batch int, interval time.Duration, max int, window time.Duration) *logForwarder {
+	if batch <= 0 {
+		batch = config.DefaultLogForwardBatchSize
+	}
+	if interval <= 0 {
+		interval = config.DefaultLogForwardInterval
+	}
+	if max < batch {
+		max = batch
+	}
+
+	f := &logForwarder{
+		send:     send,
+		batch:    batch,
+		interval: interval,
+		max:      max,
+		window:   window,
+		seen:     make(map[string]time.Time),
+		signal:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+
+	go f.run()
+	return f
+}
+
+// add buffers the event for the collector without waiting on the sends.
+func (f *logForwarder) add(ev *requests.LogEvent) {
+	key := ev.Component + "|" + ev.Severity.String() + "|" + ev.Message
+	now := time.Now()
+
+	f.Lock()
+	if last, found := f.seen[key]; found && now.Sub(last) < f.window {
+		f.Unlock()
+		return
+	}
+	if f.window > 0 {
+		f.seen[key] = now
+	}
+
+	f.buffer = append(f.buffer, ev)
+	f.trim()
+	full := len(f.buffer) >= f.batch
+	f.Unlock()
+
+	if full {
+		select {
+		case f.signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// trim drops the oldest events beyond the size of the buffer. The lock must be held.
+func (f *logForwarder) trim() {
+	if over := len(f.buffer) - f.max; over > 0 {
+		f.buffer = f.buffer[over:]
+		f.dropped += over
+	}
+}
+
+func (f *logForwarder) run() {
+	defer close(f.finished)
+
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			f.flush(true)
+			return
+		case <-f.signal:
+			f.flush(false)
+		case now := <-t.C:
+			f.prune(now)
+			f.flush(true)
+		}
+	}
+}
+
+// flush sends the buffered events in batches, including the last partial batch when requested.
+// The events are returned to the buffer and the flush stops when the collector cannot be reached.
+func (f *logForwarder) flush(partial bool) {
+	for {
+		f.Lock()
+		num := len(f.buffer)
+		if num == 0 || (num < f.batch && !partial) {
+			f.Unlock()
+			return
+		}
+		if num > f.batch {
+			num = f.batch
+		}
+
+		events := f.buffer[:num:num]
+		f.buffer = f.buffer[num:]
+		f.Unlock()
+
+		if err := f.send(events); err != nil {
+			f.Lock()
+			f.buffer = append(events, f.buffer...)
+			f.trim()
+			f.Unlock()
+			return
+		}
+	}
+}
+
+// prune forgets the events last buffered before the deduplication window.
+func (f *logForwarder) prune(now time.Time) {
+	f.Lock()
+	defer f.Unlock()
+
+	for key, last := range f.seen {
+		if now.Sub(last) >= f.window {
+			delete(f.seen, key)
+		}
+	}
+}
+
+// close sends the remaining events and returns the number of events that were not delivered.
+func (f *logForwarder) close() int {
+	close(f.done)
+
+	t := time.NewTimer(logForwardFlushTimeout)
+	defer t.Stop()
+
+	select {
+	case <-f.finished:
+	case <-t.C:
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	return f.dropped + len(f.buffer)
+}
+
+// httpLogSender posts each batch of events to the collector URL as a JSON array.
+func httpLogSender(collector string) func([]*requests.LogEvent) error {
+	client := &http.Client{Timeout: logForwardSendTimeout}
+
+	return func(events []*requests.LogEvent) error {
+		body, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Post(collector, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("The log collector returned status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// syslogSender sends each event to the syslog target as an RFC 5424 message over UDP.
+func syslogSender(addr string) func([]*requests.LogEvent) error {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "-"
+	}
+
+	return func(events []*requests.LogEvent) error {
+		conn, err := net.DialTimeout("udp", addr, logForwardSendTimeout)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_ = conn.SetWriteDeadline(time.Now().Add(logForwardSendTimeout))
+		for _, ev := range events {
+			if _, err := conn.Write([]byte(syslogMessage(host, ev))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// syslogMessage formats the event as an RFC 5424 message using the user-level facility.
+func syslogMessage(host string, ev *requests.LogEvent) string {
+	sev := 6
+	switch ev.Severity {
+	case requests.LogDebug:
+		sev = 7
+	case requests.LogWarning:
+		sev = 4
+	case requests.LogError:
+		sev = 3
+	}
+
+	msgid := "-"
+	if ev.Component != "" {
+		msgid = strings.ReplaceAll(ev.Component, " ", "_")
+	}
+
+	sd := "-"
+	if len(ev.Fields) > 0 {
+		keys := make([]string, 0, len(ev.Fields))
+		for k := range ev.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+		sd = "[amass"
+		for _, k := range keys {
+			sd += " " + k + `="` + escape.Replace(ev.Fields[k]) + `"`
+		}
+		sd += "]"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s amass - %s %s %s", 8+sev,
+		ev.Time.UTC().Format(time.RFC3339Nano), host, msgid, sd, ev.Message)
+}
+
+// setupLogForwarder starts forwarding the log events when a collector has been configured.
+func (e *Enumeration) setupLogForwarder() error {
+	if e.Config.LogForwardURL == "" {
+		return nil
+	}
+
+	f, err := newLogForwarder(e.Config)
+	if err != nil {
+		return err
+	}
+
+	e.forwarder = f
+	return nil
+}
+
+// closeLogForwarder sends the remaining log events to the collector.
+func (e *Enumeration) closeLogForwarder() {
+	if e.forwarder == nil {
+		return
+	}
+
+	if lost := e.forwarder.close(); lost > 0 && e.Config.Log != nil {
+		e.Config.Log.Printf("%d log events were not delivered to the log collector", lost)
+	}
+}
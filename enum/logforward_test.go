@@ -0,0 +1,221 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/service"
+)
+
+// logCollector records the batches of log events posted to it, and fails the requests while down.
+type logCollector struct {
+	sync.Mutex
+	*httptest.Server
+	down    bool
+	batches [][]*requests.LogEvent
+}
+
+func newLogCollector() *logCollector {
+	c := new(logCollector)
+
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Lock()
+		defer c.Unlock()
+
+		if c.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var batch []*requests.LogEvent
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.batches = append(c.batches, batch)
+	}))
+	return c
+}
+
+func (c *logCollector) setDown(down bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.down = down
+}
+
+func (c *logCollector) messages() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	var msgs []string
+	for _, batch := range c.batches {
+		for _, ev := range batch {
+			msgs = append(msgs, ev.Message)
+		}
+	}
+	return msgs
+}
+
+func (c *logCollector) sizes() []int {
+	c.Lock()
+	defer c.Unlock()
+
+	var sizes []int
+	for _, batch := range c.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func waitForMessages(c *logCollector, num int) []string {
+	for i := 0; i < 100; i++ {
+		if msgs := c.messages(); len(msgs) >= num {
+			return msgs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return c.messages()
+}
+
+func TestLogForwarderBatching(t *testing.T) {
+	c := newLogCollector()
+	defer c.Close()
+
+	f := startLogForwarder(httpLogSender(c.URL), 3, time.Hour, 100, time.Minute)
+	for _, msg := range []string{"one", "two", "two", "three", "four", "five", "six", "seven"} {
+		f.add(requests.NewLogEvent("DNS", requests.LogWarning, msg))
+	}
+
+	// Only the full batches are sent before the flush interval
+	if msgs := waitForMessages(c, 6); len(msgs) != 6 {
+		t.Fatalf("Expected two full batches, got %v", msgs)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if msgs := c.messages(); len(msgs) != 6 {
+		t.Errorf("The partial batch was sent before the shutdown: %v", msgs)
+	}
+
+	if lost := f.close(); lost != 0 {
+		t.Errorf("%d events were not delivered", lost)
+	}
+	msgs := c.messages()
+	if strings.Join(msgs, ",") != "one,two,three,four,five,six,seven" {
+		t.Errorf("The duplicate event was not dropped or the events are out of order: %v", msgs)
+	}
+	if sizes := c.sizes(); len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("Unexpected batch sizes %v", sizes)
+	}
+}
+
+func TestLogForwarderBuffering(t *testing.T) {
+	c := newLogCollector()
+	defer c.Close()
+	c.setDown(true)
+
+	f := startLogForwarder(httpLogSender(c.URL), 2, time.Hour, 4, 0)
+	for _, msg := range []string{"one", "two", "three", "four", "five", "six"} {
+		f.add(requests.NewLogEvent("DNS", requests.LogWarning, msg))
+	}
+	// Identical events are forwarded without a deduplication window
+	f.add(requests.NewLogEvent("DNS", requests.LogWarning, "six"))
+	time.Sleep(100 * time.Millisecond)
+
+	if msgs := c.messages(); len(msgs) != 0 {
+		t.Fatalf("The collector received events while down: %v", msgs)
+	}
+
+	// The oldest events were dropped from the buffer
+	c.setDown(false)
+	if lost := f.close(); lost != 3 {
+		t.Errorf("Expected 3 events to be dropped, got %d", lost)
+	}
+	if msgs := c.messages(); strings.Join(msgs, ",") != "four,five,six,six" {
+		t.Errorf("Unexpected events after the collector recovered: %v", msgs)
+	}
+}
+
+func TestSyslogMessage(t *testing.T) {
+	ev := requests.NewLogEvent("Data Source", requests.LogError, "request failed").
+		With(requests.LogFieldURL, `https://example.com/"a"`)
+	ev.Time = time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	expected := `<11>1 2021-04-01T12:00:00Z host amass - Data_Source [amass url="https://example.com/\"a\""] request failed`
+	if msg := syslogMessage("host", ev); msg != expected {
+		t.Errorf("Unexpected syslog message:\n%s\n%s", msg, expected)
+	}
+}
+
+// logSource publishes a warning twice for each domain it is requested to enumerate.
+type logSource struct {
+	service.BaseService
+}
+
+func newLogSource() *logSource {
+	s := new(logSource)
+
+	s.BaseService = *service.NewBaseService(s, "Logger")
+	return s
+}
+
+func (s *logSource) Description() string {
+	return requests.API
+}
+
+func (s *logSource) OnRequest(ctx context.Context, args service.Args) {
+	req, ok := args.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	// Allow the event bus to register the subscriptions of the enumeration
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			requests.NewLogEvent(s.String(), requests.LogWarning, "rate limited for %s", req.Domain))
+	}
+}
+
+func TestEnumerationLogForwarding(t *testing.T) {
+	c := newLogCollector()
+	defer c.Close()
+
+	sys, _ := newResumeSystem(newLogSource())
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.LogForwardURL = c.URL
+	cfg.LogForwardInterval = time.Hour
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	runResume(t, e)
+	// The events are sent during the shutdown of the enumeration
+	var found int
+	for _, msg := range c.messages() {
+		if msg == "rate limited for owasp.org" {
+			found++
+		}
+	}
+	if found != 1 {
+		t.Errorf("The warning was forwarded %d times: %v", found, c.messages())
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/datasrcs"
+)
+
+// ManifestVersion is the schema version of the run manifests written by this release.
+const ManifestVersion = 1
+
+// ManifestFile is the name of the run manifest written to the output directory by Start.
+const ManifestFile = "manifest.json"
+
+// The outcomes of the enumerations described by a Manifest.
+const (
+	ManifestCompleted = "completed"
+	ManifestPartial   = "partial"
+	ManifestFailed    = "failed"
+)
+
+// The artifacts always described by a Manifest. Other artifacts are added using AddArtifact.
+const (
+	ArtifactDirectory = "directory"
+	ArtifactJSON      = "json"
+	ArtifactJournal   = "journal"
+)
+
+// Manifest describes an enumeration run. It is written by Start when the enumeration ends,
+// including when Start returns an error, so that every run is accounted for.
+type Manifest struct {
+	Version  int           `json:"version"`
+	Event    string        `json:"event"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Status   string        `json:"status"`
+	Scope    ManifestScope `json:"scope"`
+	// The names of the data sources selected for the enumeration
+	Sources []string       `json:"sources"`
+	Totals  ManifestTotals `json:"totals"`
+	// The phases that stopped dispatching work once their time budgets expired
+	PhaseTransitions []PhaseTransition `json:"phase_transitions,omitempty"`
+	// The data sources that had their requests dropped after repeated failures
+	SidelinedSources []datasrcs.SourceHealth `json:"sidelined_sources,omitempty"`
+	// The summary of the answers verified across distinct resolvers, and the queries it cost
+	Consistency *ConsistencyReport `json:"consistency,omitempty"`
+	// Set when Start returned an error
+	Error *ManifestError `json:"error,omitempty"`
+	// The paths of the files and directories produced by the run, keyed by the artifact kind
+	Artifacts map[string]string `json:"artifacts"`
+}
+
+// ManifestScope is the scope of the enumeration described by a Manifest.
+type ManifestScope struct {
+	Domains   []string `json:"domains"`
+	Blacklist []string `json:"blacklist,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	CIDRs     []string `json:"cidrs,omitempty"`
+	ASNs      []int    `json:"asns,omitempty"`
+	Active    bool     `json:"active"`
+	Passive   bool     `json:"passive"`
+}
+
+// ManifestTotals are the summary counters of the enumeration described by a Manifest.
+type ManifestTotals struct {
+	// The number of unique in-scope names discovered
+	Names int `json:"names"`
+	// The discovery statistics of each data source, keyed by the source name
+	Sources map[string]SourceStats `json:"sources,omitempty"`
+	// The measurements of the trusted and untrusted processing lanes, keyed by the lane name
+	Lanes map[string]LaneStats `json:"lanes,omitempty"`
+}
+
+// ManifestError summarizes the error returned by Start.
+type ManifestError struct {
+	// One of config, startup or runtime
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// The components that failed during the enumeration
+	FailedComponents []string `json:"failed_components,omitempty"`
+	StorageDegraded  bool     `json:"storage_degraded,omitempty"`
+	StopReason       string   `json:"stop_reason,omitempty"`
+}
+
+// AddArtifact records the path of a file produced by the run in the manifest written by Start.
+func (e *Enumeration) AddArtifact(kind, path string) {
+	e.artifactLock.Lock()
+	defer e.artifactLock.Unlock()
+
+	if e.artifacts == nil {
+		e.artifacts = make(map[string]string)
+	}
+	e.artifacts[kind] = path
+}
+
+// manifest assembles the Manifest of the run from the state of the enumeration.
+func (e *Enumeration) manifest(started time.Time, result *Result, err error) *Manifest {
+	cfg := e.Config
+	m := &Manifest{
+		Version:  ManifestVersion,
+		Event:    cfg.UUID.String(),
+		Started:  started,
+		Finished: time.Now(),
+		Status:   ManifestCompleted,
+		Scope: ManifestScope{
+			Domains:   append([]string(nil), cfg.Domains()...),
+			Blacklist: cfg.Blacklist,
+			ASNs:      cfg.ASNs,
+			Active:    cfg.Active,
+			Passive:   cfg.Passive,
+		},
+		Sources:   []string{},
+		Artifacts: map[string]string{ArtifactDirectory: config.OutputDirectory(cfg.Dir)},
+	}
+	sort.Strings(m.Scope.Domains)
+
+	for _, addr := range cfg.Addresses {
+		m.Scope.Addresses = append(m.Scope.Addresses, addr.String())
+	}
+	for _, cidr := range cfg.CIDRs {
+		m.Scope.CIDRs = append(m.Scope.CIDRs, cidr.String())
+	}
+	for _, src := range e.srcs {
+		m.Sources = append(m.Sources, src.String())
+	}
+	sort.Strings(m.Sources)
+
+	if stats := e.SourceStats(); len(stats) > 0 {
+		m.Totals.Sources = stats
+	}
+	if !cfg.Passive {
+		m.Totals.Lanes = e.LaneStats()
+	}
+	m.PhaseTransitions = e.PhaseTransitions()
+	m.SidelinedSources = e.SidelinedSources()
+	m.Consistency = e.ConsistencyReport()
+	if f, ok := e.JSONOutput.(*os.File); ok {
+		m.Artifacts[ArtifactJSON] = f.Name()
+	}
+	e.artifactLock.Lock()
+	for kind, path := range e.artifacts {
+		m.Artifacts[kind] = path
+	}
+	e.artifactLock.Unlock()
+
+	if result != nil {
+		m.Totals.Names = result.Names
+		if result.Journal != "" {
+			m.Artifacts[ArtifactJournal] = result.Journal
+		}
+	}
+	if err == nil {
+		return m
+	}
+
+	m.Status = ManifestFailed
+	if result != nil && result.Partial() {
+		m.Status = ManifestPartial
+	}
+	m.Error = &ManifestError{
+		Kind:       manifestErrorKind(err),
+		Message:    err.Error(),
+		StopReason: e.StopReason(),
+	}
+	if result != nil {
+		m.Error.FailedComponents = result.FailedComponents
+		m.Error.StorageDegraded = result.StorageDegraded
+	}
+	return m
+}
+
+func manifestErrorKind(err error) string {
+	var cerr *ConfigError
+	var serr *StartupError
+
+	if errors.As(err, &cerr) {
+		return "config"
+	} else if errors.As(err, &serr) {
+		return "startup"
+	}
+	return "runtime"
+}
+
+// writeManifest writes the Manifest of the run to the output directory, replacing the
+// manifest of a previous run only once the new manifest has been completely written.
+func (e *Enumeration) writeManifest(m *Manifest) error {
+	dir := config.OutputDirectory(e.Config.Dir)
+	if dir == "" {
+		return errors.New("Failed to obtain the output directory")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ManifestFile+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write the manifest: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, ManifestFile))
+}
+
+// ReadManifest parses a run manifest. Manifests written by a later schema version are rejected.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var m Manifest
+
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("Failed to parse the manifest: %v", err)
+	}
+	if m.Version < 1 || m.Version > ManifestVersion {
+		return nil, fmt.Errorf("The manifest version %d is not supported", m.Version)
+	}
+	return &m, nil
+}
+
+// LoadManifest reads the run manifest at the path provided. When the path is a directory,
+// the manifest written by Start to that output directory is read.
+func LoadManifest(path string) (*Manifest, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, ManifestFile)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadManifest(f)
+}
@@ -0,0 +1,94 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+)
+
+func TestStartWritesManifest(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	_ = sys.AddAndStart(newMockSource())
+	defer func() {
+		for _, src := range sys.DataSources() {
+			_ = src.Stop()
+		}
+	}()
+
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+	cfg.Passive = true
+
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+	e.AddArtifact("text", filepath.Join(cfg.Dir, "amass.txt"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration failed: %v", err)
+	}
+
+	m, err := LoadManifest(cfg.Dir)
+	if err != nil {
+		t.Fatalf("Failed to load the manifest: %v", err)
+	}
+	if m.Version != ManifestVersion || m.Event != cfg.UUID.String() || m.Status != ManifestCompleted || m.Error != nil {
+		t.Errorf("Unexpected manifest: %+v", m)
+	}
+	if m.Started.IsZero() || m.Finished.Before(m.Started) {
+		t.Errorf("Unexpected run times: %v to %v", m.Started, m.Finished)
+	}
+	if len(m.Scope.Domains) != 1 || m.Scope.Domains[0] != "owasp.org" || !m.Scope.Passive {
+		t.Errorf("Unexpected scope: %+v", m.Scope)
+	}
+	if len(m.Sources) != 1 || m.Sources[0] != "Mock" {
+		t.Errorf("Unexpected sources: %v", m.Sources)
+	}
+	if m.Totals.Names == 0 || len(m.Totals.Sources) == 0 {
+		t.Errorf("Unexpected totals: %+v", m.Totals)
+	}
+	if m.Artifacts[ArtifactDirectory] != cfg.Dir || m.Artifacts["text"] != filepath.Join(cfg.Dir, "amass.txt") {
+		t.Errorf("Unexpected artifacts: %v", m.Artifacts)
+	}
+}
+
+func TestStartWritesFailedManifest(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+
+	// The mock system does not provide a resolver pool
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+	if _, err := e.Start(context.Background()); err == nil {
+		t.Fatal("The enumeration started without a resolver pool")
+	}
+
+	m, err := LoadManifest(filepath.Join(cfg.Dir, ManifestFile))
+	if err != nil {
+		t.Fatalf("Failed to load the manifest: %v", err)
+	}
+	if m.Status != ManifestFailed || m.Error == nil || m.Error.Kind != "startup" {
+		t.Fatalf("Unexpected manifest of the failed run: %+v", m)
+	}
+	if !strings.Contains(m.Error.Message, "resolvers") {
+		t.Errorf("Unexpected error message: %s", m.Error.Message)
+	}
+}
+
+func TestReadManifestVersion(t *testing.T) {
+	for _, input := range []string{`{"version":0}`, `{"version":99}`, `{`} {
+		if _, err := ReadManifest(strings.NewReader(input)); err == nil {
+			t.Errorf("The manifest %s was accepted", input)
+		}
+	}
+}
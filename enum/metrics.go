@@ -0,0 +1,210 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OWASP/Amass/v3/resolvers"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+// The time between the samples of the DNS query rate reported by the metrics server.
+var metricsInterval = 5 * time.Second
+
+// processMetrics holds the measurements of the enumeration exposed by the metrics server.
+type processMetrics struct {
+	sync.Mutex
+	// The DNS queries sent by the enumeration and the retries of those queries, updated atomically
+	queries      int64
+	retries      int64
+	src          *enumSource
+	started      time.Time
+	server       *http.Server
+	addr         string
+	rate         float64
+	drain        float64
+	last         int64
+	lastReleased int64
+	lastAt       time.Time
+}
+
+func (m *processMetrics) setSource(src *enumSource, started time.Time) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.src = src
+	m.started = started
+}
+
+// sample updates the DNS query rate from the queries sent since the previous sample, and the
+// drain rate of the name queue from the names released since the previous sample.
+func (m *processMetrics) sample(now time.Time) {
+	m.Lock()
+	defer m.Unlock()
+
+	count := atomic.LoadInt64(&m.queries)
+	var released int64
+	if m.src != nil {
+		for _, lane := range []string{LaneTrusted, LaneUntrusted} {
+			released += m.src.queue.stats(lane).Released
+		}
+	}
+
+	if elapsed := now.Sub(m.lastAt).Seconds(); !m.lastAt.IsZero() && elapsed > 0 {
+		m.rate = float64(count-m.last) / elapsed
+		m.drain = float64(released-m.lastReleased) / elapsed
+	}
+	m.last = count
+	m.lastReleased = released
+	m.lastAt = now
+}
+
+// countedResolver counts the DNS queries sent by the enumeration through the resolver pool,
+// and the retries allowed by the retry policy of each query.
+type countedResolver struct {
+	resolve.Resolver
+	queries *int64
+	retries *int64
+}
+
+func (r *countedResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	atomic.AddInt64(r.queries, 1)
+
+	if policy := retry; policy != nil {
+		retry = func(times int, priority int, m *dns.Msg) bool {
+			if policy(times, priority, m) {
+				atomic.AddInt64(r.retries, 1)
+				return true
+			}
+			return false
+		}
+	}
+	return r.Resolver.Query(ctx, msg, priority, retry)
+}
+
+// pool returns the resolver pool of the System, counting the queries sent by the enumeration.
+func (e *Enumeration) pool() resolve.Resolver {
+	p := e.Sys.Pool()
+	if p == nil {
+		return nil
+	}
+	return &countedResolver{
+		Resolver: p,
+		queries:  &e.metrics.queries,
+		retries:  &e.metrics.retries,
+	}
+}
+
+// StartMetricsServer starts an HTTP server listening on the address provided that exposes the
+// metrics of the enumeration at /metrics in the Prometheus text format. The server is shut down
+// when the enumeration is done. An error is returned when the address cannot be listened on.
+func (e *Enumeration) StartMetricsServer(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Failed to start the metrics server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.writeMetrics(w)
+	})
+	srv := &http.Server{Handler: mux}
+
+	e.metrics.Lock()
+	if e.metrics.server != nil {
+		e.metrics.Unlock()
+		ln.Close()
+		return fmt.Errorf("The metrics server is already listening on %s", e.metrics.addr)
+	}
+	e.metrics.server = srv
+	e.metrics.addr = ln.Addr().String()
+	e.metrics.last = atomic.LoadInt64(&e.metrics.queries)
+	e.metrics.lastAt = time.Now()
+	e.metrics.Unlock()
+
+	go func() { _ = srv.Serve(ln) }()
+	go e.stopMetricsServer(srv)
+	return nil
+}
+
+func (e *Enumeration) stopMetricsServer(srv *http.Server) {
+	<-e.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = srv.Shutdown(ctx)
+}
+
+// sampleMetrics updates the rates reported by the metrics server and Progress until the
+// enumeration is done.
+func (e *Enumeration) sampleMetrics() {
+	t := time.NewTicker(metricsInterval)
+	defer t.Stop()
+
+	e.metrics.sample(time.Now())
+	for {
+		select {
+		case <-e.done:
+			return
+		case now := <-t.C:
+			e.metrics.sample(now)
+		}
+	}
+}
+
+// writeMetrics writes the metrics of the enumeration in the Prometheus text format.
+func (e *Enumeration) writeMetrics(w io.Writer) {
+	e.metrics.Lock()
+	rate := e.metrics.rate
+	var remaining int
+	if e.metrics.src != nil {
+		remaining = e.metrics.src.queue.Len()
+	}
+	e.metrics.Unlock()
+
+	writeMetric(w, "amass_dns_queries_per_sec", "gauge",
+		"The DNS queries sent per second by the enumeration", rate)
+	writeMetric(w, "amass_names_remaining", "gauge",
+		"The names waiting to be processed by the enumeration", float64(remaining))
+	writeMetric(w, "amass_names_discovered_total", "counter",
+		"The unique in-scope names discovered by the enumeration", float64(e.failures.result().Names))
+	writeMetric(w, "amass_resolvers_active", "gauge",
+		"The resolvers currently selected for DNS queries", float64(e.activeResolvers()))
+}
+
+// activeResolvers returns the number of resolvers in the pool that are not quarantined.
+// The configured resolvers are reported when the pool does not track the resolvers.
+func (e *Enumeration) activeResolvers() int {
+	if e.Config.Passive {
+		return 0
+	}
+
+	stats := resolvers.StatsByResolver(e.Sys.Pool())
+	if stats == nil {
+		return len(e.Config.Resolvers)
+	}
+
+	var active int
+	for _, s := range stats {
+		if !s.Quarantined {
+			active++
+		}
+	}
+	return active
+}
+
+func writeMetric(w io.Writer, name, mtype, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, mtype, name, value)
+}
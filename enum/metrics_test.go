@@ -0,0 +1,86 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+func TestMetricsServer(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	sys.pool = &mockResolver{addrs: map[string]string{"www.owasp.org": "72.237.4.113"}}
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{"8.8.8.8", "1.1.1.1"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	if err := e.StartMetricsServer("127.0.0.1:0"); err != nil {
+		t.Fatalf("Failed to start the metrics server: %v", err)
+	}
+	if err := e.StartMetricsServer("127.0.0.1:0"); err == nil {
+		t.Errorf("A second metrics server was started")
+	}
+
+	e.metrics.Lock()
+	url := "http://" + e.metrics.addr + "/metrics"
+	start := e.metrics.lastAt
+	e.metrics.Unlock()
+
+	for i := 0; i < 10; i++ {
+		msg := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+		_, _ = e.pool().Query(context.Background(), msg, resolve.PriorityLow, resolve.RetryPolicy)
+	}
+	e.failures.addName()
+	e.metrics.sample(start.Add(2 * time.Second))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Failed to scrape the metrics: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %s", ct)
+	}
+	for _, line := range []string{
+		"# TYPE amass_dns_queries_per_sec gauge",
+		"amass_dns_queries_per_sec 5\n",
+		"amass_names_remaining 0\n",
+		"# TYPE amass_names_discovered_total counter",
+		"amass_names_discovered_total 1\n",
+		"amass_resolvers_active 2\n",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("The metrics are missing %q:\n%s", line, body)
+		}
+	}
+
+	// The server is shut down once the enumeration is done
+	e.stop()
+	client := &http.Client{Timeout: time.Second}
+	for i := 0; ; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			break
+		}
+		resp.Body.Close()
+
+		if i == 20 {
+			t.Fatalf("The metrics server was not shut down")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
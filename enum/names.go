@@ -14,19 +14,32 @@ import (
 
 // subdomainTask handles newly discovered proper subdomain names in the enumeration.
 type subdomainTask struct {
-	enum      *Enumeration
-	queue     queue.Queue
-	timesChan chan *timesReq
-	done      chan struct{}
+	enum        *Enumeration
+	queue       queue.Queue
+	timesChan   chan *timesReq
+	seedChan    chan map[string]int
+	sampleChan  chan *sampleReq
+	capsChan    chan chan []CappedSubdomain
+	sourcesChan chan *sourcesReq
+	// Closed by the timesManager once finalCaps and finalSources have been set
+	stopped      chan struct{}
+	finalCaps    []CappedSubdomain
+	finalSources map[string]map[string]int
+	done         chan struct{}
 }
 
 // newSubdomainTask returns an initialized SubdomainTask.
 func newSubdomainTask(e *Enumeration) *subdomainTask {
 	r := &subdomainTask{
-		enum:      e,
-		queue:     queue.NewQueue(),
-		timesChan: make(chan *timesReq, 10),
-		done:      make(chan struct{}, 2),
+		enum:        e,
+		queue:       queue.NewQueue(),
+		timesChan:   make(chan *timesReq, 10),
+		seedChan:    make(chan map[string]int),
+		sampleChan:  make(chan *sampleReq),
+		capsChan:    make(chan chan []CappedSubdomain),
+		sourcesChan: make(chan *sourcesReq),
+		stopped:     make(chan struct{}),
+		done:        make(chan struct{}, 2),
 	}
 
 	go r.timesManager()
@@ -51,7 +64,7 @@ func (r *subdomainTask) Process(ctx context.Context, data pipeline.Data, tp pipe
 	if !ok {
 		return data, nil
 	}
-	if req == nil || !r.enum.Config.IsDomainInScope(req.Name) {
+	if req == nil || r.enum.Scope.Evaluate(req.Name, nil).Domain == "" {
 		return nil, nil
 	}
 
@@ -64,13 +77,16 @@ func (r *subdomainTask) Process(ctx context.Context, data pipeline.Data, tp pipe
 		}
 	}
 
-	r.queue.Append(&requests.ResolvedRequest{
-		Name:    req.Name,
-		Domain:  req.Domain,
-		Records: append([]requests.DNSAnswer(nil), req.Records...),
-		Tag:     req.Tag,
-		Source:  req.Source,
-	})
+	// The resolved names are only kept for the data sources
+	if r.enum.hasSources() {
+		r.queue.Append(&requests.ResolvedRequest{
+			Name:    req.Name,
+			Domain:  req.Domain,
+			Records: append([]requests.DNSAnswer(nil), req.Records...),
+			Tag:     req.Tag,
+			Source:  req.Source,
+		})
+	}
 
 	return r.checkForSubdomains(ctx, req, tp)
 }
@@ -99,10 +115,12 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 		Records: append([]requests.DNSAnswer(nil), req.Records...),
 		Tag:     req.Tag,
 		Source:  req.Source,
-		Times:   r.timesForSubdomain(sub),
+		Times:   r.timesForSubdomain(sub, req.Source),
 	}
 
-	r.queue.Append(subreq)
+	if r.enum.hasSources() {
+		r.queue.Append(subreq)
+	}
 	// First time this proper subdomain has been seen?
 	if sub != req.Domain && subreq.Times == 1 {
 		pipeline.SendData(ctx, "root", subreq, tp)
@@ -112,7 +130,7 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 
 // OutputRequests sends discovered subdomain names to the enumeration data sources.
 func (r *subdomainTask) OutputRequests(num int) int {
-	if num <= 0 {
+	if num <= 0 || !r.enum.hasSources() {
 		return 0
 	}
 
@@ -133,9 +151,9 @@ loop:
 		for _, src := range r.enum.srcs {
 			switch v := element.(type) {
 			case *requests.ResolvedRequest:
-				src.Request(r.enum.ctx, v.Clone())
+				r.enum.sourceRequest(src, v.Clone())
 			case *requests.SubdomainRequest:
-				src.Request(r.enum.ctx, v.Clone())
+				r.enum.sourceRequest(src, v.Clone())
 			default:
 				continue loop
 			}
@@ -150,28 +168,45 @@ loop:
 	return count
 }
 
-func (r *subdomainTask) timesForSubdomain(sub string) int {
+func (r *subdomainTask) timesForSubdomain(sub, source string) int {
 	ch := make(chan int, 2)
 
 	r.timesChan <- &timesReq{
-		Sub: sub,
-		Ch:  ch,
+		Sub:    sub,
+		Source: source,
+		Ch:     ch,
 	}
 
 	return <-ch
 }
 
+// seedTimes adds the times each proper subdomain was seen before the enumeration started.
+func (r *subdomainTask) seedTimes(subdomains map[string]int) {
+	r.seedChan <- subdomains
+}
+
 type timesReq struct {
+	Sub    string
+	Source string
+	Ch     chan int
+}
+
+type sampleReq struct {
 	Sub string
-	Ch  chan int
+	Ch  chan bool
 }
 
 func (r *subdomainTask) timesManager() {
 	subdomains := make(map[string]int)
+	caps := make(map[string]*subdomainCap)
+	sources := make(map[string]map[string]int)
 
 	for {
 		select {
 		case <-r.done:
+			r.finalCaps = cappedSubdomains(r.enum.Config.MaxNamesPerSubdomain, subdomains, caps)
+			r.finalSources = copySubdomainSources(sources, "")
+			close(r.stopped)
 			return
 		case req := <-r.timesChan:
 			times, found := subdomains[req.Sub]
@@ -182,7 +217,18 @@ func (r *subdomainTask) timesManager() {
 			}
 
 			subdomains[req.Sub] = times
+			addSubdomainSource(sources, req.Sub, req.Source)
 			req.Ch <- times
+		case seed := <-r.seedChan:
+			for sub, times := range seed {
+				subdomains[sub] += times
+			}
+		case req := <-r.sampleChan:
+			req.Ch <- r.sample(req.Sub, subdomains[req.Sub], caps)
+		case ch := <-r.capsChan:
+			ch <- cappedSubdomains(r.enum.Config.MaxNamesPerSubdomain, subdomains, caps)
+		case req := <-r.sourcesChan:
+			req.Ch <- copySubdomainSources(sources, req.Sub)
 		}
 	}
 }
@@ -0,0 +1,166 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	amassnet "github.com/OWASP/Amass/v3/net"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+// The property predicate used to store the DNS hosting provider on zone nodes.
+const dnsProviderPredicate = "dns_provider"
+
+// submitFingerprint identifies the DNS hosting providers of the zone without blocking the caller,
+// since the CHAOS class queries wait on the name servers directly.
+func (dt *dNSTask) submitFingerprint(ctx context.Context, zone string, servers []string) {
+	dt.fingerprints.submit(ctx, func() {
+		dt.fingerprintZone(ctx, zone, servers)
+	})
+}
+
+// waitFingerprints blocks until the submitted zones have been fingerprinted.
+func (dt *dNSTask) waitFingerprints() {
+	dt.fingerprints.wait()
+}
+
+// fingerprintZone identifies the DNS hosting providers of the zone using the name server
+// hostnames and, in active mode, the version.bind and hostname.bind CHAOS class responses.
+func (dt *dNSTask) fingerprintZone(ctx context.Context, zone string, servers []string) {
+	cfg, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+
+	// The provider names are displayed, so their case is preserved
+	found := make(map[string]struct{})
+	for _, server := range servers {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		var versions []string
+		// CHAOS class queries are sent directly to the name servers
+		if cfg.Active {
+			if addr, err := nameserverAddr(ctx, dt.enum.pool(), server); err == nil {
+				for _, name := range []string{"version.bind.", "hostname.bind."} {
+					if txt := chaosTXTQuery(ctx, addr, name); txt != "" {
+						versions = append(versions, txt)
+					}
+				}
+			}
+		}
+
+		if p := cfg.NameServerProvider(server, versions...); p != "" {
+			found[p] = struct{}{}
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	providers := make([]string, 0, len(found))
+	for p := range found {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+
+	node := netmap.Node(zone)
+	for _, p := range providers {
+		if err := dt.enum.graphFailure(dt.enum.Graph.UpsertProperty(node, dnsProviderPredicate, p)); err != nil {
+			bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s failed to insert the DNS provider: %v", dt.enum.Graph, err))
+		}
+	}
+
+	if len(providers) > 1 {
+		bus.Publish(requests.LogTopic, eventbus.PriorityHigh, requests.NewLogEvent("DNS", requests.LogInfo,
+			"Zone %s is split across multiple providers: %s", zone, strings.Join(providers, ", ")).With(requests.LogFieldDomain, zone))
+	}
+}
+
+// chaosTXTQuery returns the CHAOS class TXT record data for the name provided, as answered by the server.
+func chaosTXTQuery(ctx context.Context, addr, name string) string {
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, dns.TypeTXT)
+	msg.Question[0].Qclass = dns.ClassCHAOS
+
+	resp, err := directQuery(ctx, addr, msg)
+	if err != nil || resp.Rcode != dns.RcodeSuccess {
+		return ""
+	}
+
+	for _, rr := range resp.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			return strings.Join(txt.Txt, " ")
+		}
+	}
+	return ""
+}
+
+// directQuery sends the message to the DNS server at the address provided, bypassing the resolver pool.
+func directQuery(ctx context.Context, addr string, msg *dns.Msg) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	network := "udp"
+	// The SOCKS5 proxy only carries TCP connections
+	if amassnet.Proxied() {
+		network = "tcp"
+	}
+
+	conn, err := amassnet.DialContext(ctx, network, net.JoinHostPort(addr, "53"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	co := &dns.Conn{Conn: conn}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = co.SetDeadline(deadline)
+	}
+	if err := co.WriteMsg(msg); err != nil {
+		return nil, err
+	}
+	return co.ReadMsg()
+}
+
+func nameserverAddr(ctx context.Context, pool resolve.Resolver, server string) (string, error) {
+	var err error
+	var found bool
+	var qtype uint16
+	var resp *dns.Msg
+
+	for _, t := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		msg := resolve.QueryMsg(server, t)
+
+		resp, err = pool.Query(ctx, msg, resolve.PriorityHigh, resolve.RetryPolicy)
+		if err == nil && resp != nil && len(resp.Answer) > 0 {
+			qtype = t
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("DNS server %s has no A or AAAA records", server)
+	}
+
+	rr := resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype)
+	if len(rr) == 0 {
+		return "", fmt.Errorf("DNS server %s has no A or AAAA records", server)
+	}
+
+	return rr[0].Data, nil
+}
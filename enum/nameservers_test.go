@@ -0,0 +1,49 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/netmap"
+	"github.com/cayleygraph/quad"
+)
+
+func TestSubmitFingerprint(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	e.setupContext(context.Background())
+	defer func() {
+		e.stop()
+		e.Close()
+	}()
+
+	if _, err := e.Graph.UpsertFQDN("owasp.org", "DNS", cfg.UUID.String()); err != nil {
+		t.Fatalf("Failed to insert the zone: %v", err)
+	}
+
+	servers := []string{"ns-1.awsdns-01.com", "kate.ns.cloudflare.com"}
+	e.dnsTask.submitFingerprint(e.ctx, "owasp.org", servers)
+	// The zone is fingerprinted outside of the pipeline, and stored before the wait returns
+	e.dnsTask.waitFingerprints()
+
+	props, err := e.Graph.ReadProperties(netmap.Node("owasp.org"), dnsProviderPredicate)
+	if err != nil || len(props) != 2 {
+		t.Fatalf("Expected the two providers of the zone to be stored: %v, %v", props, err)
+	}
+
+	found := make(map[string]bool)
+	for _, p := range props {
+		if v, ok := quad.NativeOf(p.Value).(string); ok {
+			found[v] = true
+		}
+	}
+	if !found["Amazon Route 53"] || !found["Cloudflare"] {
+		t.Errorf("Unexpected providers stored for the zone: %v", found)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// OutputRecord is the newline-delimited JSON record written to the JSONOutput of the enumeration,
+// which provides the fields of the output record and the time the record was written.
+type OutputRecord struct {
+	*requests.Output
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewOutputRecord returns the OutputRecord for the enumeration output written at the time provided.
+func NewOutputRecord(o *requests.Output, ts time.Time) *OutputRecord {
+	c := o.Clone().(*requests.Output)
+	if c.Addresses == nil {
+		c.Addresses = []requests.AddressInfo{}
+	}
+	for i, a := range c.Addresses {
+		if a.CIDRStr == "" && a.Netblock != nil {
+			c.Addresses[i].CIDRStr = a.Netblock.String()
+		}
+	}
+	if c.LastSeen != nil {
+		last := c.LastSeen.UTC()
+		c.LastSeen = &last
+	}
+
+	return &OutputRecord{Output: c, Timestamp: ts.UTC()}
+}
+
+// jsonOutput serializes the output records written by concurrent consumers of the enumeration output.
+type jsonOutput struct {
+	sync.Mutex
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// writeJSONOutput writes the output record to the JSONOutput of the enumeration as a line of JSON,
+// when the writer has been set. The records are buffered while the enumeration runs and flushed when
+// it is done. The records written after the enumeration is done are flushed immediately. The records
+// already delivered to the JSON destination of the delivery journal are not written.
+func (e *Enumeration) writeJSONOutput(o *requests.Output) error {
+	if e.JSONOutput == nil || o == nil {
+		return nil
+	}
+
+	return e.DeliverOutput(DeliveryJSON, o, e.writeJSONRecord)
+}
+
+func (e *Enumeration) writeJSONRecord(o *requests.Output) error {
+	e.jsonOut.Lock()
+	defer e.jsonOut.Unlock()
+
+	if e.jsonOut.buf == nil {
+		e.jsonOut.buf = bufio.NewWriter(e.JSONOutput)
+		e.jsonOut.enc = json.NewEncoder(e.jsonOut.buf)
+		e.jsonOut.enc.SetEscapeHTML(false)
+	}
+	if err := e.jsonOut.enc.Encode(NewOutputRecord(o, time.Now())); err != nil {
+		return err
+	}
+
+	select {
+	case <-e.done:
+		return e.jsonOut.buf.Flush()
+	default:
+	}
+	return nil
+}
+
+// FlushJSONOutput writes the buffered output records to the JSONOutput of the enumeration.
+func (e *Enumeration) FlushJSONOutput() error {
+	e.jsonOut.Lock()
+	defer e.jsonOut.Unlock()
+
+	if e.jsonOut.buf == nil {
+		return nil
+	}
+	return e.jsonOut.buf.Flush()
+}
@@ -0,0 +1,120 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestWriteJSONOutput(t *testing.T) {
+	cfg := config.NewConfig()
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	var buf bytes.Buffer
+	e.JSONOutput = &buf
+	_, cidr, _ := net.ParseCIDR("72.237.4.0/24")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_ = e.writeJSONOutput(&requests.Output{
+				Name:   fmt.Sprintf("host%d.owasp.org", i),
+				Domain: "owasp.org",
+				Addresses: []requests.AddressInfo{{
+					Address:     net.ParseIP("72.237.4.113"),
+					Netblock:    cidr,
+					ASN:         26808,
+					Description: "UTICA-COLLEGE",
+				}},
+				Tag:     requests.DNS,
+				Sources: []string{"DNS"},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if err := e.FlushJSONOutput(); err != nil {
+		t.Fatalf("Failed to flush the records: %v", err)
+	}
+
+	var count int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Line %d is not a JSON object: %v", count+1, err)
+		}
+		for _, field := range []string{"name", "domain", "addresses", "tag", "sources", "timestamp"} {
+			if _, found := rec[field]; !found {
+				t.Errorf("Line %d is missing the %s field", count+1, field)
+			}
+		}
+
+		addrs, _ := rec["addresses"].([]interface{})
+		if len(addrs) != 1 {
+			t.Fatalf("Line %d has %d addresses", count+1, len(addrs))
+		}
+		addr := addrs[0].(map[string]interface{})
+		if addr["ip"] != "72.237.4.113" || addr["cidr"] != "72.237.4.0/24" ||
+			addr["asn"] != float64(26808) || addr["desc"] != "UTICA-COLLEGE" {
+			t.Errorf("Line %d has an unexpected address: %v", count+1, addr)
+		}
+		count++
+	}
+	if count != 20 {
+		t.Errorf("Expected 20 records, got %d", count)
+	}
+
+	// The records written after the enumeration is done are not left in the buffer
+	e.stop()
+	_ = e.writeJSONOutput(&requests.Output{Name: "owasp.org", Domain: "owasp.org", NS: []string{"ns1.owasp.org"}})
+	var rec OutputRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil || rec.Name != "owasp.org" || len(rec.NS) != 1 {
+		t.Errorf("The record written after the enumeration was done was not flushed: %v", err)
+	}
+}
+
+func TestOutputRecordFields(t *testing.T) {
+	o := &requests.Output{
+		Name:        "www.owasp.org",
+		Domain:      "owasp.org",
+		Tag:         requests.DNS,
+		Sources:     []string{"DNS"},
+		Wildcard:    "dynamic",
+		Consistency: "inconsistent",
+		AnswerSets:  []string{"8.8.8.8: 72.237.4.113"},
+		Rotating:    true,
+	}
+
+	data, err := json.Marshal(NewOutputRecord(o, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to marshal the record: %v", err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("Failed to unmarshal the record: %v", err)
+	}
+	// The record provides each field of the output
+	for _, field := range []string{"wildcard", "consistency", "answer_sets", "rotating", "addresses", "timestamp"} {
+		if _, found := rec[field]; !found {
+			t.Errorf("The record is missing the %s field: %s", field, data)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/cayleygraph/quad"
+	"github.com/miekg/dns"
+)
+
+// DisappearedPredicate is the property of FQDN nodes that holds the time a previously known name
+// was first observed to no longer exist. The property is removed once the name resolves again.
+const DisappearedPredicate = "nxdomain_since"
+
+// DisappearedName is a name from a prior enumeration that returned NXDOMAIN from multiple resolvers.
+type DisappearedName struct {
+	Name   string
+	Domain string
+	// The first time the name was observed to no longer exist
+	Since time.Time
+}
+
+// knownNames tracks the names from prior enumerations and those that have disappeared.
+type knownNames struct {
+	sync.Mutex
+	names       map[string]struct{}
+	disappeared map[string]*DisappearedName
+}
+
+func newKnownNames() *knownNames {
+	return &knownNames{
+		names:       make(map[string]struct{}),
+		disappeared: make(map[string]*DisappearedName),
+	}
+}
+
+func (k *knownNames) add(name string) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.names[name] = struct{}{}
+}
+
+func (k *knownNames) has(name string) bool {
+	k.Lock()
+	defer k.Unlock()
+
+	_, found := k.names[name]
+	return found
+}
+
+// confirmedNXDOMAIN returns true when the query error is a NXDOMAIN response that was repeated
+// after retrying the query. Timeouts, resolver errors and deadlines are not negative answers.
+func confirmedNXDOMAIN(err error, retried bool) bool {
+	var rerr *resolve.ResolveError
+
+	return retried && errors.As(err, &rerr) && rerr.Rcode == dns.RcodeNameError
+}
+
+// nameDisappeared records the negative observation for a name from a prior enumeration
+// on its FQDN node in the graph databases of the system.
+func (e *Enumeration) nameDisappeared(name, domain string) {
+	if !e.known.has(name) {
+		return
+	}
+
+	since := time.Now()
+	for _, g := range e.Sys.GraphDatabases() {
+		node, err := g.ReadNode(name, netmap.TypeFQDN)
+		if err != nil {
+			continue
+		}
+
+		if t, found := readDisappeared(g, node); found {
+			if t.Before(since) {
+				since = t
+			}
+			continue
+		}
+		if err := g.UpsertProperty(node, DisappearedPredicate, since.UTC().Format(time.RFC3339)); err != nil {
+			e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", g.String(), err))
+		}
+	}
+
+	e.known.Lock()
+	_, already := e.known.disappeared[name]
+	e.known.disappeared[name] = &DisappearedName{
+		Name:   name,
+		Domain: domain,
+		Since:  since,
+	}
+	e.known.Unlock()
+
+	if !already {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh,
+			fmt.Sprintf("DNS: The previously known name %s no longer exists (NXDOMAIN)", name))
+	}
+}
+
+// nameResolved ages out the negative observation once the previously known name resolves again.
+func (e *Enumeration) nameResolved(name string) {
+	if !e.known.has(name) {
+		return
+	}
+
+	e.known.Lock()
+	delete(e.known.disappeared, name)
+	e.known.Unlock()
+
+	for _, g := range e.Sys.GraphDatabases() {
+		node, err := g.ReadNode(name, netmap.TypeFQDN)
+		if err != nil {
+			continue
+		}
+
+		if props, err := g.ReadProperties(node, DisappearedPredicate); err == nil {
+			for _, p := range props {
+				if err := g.DeleteProperty(node, DisappearedPredicate, p.Value); err != nil {
+					e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", g.String(), err))
+				}
+			}
+		}
+	}
+}
+
+// DisappearedNames returns the names from prior enumerations that no longer exist, sorted by name.
+func (e *Enumeration) DisappearedNames() []*DisappearedName {
+	e.known.Lock()
+	defer e.known.Unlock()
+
+	var names []*DisappearedName
+	for _, d := range e.known.disappeared {
+		names = append(names, d)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i].Name < names[j].Name })
+	return names
+}
+
+// ReadDisappeared returns the time the name of the FQDN node was first observed to no longer exist.
+func ReadDisappeared(g *netmap.Graph, name string) (time.Time, bool) {
+	node, err := g.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return readDisappeared(g, node)
+}
+
+func readDisappeared(g *netmap.Graph, node netmap.Node) (time.Time, bool) {
+	props, err := g.ReadProperties(node, DisappearedPredicate)
+	if err != nil || len(props) == 0 {
+		return time.Time{}, false
+	}
+
+	var earliest time.Time
+	for _, p := range props {
+		if t, err := time.Parse(time.RFC3339, quad.ToString(p.Value)); err == nil && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
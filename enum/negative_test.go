@@ -0,0 +1,90 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+func TestConfirmedNXDOMAIN(t *testing.T) {
+	nxdomain := &resolve.ResolveError{Err: "NXDOMAIN", Rcode: dns.RcodeNameError}
+
+	if !confirmedNXDOMAIN(nxdomain, true) {
+		t.Errorf("The repeated NXDOMAIN response was not confirmed")
+	}
+	if confirmedNXDOMAIN(nxdomain, false) {
+		t.Errorf("The NXDOMAIN response from a single resolver was confirmed")
+	}
+
+	for _, err := range []error{
+		nil,
+		errors.New("failed"),
+		&resolve.ResolveError{Err: "timeout", Rcode: resolve.TimeoutRcode},
+		&resolve.ResolveError{Err: "resolver error", Rcode: resolve.ResolverErrRcode},
+		&resolve.ResolveError{Err: "SERVFAIL", Rcode: dns.RcodeServerFailure},
+	} {
+		if confirmedNXDOMAIN(err, true) {
+			t.Errorf("The error %v was confirmed as a NXDOMAIN response", err)
+		}
+	}
+}
+
+func TestNameDisappeared(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	db := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer db.Close()
+
+	sys := newMockSystem(cfg)
+	sys.dbs = []*netmap.Graph{db}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	for _, name := range []string{"www.owasp.org", "dev.owasp.org"} {
+		if _, err := db.UpsertFQDN(name, "Mock", "prior-event"); err != nil {
+			t.Fatalf("Failed to insert the FQDN: %v", err)
+		}
+	}
+	e.known.add("www.owasp.org")
+
+	// Names not from a prior enumeration are not recorded
+	e.nameDisappeared("dev.owasp.org", "owasp.org")
+	if _, found := ReadDisappeared(db, "dev.owasp.org"); found {
+		t.Errorf("The name from outside the prior enumerations was recorded as disappeared")
+	}
+
+	e.nameDisappeared("www.owasp.org", "owasp.org")
+	first, found := ReadDisappeared(db, "www.owasp.org")
+	if !found {
+		t.Fatalf("The previously known name was not recorded as disappeared")
+	}
+	if gone := e.DisappearedNames(); len(gone) != 1 || gone[0].Name != "www.owasp.org" {
+		t.Errorf("The disappeared names were not returned by the enumeration: %v", gone)
+	}
+
+	// The earliest observation is kept
+	e.nameDisappeared("www.owasp.org", "owasp.org")
+	if props, err := db.ReadProperties(netmap.Node("www.owasp.org"), DisappearedPredicate); err != nil || len(props) != 1 {
+		t.Errorf("The negative observation was recorded %d times", len(props))
+	}
+	if since, _ := ReadDisappeared(db, "www.owasp.org"); !since.Equal(first) {
+		t.Errorf("The time of the first observation changed from %v to %v", first, since)
+	}
+
+	// The flag ages out once the name resolves again
+	e.nameResolved("www.owasp.org")
+	if _, found := ReadDisappeared(db, "www.owasp.org"); found {
+		t.Errorf("The negative observation remained after the name resolved")
+	}
+	if gone := e.DisappearedNames(); len(gone) != 0 {
+		t.Errorf("The resolved name was still returned as disappeared")
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build !race
+// +build !race
+
+package enum
+
+const raceEnabled = false
@@ -0,0 +1,92 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestNoSourcesVerifyNames(t *testing.T) {
+	addrs := make(map[string]string, 1000)
+	for i := 0; i < 1000; i++ {
+		addrs[fmt.Sprintf("host%d.owasp.org", i)] = fmt.Sprintf("72.237.%d.%d", i/250, i%250+1)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	sys.pool = &mockResolver{addrs: addrs}
+	// The addresses are attributed to the netblock without querying the data sources
+	sys.cache.Update(&requests.ASNRequest{
+		Address:     "72.237.0.0",
+		ASN:         26808,
+		Prefix:      "72.237.0.0/16",
+		Description: "UTICA-AS",
+		Tag:         requests.RIR,
+		Source:      "RIR",
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	for name := range addrs {
+		cfg.ProvidedNames = append(cfg.ProvidedNames, name)
+	}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	if e.hasSources() {
+		t.Fatalf("The enumeration selected data sources from an empty system")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration returned an error: %v", err)
+	}
+
+	pairs, err := e.Graph.NamesToAddrs(e.Config.UUID.String(), cfg.ProvidedNames...)
+	if err != nil {
+		t.Fatalf("Failed to obtain the addresses of the names: %v", err)
+	}
+
+	found := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		found[p.Name] = p.Addr
+	}
+	for name, addr := range addrs {
+		if found[name] != addr {
+			t.Errorf("The name %s was stored with the address %q instead of %s", name, found[name], addr)
+		}
+	}
+
+	var prefix bool
+	for _, cidr := range e.Graph.ReadASPrefixes(26808) {
+		if cidr == "72.237.0.0/16" {
+			prefix = true
+		}
+	}
+	if !prefix {
+		t.Errorf("The addresses were not attributed to the netblock")
+	}
+}
+
+func TestNoSourcesPassive(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Passive = true
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	var cerr *ConfigError
+	if _, err := e.Start(context.Background()); !errors.As(err, &cerr) {
+		t.Errorf("The passive enumeration without data sources returned %v", err)
+	}
+}
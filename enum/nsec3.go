@@ -0,0 +1,63 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/OWASP/Amass/v3/resolvers"
+	"github.com/caffix/resolve"
+)
+
+// Nsec3Traversal walks the NSEC3 hash chain of the domain using the resolver pool of the
+// enumeration, and cracks the hashed owner names using the brute forcing wordlist and the
+// first labels of the names discovered so far. The in-scope names recovered are returned,
+// and the boolean is true when the zone is signed using NSEC3.
+func (e *Enumeration) Nsec3Traversal(ctx context.Context, domain string, priority int) ([]string, bool, error) {
+	pool := e.pool()
+	if pool == nil {
+		return nil, false, errors.New("Nsec3Traversal: The system did not provide a resolver pool")
+	}
+
+	reqs, signed, err := e.nsec3Traversal(ctx, pool, domain, priority)
+	var names []string
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	return names, signed, err
+}
+
+// nsec3Traversal returns the in-scope names recovered from the NSEC3 hash chain of the zone.
+func (e *Enumeration) nsec3Traversal(ctx context.Context, r resolve.Resolver, zone string, priority int) ([]*requests.DNSRequest, bool, error) {
+	param, err := resolvers.Nsec3Param(ctx, r, zone, priority)
+	if err != nil || param == nil {
+		return nil, false, err
+	}
+
+	reqs, err := resolvers.Nsec3Walk(ctx, r, zone, param, e.nsec3Candidates(), priority)
+	var results []*requests.DNSRequest
+	for _, req := range reqs {
+		if domain := e.Scope.Evaluate(req.Name, nil).Domain; domain != "" {
+			req.Domain = domain
+			results = append(results, req)
+		}
+	}
+	return results, true, err
+}
+
+// nsec3Candidates returns the brute forcing wordlist and the first labels of the names discovered
+// so far, which are tried against the hashed owner names of NSEC3 zones.
+func (e *Enumeration) nsec3Candidates() []string {
+	candidates := append([]string(nil), e.Config.Wordlist...)
+
+	for _, name := range e.Graph.EventFQDNs(e.Config.UUID.String()) {
+		if labels := strings.SplitN(name, ".", 2); len(labels) == 2 {
+			candidates = append(candidates, labels[0])
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,138 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+// nsec3Resolver serves synthetic NSEC3 records for a signed zone holding the labels provided.
+// The zone is not signed when the labels are nil.
+type nsec3Resolver struct {
+	mockResolver
+	zone   string
+	salt   string
+	hashes []string
+}
+
+func newNsec3Resolver(zone string, labels []string) *nsec3Resolver {
+	r := &nsec3Resolver{zone: dns.Fqdn(zone), salt: "AABB"}
+	if labels == nil {
+		return r
+	}
+
+	r.hashes = append(r.hashes, dns.HashName(r.zone, dns.SHA1, 1, r.salt))
+	for _, label := range labels {
+		r.hashes = append(r.hashes, dns.HashName(label+"."+r.zone, dns.SHA1, 1, r.salt))
+	}
+	sort.Strings(r.hashes)
+	return r
+}
+
+func (r *nsec3Resolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	q := msg.Question[0]
+	resp := new(dns.Msg)
+	resp.SetReply(msg)
+
+	if q.Qtype == dns.TypeNSEC3PARAM {
+		if r.hashes != nil {
+			resp.Answer = append(resp.Answer, &dns.NSEC3PARAM{
+				Hdr:        dns.RR_Header{Name: r.zone, Rrtype: dns.TypeNSEC3PARAM, Class: dns.ClassINET},
+				Hash:       dns.SHA1,
+				Iterations: 1,
+				SaltLength: 2,
+				Salt:       r.salt,
+			})
+		}
+		return resp, nil
+	}
+
+	hash := dns.HashName(q.Name, dns.SHA1, 1, r.salt)
+	i := sort.SearchStrings(r.hashes, hash)
+	if i == len(r.hashes) || r.hashes[i] != hash {
+		i = (i + len(r.hashes) - 1) % len(r.hashes)
+		resp.Rcode = dns.RcodeNameError
+	}
+	resp.Ns = append(resp.Ns, &dns.NSEC3{
+		Hdr:        dns.RR_Header{Name: strings.ToLower(r.hashes[i]) + "." + r.zone, Rrtype: dns.TypeNSEC3, Class: dns.ClassINET},
+		Hash:       dns.SHA1,
+		Iterations: 1,
+		SaltLength: 2,
+		Salt:       r.salt,
+		HashLength: 20,
+		NextDomain: r.hashes[(i+1)%len(r.hashes)],
+	})
+	return resp, nil
+}
+
+func TestEnumerationNsec3Traversal(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Wordlist = []string{"www", "mail", "ftp"}
+	sys := newMockSystem(cfg)
+	sys.pool = newNsec3Resolver("owasp.org", []string{"www", "mail", "vpn"})
+
+	e := NewEnumeration(cfg, sys)
+	e.setupContext(context.Background())
+	defer func() {
+		e.stop()
+		e.Close()
+	}()
+
+	names, signed, err := e.Nsec3Traversal(e.ctx, "owasp.org", resolve.PriorityHigh)
+	if err != nil || !signed {
+		t.Fatalf("The NSEC3 walk failed: %v, signed: %t", err, signed)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "mail.owasp.org,www.owasp.org" {
+		t.Errorf("Unexpected names recovered from the NSEC3 chain: %v", names)
+	}
+
+	// Zones not signed using NSEC3 are reported without an error
+	sys.pool = newNsec3Resolver("owasp.org", nil)
+	if names, signed, err := e.Nsec3Traversal(e.ctx, "owasp.org", resolve.PriorityHigh); err != nil || signed || len(names) > 0 {
+		t.Errorf("Unexpected walk of the unsigned zone: %v, %t, %v", names, signed, err)
+	}
+}
+
+func TestActiveNsec3WalkPublishesNames(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Active = true
+	cfg.AddDomain("owasp.org")
+	cfg.Wordlist = []string{"www", "dev"}
+	sys := newMockSystem(cfg)
+
+	e := NewEnumeration(cfg, sys)
+	e.setupContext(context.Background())
+	defer func() {
+		e.stop()
+		e.Close()
+	}()
+
+	found := make(chan *requests.DNSRequest, 10)
+	e.Bus.Subscribe(requests.NewNameTopic, func(req *requests.DNSRequest) { found <- req })
+	requests.WaitForSubscriptions(e.ctx, e.Bus)
+
+	a := newActiveTask(e, 1)
+	defer a.Stop()
+	a.nsec3Walk(e.ctx, newNsec3Resolver("owasp.org", []string{"dev"}), "owasp.org")
+
+	select {
+	case req := <-found:
+		if req.Name != "dev.owasp.org" || req.Domain != "owasp.org" || req.Source != "NSEC3 Walk" {
+			t.Errorf("Unexpected name published by the NSEC3 walk: %+v", req)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The NSEC3 walk did not publish the recovered name")
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/cayleygraph/quad"
+)
+
+// ObservedPredicate is the property of FQDN nodes that holds the time range a passive DNS source
+// observed the name within. The graph edges cannot hold properties, so the range is kept on the
+// node with the name of the source, formatted as "source|first|last" using RFC3339 times.
+const ObservedPredicate = "observed"
+
+// Observation is the time range that a passive DNS source observed a name within.
+type Observation struct {
+	Source    string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (o *Observation) merge(first, last time.Time) {
+	if !first.IsZero() && (o.FirstSeen.IsZero() || first.Before(o.FirstSeen)) {
+		o.FirstSeen = first
+	}
+	if last.After(o.LastSeen) {
+		o.LastSeen = last
+	}
+}
+
+func (o *Observation) value() string {
+	return strings.Join([]string{o.Source, formatObserved(o.FirstSeen), formatObserved(o.LastSeen)}, "|")
+}
+
+func formatObserved(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseObservation(value string) (*Observation, bool) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 || parts[0] == "" {
+		return nil, false
+	}
+
+	o := &Observation{Source: parts[0]}
+	if parts[1] != "" {
+		t, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			return nil, false
+		}
+		o.FirstSeen = t
+	}
+	if parts[2] != "" {
+		t, err := time.Parse(time.RFC3339, parts[2])
+		if err != nil {
+			return nil, false
+		}
+		o.LastSeen = t
+	}
+	return o, true
+}
+
+// observations tracks the time ranges reported by the data sources for each name. The ranges are
+// collected before the names are deduplicated, since each source can report the name.
+type observations struct {
+	sync.Mutex
+	names map[string]map[string]*Observation
+}
+
+func newObservations() *observations {
+	return &observations{names: make(map[string]map[string]*Observation)}
+}
+
+// add merges the time range into the observation of the name by the source, and returns a copy.
+func (o *observations) add(name, source string, first, last time.Time) Observation {
+	o.Lock()
+	defer o.Unlock()
+
+	srcs, found := o.names[name]
+	if !found {
+		srcs = make(map[string]*Observation)
+		o.names[name] = srcs
+	}
+
+	obs, found := srcs[source]
+	if !found {
+		obs = &Observation{Source: source}
+		srcs[source] = obs
+	}
+
+	obs.merge(first, last)
+	return *obs
+}
+
+func (o *observations) get(name string) []Observation {
+	o.Lock()
+	defer o.Unlock()
+
+	var results []Observation
+	for _, obs := range o.names[name] {
+		results = append(results, *obs)
+	}
+	return results
+}
+
+func (o *observations) all() []string {
+	o.Lock()
+	defer o.Unlock()
+
+	names := make([]string, 0, len(o.names))
+	for name := range o.names {
+		names = append(names, name)
+	}
+	return names
+}
+
+// observeName records the time range provided by the data source for the name.
+func (e *Enumeration) observeName(req *requests.DNSRequest) {
+	if req.FirstSeen.IsZero() && req.LastSeen.IsZero() {
+		return
+	}
+
+	obs := e.observed.add(req.Name, req.Source, req.FirstSeen, req.LastSeen)
+	e.storeObservation(req.Name, obs)
+}
+
+// storeObservation attaches the observation to the FQDN node once the name is in the graph.
+func (e *Enumeration) storeObservation(name string, obs Observation) {
+	node, err := e.Graph.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return
+	}
+	// The previous time range reported by the source is replaced
+	if props, err := e.Graph.ReadProperties(node, ObservedPredicate); err == nil {
+		for _, p := range props {
+			if prev, ok := parseObservation(quad.ToString(p.Value)); ok && prev.Source == obs.Source {
+				_ = e.Graph.DeleteProperty(node, ObservedPredicate, p.Value)
+			}
+		}
+	}
+
+	if err := e.graphFailure(e.Graph.UpsertProperty(node, ObservedPredicate, obs.value())); err != nil {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("%s: %v", e.Graph.String(), err))
+	}
+}
+
+// storeObservations attaches the observations of the name to its FQDN node.
+func (e *Enumeration) storeObservations(name string) {
+	for _, obs := range e.observed.get(name) {
+		e.storeObservation(name, obs)
+	}
+}
+
+// storeAllObservations attaches the observations to the names stored after they were reported.
+func (e *Enumeration) storeAllObservations() {
+	for _, name := range e.observed.all() {
+		e.storeObservations(name)
+	}
+}
+
+// staleName returns true when the passive DNS sources most recently observed the name longer ago
+// than the maximum record age. Names without observation dates are never stale.
+func (e *Enumeration) staleName(name string) bool {
+	var last time.Time
+
+	for _, obs := range e.observed.get(name) {
+		if obs.LastSeen.After(last) {
+			last = obs.LastSeen
+		}
+	}
+	return e.Config.RecordTooOld(last)
+}
+
+// ReadObservations returns the time ranges that the passive DNS sources observed the name within.
+func ReadObservations(g *netmap.Graph, name string) []*Observation {
+	node, err := g.ReadNode(name, netmap.TypeFQDN)
+	if err != nil {
+		return nil
+	}
+
+	props, err := g.ReadProperties(node, ObservedPredicate)
+	if err != nil {
+		return nil
+	}
+
+	var results []*Observation
+	for _, p := range props {
+		if obs, ok := parseObservation(quad.ToString(p.Value)); ok {
+			results = append(results, obs)
+		}
+	}
+	return results
+}
+
+// ReadLastSeen returns the most recent time that the name was observed across the passive DNS sources.
+func ReadLastSeen(g *netmap.Graph, name string) (time.Time, bool) {
+	var last time.Time
+
+	for _, obs := range ReadObservations(g, name) {
+		if obs.LastSeen.After(last) {
+			last = obs.LastSeen
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// StaleRecord returns true when the most recent observation of the name across the passive
+// DNS sources is older than the maximum age. Names without observations are never stale.
+func StaleRecord(g *netmap.Graph, name string, maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+
+	last, found := ReadLastSeen(g, name)
+	return found && time.Since(last) > maxAge
+}
@@ -0,0 +1,115 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestObservations(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	first := time.Date(2012, time.March, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2014, time.June, 1, 0, 0, 0, 0, time.UTC)
+	// The name is observed before it is stored in the graph
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "DNSDB", FirstSeen: first, LastSeen: last})
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "DNSDB", FirstSeen: first.AddDate(1, 0, 0), LastSeen: last.AddDate(0, -1, 0)})
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "CIRCL", FirstSeen: first, LastSeen: last.AddDate(1, 0, 0)})
+	// Names without observation dates are not tracked
+	e.observeName(&requests.DNSRequest{Name: "mail.owasp.org", Source: "Crtsh"})
+
+	if obs := ReadObservations(e.Graph, "www.owasp.org"); len(obs) != 0 {
+		t.Errorf("The observations were stored before the name: %+v", obs)
+	}
+	if _, err := e.Graph.UpsertFQDN("www.owasp.org", "DNSDB", cfg.UUID.String()); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+	if _, err := e.Graph.UpsertFQDN("mail.owasp.org", "Crtsh", cfg.UUID.String()); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+	e.storeAllObservations()
+
+	obs := ReadObservations(e.Graph, "www.owasp.org")
+	if len(obs) != 2 {
+		t.Fatalf("Expected an observation for each source, got %+v", obs)
+	}
+	for _, o := range obs {
+		switch o.Source {
+		case "DNSDB":
+			if !o.FirstSeen.Equal(first) || !o.LastSeen.Equal(last) {
+				t.Errorf("The DNSDB time ranges were not merged: %+v", o)
+			}
+		case "CIRCL":
+			if !o.FirstSeen.Equal(first) || !o.LastSeen.Equal(last.AddDate(1, 0, 0)) {
+				t.Errorf("Unexpected CIRCL time range: %+v", o)
+			}
+		default:
+			t.Errorf("Unexpected observation source: %s", o.Source)
+		}
+	}
+
+	// A later observation replaces the previous range of the source on the node
+	newer := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "DNSDB", LastSeen: newer})
+	if n := len(ReadObservations(e.Graph, "www.owasp.org")); n != 2 {
+		t.Errorf("Expected the DNSDB range to be replaced, got %d observations", n)
+	}
+	if got, found := ReadLastSeen(e.Graph, "www.owasp.org"); !found || !got.Equal(newer) {
+		t.Errorf("Expected the most recent observation %v, got %v", newer, got)
+	}
+	if _, found := ReadLastSeen(e.Graph, "mail.owasp.org"); found {
+		t.Error("A last seen time was returned for a name without observations")
+	}
+}
+
+func TestStaleRecord(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	for _, name := range []string{"old.owasp.org", "www.owasp.org", "mail.owasp.org"} {
+		if _, err := e.Graph.UpsertFQDN(name, "DNSDB", cfg.UUID.String()); err != nil {
+			t.Fatalf("Failed to insert the name: %v", err)
+		}
+	}
+	e.observeName(&requests.DNSRequest{Name: "old.owasp.org", Source: "DNSDB", LastSeen: time.Now().AddDate(-10, 0, 0)})
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "DNSDB", LastSeen: time.Now().AddDate(-10, 0, 0)})
+	e.observeName(&requests.DNSRequest{Name: "www.owasp.org", Source: "CIRCL", LastSeen: time.Now().AddDate(0, -1, 0)})
+
+	maxAge := 365 * 24 * time.Hour
+	if !StaleRecord(e.Graph, "old.owasp.org", maxAge) {
+		t.Error("The name observed a decade ago was not stale")
+	}
+	if StaleRecord(e.Graph, "www.owasp.org", maxAge) {
+		t.Error("The name was stale while another source observed it recently")
+	}
+	if StaleRecord(e.Graph, "mail.owasp.org", maxAge) {
+		t.Error("The name without observations was stale")
+	}
+	if StaleRecord(e.Graph, "old.owasp.org", 0) {
+		t.Error("The name was stale without a maximum age")
+	}
+
+	// The passive output excludes the names observed before the configured maximum age
+	if e.staleName("old.owasp.org") {
+		t.Error("The name was excluded without a maximum record age")
+	}
+	cfg.MaxRecordAge = maxAge
+	if !e.staleName("old.owasp.org") {
+		t.Error("The name observed a decade ago was not excluded")
+	}
+	if e.staleName("www.owasp.org") || e.staleName("mail.owasp.org") {
+		t.Error("A recently observed name or a name without observations was excluded")
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/filter"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+	"github.com/caffix/queue"
+	"github.com/caffix/service"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// The time between the extractions of the output records while the enumeration runs
+	outputInterval = 15 * time.Second
+	// The number of output records buffered for the consumer of the enumeration output
+	outputChanSize = 100
+)
+
+// SourceTags maps the names of the data sources to the tags of the names they discover.
+type SourceTags map[string]string
+
+// NewSourceTags returns the SourceTags of the DNS techniques and the data sources provided.
+func NewSourceTags(srcs []service.Service) SourceTags {
+	tags := SourceTags{
+		"DNS":          requests.DNS,
+		"Reverse DNS":  requests.DNS,
+		"NSEC Walk":    requests.DNS,
+		"NSEC3 Walk":   requests.DNS,
+		"DNS Zone XFR": requests.AXFR,
+		"Active Crawl": requests.CRAWL,
+		"JS Analysis":  requests.CRAWL,
+		"Active Cert":  requests.CERT,
+	}
+
+	for _, src := range srcs {
+		tags[src.String()] = src.Description()
+	}
+	return tags
+}
+
+// Select returns the tag of a name discovered by the sources provided. The trusted tags
+// are selected over the others, and the DNS tag is returned when no source is known.
+func (st SourceTags) Select(sources []string) string {
+	var trusted, others []string
+
+	for _, src := range sources {
+		tag, found := st[src]
+		if !found {
+			continue
+		}
+
+		if requests.TrustedTag(tag) {
+			trusted = append(trusted, tag)
+		} else {
+			others = append(others, tag)
+		}
+	}
+
+	tags := others
+	if len(trusted) > 0 {
+		tags = trusted
+	}
+
+	if len(tags) == 0 {
+		return requests.DNS
+	}
+
+	sel := 0
+	if m := len(tags); m > 0 {
+		sel = rand.Int() % m
+	}
+
+	return tags[sel]
+}
+
+// enumOutput holds the state of the output records delivered to the consumer of the enumeration output.
+type enumOutput struct {
+	sync.Mutex
+	// The names already extracted from the graph
+	known filter.Filter
+	tags  SourceTags
+	// The records waiting to be sent on the channel returned by Output
+	records  queue.Queue
+	ch       chan *requests.Output
+	finished chan struct{}
+	once     sync.Once
+}
+
+func newEnumOutput(srcs []service.Service) *enumOutput {
+	return &enumOutput{
+		known:    filter.NewBloomFilter(1 << 22),
+		tags:     NewSourceTags(srcs),
+		records:  queue.NewQueue(),
+		finished: make(chan struct{}),
+	}
+}
+
+// Output returns the channel receiving the deduplicated output records of the enumeration, which
+// provide the infrastructure announcing each address and have the Transforms applied. The records
+// are extracted from the graph periodically while the enumeration runs, and the channel is closed
+// once the remaining records have been sent after the enumeration is done. Output must be called
+// before the enumeration is started, and the channel must be drained by the caller.
+func (e *Enumeration) Output() <-chan *requests.Output {
+	e.output.Lock()
+	defer e.output.Unlock()
+
+	if e.output.ch == nil {
+		e.output.ch = make(chan *requests.Output, outputChanSize)
+		go e.forwardOutput(e.output.ch)
+	}
+	return e.output.ch
+}
+
+func (e *Enumeration) forwardOutput(ch chan *requests.Output) {
+	defer close(ch)
+
+	send := func() {
+		for {
+			element, ok := e.output.records.Next()
+			if !ok {
+				return
+			}
+			ch <- element.(*requests.Output)
+		}
+	}
+
+	for {
+		select {
+		case <-e.output.finished:
+			send()
+			return
+		case <-e.output.records.Signal():
+			send()
+		}
+	}
+}
+
+func (e *Enumeration) processOutput() {
+	t := time.NewTicker(outputInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-t.C:
+			e.extractOutput(false)
+		}
+	}
+}
+
+// finishOutput delivers the remaining output records once the enumeration has stored its findings.
+func (e *Enumeration) finishOutput() {
+	e.output.once.Do(func() {
+		e.extractOutput(true)
+		_ = e.FlushJSONOutput()
+		close(e.output.finished)
+	})
+}
+
+// extractOutput delivers the output records of the names stored since the previous extraction.
+// The apex domain records are delivered by the final extraction, since they are complete once
+// the enumeration is done.
+func (e *Enumeration) extractOutput(final bool) {
+	e.output.Lock()
+	defer e.output.Unlock()
+
+	for _, o := range e.eventOutput(e.output.known) {
+		if e.Scope.Evaluate(o.Name, nil).Domain == "" || e.IsApexOutput(o) {
+			continue
+		}
+		e.deliverOutput(o)
+	}
+	if !final {
+		return
+	}
+
+	for _, o := range e.ApexOutput() {
+		e.deliverOutput(o)
+	}
+}
+
+// eventOutput returns the output records of the names stored by the enumeration that are not in the filter.
+func (e *Enumeration) eventOutput(f filter.Filter) []*requests.Output {
+	uuid := e.Config.UUID.String()
+
+	if e.Config.Passive {
+		output := EventNames(e.Graph, uuid, e.output.tags, f, e.Config.MaxRecordAge)
+		// The names were not resolved, so their freshness is judged by the observations of the sources
+		for _, o := range output {
+			o.SetLastObserved()
+		}
+		return output
+	}
+
+	return EventOutput(e.Graph, uuid, e.output.tags, f, true, e.Sys.Cache())
+}
+
+// deliverOutput applies the Transforms to the output record and delivers the result to the
+// consumers of the enumeration output. The lock of the output must be held.
+func (e *Enumeration) deliverOutput(o *requests.Output) {
+	if o = e.TransformOutput(o); o == nil {
+		return
+	}
+
+	e.TraceOutput(o)
+	_ = e.writeJSONOutput(o)
+	e.sendOutput(o)
+
+	if e.output.ch != nil {
+		e.output.records.Append(o)
+	}
+}
+
+type outLookup map[string]*requests.Output
+
+// EventOutput returns findings within the receiver Graph for the event identified by the uuid string
+// parameter and not already in the filter StringFilter argument. The filter is updated by EventOutput.
+func EventOutput(g *netmap.Graph, uuid string, tags SourceTags, f filter.Filter, asninfo bool, cache *requests.ASNCache) []*requests.Output {
+	// Make sure a filter has been created
+	if f == nil {
+		f = filter.NewStringFilter()
+	}
+
+	var names []string
+	for _, name := range g.EventFQDNs(uuid) {
+		if !f.Has(name) {
+			names = append(names, name)
+		}
+	}
+
+	return namesOutput(g, uuid, tags, names, f, asninfo, cache)
+}
+
+// EventOutputBetween returns the findings of EventOutput for the names of the event identified by
+// the uuid string parameter that were seen within the time range. The names seen outside of the
+// range are not added to the filter.
+func EventOutputBetween(g *netmap.Graph, uuid string, tags SourceTags, start, end time.Time, f filter.Filter, asninfo bool, cache *requests.ASNCache) []*requests.Output {
+	if f == nil {
+		f = filter.NewStringFilter()
+	}
+
+	var names []string
+	for _, name := range g.EventFQDNs(uuid) {
+		if !f.Has(name) && SeenBetween(g, name, netmap.TypeFQDN, start, end) {
+			names = append(names, name)
+		}
+	}
+
+	return namesOutput(g, uuid, tags, names, f, asninfo, cache)
+}
+
+func namesOutput(g *netmap.Graph, uuid string, tags SourceTags, names []string, f filter.Filter, asninfo bool, cache *requests.ASNCache) []*requests.Output {
+	lookup := make(outLookup, len(names))
+	for _, o := range buildNameInfo(g, uuid, tags, names) {
+		lookup[o.Name] = o
+	}
+
+	pairs, err := g.NamesToAddrs(uuid, names...)
+	if err != nil {
+		return nil
+	}
+	// Build the lookup map used to create the final result set
+	for _, p := range pairs {
+		if p.Name == "" || p.Addr == "" {
+			continue
+		}
+		if o, found := lookup[p.Name]; found {
+			o.Addresses = append(o.Addresses, requests.AddressInfo{Address: net.ParseIP(p.Addr)})
+		}
+	}
+
+	if !asninfo || cache == nil {
+		return removeDuplicates(lookup, f)
+	}
+	return addInfrastructureInfo(lookup, f, cache)
+}
+
+func removeDuplicates(lookup outLookup, filter filter.Filter) []*requests.Output {
+	output := make([]*requests.Output, 0, len(lookup))
+
+	for _, o := range lookup {
+		if !filter.Duplicate(o.Name) {
+			output = append(output, o)
+		}
+	}
+
+	return output
+}
+
+func addInfrastructureInfo(lookup outLookup, filter filter.Filter, cache *requests.ASNCache) []*requests.Output {
+	output := make([]*requests.Output, 0, len(lookup))
+
+	for _, o := range lookup {
+		var newaddrs []requests.AddressInfo
+
+		for _, a := range o.Addresses {
+			i := cache.AddrSearch(a.Address.String())
+			if i == nil {
+				continue
+			}
+
+			_, netblock, _ := net.ParseCIDR(i.Prefix)
+			newaddrs = append(newaddrs, requests.AddressInfo{
+				Address:     a.Address,
+				ASN:         i.ASN,
+				CIDRStr:     i.Prefix,
+				Netblock:    netblock,
+				Description: i.Description,
+			})
+		}
+
+		o.Addresses = newaddrs
+		if len(o.Addresses) > 0 && !filter.Duplicate(o.Name) {
+			output = append(output, o)
+		}
+	}
+
+	return output
+}
+
+// EventNames returns findings within the receiver Graph for the event identified by the uuid string
+// parameter and not already in the filter StringFilter argument. The filter is updated by EventNames.
+// Names most recently observed by the passive DNS sources longer ago than maxAge are excluded.
+func EventNames(g *netmap.Graph, uuid string, tags SourceTags, f filter.Filter, maxAge time.Duration) []*requests.Output {
+	// Make sure a filter has been created
+	if f == nil {
+		f = filter.NewStringFilter()
+	}
+
+	var names []string
+	for _, name := range g.EventFQDNs(uuid) {
+		// Stale names are not added to the filter, since later observations can refresh them
+		if !f.Has(name) && !StaleRecord(g, name, maxAge) {
+			names = append(names, name)
+		}
+	}
+
+	var results []*requests.Output
+	for _, o := range buildNameInfo(g, uuid, tags, names) {
+		if !f.Duplicate(o.Name) {
+			results = append(results, o)
+		}
+	}
+	return results
+}
+
+func buildNameInfo(g *netmap.Graph, uuid string, tags SourceTags, names []string) []*requests.Output {
+	results := make(map[string]*requests.Output, len(names))
+
+	for _, name := range names {
+		if _, found := results[name]; found {
+			continue
+		}
+
+		n := netmap.Node(name)
+		if srcs, err := g.NodeSources(n, uuid); err == nil {
+			o := &requests.Output{
+				Name:     name,
+				Sources:  srcs,
+				Wildcard: ReadWildcardType(g, name),
+			}
+
+			o.Consistency, o.AnswerSets = ReadConsistency(g, name)
+			if last, found := ReadLastSeen(g, name); found {
+				o.LastSeen = &last
+			}
+			o.Rotating = ReadRotation(g, name)
+			if chain := ReadCNAMEChain(g, uuid, name); chain != nil {
+				o.CNAMEChain = chain.Targets
+				o.ChainFlag = chain.Flag
+				o.ChainProvider = chain.Provider
+			}
+			results[name] = o
+		}
+	}
+
+	var final []*requests.Output
+	for _, o := range results {
+		d, err := publicsuffix.EffectiveTLDPlusOne(o.Name)
+		if err != nil {
+			continue
+		}
+		o.Domain = d
+
+		o.Tag = tags.Select(o.Sources)
+		final = append(final, o)
+	}
+	return final
+}
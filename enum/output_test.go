@@ -0,0 +1,90 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/netmap"
+)
+
+func TestEventOutputBetween(t *testing.T) {
+	g := netmap.NewGraph(netmap.NewCayleyGraphMemory())
+	defer g.Close()
+
+	uuid := "8e3c2a6b-1d2f-4d6e-9c1a-6c6f1c9a2b77"
+	if _, err := InsertFQDN(g, "www.owasp.org", "DNS", requests.DNS, uuid); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := InsertFQDN(g, "mail.owasp.org", "DNS", requests.DNS, uuid); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+	for name, addr := range map[string]string{"www.owasp.org": "104.22.26.77", "mail.owasp.org": "104.22.27.77"} {
+		if err := g.UpsertA(name, addr, "DNS", uuid); err != nil {
+			t.Fatalf("Failed to insert the address of %s: %v", name, err)
+		}
+	}
+
+	output := EventOutputBetween(g, uuid, nil, between, time.Now(), nil, false, nil)
+	if len(output) != 1 || output[0].Name != "mail.owasp.org" {
+		t.Fatalf("Unexpected output for the names seen within the range: %+v", output)
+	}
+
+	output = EventOutputBetween(g, uuid, nil, between.Add(-time.Hour), time.Now(), nil, false, nil)
+	if len(output) != 2 {
+		t.Errorf("Expected output for two names seen within the range, got %d", len(output))
+	}
+}
+
+func TestEnumerationOutput(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ApexRecords = false
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	e.Transforms = []OutputTransform{
+		func(o *requests.Output) *requests.Output {
+			if strings.HasPrefix(o.Name, "internal.") {
+				return nil
+			}
+			o.Tag = "asset"
+			return o
+		},
+	}
+
+	uuid := cfg.UUID.String()
+	for _, name := range []string{"www.owasp.org", "internal.owasp.org", "www.example.com"} {
+		if _, err := e.Graph.UpsertFQDN(name, "DNS", uuid); err != nil {
+			t.Fatalf("Failed to insert %s: %v", name, err)
+		}
+		if err := e.Graph.UpsertA(name, "72.237.4.113", "DNS", uuid); err != nil {
+			t.Fatalf("Failed to insert the address of %s: %v", name, err)
+		}
+	}
+	addMockInfrastructure(e.Sys.(*mockSystem))
+
+	output := e.Output()
+	e.finishOutput()
+
+	var received []*requests.Output
+	for o := range output {
+		received = append(received, o)
+	}
+	// The names out of scope and the records dropped by the transforms are not delivered
+	if len(received) != 1 || received[0].Name != "www.owasp.org" {
+		t.Fatalf("Unexpected output records: %+v", received)
+	}
+	if o := received[0]; o.Tag != "asset" || len(o.Addresses) != 1 ||
+		o.Addresses[0].ASN != 26808 || o.Addresses[0].CIDRStr != "72.237.4.0/24" {
+		t.Errorf("The output record was not transformed or lacks the infrastructure: %+v", o)
+	}
+}
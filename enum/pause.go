@@ -0,0 +1,76 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"sync"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+// pauseState tracks whether the enumeration has been paused by the caller.
+type pauseState struct {
+	sync.Mutex
+	paused bool
+	// Closed when the enumeration is resumed
+	resume chan struct{}
+}
+
+// Pause stops the enumeration from releasing new names to the pipeline and from sending
+// requests to the data sources. The queued names and the filters are kept, and the names
+// already released are still processed. Calling Pause on a paused enumeration has no effect.
+func (e *Enumeration) Pause() {
+	e.pause.Lock()
+	defer e.pause.Unlock()
+
+	if e.pause.paused {
+		return
+	}
+	e.pause.paused = true
+	e.pause.resume = make(chan struct{})
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, "The enumeration has been paused")
+}
+
+// Resume continues a paused enumeration where it left off. Calling Resume on an enumeration
+// that is not paused has no effect.
+func (e *Enumeration) Resume() {
+	e.pause.Lock()
+	defer e.pause.Unlock()
+
+	if !e.pause.paused {
+		return
+	}
+	e.pause.paused = false
+	close(e.pause.resume)
+	e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, "The enumeration has been resumed")
+}
+
+// Paused returns true when the enumeration has been paused.
+func (e *Enumeration) Paused() bool {
+	e.pause.Lock()
+	defer e.pause.Unlock()
+
+	return e.pause.paused
+}
+
+// waitWhilePaused blocks while the enumeration is paused. It returns false
+// when the enumeration ended before it was resumed.
+func (e *Enumeration) waitWhilePaused() bool {
+	e.pause.Lock()
+	paused, resume := e.pause.paused, e.pause.resume
+	e.pause.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resume:
+		return true
+	case <-e.done:
+	case <-e.ctx.Done():
+	}
+	return false
+}
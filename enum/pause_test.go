@@ -0,0 +1,93 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestPauseResume(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	e.setupContext(context.Background())
+	defer func() {
+		e.stop()
+		e.Close()
+	}()
+
+	// Resuming an enumeration that is running has no effect
+	e.Resume()
+	if e.Paused() {
+		t.Fatal("The running enumeration was paused by Resume")
+	}
+
+	e.nameSrc = newEnumSource(e, 10)
+	e.nameSrc.timeout = 50 * time.Millisecond
+	e.nameSrc.queue.Append(&requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"})
+
+	e.Pause()
+	e.Pause()
+	if !e.Paused() {
+		t.Fatal("The enumeration was not paused")
+	}
+
+	next := make(chan bool)
+	go func() { next <- e.nameSrc.Next(e.ctx) }()
+
+	// Neither the queued name nor the timeout for new data end the wait while paused
+	select {
+	case <-next:
+		t.Fatal("The input source released a name while the enumeration was paused")
+	case <-time.After(250 * time.Millisecond):
+	}
+	if e.nameSrc.queue.Len() != 1 {
+		t.Errorf("The paused input source did not hold the queued name")
+	}
+
+	e.Resume()
+	e.Resume()
+	select {
+	case ok := <-next:
+		if !ok {
+			t.Fatal("The input source ended after the enumeration was resumed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The input source did not continue after the enumeration was resumed")
+	}
+	if req, ok := e.nameSrc.Data().(*requests.DNSRequest); !ok || req.Name != "www.owasp.org" {
+		t.Errorf("The queued name was not released after the enumeration was resumed")
+	}
+}
+
+func TestPauseEndsWithEnumeration(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	e.setupContext(context.Background())
+	defer e.Close()
+
+	e.Pause()
+	waiting := make(chan bool)
+	go func() { waiting <- e.waitWhilePaused() }()
+
+	e.stop()
+	select {
+	case ok := <-waiting:
+		if ok {
+			t.Error("The wait reported a resumed enumeration after it ended")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The paused enumeration did not end")
+	}
+}
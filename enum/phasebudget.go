@@ -0,0 +1,213 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+// How often the phase budgets are checked for expiration while no work is dispatched.
+const phaseBudgetInterval = time.Second
+
+// PhaseTransition records a phase of the enumeration that stopped dispatching work
+// once its time budget expired. The work already dispatched for the phase is completed.
+type PhaseTransition struct {
+	Phase   string    `json:"phase"`
+	Budget  string    `json:"budget"`
+	Started time.Time `json:"started"`
+	Expired time.Time `json:"expired"`
+}
+
+// phaseBudgets tracks the time spent on the phases that have a budget. The time spent on
+// a phase is counted from when the phase dispatched its first work.
+type phaseBudgets struct {
+	sync.Mutex
+	now         func() time.Time
+	budgets     map[EnumPhase]time.Duration
+	started     map[EnumPhase]time.Time
+	expired     map[EnumPhase]bool
+	transitions []PhaseTransition
+	// Executed for each phase transition, without holding the lock
+	onExpire func(PhaseTransition)
+}
+
+func newPhaseBudgets(budgets map[EnumPhase]time.Duration, now func() time.Time) *phaseBudgets {
+	if now == nil {
+		now = time.Now
+	}
+
+	return &phaseBudgets{
+		now:      now,
+		budgets:  budgets,
+		started:  make(map[EnumPhase]time.Time),
+		expired:  make(map[EnumPhase]bool),
+		onExpire: func(PhaseTransition) {},
+	}
+}
+
+// Allow returns true when work can be dispatched for the phase. Phases without a budget
+// are always allowed. The first call for a phase with a budget starts its clock.
+func (pb *phaseBudgets) Allow(p EnumPhase) bool {
+	if pb == nil {
+		return true
+	}
+
+	pb.Lock()
+	budget, found := pb.budgets[p]
+	if !found {
+		pb.Unlock()
+		return true
+	}
+	if pb.expired[p] {
+		pb.Unlock()
+		return false
+	}
+
+	now := pb.now()
+	start, found := pb.started[p]
+	if !found {
+		pb.started[p] = now
+		pb.Unlock()
+		return true
+	}
+	if now.Sub(start) < budget {
+		pb.Unlock()
+		return true
+	}
+
+	t := pb.expire(p, now)
+	pb.Unlock()
+
+	pb.onExpire(t)
+	return false
+}
+
+// Budget returns when the phase dispatched its first work and the budget of the phase.
+// The start is zero when the phase has not started, and the budget is zero without one.
+func (pb *phaseBudgets) Budget(p EnumPhase) (time.Time, time.Duration) {
+	if pb == nil {
+		return time.Time{}, 0
+	}
+
+	pb.Lock()
+	defer pb.Unlock()
+
+	return pb.started[p], pb.budgets[p]
+}
+
+// Check expires the started phases that have exhausted their budgets.
+func (pb *phaseBudgets) Check() {
+	if pb == nil {
+		return
+	}
+
+	pb.Lock()
+	now := pb.now()
+	var expired []PhaseTransition
+	for p := Sources; p <= ActiveProbes; p++ {
+		start, found := pb.started[p]
+
+		if found && !pb.expired[p] && now.Sub(start) >= pb.budgets[p] {
+			expired = append(expired, pb.expire(p, now))
+		}
+	}
+	pb.Unlock()
+
+	for _, t := range expired {
+		pb.onExpire(t)
+	}
+}
+
+// expire records the transition of the phase. The caller must hold the lock.
+func (pb *phaseBudgets) expire(p EnumPhase, now time.Time) PhaseTransition {
+	pb.expired[p] = true
+
+	t := PhaseTransition{
+		Phase:   p.String(),
+		Budget:  pb.budgets[p].String(),
+		Started: pb.started[p],
+		Expired: now,
+	}
+	pb.transitions = append(pb.transitions, t)
+	return t
+}
+
+// Transitions returns the phases that have exhausted their budgets, in order of expiration.
+func (pb *phaseBudgets) Transitions() []PhaseTransition {
+	if pb == nil {
+		return nil
+	}
+
+	pb.Lock()
+	defer pb.Unlock()
+
+	return append([]PhaseTransition(nil), pb.transitions...)
+}
+
+// parsePhaseBudgets returns the budgets of the configuration keyed by the phase. The phase
+// names can be provided with underscores in place of the spaces, such as brute_forcing.
+func parsePhaseBudgets(budgets map[string]time.Duration) (map[EnumPhase]time.Duration, error) {
+	results := make(map[EnumPhase]time.Duration, len(budgets))
+
+loop:
+	for name, budget := range budgets {
+		n := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", " "))
+
+		if budget <= 0 {
+			return nil, fmt.Errorf("The time budget of the %s phase must be positive", n)
+		}
+		for p, pname := range phaseNames {
+			if n == pname {
+				results[p] = budget
+				continue loop
+			}
+		}
+		return nil, fmt.Errorf("The time budget was provided for the unknown phase %s", name)
+	}
+	return results, nil
+}
+
+// setupPhaseBudgets enforces the time budgets of the configuration on the enumeration phases.
+func (e *Enumeration) setupPhaseBudgets() error {
+	budgets, err := parsePhaseBudgets(e.Config.PhaseBudgets)
+	if err != nil {
+		return err
+	}
+
+	e.phaseBudgets = newPhaseBudgets(budgets, e.clock)
+	e.phaseBudgets.onExpire = func(t PhaseTransition) {
+		e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, fmt.Sprintf("The %s phase exhausted "+
+			"its time budget of %s: no further work is dispatched for the phase", t.Phase, t.Budget))
+	}
+	if len(budgets) > 0 {
+		go e.monitorPhaseBudgets()
+	}
+	return nil
+}
+
+// monitorPhaseBudgets logs the phase transitions while the phases are not dispatching work.
+func (e *Enumeration) monitorPhaseBudgets() {
+	t := time.NewTicker(phaseBudgetInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-t.C:
+			e.phaseBudgets.Check()
+		}
+	}
+}
+
+// PhaseTransitions returns the phases that stopped dispatching work once their time budgets expired.
+func (e *Enumeration) PhaseTransitions() []PhaseTransition {
+	return e.phaseBudgets.Transitions()
+}
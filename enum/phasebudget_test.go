@@ -0,0 +1,152 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+// fakeClock provides the time to the phase budgets, which only moves when advanced.
+type fakeClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestPhaseBudgets(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)}
+	pb := newPhaseBudgets(map[EnumPhase]time.Duration{
+		Sources:    30 * time.Minute,
+		BruteForce: 2 * time.Hour,
+	}, clock.Now)
+
+	var logged []string
+	pb.onExpire = func(t PhaseTransition) { logged = append(logged, t.Phase) }
+
+	if !pb.Allow(Sources) {
+		t.Fatal("The sources phase was not allowed to start")
+	}
+	clock.Advance(20 * time.Minute)
+	// The clock of each phase starts with the first work dispatched
+	if !pb.Allow(Sources) || !pb.Allow(BruteForce) {
+		t.Fatal("The phases were stopped within their budgets")
+	}
+
+	clock.Advance(15 * time.Minute)
+	if pb.Allow(Sources) {
+		t.Error("The sources phase continued after its budget expired")
+	}
+	if !pb.Allow(BruteForce) || !pb.Allow(AddressProcessing) {
+		t.Error("The later phases were stopped by the expired sources budget")
+	}
+
+	clock.Advance(2 * time.Hour)
+	// The periodic check expires the phases that are not dispatching work
+	pb.Check()
+	if pb.Allow(BruteForce) {
+		t.Error("The brute forcing phase continued after its budget expired")
+	}
+	if !pb.Allow(AddressProcessing) {
+		t.Error("The address processing phase without a budget was stopped")
+	}
+
+	trans := pb.Transitions()
+	if len(trans) != 2 || trans[0].Phase != "sources" || trans[1].Phase != "brute forcing" {
+		t.Fatalf("Unexpected phase transitions: %+v", trans)
+	}
+	if trans[0].Budget != "30m0s" || trans[0].Expired.Sub(trans[0].Started) != 35*time.Minute {
+		t.Errorf("Unexpected transition of the sources phase: %+v", trans[0])
+	}
+	if trans[1].Expired.Sub(trans[1].Started) != 2*time.Hour+15*time.Minute {
+		t.Errorf("Unexpected transition of the brute forcing phase: %+v", trans[1])
+	}
+	if len(logged) != 2 {
+		t.Errorf("Expected each transition to be logged once, got %v", logged)
+	}
+}
+
+func TestParsePhaseBudgets(t *testing.T) {
+	budgets, err := parsePhaseBudgets(map[string]time.Duration{
+		"sources":            30 * time.Minute,
+		"brute_forcing":      2 * time.Hour,
+		"Address Processing": time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the phase budgets: %v", err)
+	}
+	if budgets[Sources] != 30*time.Minute || budgets[BruteForce] != 2*time.Hour || budgets[AddressProcessing] != time.Hour {
+		t.Errorf("Unexpected phase budgets: %v", budgets)
+	}
+
+	if _, err := parsePhaseBudgets(map[string]time.Duration{"crawling": time.Hour}); err == nil {
+		t.Error("The budget of an unknown phase was accepted")
+	}
+	if _, err := parsePhaseBudgets(map[string]time.Duration{"sources": 0}); err == nil {
+		t.Error("A budget without any time was accepted")
+	}
+}
+
+func TestPhaseBudgetTransitions(t *testing.T) {
+	api := newPhaseSource("API", requests.API)
+	brute := newPhaseSource("Brute", requests.BRUTE)
+	sys := newPhaseSystem(api, brute)
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+	cfg.PhaseBudgets = map[string]time.Duration{"sources": 30 * time.Minute}
+
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+	// Each reading of the clock is an hour after the previous one
+	var readings int64
+	start := time.Now()
+	e.clock = func() time.Time {
+		return start.Add(time.Duration(atomic.AddInt64(&readings, 1)) * time.Hour)
+	}
+
+	runPhases(t, e, Sources, Resolution, BruteForce, AddressProcessing)
+	if api.count() != 1 {
+		t.Errorf("Expected the API source to only receive the root domain name, got %d requests", api.count())
+	}
+	if brute.count() < 2 {
+		t.Errorf("The brute forcing phase without a budget did not continue, got %d requests", brute.count())
+	}
+	if !hasName(e, "www.owasp.org") {
+		t.Errorf("The work dispatched before the transition was not completed")
+	}
+
+	trans := e.PhaseTransitions()
+	if len(trans) != 1 || trans[0].Phase != Sources.String() {
+		t.Fatalf("Unexpected phase transitions: %+v", trans)
+	}
+
+	m, err := LoadManifest(cfg.Dir)
+	if err != nil {
+		t.Fatalf("Failed to load the manifest: %v", err)
+	}
+	if len(m.PhaseTransitions) != 1 || m.PhaseTransitions[0].Phase != Sources.String() {
+		t.Errorf("The phase transition was not recorded in the manifest: %+v", m.PhaseTransitions)
+	}
+}
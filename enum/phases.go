@@ -0,0 +1,173 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/datasrcs"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/service"
+)
+
+// EnumPhase is a part of the enumeration that can be selected with EnablePhases.
+type EnumPhase int
+
+// The phases of the enumeration.
+const (
+	// Sources queries the data sources for names and addresses.
+	Sources EnumPhase = iota
+	// Resolution verifies the names using DNS queries.
+	Resolution
+	// BruteForce guesses names using the wordlist.
+	BruteForce
+	// Alterations guesses names by altering the names already resolved.
+	Alterations
+	// AddressProcessing stores the addresses with their netblocks and ASNs, and performs
+	// the reverse DNS sweeps when names are resolved.
+	AddressProcessing
+	// ActiveProbes pulls certificates and attempts zone transfers.
+	ActiveProbes
+)
+
+var phaseNames = map[EnumPhase]string{
+	Sources:           "sources",
+	Resolution:        "resolution",
+	BruteForce:        "brute forcing",
+	Alterations:       "alterations",
+	AddressProcessing: "address processing",
+	ActiveProbes:      "active probes",
+}
+
+// String implements the Stringer interface.
+func (p EnumPhase) String() string {
+	if name, found := phaseNames[p]; found {
+		return name
+	}
+	return fmt.Sprintf("phase %d", int(p))
+}
+
+// phaseSet is the bit set of the phases selected for the enumeration.
+type phaseSet uint
+
+func newPhaseSet(phases ...EnumPhase) phaseSet {
+	var s phaseSet
+
+	for _, p := range phases {
+		s |= 1 << uint(p)
+	}
+	return s
+}
+
+func (s phaseSet) has(p EnumPhase) bool {
+	return s&(1<<uint(p)) != 0
+}
+
+// presetPhases returns the phases selected by the Passive, Active, BruteForcing and Alterations settings.
+func presetPhases(cfg *config.Config) phaseSet {
+	if cfg.Passive {
+		return newPhaseSet(Sources)
+	}
+
+	s := newPhaseSet(Sources, Resolution, AddressProcessing)
+	if cfg.BruteForcing {
+		s |= newPhaseSet(BruteForce)
+	}
+	if cfg.Alterations {
+		s |= newPhaseSet(Alterations)
+	}
+	if cfg.Active {
+		s |= newPhaseSet(ActiveProbes)
+	}
+	return s
+}
+
+// EnablePhases selects the phases performed by the enumeration, instead of the phases selected
+// by the Passive, Active, BruteForcing and Alterations settings, which are updated to match the
+// selection. Only the components required by the phases are wired into the enumeration.
+// An error is returned for incompatible combinations. EnablePhases must be called before Start.
+func (e *Enumeration) EnablePhases(phases ...EnumPhase) error {
+	s := newPhaseSet(phases...)
+
+	for _, p := range phases {
+		if _, found := phaseNames[p]; !found {
+			return fmt.Errorf("The enumeration %s is not known", p)
+		}
+	}
+	if s == 0 {
+		return errors.New("No enumeration phases were selected")
+	}
+	if !s.has(Sources) && !s.has(Resolution) {
+		return errors.New("The enumeration requires the sources or resolution phase to discover names")
+	}
+	for _, p := range []EnumPhase{BruteForce, Alterations, ActiveProbes} {
+		if s.has(p) && !s.has(Resolution) {
+			return fmt.Errorf("The %s phase cannot be performed without the resolution phase", p)
+		}
+	}
+
+	e.phases = s
+	e.Config.Passive = !s.has(Resolution)
+	e.Config.Active = s.has(ActiveProbes)
+	e.Config.BruteForcing = s.has(BruteForce)
+	e.Config.Alterations = s.has(Alterations)
+
+	e.srcs = phaseSources(s, datasrcs.SelectedDataSources(e.Config, e.Sys.DataSources()))
+	if s.has(Resolution) && e.dnsTask == nil {
+		e.dnsTask = newDNSTask(e)
+		e.subTask = newSubdomainTask(e)
+	}
+	return nil
+}
+
+// Phases returns the phases performed by the enumeration.
+func (e *Enumeration) Phases() []EnumPhase {
+	s := e.phaseSet()
+
+	var phases []EnumPhase
+	for p := Sources; p <= ActiveProbes; p++ {
+		if s.has(p) {
+			phases = append(phases, p)
+		}
+	}
+	return phases
+}
+
+// phase returns true when the enumeration performs the phase.
+func (e *Enumeration) phase(p EnumPhase) bool {
+	return e.phaseSet().has(p)
+}
+
+func (e *Enumeration) phaseSet() phaseSet {
+	if e.phases == 0 {
+		return presetPhases(e.Config)
+	}
+	return e.phases
+}
+
+// phaseSources returns the data sources used by the phases. The guessing sources
+// are only used by the brute forcing and alterations phases.
+func phaseSources(s phaseSet, srcs []service.Service) []service.Service {
+	var selected []service.Service
+
+	for _, src := range srcs {
+		if s.has(sourcePhase(src)) {
+			selected = append(selected, src)
+		}
+	}
+	return selected
+}
+
+// sourcePhase returns the phase that the requests sent to the data source belong to.
+func sourcePhase(src service.Service) EnumPhase {
+	switch src.Description() {
+	case requests.BRUTE:
+		return BruteForce
+	case requests.ALT:
+		return Alterations
+	}
+	return Sources
+}
@@ -0,0 +1,289 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+	"github.com/caffix/netmap"
+	"github.com/caffix/resolve"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+)
+
+// mockResolver answers the A queries for the names it knows and returns NXDOMAIN for the rest.
+type mockResolver struct {
+	addrs map[string]string
+}
+
+func (r *mockResolver) String() string { return "mock" }
+func (r *mockResolver) Stop()          {}
+func (r *mockResolver) Stopped() bool  { return false }
+
+func (r *mockResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	q := msg.Question[0]
+	resp := msg.Copy()
+	resp.Response = true
+
+	if addr, found := r.addrs[strings.TrimSuffix(q.Name, ".")]; found && q.Qtype == dns.TypeA {
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP(addr),
+		})
+		return resp, nil
+	}
+
+	resp.Rcode = dns.RcodeNameError
+	return resp, &resolve.ResolveError{Err: "NXDOMAIN", Rcode: dns.RcodeNameError}
+}
+
+func (r *mockResolver) WildcardType(ctx context.Context, msg *dns.Msg, domain string) int {
+	return resolve.WildcardTypeNone
+}
+
+// phaseSource counts the requests received and reports a name with its address for each domain.
+type phaseSource struct {
+	service.BaseService
+	sync.Mutex
+	srcType  string
+	requests int
+}
+
+func newPhaseSource(name, srcType string) *phaseSource {
+	p := &phaseSource{srcType: srcType}
+
+	p.BaseService = *service.NewBaseService(p, name)
+	return p
+}
+
+func (p *phaseSource) Description() string {
+	return p.srcType
+}
+
+func (p *phaseSource) OnRequest(ctx context.Context, args service.Args) {
+	p.Lock()
+	p.requests++
+	p.Unlock()
+
+	req, ok := args.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	_, bus, err := requests.ContextConfigBus(ctx)
+	if err != nil {
+		return
+	}
+	// Allow the event bus to register the subscriptions of the enumeration
+	time.Sleep(100 * time.Millisecond)
+
+	bus.Publish(requests.NewNameTopic, eventbus.PriorityHigh, &requests.DNSRequest{
+		Name:   "www." + req.Domain,
+		Domain: req.Domain,
+		Tag:    p.srcType,
+		Source: p.String(),
+	})
+	bus.Publish(requests.NewAddrTopic, eventbus.PriorityHigh, &requests.AddrRequest{
+		Address: "72.237.4.113",
+		Domain:  req.Domain,
+		Tag:     p.srcType,
+		Source:  p.String(),
+	})
+}
+
+func (p *phaseSource) count() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.requests
+}
+
+func newPhaseSystem(srcs ...*phaseSource) *mockSystem {
+	sys := newMockSystem(config.NewConfig())
+	sys.pool = &mockResolver{addrs: map[string]string{
+		"owasp.org":     "72.237.4.113",
+		"www.owasp.org": "72.237.4.113",
+	}}
+	// The address is attributed to the netblock without querying the data sources
+	sys.cache.Update(&requests.ASNRequest{
+		Address:     "72.237.4.0",
+		ASN:         26808,
+		Prefix:      "72.237.4.0/24",
+		Description: "UTICA-AS",
+		Tag:         requests.RIR,
+		Source:      "RIR",
+	})
+
+	for _, src := range srcs {
+		_ = sys.AddAndStart(src)
+	}
+	return sys
+}
+
+func stopSources(sys *mockSystem) {
+	for _, src := range sys.DataSources() {
+		_ = src.Stop()
+	}
+}
+
+func runPhases(t *testing.T, e *Enumeration, phases ...EnumPhase) {
+	if err := e.EnablePhases(phases...); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	if _, err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration returned an error: %v", err)
+	}
+}
+
+func hasNodes(t *testing.T, e *Enumeration, ntype string) bool {
+	nodes, err := e.Graph.AllNodesOfType(ntype, e.Config.UUID.String())
+
+	return err == nil && len(nodes) > 0
+}
+
+func hasName(e *Enumeration, name string) bool {
+	for _, n := range e.Graph.EventFQDNs(e.Config.UUID.String()) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPhasesSourcesWithAddresses(t *testing.T) {
+	api := newPhaseSource("API", requests.API)
+	brute := newPhaseSource("Brute", requests.BRUTE)
+	sys := newPhaseSystem(api, brute)
+	defer stopSources(sys)
+	// No DNS queries can be sent without a resolver pool
+	sys.pool = nil
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	runPhases(t, e, Sources, AddressProcessing)
+	if !e.Config.Passive || e.Config.BruteForcing {
+		t.Errorf("The configuration was not updated to match the phases")
+	}
+	if !hasName(e, "www.owasp.org") {
+		t.Errorf("The name provided by the data source was not stored")
+	}
+	if !hasNodes(t, e, netmap.TypeNetblock) {
+		t.Errorf("The address provided by the data source was not stored with the netblock")
+	}
+	if api.count() == 0 || brute.count() != 0 {
+		t.Errorf("Expected only the API source to be queried, got %d API and %d brute requests", api.count(), brute.count())
+	}
+}
+
+func TestPhasesResolutionOnly(t *testing.T) {
+	api := newPhaseSource("API", requests.API)
+	sys := newPhaseSystem(api)
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org", "bogus.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	runPhases(t, e, Resolution)
+	if api.count() != 0 {
+		t.Errorf("The data source received %d requests without the sources phase", api.count())
+	}
+	if !hasName(e, "www.owasp.org") {
+		t.Errorf("The provided name was not verified")
+	}
+	if hasName(e, "bogus.owasp.org") {
+		t.Errorf("The provided name without DNS records was stored")
+	}
+	if hasNodes(t, e, netmap.TypeNetblock) {
+		t.Errorf("The addresses were processed without the address processing phase")
+	}
+}
+
+func TestPhasesSourcesWithResolution(t *testing.T) {
+	api := newPhaseSource("API", requests.API)
+	alt := newPhaseSource("Alterations", requests.ALT)
+	sys := newPhaseSystem(api, alt)
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	runPhases(t, e, Sources, Resolution)
+	if e.Config.Passive || e.Config.Alterations {
+		t.Errorf("The configuration was not updated to match the phases")
+	}
+	if !hasName(e, "www.owasp.org") {
+		t.Errorf("The name provided by the data source was not resolved")
+	}
+	if hasNodes(t, e, netmap.TypeNetblock) {
+		t.Errorf("The addresses were processed without the address processing phase")
+	}
+	if api.count() == 0 || alt.count() != 0 {
+		t.Errorf("Expected only the API source to be queried, got %d API and %d alterations requests", api.count(), alt.count())
+	}
+}
+
+func TestPhaseValidation(t *testing.T) {
+	for _, tc := range []struct {
+		phases []EnumPhase
+		valid  bool
+	}{
+		{nil, false},
+		{[]EnumPhase{AddressProcessing}, false},
+		{[]EnumPhase{Sources, BruteForce}, false},
+		{[]EnumPhase{Sources, Alterations}, false},
+		{[]EnumPhase{Sources, ActiveProbes}, false},
+		{[]EnumPhase{Sources, EnumPhase(42)}, false},
+		{[]EnumPhase{Sources}, true},
+		{[]EnumPhase{Resolution, BruteForce}, true},
+		{[]EnumPhase{Sources, Resolution, AddressProcessing, ActiveProbes}, true},
+	} {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := NewEnumeration(cfg, newMockSystem(cfg))
+
+		if err := e.EnablePhases(tc.phases...); (err == nil) != tc.valid {
+			t.Errorf("Phases %v: expected valid to be %t, got the error %v", tc.phases, tc.valid, err)
+		}
+		e.Close()
+	}
+}
+
+func TestPhasePresets(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	if phases := e.Phases(); len(phases) != 1 || phases[0] != Sources {
+		t.Errorf("Expected the passive preset to only select the sources phase, got %v", phases)
+	}
+
+	cfg.Passive = false
+	cfg.Active = true
+	cfg.BruteForcing = false
+	cfg.Alterations = true
+	if !e.phase(Resolution) || !e.phase(ActiveProbes) || !e.phase(Alterations) || e.phase(BruteForce) {
+		t.Errorf("The active preset selected the wrong phases: %v", e.Phases())
+	}
+}
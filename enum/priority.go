@@ -0,0 +1,110 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/queue"
+)
+
+// PriorityNameManager is the queue of the enumeration input source used when the configuration
+// sets PriorityNames. It implements queue.Queue using a critical, high and low priority queue,
+// following the priority levels of the event bus. Names from zone transfers, certificates and
+// DNS are processed first, and names from scraping and name guessing are processed last.
+type PriorityNameManager struct {
+	critical queue.Queue
+	high     queue.Queue
+	low      queue.Queue
+	signal   chan struct{}
+}
+
+// NewPriorityNameManager returns an empty PriorityNameManager.
+func NewPriorityNameManager() *PriorityNameManager {
+	return &PriorityNameManager{
+		critical: queue.NewQueue(),
+		high:     queue.NewQueue(),
+		low:      queue.NewQueue(),
+		signal:   make(chan struct{}, 1),
+	}
+}
+
+// nameTagPriority returns the queue priority for the names discovered using the tag.
+func nameTagPriority(tag string) int {
+	switch tag {
+	case requests.AXFR, requests.CERT, requests.DNS:
+		return queue.PriorityCritical
+	case requests.SCRAPE, requests.BRUTE, requests.ALT, requests.GUESS:
+		return queue.PriorityLow
+	}
+	return queue.PriorityHigh
+}
+
+// InputName adds the name to the queue selected by the tag of the request.
+func (p *PriorityNameManager) InputName(req *requests.DNSRequest) {
+	p.AppendPriority(req, nameTagPriority(req.Tag))
+}
+
+// Append implements the queue.Queue interface. The names are routed using the request tag,
+// and the other data is added to the high priority queue.
+func (p *PriorityNameManager) Append(data interface{}) {
+	if req, ok := data.(*requests.DNSRequest); ok && req != nil {
+		p.InputName(req)
+		return
+	}
+	p.AppendPriority(data, queue.PriorityHigh)
+}
+
+// AppendPriority implements the queue.Queue interface.
+func (p *PriorityNameManager) AppendPriority(data interface{}, priority int) {
+	switch {
+	case priority >= queue.PriorityCritical:
+		p.critical.Append(data)
+	case priority <= queue.PriorityLow:
+		p.low.Append(data)
+	default:
+		p.high.Append(data)
+	}
+
+	select {
+	case p.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Signal implements the queue.Queue interface.
+func (p *PriorityNameManager) Signal() <-chan struct{} {
+	return p.signal
+}
+
+// Next implements the queue.Queue interface. The critical queue is drained first,
+// then the high priority queue and then the low priority queue.
+func (p *PriorityNameManager) Next() (interface{}, bool) {
+	for _, q := range p.queues() {
+		if data, ok := q.Next(); ok {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// Process implements the queue.Queue interface.
+func (p *PriorityNameManager) Process(callback func(interface{})) {
+	for _, q := range p.queues() {
+		q.Process(callback)
+	}
+}
+
+// Empty implements the queue.Queue interface.
+func (p *PriorityNameManager) Empty() bool {
+	return p.Len() == 0
+}
+
+// Len implements the queue.Queue interface.
+func (p *PriorityNameManager) Len() int {
+	return p.critical.Len() + p.high.Len() + p.low.Len()
+}
+
+func (p *PriorityNameManager) queues() []queue.Queue {
+	return []queue.Queue{p.critical, p.high, p.low}
+}
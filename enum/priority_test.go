@@ -0,0 +1,75 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+)
+
+func TestPriorityNameManager(t *testing.T) {
+	p := NewPriorityNameManager()
+
+	for _, req := range []*requests.DNSRequest{
+		{Name: "scraped.owasp.org", Tag: requests.SCRAPE},
+		{Name: "brute.owasp.org", Tag: requests.BRUTE},
+		{Name: "api.owasp.org", Tag: requests.API},
+		{Name: "cert.owasp.org", Tag: requests.CERT},
+		{Name: "archive.owasp.org", Tag: requests.ARCHIVE},
+		{Name: "axfr.owasp.org", Tag: requests.AXFR},
+	} {
+		p.InputName(req)
+	}
+	p.Append(&requests.AddrRequest{Address: "72.237.4.113"})
+
+	select {
+	case <-p.Signal():
+	default:
+		t.Error("The manager did not signal the new names")
+	}
+	if p.Len() != 7 {
+		t.Fatalf("Expected 7 elements in the manager, got %d", p.Len())
+	}
+
+	expected := []string{"cert.owasp.org", "axfr.owasp.org", "api.owasp.org",
+		"archive.owasp.org", "72.237.4.113", "scraped.owasp.org", "brute.owasp.org"}
+	for i, name := range expected {
+		data, ok := p.Next()
+		if !ok {
+			t.Fatalf("The manager was drained after %d elements", i)
+		}
+
+		var got string
+		switch v := data.(type) {
+		case *requests.DNSRequest:
+			got = v.Name
+		case *requests.AddrRequest:
+			got = v.Address
+		}
+		if got != name {
+			t.Errorf("Expected %s at position %d, got %s", name, i, got)
+		}
+	}
+	if !p.Empty() {
+		t.Errorf("The manager still has %d elements", p.Len())
+	}
+}
+
+func TestPriorityNamesConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Passive = true
+	cfg.PriorityNames = true
+	cfg.AddDomain("owasp.org")
+
+	e := NewEnumeration(cfg, newMockSystem(cfg))
+	defer e.Close()
+
+	src := newEnumSource(e, 10)
+	defer src.Stop()
+	if _, ok := src.queue.trusted.(*PriorityNameManager); !ok {
+		t.Errorf("The input source did not use the PriorityNameManager")
+	}
+}
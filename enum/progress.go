@@ -0,0 +1,71 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of the work performed and the work waiting within an enumeration.
+type Progress struct {
+	// Names waiting in the input source, and the names waiting in each of its lanes
+	NameQueueLen int
+	LaneQueueLen map[string]int
+	// Requests waiting for the duplicate name checks and the reverse DNS sweeps
+	RequestQueueLen int
+	// DNS queries sent per second, measured over the most recent sample
+	QueriesPerSec float64
+	// Percentage of the DNS queries sent that were retries
+	RetryPercent float64
+	// In-scope names stored in the graph for the event
+	NamesStored int
+	// Root domain names that have not been released to the input source
+	DomainsPending int
+	// Time since the enumeration was started
+	Elapsed time.Duration
+	// Rough estimate of the time to drain the name queue at the recent release rate, which is
+	// zero when the names are not being released
+	ETA time.Duration
+	// Names discovered per bucket of the enumeration, followed by the bucket in progress
+	DiscoveryRates []DiscoveryBucket
+}
+
+// Progress returns a snapshot of the progress of the enumeration. It does not wait on the
+// pipeline, and can be called frequently from another goroutine while the enumeration is running.
+func (e *Enumeration) Progress() Progress {
+	p := Progress{LaneQueueLen: make(map[string]int, len(e.lanes))}
+
+	e.metrics.Lock()
+	src := e.metrics.src
+	started := e.metrics.started
+	p.QueriesPerSec = e.metrics.rate
+	drain := e.metrics.drain
+	e.metrics.Unlock()
+
+	queries := atomic.LoadInt64(&e.metrics.queries)
+	retries := atomic.LoadInt64(&e.metrics.retries)
+	if total := queries + retries; total > 0 {
+		p.RetryPercent = float64(retries) / float64(total) * 100
+	}
+
+	if src != nil {
+		p.NameQueueLen = src.queue.Len()
+		for lane := range e.lanes {
+			p.LaneQueueLen[lane] = src.queue.stats(lane).Queued
+		}
+		p.RequestQueueLen = src.dups.Len() + src.sweeps.Len()
+	}
+	if drain > 0 {
+		p.ETA = time.Duration(float64(p.NameQueueLen) / drain * float64(time.Second))
+	}
+	if !started.IsZero() {
+		p.Elapsed = time.Since(started)
+	}
+
+	p.DiscoveryRates = e.rates.Buckets()
+	p.NamesStored = e.failures.result().Names
+	p.DomainsPending = e.checkpoints.pendingDomains(e.Config)
+	return p
+}
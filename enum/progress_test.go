@@ -0,0 +1,129 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OWASP/Amass/v3/config"
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/resolve"
+	"github.com/miekg/dns"
+)
+
+// retryResolver retries each query once before answering it with the mockResolver.
+type retryResolver struct {
+	mockResolver
+}
+
+func (r *retryResolver) Query(ctx context.Context, msg *dns.Msg, priority int, retry resolve.Retry) (*dns.Msg, error) {
+	if retry != nil {
+		retry(1, priority, msg)
+	}
+	return r.mockResolver.Query(ctx, msg, priority, retry)
+}
+
+func TestProgress(t *testing.T) {
+	sys, _ := newResumeSystem()
+	defer stopSources(sys)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org", "mail.owasp.org", "ftp.owasp.org"}
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	p := e.Progress()
+	if p.DomainsPending != 1 || p.NamesStored != 0 || p.Elapsed != 0 || p.NameQueueLen != 0 {
+		t.Errorf("Unexpected progress before the enumeration was started: %+v", p)
+	}
+
+	if err := e.EnablePhases(Resolution); err != nil {
+		t.Fatalf("Failed to select the phases: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The snapshots are taken while the enumeration is running
+		for {
+			select {
+			case <-e.done:
+				return
+			default:
+				_ = e.Progress()
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	runResume(t, e)
+	<-done
+
+	p = e.Progress()
+	if p.DomainsPending != 0 {
+		t.Errorf("%d domains were not released", p.DomainsPending)
+	}
+	if p.NamesStored < len(cfg.ProvidedNames) {
+		t.Errorf("%d names were stored instead of at least %d", p.NamesStored, len(cfg.ProvidedNames))
+	}
+	if p.Elapsed <= 0 || len(p.LaneQueueLen) != 2 {
+		t.Errorf("Unexpected progress after the enumeration: %+v", p)
+	}
+	if p.RetryPercent != 0 {
+		t.Errorf("The queries were counted as %g%% retries", p.RetryPercent)
+	}
+	// The names discovered are counted in the bucket in progress
+	if n := len(p.DiscoveryRates); n != 1 || p.DiscoveryRates[n-1].Count < len(cfg.ProvidedNames) {
+		t.Errorf("Unexpected discovery rates after the enumeration: %+v", p.DiscoveryRates)
+	}
+}
+
+func TestProgressRetriesAndETA(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	sys.pool = &retryResolver{mockResolver{addrs: map[string]string{"www.owasp.org": "72.237.4.113"}}}
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	// The input source does not start its goroutines without DNS resolution
+	cfg.Passive = true
+	e := NewEnumeration(cfg, sys)
+	defer e.Close()
+
+	retry := func(times, priority int, msg *dns.Msg) bool { return times < 2 }
+	for i := 0; i < 4; i++ {
+		msg := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+		_, _ = e.pool().Query(context.Background(), msg, resolve.PriorityLow, retry)
+	}
+	// Each of the queries was retried once
+	if p := e.Progress(); p.RetryPercent != 50 {
+		t.Errorf("Expected 50%% retries, got %g%%", p.RetryPercent)
+	}
+
+	src := newEnumSource(e, 0)
+	defer src.Stop()
+
+	start := time.Now()
+	e.metrics.setSource(src, start)
+	e.metrics.sample(start)
+	for i := 0; i < 10; i++ {
+		src.queue.Append(&requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org", Tag: requests.DNS})
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := src.queue.Next(); !ok {
+			t.Fatalf("The name queue did not release a name")
+		}
+	}
+	e.metrics.sample(start.Add(2 * time.Second))
+
+	// Eight names remain at the rate of one name released per second
+	p := e.Progress()
+	if p.NameQueueLen != 8 || p.ETA != 8*time.Second {
+		t.Errorf("Expected 8 names and an ETA of 8s, got %d names and %v", p.NameQueueLen, p.ETA)
+	}
+	if p.Elapsed <= 0 {
+		t.Errorf("The elapsed time was not measured")
+	}
+}
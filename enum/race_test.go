@@ -0,0 +1,10 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build race
+// +build race
+
+package enum
+
+// The bolt graph database fails the pointer checks performed by the race detector.
+const raceEnabled = true
@@ -0,0 +1,181 @@
+// Copyright 2017-2021 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package enum
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OWASP/Amass/v3/requests"
+	"github.com/caffix/eventbus"
+)
+
+const (
+	discoveryBucketSize = 5 * time.Minute
+	// The weight given to the most recent bucket in the decaying rate
+	discoveryRateAlpha = 0.5
+)
+
+// DiscoveryBucket is the number of names discovered during one period of the enumeration.
+type DiscoveryBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+	// The decaying rate of discovery, in names per bucket, when the bucket was closed
+	Rate float64 `json:"rate"`
+	// Set when names were discovered by brute forcing or the enumeration was paused during
+	// the bucket, so the bucket was left out of the decaying rate and the plateau detection
+	Suspended bool `json:"suspended,omitempty"`
+}
+
+// discoveryRate tracks the names discovered per bucket and detects when the yield plateaus.
+type discoveryRate struct {
+	sync.Mutex
+	buckets  []*DiscoveryBucket
+	current  *DiscoveryBucket
+	peak     float64
+	below    int
+	fraction float64
+	required int
+	// Set when names were discovered by brute forcing since the bucket was last closed
+	bruting bool
+}
+
+func newDiscoveryRate(fraction float64, required int, start time.Time) *discoveryRate {
+	return &discoveryRate{
+		current:  &DiscoveryBucket{Start: start},
+		fraction: fraction,
+		required: required,
+	}
+}
+
+// Inc counts a newly discovered name in the current bucket.
+func (d *discoveryRate) Inc() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.current.Count++
+}
+
+// BruteForced records that a name discovered by brute forcing was not counted in the current bucket.
+func (d *discoveryRate) BruteForced() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.bruting = true
+}
+
+// Close ends the current bucket, starts the next and returns true when the
+// rate of discovery has stayed below the fraction of the peak for the required buckets.
+// The bucket is suspended when the enumeration is paused or names were discovered by
+// brute forcing, since those names are not counted and the rate would indicate a plateau.
+// A suspended bucket carries the previous rate forward, and is skipped by the plateau check.
+func (d *discoveryRate) Close(now time.Time, paused bool) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	cur := d.current
+	cur.Suspended = paused || d.bruting
+	d.bruting = false
+
+	var prev float64
+	if num := len(d.buckets); num > 0 {
+		prev = d.buckets[num-1].Rate
+	}
+	switch {
+	case cur.Suspended:
+		cur.Rate = prev
+	case len(d.buckets) == 0:
+		cur.Rate = float64(cur.Count)
+	default:
+		cur.Rate = discoveryRateAlpha*float64(cur.Count) + (1-discoveryRateAlpha)*prev
+	}
+
+	d.buckets = append(d.buckets, cur)
+	d.current = &DiscoveryBucket{Start: now}
+	if cur.Suspended {
+		return false
+	}
+
+	if cur.Rate > d.peak {
+		d.peak = cur.Rate
+	}
+	if d.peak > 0 && cur.Rate < d.fraction*d.peak {
+		d.below++
+	} else {
+		d.below = 0
+	}
+
+	return d.required > 0 && d.below >= d.required
+}
+
+// Buckets returns a copy of the closed buckets followed by the bucket in progress.
+func (d *discoveryRate) Buckets() []DiscoveryBucket {
+	d.Lock()
+	defer d.Unlock()
+
+	series := make([]DiscoveryBucket, 0, len(d.buckets)+1)
+	for _, b := range d.buckets {
+		series = append(series, *b)
+	}
+	return append(series, *d.current)
+}
+
+// DiscoveryRates returns the number of names discovered per five minute bucket of the enumeration.
+// Names discovered by brute forcing are not included, since that yield is bursty, and the
+// buckets closed while brute forcing or paused are suspended.
+func (e *Enumeration) DiscoveryRates() []DiscoveryBucket {
+	return e.rates.Buckets()
+}
+
+// StopReason returns the explanation for an enumeration terminated by the engine itself.
+func (e *Enumeration) StopReason() string {
+	e.stopLock.Lock()
+	defer e.stopLock.Unlock()
+
+	return e.stopReason
+}
+
+func (e *Enumeration) countDiscovery(tag string) {
+	if tag == requests.BRUTE {
+		e.rates.BruteForced()
+		return
+	}
+	e.rates.Inc()
+}
+
+func (e *Enumeration) monitorDiscoveryRate() {
+	t := time.NewTicker(discoveryBucketSize)
+	defer t.Stop()
+
+	var suggested bool
+	for {
+		select {
+		case <-e.done:
+			return
+		case now := <-t.C:
+			e.reallocateBudgets(now)
+			// The names are not released while paused, so the rate does not indicate a plateau
+			if !e.rates.Close(now, e.Paused()) {
+				continue
+			}
+
+			msg := fmt.Sprintf("The rate of discovery has dropped below %.0f%% of the peak for %d consecutive buckets",
+				e.Config.PlateauFraction*100, e.Config.PlateauBuckets)
+			if e.Config.AutoStopOnPlateau {
+				e.stopLock.Lock()
+				e.stopReason = msg
+				e.stopLock.Unlock()
+
+				e.Bus.Publish(requests.LogTopic, eventbus.PriorityCritical, msg+": stopping the enumeration")
+				e.stop()
+				return
+			}
+			if !suggested {
+				suggested = true
+				e.Bus.Publish(requests.LogTopic, eventbus.PriorityHigh, msg+": consider stopping the enumeration")
+			}
+		}
+	}
+}